@@ -0,0 +1,108 @@
+package btrie
+
+// Allocator allocates and frees values of type T, which are typically trie nodes,
+// such as those of a trie created by [NewArrayTrieWithAllocator].
+// A trie using an Allocator frees each value it allocated once it no longer references it,
+// and never uses a value after freeing it.
+// An Allocator is not required to be safe for concurrent use.
+type Allocator[T any] interface {
+	// Alloc returns a pointer to a new zero value.
+	Alloc() *T
+
+	// Free releases a value returned by Alloc, which the Allocator may return again from a later Alloc.
+	Free(value *T)
+
+	// Stats returns statistics about this Allocator's values.
+	Stats() AllocStats
+}
+
+// AllocStats are statistics about an [Allocator]'s values.
+type AllocStats struct {
+	// Allocs is the number of calls to Alloc.
+	Allocs int
+
+	// Frees is the number of calls to Free.
+	Frees int
+
+	// Reserved is the number of values held by the Allocator, both live and available for reuse.
+	// Allocators which do not hold values report the number of live values, Allocs - Frees.
+	Reserved int
+}
+
+// Live returns the number of values allocated and not yet freed.
+func (s AllocStats) Live() int {
+	return s.Allocs - s.Frees
+}
+
+// NewHeapAllocator returns an Allocator that allocates each value separately on the Go heap,
+// leaving freed values for the garbage collector.
+// This is the same as a trie allocating its own nodes, but with statistics.
+func NewHeapAllocator[T any]() Allocator[T] {
+	return &heapAllocator[T]{}
+}
+
+type heapAllocator[T any] struct {
+	stats AllocStats
+}
+
+func (a *heapAllocator[T]) Alloc() *T {
+	a.stats.Allocs++
+	return new(T)
+}
+
+func (a *heapAllocator[T]) Free(*T) {
+	a.stats.Frees++
+}
+
+func (a *heapAllocator[T]) Stats() AllocStats {
+	stats := a.stats
+	stats.Reserved = stats.Live()
+	return stats
+}
+
+// NewArenaAllocator returns an Allocator that allocates values in chunks of chunkSize,
+// reusing freed values before allocating another chunk.
+// This reduces the number of separate allocations, and keeps values allocated together close in memory.
+// Chunks are never released, so the memory held is proportional to the most values ever live at once.
+// NewArenaAllocator will panic if chunkSize is less than 1.
+func NewArenaAllocator[T any](chunkSize int) Allocator[T] {
+	if chunkSize < 1 {
+		panic("chunkSize must be positive")
+	}
+	return &arenaAllocator[T]{chunkSize: chunkSize}
+}
+
+type arenaAllocator[T any] struct {
+	chunk     []T  // the unused part of the current chunk
+	free      []*T // freed values, available for reuse
+	chunkSize int
+	stats     AllocStats
+}
+
+func (a *arenaAllocator[T]) Alloc() *T {
+	a.stats.Allocs++
+	if len(a.free) > 0 {
+		value := a.free[len(a.free)-1]
+		a.free = a.free[:len(a.free)-1]
+		return value
+	}
+	if len(a.chunk) == 0 {
+		a.chunk = make([]T, a.chunkSize)
+		a.stats.Reserved += a.chunkSize
+	}
+	value := &a.chunk[0]
+	a.chunk = a.chunk[1:]
+	return value
+}
+
+func (a *arenaAllocator[T]) Free(value *T) {
+	a.stats.Frees++
+	// Zero it so it is a new zero value when reused, and so it doesn't keep anything else alive.
+	var zero T
+	*value = zero
+	a.free = append(a.free, value)
+}
+
+func (a *arenaAllocator[T]) Stats() AllocStats {
+	return a.stats
+}
@@ -0,0 +1,104 @@
+package btrie_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/phiryll/btrie"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestArenaAllocator(t *testing.T) {
+	t.Parallel()
+	assert.Panics(t, func() { btrie.NewArenaAllocator[int](0) })
+	alloc := btrie.NewArenaAllocator[int](2)
+	a, b, c := alloc.Alloc(), alloc.Alloc(), alloc.Alloc()
+	assert.Equal(t, btrie.AllocStats{Allocs: 3, Frees: 0, Reserved: 4}, alloc.Stats())
+	*a, *b, *c = 1, 2, 3
+	alloc.Free(b)
+	assert.Equal(t, 2, alloc.Stats().Live())
+	d := alloc.Alloc()
+	assert.Same(t, b, d, "freed values are reused")
+	assert.Equal(t, 0, *d, "reused values are zeroed")
+	assert.Equal(t, btrie.AllocStats{Allocs: 4, Frees: 1, Reserved: 4}, alloc.Stats())
+}
+
+func TestHeapAllocator(t *testing.T) {
+	t.Parallel()
+	alloc := btrie.NewHeapAllocator[int]()
+	a, b := alloc.Alloc(), alloc.Alloc()
+	assert.NotSame(t, a, b)
+	alloc.Free(a)
+	assert.Equal(t, btrie.AllocStats{Allocs: 2, Frees: 1, Reserved: 1}, alloc.Stats())
+}
+
+// A user-defined Allocator, which keeps freed values in a list for reuse.
+type listAllocator[T any] struct {
+	free  []*T
+	stats btrie.AllocStats
+}
+
+func newListAllocator[T any]() btrie.Allocator[T] {
+	return &listAllocator[T]{}
+}
+
+func (a *listAllocator[T]) Alloc() *T {
+	a.stats.Allocs++
+	if len(a.free) == 0 {
+		a.stats.Reserved++
+		return new(T)
+	}
+	value := a.free[len(a.free)-1]
+	a.free = a.free[:len(a.free)-1]
+	var zero T
+	*value = zero
+	return value
+}
+
+func (a *listAllocator[T]) Free(value *T) {
+	a.stats.Frees++
+	a.free = append(a.free, value)
+}
+
+func (a *listAllocator[T]) Stats() btrie.AllocStats {
+	return a.stats
+}
+
+// Every node allocated for the entries must be freed once they are all deleted.
+func TestArrayTrieAllocator(t *testing.T) {
+	t.Parallel()
+	assert.Panics(t, func() { btrie.NewArrayTrieWithAllocator[byte](nil) })
+	assert.Panics(t, func() { btrie.NewArrayTrieWithArena[byte](0) })
+	for _, config := range rangeTestConfigs {
+		t.Run(config.name, func(t *testing.T) {
+			t.Parallel()
+			for _, factory := range []func() (btrie.BTrie[byte], func() btrie.AllocStats){
+				func() (btrie.BTrie[byte], func() btrie.AllocStats) {
+					return btrie.NewArrayTrieWithAllocator[byte](btrie.NewHeapAllocator)
+				},
+				func() (btrie.BTrie[byte], func() btrie.AllocStats) {
+					return btrie.NewArrayTrieWithAllocator[byte](newListAllocator)
+				},
+				func() (btrie.BTrie[byte], func() btrie.AllocStats) {
+					return btrie.NewArrayTrieWithArena[byte](arenaChunkSize)
+				},
+			} {
+				trie, stats := factory()
+				existing := map[string]byte{}
+				for key, value := range config.entries {
+					trie.Put([]byte(key), value)
+					existing[key] = value
+				}
+				assertSame(t, existing, trie)
+				if len(config.entries) > 1 {
+					assert.Positive(t, stats().Live())
+				}
+				for key := range config.entries {
+					trie.Delete([]byte(key))
+				}
+				assert.Equal(t, 0, stats().Live())
+				assert.Equal(t, "{size: 0, depth: 0}", fmt.Sprint(trie))
+			}
+		})
+	}
+}
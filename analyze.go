@@ -0,0 +1,199 @@
+package btrie
+
+import "unsafe"
+
+// CompressionReport estimates how many nodes a trie would need under several node layouts, see [AnalyzeCompression].
+// The uncompressed trie has one node for every distinct prefix of a key, including the empty prefix at the root,
+// and every layout is derived from it.
+type CompressionReport struct {
+	// Entries is the number of entries in the trie.
+	Entries int
+
+	// Nodes is the number of nodes in the uncompressed trie.
+	Nodes int
+
+	// CurrentNodes is the number of nodes the analyzed trie actually has, or -1 if its structure is unknown.
+	CurrentNodes int
+
+	// PathCompressedNodes is the number of nodes if every chain of nodes having a single child and no value,
+	// other than the root, is merged into the node below it, as in a radix tree.
+	PathCompressedNodes int
+
+	// Leaves is the number of nodes in the uncompressed trie without children.
+	Leaves int
+
+	// FanoutClasses is the number of nodes with children in the uncompressed trie,
+	// by the smallest adaptive node type which can hold their children: up to 4, 16, 48, or 256 children.
+	FanoutClasses [4]int
+
+	// ArrayChildBytes is the memory needed for the children of every node with children,
+	// if each has an array of 256 child pointers.
+	ArrayChildBytes int64
+
+	// AdaptiveChildBytes is the memory needed for the children of every node with children,
+	// if each uses the smallest adaptive node type which can hold them.
+	// Types for up to 4 or 16 children have a key byte and a pointer per child,
+	// the type for up to 48 children has a 256 byte index and a pointer per child,
+	// and the type for up to 256 children has an array of 256 child pointers.
+	AdaptiveChildBytes int64
+
+	// BurstNodes is the number of nodes if every maximal subtree with at most BurstThreshold entries
+	// is replaced by a single bucket node holding the suffixes of its keys, as in a burst trie.
+	BurstNodes int
+
+	// BurstThreshold is the largest number of entries held by a bucket for BurstNodes,
+	// which is the same threshold used by tries created by [NewHATTrie].
+	BurstThreshold int
+}
+
+// The largest number of children held by each adaptive node type in CompressionReport.FanoutClasses.
+var fanoutClassSizes = [4]int{4, 16, 48, 256}
+
+// AnalyzeCompression returns an estimate of the number of nodes trie would need with path compression,
+// adaptive node types, and leaf bursting, so the gains of migrating between implementations can be predicted.
+// The estimates depend only on the keys in trie, which are ranged over in their entirety.
+// For tries created by this package, CurrentNodes is counted by traversing the nodes of trie,
+// without ranging over the entries.
+func AnalyzeCompression[V any](trie BTrie[V]) CompressionReport {
+	report := CompressionReport{CurrentNodes: -1, BurstThreshold: maxBucketSize}
+	if t, ok := trie.(nodeCounter); ok {
+		report.CurrentNodes = t.nodeCount()
+	}
+	// One frame per node of the uncompressed trie on the path to the previous key, which are complete when popped.
+	type frame struct {
+		children, entries, nodes int
+		isTerminal               bool
+		// The number of nodes in the children's subtrees which could be bursted into a bucket each.
+		smallChildNodes, smallChildren int
+	}
+	stack := []frame{{}}
+	pop := func() {
+		top := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		top.nodes++
+		report.Nodes++
+		if top.children == 0 {
+			report.Leaves++
+		} else {
+			for i, size := range fanoutClassSizes {
+				if top.children <= size {
+					report.FanoutClasses[i]++
+					break
+				}
+			}
+			report.ArrayChildBytes += 256 * int64(unsafe.Sizeof(uintptr(0)))
+			report.AdaptiveChildBytes += adaptiveChildBytes(top.children)
+		}
+		if top.children == 1 && !top.isTerminal && len(stack) > 0 {
+			report.PathCompressedNodes--
+		}
+		if top.entries > maxBucketSize || len(stack) == 0 {
+			// Each small child's subtree is replaced by a single bucket.
+			report.BurstNodes -= top.smallChildNodes - top.smallChildren
+		}
+		if len(stack) == 0 {
+			if top.entries <= maxBucketSize {
+				report.BurstNodes = 1 - report.Nodes
+			}
+			return
+		}
+		parent := &stack[len(stack)-1]
+		parent.entries += top.entries
+		parent.nodes += top.nodes
+		if top.entries <= maxBucketSize {
+			parent.smallChildNodes += top.nodes
+			parent.smallChildren++
+		}
+	}
+	var prev []byte
+	for key := range All(trie) {
+		report.Entries++
+		for len(stack) > CommonPrefixLen(prev, key)+1 {
+			pop()
+		}
+		for len(stack) <= len(key) {
+			stack[len(stack)-1].children++
+			stack = append(stack, frame{})
+		}
+		stack[len(stack)-1].isTerminal = true
+		stack[len(stack)-1].entries++
+		prev = key
+	}
+	for len(stack) > 0 {
+		pop()
+	}
+	report.PathCompressedNodes += report.Nodes
+	report.BurstNodes += report.Nodes
+	return report
+}
+
+// Returns the memory needed for the given number of children by the smallest adaptive node type which can hold them.
+func adaptiveChildBytes(children int) int64 {
+	ptrSize := int64(unsafe.Sizeof(uintptr(0)))
+	switch {
+	case children <= fanoutClassSizes[1]:
+		size := int64(fanoutClassSizes[0])
+		if children > fanoutClassSizes[0] {
+			size = int64(fanoutClassSizes[1])
+		}
+		return size * (1 + ptrSize)
+	case children <= fanoutClassSizes[2]:
+		return 256 + int64(fanoutClassSizes[2])*ptrSize
+	default:
+		return 256 * ptrSize
+	}
+}
+
+// Implemented by tries which can count their own nodes.
+type nodeCounter interface {
+	nodeCount() int
+}
+
+func (n *arrayTrieNode[V]) nodeCount() int {
+	count := 1
+	if n.children != nil {
+		for _, child := range n.children {
+			if child != nil {
+				count += child.nodeCount()
+			}
+		}
+	}
+	return count
+}
+
+func (n *hatTrieNode[V]) nodeCount() int {
+	count := 1
+	if n.children != nil {
+		for _, child := range n.children {
+			if child != nil {
+				count += child.nodeCount()
+			}
+		}
+	}
+	return count
+}
+
+func (t *WeightedTrie[V]) nodeCount() int {
+	return t.root.nodeCount()
+}
+
+func (n *weightedTrieNode[V]) nodeCount() int {
+	count := 1
+	for _, child := range n.children {
+		count += child.nodeCount()
+	}
+	return count
+}
+
+func (t *ptrTrie[V]) nodeCount() int {
+	return t.nodeCountAt(ptrRoot)
+}
+
+func (t *ptrTrie[V]) nodeCountAt(index int32) int {
+	count := 1
+	n := t.node(index)
+	for i := range int(n.size) {
+		count += t.nodeCountAt(t.child(n, i))
+	}
+	return count
+}
@@ -0,0 +1,66 @@
+package btrie_test
+
+import (
+	"testing"
+
+	"github.com/phiryll/btrie"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnalyzeCompression(t *testing.T) {
+	t.Parallel()
+	currentNodes := map[string]int{
+		"reference":        -1,
+		"pointer-trie":     6,
+		"array-trie":       6,
+		"arena-array-trie": 6,
+		"hat-trie":         1,
+		"weighted-trie":    6,
+		"map-trie":         -1,
+	}
+	for _, def := range implDefs {
+		t.Run("impl="+def.name, func(t *testing.T) {
+			t.Parallel()
+			trie := def.factory()
+			empty := btrie.AnalyzeCompression[byte](trie)
+			assert.Equal(t, 1, empty.Nodes)
+			assert.Equal(t, 1, empty.PathCompressedNodes)
+			assert.Equal(t, 1, empty.BurstNodes)
+			assert.Equal(t, 1, empty.Leaves)
+
+			for _, key := range []string{"abc", "abd", "x"} {
+				trie.Put([]byte(key), 0)
+			}
+			assert.Equal(t, btrie.CompressionReport{
+				Entries:             3,
+				Nodes:               6,
+				CurrentNodes:        currentNodes[def.name],
+				PathCompressedNodes: 5,
+				Leaves:              3,
+				FanoutClasses:       [4]int{3, 0, 0, 0},
+				ArrayChildBytes:     3 * 2048,
+				AdaptiveChildBytes:  3 * 36,
+				BurstNodes:          1,
+				BurstThreshold:      32,
+			}, btrie.AnalyzeCompression[byte](trie))
+		})
+	}
+
+	trie := btrie.NewArrayTrie[byte]()
+	for i := range 40 {
+		trie.Put([]byte{0, byte(i)}, 0)
+	}
+	for i := range 10 {
+		trie.Put([]byte{1, byte(i)}, 0)
+	}
+	report := btrie.AnalyzeCompression(trie)
+	assert.Equal(t, 50, report.Entries)
+	assert.Equal(t, 53, report.Nodes)
+	assert.Equal(t, 53, report.PathCompressedNodes)
+	assert.Equal(t, 50, report.Leaves)
+	assert.Equal(t, [4]int{1, 1, 1, 0}, report.FanoutClasses)
+	assert.Equal(t, int64(3*2048), report.ArrayChildBytes)
+	assert.Equal(t, int64(36+144+640), report.AdaptiveChildBytes)
+	// The subtree for {1} has few enough entries to be a bucket.
+	assert.Equal(t, 43, report.BurstNodes)
+}
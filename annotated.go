@@ -0,0 +1,91 @@
+package btrie
+
+import (
+	"iter"
+	"time"
+)
+
+// Annotation is the envelope stored for each key in an [Annotated] trie.
+type Annotation[V any] struct {
+	// Value is the current value.
+	Value V
+
+	// CreatedAt is when the key was first put, or first put after it was last deleted.
+	CreatedAt time.Time
+
+	// UpdatedAt is when the value was last put.
+	UpdatedAt time.Time
+
+	// Version is the number of times the value has been put since CreatedAt, starting at 1.
+	Version uint64
+}
+
+// Annotated is a BTrie which records when each entry was created and last updated, and how many times it was put.
+// This is the metadata needed by features such as expiration, conflict resolution, and change tracking.
+// Annotated implements [BTrie], and the metadata is available from [Annotated.GetAnnotation] and [Annotated.Annotations].
+// Times are given by the clock set with [Annotated.SetClock], which is [time.Now] by default.
+//
+// An Annotated trie is not safe for concurrent use.
+type Annotated[V any] struct {
+	trie BTrie[Annotation[V]]
+	now  func() time.Time
+}
+
+// NewAnnotated returns a new, empty Annotated trie.
+func NewAnnotated[V any]() *Annotated[V] {
+	return &Annotated[V]{NewArrayTrie[Annotation[V]](), time.Now}
+}
+
+// SetClock sets the function used to get the time of each Put. SetClock will panic if now is nil.
+func (t *Annotated[V]) SetClock(now func() time.Time) {
+	if now == nil {
+		panic("clock must be non-nil")
+	}
+	t.now = now
+}
+
+func (t *Annotated[V]) Get(key []byte) (V, bool) {
+	annotation, ok := t.trie.Get(key)
+	return annotation.Value, ok
+}
+
+// GetAnnotation returns the envelope for key and whether or not it exists.
+func (t *Annotated[V]) GetAnnotation(key []byte) (Annotation[V], bool) {
+	return t.trie.Get(key)
+}
+
+func (t *Annotated[V]) Put(key []byte, value V) (V, bool) {
+	now := t.now()
+	annotation, ok := t.trie.Get(key)
+	prev := annotation.Value
+	if !ok {
+		annotation = Annotation[V]{CreatedAt: now}
+	}
+	annotation.Value = value
+	annotation.UpdatedAt = now
+	annotation.Version++
+	t.trie.Put(key, annotation)
+	return prev, ok
+}
+
+func (t *Annotated[V]) Delete(key []byte) (V, bool) {
+	annotation, ok := t.trie.Delete(key)
+	return annotation.Value, ok
+}
+
+func (t *Annotated[V]) Range(bounds *Bounds) iter.Seq2[[]byte, V] {
+	itr := t.trie.Range(bounds)
+	return func(yield func([]byte, V) bool) {
+		for k, annotation := range itr {
+			if !yield(k, annotation.Value) {
+				return
+			}
+		}
+	}
+}
+
+// Annotations returns a sequence of key/envelope pairs over the given bounds.
+// This trie must not be mutated during an Annotations iteration.
+func (t *Annotated[V]) Annotations(bounds *Bounds) iter.Seq2[[]byte, Annotation[V]] {
+	return t.trie.Range(bounds)
+}
@@ -0,0 +1,76 @@
+package btrie_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/phiryll/btrie"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnnotated(t *testing.T) {
+	t.Parallel()
+	trie := btrie.NewAnnotated[string]()
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := start
+	trie.SetClock(func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	})
+	at := func(seconds int) time.Time {
+		return start.Add(time.Duration(seconds) * time.Second)
+	}
+
+	_, ok := trie.Put([]byte("a"), "a1")
+	assert.False(t, ok)
+	trie.Put([]byte("b"), "b1")
+	prev, ok := trie.Put([]byte("a"), "a2")
+	assert.True(t, ok)
+	assert.Equal(t, "a1", prev)
+
+	value, ok := trie.Get([]byte("a"))
+	assert.True(t, ok)
+	assert.Equal(t, "a2", value)
+	annotation, ok := trie.GetAnnotation([]byte("a"))
+	assert.True(t, ok)
+	assert.Equal(t, btrie.Annotation[string]{Value: "a2", CreatedAt: at(1), UpdatedAt: at(3), Version: 2}, annotation)
+	_, ok = trie.GetAnnotation([]byte("c"))
+	assert.False(t, ok)
+
+	// Deleting a key resets its metadata.
+	prev, ok = trie.Delete([]byte("b"))
+	assert.True(t, ok)
+	assert.Equal(t, "b1", prev)
+	_, ok = trie.Delete([]byte("b"))
+	assert.False(t, ok)
+	trie.Put([]byte("b"), "b2")
+	annotation, _ = trie.GetAnnotation([]byte("b"))
+	assert.Equal(t, btrie.Annotation[string]{Value: "b2", CreatedAt: at(4), UpdatedAt: at(4), Version: 1}, annotation)
+
+	var keys []string
+	var values []string
+	for k, v := range trie.Range(forwardAll) {
+		keys = append(keys, string(k))
+		values = append(values, v)
+	}
+	assert.Equal(t, []string{"a", "b"}, keys)
+	assert.Equal(t, []string{"a2", "b2"}, values)
+
+	var versions []uint64
+	for _, annotation := range trie.Annotations(reverseAll) {
+		versions = append(versions, annotation.Version)
+	}
+	assert.Equal(t, []uint64{1, 2}, versions)
+
+	assert.Panics(t, func() { trie.SetClock(nil) })
+}
+
+func TestAnnotatedDefaultClock(t *testing.T) {
+	t.Parallel()
+	trie := btrie.NewAnnotated[int]()
+	before := time.Now()
+	trie.Put([]byte{1}, 1)
+	annotation, _ := trie.GetAnnotation([]byte{1})
+	assert.False(t, annotation.CreatedAt.Before(before))
+	assert.False(t, annotation.CreatedAt.After(time.Now()))
+}
@@ -0,0 +1,137 @@
+package btrie
+
+import (
+	"encoding/binary"
+	"iter"
+)
+
+// ArenaTrie is a BTrie which stores its values encoded by a Codec in a single byte slice, the arena,
+// so that its nodes hold only an int offset into the arena instead of the value itself.
+// For tries with many medium-sized values, this avoids allocating each value separately,
+// and the garbage collector does not need to scan the values.
+//
+// The arena is append-only, replaced and deleted values are left in place as garbage until [ArenaTrie.Compact].
+// Values are decoded by every method returning them, so a returned value never shares memory with the arena.
+//
+// If the codec returns an error, a Put is not applied and a Get or Range returns a zero value,
+// and the first such error is reported by [ArenaTrie.Err].
+// ArenaTrie implements [BTrie], and is not safe for concurrent use.
+type ArenaTrie[V any] struct {
+	offsets BTrie[int]
+	arena   []byte
+	codec   Codec[V]
+	buf     []byte
+	garbage int
+	err     error
+}
+
+// NewArenaTrie returns a new, empty ArenaTrie which uses codec to encode values into arena.
+// Only the capacity of arena is used, its contents will be overwritten.
+// The arena is reallocated like any other slice if its capacity is exceeded.
+func NewArenaTrie[V any](arena []byte, codec Codec[V]) *ArenaTrie[V] {
+	return &ArenaTrie[V]{offsets: NewArrayTrie[int](), arena: arena[:0], codec: codec}
+}
+
+// Err returns the first error returned by the codec, or nil if there was none.
+func (t *ArenaTrie[V]) Err() error {
+	return t.err
+}
+
+// Size returns the number of bytes in the arena, and how many of those are garbage.
+func (t *ArenaTrie[V]) Size() (int, int) {
+	return len(t.arena), t.garbage
+}
+
+// Compact copies the live values into a new arena of exactly the needed size, discarding the garbage.
+func (t *ArenaTrie[V]) Compact() {
+	arena := make([]byte, 0, len(t.arena)-t.garbage)
+	offsets := NewArrayTrie[int]()
+	for key, offset := range All(t.offsets) {
+		_, size := t.record(offset)
+		offsets.Put(key, len(arena))
+		arena = append(arena, t.arena[offset:offset+size]...)
+	}
+	t.offsets = offsets
+	t.arena = arena
+	t.garbage = 0
+}
+
+func (t *ArenaTrie[V]) Get(key []byte) (V, bool) {
+	offset, ok := t.offsets.Get(key)
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	return t.decode(offset), true
+}
+
+func (t *ArenaTrie[V]) Put(key []byte, value V) (V, bool) {
+	if key == nil {
+		panic("key must be non-nil")
+	}
+	var err error
+	t.buf, err = t.codec.AppendValue(t.buf[:0], value)
+	if err != nil {
+		t.setErr(err)
+		return t.Get(key)
+	}
+	offset := len(t.arena)
+	t.arena = binary.AppendUvarint(t.arena, uint64(len(t.buf)))
+	t.arena = append(t.arena, t.buf...)
+	prevOffset, ok := t.offsets.Put(key, offset)
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	return t.discard(prevOffset), true
+}
+
+func (t *ArenaTrie[V]) Delete(key []byte) (V, bool) {
+	offset, ok := t.offsets.Delete(key)
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	return t.discard(offset), true
+}
+
+func (t *ArenaTrie[V]) Range(bounds *Bounds) iter.Seq2[[]byte, V] {
+	return func(yield func([]byte, V) bool) {
+		for key, offset := range t.offsets.Range(bounds) {
+			if !yield(key, t.decode(offset)) {
+				return
+			}
+		}
+	}
+}
+
+// Returns the encoded value at offset, and the number of arena bytes used by its record.
+func (t *ArenaTrie[V]) record(offset int) ([]byte, int) {
+	size, n := binary.Uvarint(t.arena[offset:])
+	end := offset + n + int(size) //nolint:gosec // size was written by Put
+	return t.arena[offset+n : end], end - offset
+}
+
+// Returns the decoded value at offset, or a zero value if it could not be decoded.
+func (t *ArenaTrie[V]) decode(offset int) V {
+	data, _ := t.record(offset)
+	value, err := t.codec.DecodeValue(data)
+	if err != nil {
+		t.setErr(err)
+	}
+	return value
+}
+
+// Returns the decoded value at offset, and marks its record as garbage.
+func (t *ArenaTrie[V]) discard(offset int) V {
+	value := t.decode(offset)
+	_, size := t.record(offset)
+	t.garbage += size
+	return value
+}
+
+func (t *ArenaTrie[V]) setErr(err error) {
+	if t.err == nil {
+		t.err = err
+	}
+}
@@ -0,0 +1,118 @@
+package btrie_test
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+
+	"github.com/phiryll/btrie"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArenaTrie(t *testing.T) {
+	t.Parallel()
+	random := rand.New(rand.NewSource(493))
+	trie := btrie.NewArenaTrie(make([]byte, 0, 64), btrie.BytesCodec{})
+	expected := map[string][]byte{}
+	for range 2000 {
+		key := []byte{byte(random.Intn(8)), byte(random.Intn(8))}
+		if random.Intn(3) == 0 {
+			prev, ok := trie.Delete(key)
+			expectedPrev, expectedOk := expected[string(key)]
+			assert.Equal(t, expectedOk, ok)
+			assert.Equal(t, expectedPrev, prev)
+			delete(expected, string(key))
+			continue
+		}
+		value := make([]byte, random.Intn(20))
+		random.Read(value)
+		prev, ok := trie.Put(key, value)
+		expectedPrev, expectedOk := expected[string(key)]
+		assert.Equal(t, expectedOk, ok)
+		assert.Equal(t, expectedPrev, prev)
+		expected[string(key)] = value
+	}
+	require.NoError(t, trie.Err())
+	assertSameBytes(t, expected, trie)
+
+	size, garbage := trie.Size()
+	assert.Positive(t, garbage)
+	trie.Compact()
+	compactSize, compactGarbage := trie.Size()
+	assert.Equal(t, size-garbage, compactSize)
+	assert.Zero(t, compactGarbage)
+	assertSameBytes(t, expected, trie)
+
+	// Returned values never share memory with the arena.
+	for key := range expected {
+		value, _ := trie.Get([]byte(key))
+		if len(value) > 0 {
+			value[0]++
+			actual, _ := trie.Get([]byte(key))
+			assert.Equal(t, expected[key], actual)
+			break
+		}
+	}
+}
+
+func assertSameBytes(t *testing.T, expected map[string][]byte, trie btrie.BTrie[[]byte]) {
+	count := 0
+	for key, value := range btrie.All(trie) {
+		assert.Equal(t, expected[string(key)], value, keyName(key))
+		count++
+	}
+	assert.Len(t, expected, count)
+	for key, value := range expected {
+		actual, ok := trie.Get([]byte(key))
+		assert.True(t, ok)
+		assert.Equal(t, value, actual)
+	}
+}
+
+func TestArenaTrieGarbage(t *testing.T) {
+	t.Parallel()
+	trie := btrie.NewArenaTrie[[]byte](nil, btrie.BytesCodec{})
+	trie.Put([]byte{1}, []byte("abc"))
+	size, garbage := trie.Size()
+	assert.Equal(t, 4, size)
+	assert.Zero(t, garbage)
+	trie.Put([]byte{1}, []byte("de"))
+	size, garbage = trie.Size()
+	assert.Equal(t, 7, size)
+	assert.Equal(t, 4, garbage)
+	trie.Delete([]byte{1})
+	size, garbage = trie.Size()
+	assert.Equal(t, 7, size)
+	assert.Equal(t, 7, garbage)
+	trie.Compact()
+	size, garbage = trie.Size()
+	assert.Zero(t, size)
+	assert.Zero(t, garbage)
+}
+
+func TestArenaTrieCodecError(t *testing.T) {
+	t.Parallel()
+	trie := btrie.NewArenaTrie[[]byte](nil, failingCodec{})
+	prev, ok := trie.Put([]byte{1}, []byte("abc"))
+	assert.False(t, ok)
+	assert.Nil(t, prev)
+	require.ErrorIs(t, trie.Err(), errEncode)
+	_, ok = trie.Get([]byte{1})
+	assert.False(t, ok, "failed put must not be applied")
+	size, _ := trie.Size()
+	assert.Zero(t, size)
+}
+
+var errEncode = errors.New("encode failed")
+
+// failingCodec is a btrie.Codec which fails to encode any value.
+type failingCodec struct{}
+
+func (failingCodec) AppendValue(buf, _ []byte) ([]byte, error) {
+	return buf, errEncode
+}
+
+func (failingCodec) DecodeValue(data []byte) ([]byte, error) {
+	return data, nil
+}
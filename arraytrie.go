@@ -4,20 +4,34 @@ import (
 	"bytes"
 	"fmt"
 	"iter"
+	"math"
 	"strings"
 )
 
+// A node of a trie created by [NewArrayTrie], [NewArrayTrieWithAllocator], or [NewArrayTrieWithArena].
+// The root of a trie is its BTrie.
 type arrayTrieNode[V any] struct {
 	children    *[256]*arrayTrieNode[V] // only non-nil if there are children
+	suffix      []byte                  // only non-nil for a suffix leaf, see below
 	value       V                       // valid only if isTerminal is true
+	count       int                     // number of values in this subtree, including this node's
 	numChildren uint16                  // possible values 0-256, so need the extra byte
 	isTerminal  bool                    // whether value is valid
 }
 
+// A suffix leaf is a node without children whose value's key is the path to the node followed by its suffix,
+// instead of a chain of single-child nodes, one per byte of the suffix.
+// It is split lazily, one byte at a time, when another key having the same prefix is put.
+// This makes putting a long key allocate a constant number of nodes instead of one per byte.
+// The root is never a suffix leaf, and a suffix leaf's value is always valid.
+
 // NewArrayTrie returns a new BTrie with pointers to children stored in arrays.
+// Every node counts the values in its subtree, and these prefix counts are maintained as keys are put and deleted.
+// [HeavyPrefixes], [FanoutCounts], [SplitPoints], [SeekNth], and [EvictFraction] use them
+// to skip entries they would otherwise have to range over. The counts are not visible through a wrapper.
 func NewArrayTrie[V any]() BTrie[V] {
 	var zero V
-	return &arrayTrieNode[V]{nil, zero, 0, false}
+	return &arrayTrieNode[V]{nil, nil, zero, 0, 0, false}
 }
 
 func (n *arrayTrieNode[V]) Get(key []byte) (V, bool) {
@@ -25,15 +39,19 @@ func (n *arrayTrieNode[V]) Get(key []byte) (V, bool) {
 		panic("key must be non-nil")
 	}
 	var zero V
-	for _, keyByte := range key {
+	i := 0
+	for ; i < len(key) && n.suffix == nil; i++ {
 		if n.children == nil {
 			return zero, false
 		}
-		n = n.children[keyByte]
+		n = n.children[key[i]]
 		if n == nil {
 			return zero, false
 		}
 	}
+	if n.suffix != nil && !bytes.Equal(key[i:], n.suffix) {
+		return zero, false
+	}
 	// n = found key
 	if n.isTerminal {
 		return n.value, true
@@ -41,28 +59,128 @@ func (n *arrayTrieNode[V]) Get(key []byte) (V, bool) {
 	return zero, false
 }
 
+func (n *arrayTrieNode[V]) getWithDepth(key []byte) (V, int, bool) {
+	var zero V
+	i := 0
+	for ; i < len(key) && n.suffix == nil; i++ {
+		if n.children == nil || n.children[key[i]] == nil {
+			return zero, i, false
+		}
+		n = n.children[key[i]]
+	}
+	if n.suffix != nil {
+		if bytes.Equal(key[i:], n.suffix) {
+			return n.value, len(key), true
+		}
+		return zero, i + CommonPrefixLen(key[i:], n.suffix), false
+	}
+	// n = found key
+	if n.isTerminal {
+		return n.value, i, true
+	}
+	return zero, i, false
+}
+
+func (n *arrayTrieNode[V]) prefixesOf(key []byte) iter.Seq2[[]byte, V] {
+	return func(yield func([]byte, V) bool) {
+		node := n
+		for i := 0; ; i++ {
+			if node.suffix != nil {
+				if bytes.HasPrefix(key[i:], node.suffix) {
+					yield(bytes.Clone(key[:i+len(node.suffix)]), node.value)
+				}
+				return
+			}
+			if node.isTerminal && !yield(bytes.Clone(key[:i]), node.value) {
+				return
+			}
+			if i == len(key) || node.children == nil {
+				return
+			}
+			node = node.children[key[i]]
+			if node == nil {
+				return
+			}
+		}
+	}
+}
+
+// NewArrayTrieWithAllocator returns a new BTrie like [NewArrayTrie], which allocates every node except the root
+// from the [Allocator] returned by newAlloc, and a function returning that Allocator's stats.
+// The node type is internal to the trie, so newAlloc is typically a generic constructor such as [NewHeapAllocator],
+// whose type argument is inferred, as in NewArrayTrieWithAllocator[V](NewHeapAllocator).
+// newAlloc is called once. Nodes are freed as soon as they are removed from the trie.
+// NewArrayTrieWithAllocator will panic if newAlloc is nil.
+func NewArrayTrieWithAllocator[V any](newAlloc func() Allocator[arrayTrieNode[V]]) (BTrie[V], func() AllocStats) {
+	if newAlloc == nil {
+		panic("newAlloc must be non-nil")
+	}
+	var zero V
+	alloc := newAlloc()
+	return &allocArrayTrie[V]{&arrayTrieNode[V]{nil, nil, zero, 0, 0, false}, alloc}, alloc.Stats
+}
+
+// NewArrayTrieWithArena returns a new BTrie like [NewArrayTrieWithAllocator],
+// using an Allocator returned by [NewArenaAllocator] with chunkSize.
+// NewArrayTrieWithArena will panic if chunkSize is less than 1.
+func NewArrayTrieWithArena[V any](chunkSize int) (BTrie[V], func() AllocStats) {
+	return NewArrayTrieWithAllocator(func() Allocator[arrayTrieNode[V]] {
+		return NewArenaAllocator[arrayTrieNode[V]](chunkSize)
+	})
+}
+
+// An array trie using an allocator, which only changes how Put and Delete create and remove nodes.
+type allocArrayTrie[V any] struct {
+	*arrayTrieNode[V]
+	alloc Allocator[arrayTrieNode[V]]
+}
+
+func (t *allocArrayTrie[V]) Put(key []byte, value V) (V, bool) {
+	return t.put(key, value, t.alloc)
+}
+
+func (t *allocArrayTrie[V]) Delete(key []byte) (V, bool) {
+	return t.delete(key, t.alloc)
+}
+
+func (t *allocArrayTrie[V]) Format(f fmt.State, verb rune) {
+	formatTrie(f, verb, t)
+}
+
 func (n *arrayTrieNode[V]) Put(key []byte, value V) (V, bool) {
+	return n.put(key, value, nil)
+}
+
+// If alloc is nil, nodes are allocated by Go.
+func (n *arrayTrieNode[V]) put(key []byte, value V, alloc Allocator[arrayTrieNode[V]]) (V, bool) {
 	if key == nil {
 		panic("key must be non-nil")
 	}
 	var zero V
+	root := n
 	for i, keyByte := range key {
+		if n.suffix != nil {
+			if bytes.Equal(key[i:], n.suffix) {
+				prev := n.value
+				n.value = value
+				return prev, true
+			}
+			n.split(alloc)
+		}
 		if n.children == nil {
 			n.children = &[256]*arrayTrieNode[V]{}
 		}
 		if n.children[keyByte] == nil {
-			child := &arrayTrieNode[V]{nil, value, 0, true}
-			for k := len(key) - 1; k > i; k-- {
-				parent := &arrayTrieNode[V]{&[256]*arrayTrieNode[V]{}, zero, 1, false}
-				parent.children[key[k]] = child
-				child = parent
-			}
-			n.children[keyByte] = child
+			n.children[keyByte] = newArrayTrieLeaf(alloc, bytes.Clone(key[i+1:]), value)
 			n.numChildren++
+			root.addCount(key[:i], 1)
 			return zero, false
 		}
 		n = n.children[keyByte]
 	}
+	if n.suffix != nil {
+		n.split(alloc)
+	}
 	// n = found key, replace value
 	if n.isTerminal {
 		prev := n.value
@@ -71,28 +189,75 @@ func (n *arrayTrieNode[V]) Put(key []byte, value V) (V, bool) {
 	}
 	n.value = value
 	n.isTerminal = true
+	root.addCount(key, 1)
 	return zero, false
 }
 
+// Returns a new leaf node allocated by alloc, which is a suffix leaf unless suffix is empty.
+// If alloc is nil, the node is allocated by Go. The returned node retains suffix.
+func newArrayTrieLeaf[V any](alloc Allocator[arrayTrieNode[V]], suffix []byte, value V) *arrayTrieNode[V] {
+	if len(suffix) == 0 {
+		suffix = nil
+	}
+	if alloc == nil {
+		return &arrayTrieNode[V]{nil, suffix, value, 1, 0, true}
+	}
+	leaf := alloc.Alloc()
+	*leaf = arrayTrieNode[V]{nil, suffix, value, 1, 0, true}
+	return leaf
+}
+
+// Moves the value of suffix leaf n to a new child, shortening the suffix by one byte.
+// Afterward, n is an ordinary node with a single child and no value.
+func (n *arrayTrieNode[V]) split(alloc Allocator[arrayTrieNode[V]]) {
+	var zero V
+	n.children = &[256]*arrayTrieNode[V]{}
+	n.children[n.suffix[0]] = newArrayTrieLeaf(alloc, n.suffix[1:], n.value)
+	n.numChildren = 1
+	n.suffix = nil
+	n.value = zero
+	n.isTerminal = false
+}
+
+// Adds delta to the counts of n and every node on the path to key, all of which must exist.
+func (n *arrayTrieNode[V]) addCount(key []byte, delta int) {
+	n.count += delta
+	for _, keyByte := range key {
+		n = n.children[keyByte]
+		n.count += delta
+	}
+}
+
 func (n *arrayTrieNode[V]) Delete(key []byte) (V, bool) {
+	return n.delete(key, nil)
+}
+
+// If alloc is nil, removed nodes are left for Go to collect.
+func (n *arrayTrieNode[V]) delete(key []byte, alloc Allocator[arrayTrieNode[V]]) (V, bool) {
 	if key == nil {
 		panic("key must be non-nil")
 	}
 	var zero V
-	// If the deleted node has no children, remove the subtree rooted at prune.children[pruneIndex].
+	root := n
+	// If the deleted node has no children, remove the subtree rooted at prune.children[key[pruneDepth]].
 	var prune *arrayTrieNode[V]
-	var pruneIndex byte
-	for i, keyByte := range key {
+	var pruneDepth int
+	i := 0
+	for ; i < len(key) && n.suffix == nil; i++ {
+		keyByte := key[i]
 		if n.children == nil || n.children[keyByte] == nil {
 			return zero, false
 		}
 		// If either n is the root, or n has a value, or n has more than one child, then n itself cannot be pruned.
 		// If so, move the maybe-pruned subtree to n.children[index].
 		if i == 0 || n.isTerminal || n.numChildren > 1 {
-			prune, pruneIndex = n, keyByte
+			prune, pruneDepth = n, i
 		}
 		n = n.children[keyByte]
 	}
+	if n.suffix != nil && !bytes.Equal(key[i:], n.suffix) {
+		return zero, false
+	}
 	// n = found key
 	if !n.isTerminal {
 		return zero, false
@@ -100,119 +265,274 @@ func (n *arrayTrieNode[V]) Delete(key []byte) (V, bool) {
 	prev := n.value
 	n.value = zero
 	n.isTerminal = false
+	root.addCount(key[:i], -1)
 	if len(key) > 0 && n.children == nil {
-		prune.children[pruneIndex] = nil
+		pruned := prune.children[key[pruneDepth]]
+		prune.children[key[pruneDepth]] = nil
 		prune.numChildren--
+		if prune.numChildren == 0 {
+			// Otherwise prune could never be pruned itself.
+			prune.children = nil
+		}
+		if alloc != nil {
+			// The pruned subtree is a chain of nodes along key, ending with n.
+			for depth := pruneDepth + 1; pruned != n; depth++ {
+				next := pruned.children[key[depth]]
+				alloc.Free(pruned)
+				pruned = next
+			}
+			alloc.Free(n)
+		}
 	}
 	return prev, true
 }
 
-// An iter.Seq of these is returned from the adjFunction used internally by Range.
-// key = path from root to node
-// It is cached here for efficiency, otherwise an iter.Seq of []*arrayTrieNode[V] would be used directly.
-// Note that the key must be cloned when yielded from Range.
-type arrayTrieRangePath[V any] struct {
-	node *arrayTrieNode[V]
-	key  []byte
+// A node being traversed by Range, and the remaining children of that node to traverse.
+// Range uses an explicit stack of these and a single reused key buffer,
+// so that traversing a node does not allocate.
+type arrayTrieFrame[V any] struct {
+	node      *arrayTrieNode[V]
+	next      int    // index of the next child to consider
+	stop      int    // index of the last child to consider, inclusive
+	remaining uint16 // number of children not yet traversed
 }
 
 func (n *arrayTrieNode[V]) Range(bounds *Bounds) iter.Seq2[[]byte, V] {
+	return n.rangeWithMaxDepth(bounds, 0)
+}
+
+func (n *arrayTrieNode[V]) rangeWithMaxDepth(bounds *Bounds, maxDepth int) iter.Seq2[[]byte, V] {
 	bounds = bounds.Clone()
-	root := arrayTrieRangePath[V]{n, []byte{}}
-	var pathItr iter.Seq[*arrayTrieRangePath[V]]
 	if bounds.IsReverse {
-		pathItr = postOrder(&root, arrayTrieReverseAdj[V](bounds))
-	} else {
-		pathItr = preOrder(&root, arrayTrieForwardAdj[V](bounds))
+		return n.rangeReverse(bounds, maxDepth, nil)
 	}
-	return func(yield func([]byte, V) bool) {
-		for path := range pathItr {
-			cmp := bounds.Compare(path.key)
-			if cmp < 0 {
+	return n.rangeForward(bounds, maxDepth, nil)
+}
+
+func (n *arrayTrieNode[V]) rangeIntoBuffer(bounds *Bounds, buf []byte) iter.Seq2[[]byte, V] {
+	bounds = bounds.Clone()
+	if bounds.IsReverse {
+		return n.rangeReverse(bounds, cap(buf), buf)
+	}
+	return n.rangeForward(bounds, cap(buf), buf)
+}
+
+func (n *arrayTrieNode[V]) all(isReverse bool) iter.Seq2[[]byte, V] {
+	if isReverse {
+		return n.rangeReverse(nil, 0, nil)
+	}
+	return n.rangeForward(nil, 0, nil)
+}
+
+// Returns a copy of key, written into *buf if *buf is non-nil.
+func copyKey(buf *[]byte, key []byte) []byte {
+	if *buf == nil {
+		return bytes.Clone(key)
+	}
+	*buf = append((*buf)[:0], key...)
+	return *buf
+}
+
+func (n *arrayTrieNode[V]) childBytes(prefix []byte) iter.Seq[byte] {
+	i := 0
+	for ; i < len(prefix) && n.suffix == nil; i++ {
+		if n.children == nil {
+			return emptySeq
+		}
+		n = n.children[prefix[i]]
+		if n == nil {
+			return emptySeq
+		}
+	}
+	if n.suffix != nil {
+		rest := prefix[i:]
+		if len(rest) >= len(n.suffix) || !bytes.HasPrefix(n.suffix, rest) {
+			return emptySeq
+		}
+		keyByte := n.suffix[len(rest)]
+		return func(yield func(byte) bool) {
+			yield(keyByte)
+		}
+	}
+	if n.children == nil {
+		return emptySeq
+	}
+	return func(yield func(byte) bool) {
+		count := n.numChildren
+		for i, child := range n.children {
+			if child == nil {
 				continue
 			}
-			if cmp > 0 {
+			if !yield(byte(i)) {
 				return
 			}
-			if path.node.isTerminal && !yield(bytes.Clone(path.key), path.node.value) {
+			count--
+			if count == 0 {
 				return
 			}
 		}
 	}
 }
 
-func arrayTrieForwardAdj[V any](bounds *Bounds) adjFunction[*arrayTrieRangePath[V]] {
-	// Sometimes a child is not within the bounds, but one of its descendants is.
-	return func(path *arrayTrieRangePath[V]) iter.Seq[*arrayTrieRangePath[V]] {
-		if path.node.children == nil {
-			return emptySeq
-		}
-		start, stop, ok := bounds.childBounds(path.key)
-		if !ok {
-			// Unreachable because of how the trie is traversed forward.
-			panic("unreachable")
-		}
-		return func(yield func(*arrayTrieRangePath[V]) bool) {
-			count := path.node.numChildren
-			for i, child := range path.node.children[start : int(stop)+1] {
-				if child == nil {
-					continue
+// Traverses in pre-order. If bounds is nil, all entries are traversed.
+// If buf is non-nil, every key is yielded in buf, which is replaced by a larger one if needed.
+func (n *arrayTrieNode[V]) rangeForward(bounds *Bounds, maxDepth int, buf []byte) iter.Seq2[[]byte, V] {
+	return func(yield func([]byte, V) bool) {
+		buf := buf
+		key := make([]byte, 0, maxDepth)
+		stack := make([]arrayTrieFrame[V], 0, maxDepth)
+		node := n
+		for {
+			// invariant: key = path from root to node, len(key) = len(stack)
+			// The suffix is only appended temporarily, it is truncated below with the rest of the key.
+			valueKey := append(key, node.suffix...)
+			if bounds != nil {
+				cmp := bounds.Compare(valueKey)
+				if cmp > 0 {
+					return
 				}
-				if !yield(&arrayTrieRangePath[V]{child, append(path.key, start+byte(i))}) {
+				if cmp == 0 && node.isTerminal && !yield(copyKey(&buf, valueKey), node.value) {
 					return
 				}
-				count--
-				if count == 0 {
+			} else if node.isTerminal && !yield(copyKey(&buf, valueKey), node.value) {
+				return
+			}
+			if node.children != nil {
+				// Sometimes a child is not within the bounds, but one of its descendants is.
+				start, stop := byte(0), byte(math.MaxUint8)
+				if bounds != nil {
+					var ok bool
+					start, stop, ok = bounds.childBounds(key)
+					if !ok {
+						// Unreachable because of how the trie is traversed forward.
+						panic("unreachable")
+					}
+				}
+				stack = append(stack, arrayTrieFrame[V]{node, int(start), int(stop), node.numChildren})
+			}
+			node = nil
+			for node == nil {
+				if len(stack) == 0 {
 					return
 				}
+				top := &stack[len(stack)-1]
+				for ; top.remaining > 0 && top.next <= top.stop; top.next++ {
+					if child := top.node.children[top.next]; child != nil {
+						node = child
+						key = append(key[:len(stack)-1], byte(top.next))
+						top.next++
+						top.remaining--
+						break
+					}
+				}
+				if node == nil {
+					stack = stack[:len(stack)-1]
+				}
 			}
 		}
 	}
 }
 
-func arrayTrieReverseAdj[V any](bounds *Bounds) adjFunction[*arrayTrieRangePath[V]] {
-	// Sometimes a child is not within the bounds, but one of its descendants is.
-	return func(path *arrayTrieRangePath[V]) iter.Seq[*arrayTrieRangePath[V]] {
-		if path.node.children == nil {
-			return emptySeq
-		}
-		start, stop, ok := bounds.childBounds(path.key)
-		if !ok {
-			return emptySeq
-		}
-		return func(yield func(*arrayTrieRangePath[V]) bool) {
-			children := path.node.children[stop : int(start)+1]
-			count := path.node.numChildren
-			for i := len(children) - 1; i >= 0; i-- {
-				child := children[i]
-				if child == nil {
+// Traverses in post-order, visiting children in reverse. If bounds is nil, all entries are traversed.
+// If buf is non-nil, every key is yielded in buf, which is replaced by a larger one if needed.
+func (n *arrayTrieNode[V]) rangeReverse(bounds *Bounds, maxDepth int, buf []byte) iter.Seq2[[]byte, V] {
+	return func(yield func([]byte, V) bool) {
+		buf := buf
+		key := make([]byte, 0, maxDepth)
+		stack := append(make([]arrayTrieFrame[V], 0, maxDepth+1), n.reverseFrame(bounds, key))
+		for {
+			// invariant: key = path from root to top.node, len(key) = len(stack) - 1
+			top := &stack[len(stack)-1]
+			var child *arrayTrieNode[V]
+			for ; top.remaining > 0 && top.next >= top.stop; top.next-- {
+				if child = top.node.children[top.next]; child != nil {
+					key = append(key, byte(top.next))
+					top.next--
+					top.remaining--
+					break
+				}
+			}
+			node, isLeaf := top.node, false
+			if child != nil {
+				if child.children != nil {
+					stack = append(stack, child.reverseFrame(bounds, key))
 					continue
 				}
-				if !yield(&arrayTrieRangePath[V]{child, append(path.key, stop+byte(i))}) {
+				// A leaf has no children to visit first, so visit it now instead of pushing a frame for it.
+				node, isLeaf = child, true
+			}
+			// This does not modify key, only possibly the unused part of its backing array.
+			valueKey := append(key, node.suffix...)
+			if bounds != nil {
+				cmp := bounds.Compare(valueKey)
+				if cmp > 0 {
 					return
 				}
-				count--
-				if count == 0 {
+				if cmp == 0 && node.isTerminal && !yield(copyKey(&buf, valueKey), node.value) {
 					return
 				}
+			} else if node.isTerminal && !yield(copyKey(&buf, valueKey), node.value) {
+				return
 			}
+			if !isLeaf {
+				stack = stack[:len(stack)-1]
+				if len(stack) == 0 {
+					return
+				}
+			}
+			key = key[:len(key)-1]
+		}
+	}
+}
+
+func (n *arrayTrieNode[V]) reverseFrame(bounds *Bounds, key []byte) arrayTrieFrame[V] {
+	if n.children == nil {
+		return arrayTrieFrame[V]{n, -1, 0, 0}
+	}
+	// Sometimes a child is not within the bounds, but one of its descendants is.
+	start, stop := byte(math.MaxUint8), byte(0)
+	if bounds != nil {
+		var ok bool
+		start, stop, ok = bounds.childBounds(key)
+		if !ok {
+			return arrayTrieFrame[V]{n, -1, 0, 0}
 		}
 	}
+	return arrayTrieFrame[V]{n, int(start), int(stop), n.numChildren}
 }
 
 func (n *arrayTrieNode[V]) String() string {
 	var s strings.Builder
-	n.printNode(&s, 0, "")
+	n.printTo(&s, -1)
 	return s.String()
 }
 
+func (n *arrayTrieNode[V]) Format(f fmt.State, verb rune) {
+	formatTrie(f, verb, n)
+}
+
+func (n *arrayTrieNode[V]) printTo(s *strings.Builder, levels int) {
+	n.printNode(s, 0, "", levels)
+}
+
 //nolint:revive
-func (n *arrayTrieNode[V]) printNode(s *strings.Builder, keyByte byte, indent string) {
+func (n *arrayTrieNode[V]) printNode(s *strings.Builder, keyByte byte, indent string, levels int) {
 	if indent == "" {
 		s.WriteString("[]")
 	} else {
 		fmt.Fprintf(s, "%s%02X", indent, keyByte)
 	}
+	// A suffix leaf is printed as the chain of nodes it replaces.
+	for _, suffixByte := range n.suffix {
+		s.WriteString("\n")
+		if levels == 0 {
+			s.WriteString(indent + "  ...\n")
+			return
+		}
+		indent += "  "
+		levels--
+		fmt.Fprintf(s, "%s%02X", indent, suffixByte)
+	}
 	if n.isTerminal {
 		fmt.Fprintf(s, ": %v\n", n.value)
 	} else {
@@ -221,9 +541,31 @@ func (n *arrayTrieNode[V]) printNode(s *strings.Builder, keyByte byte, indent st
 	if n.children == nil {
 		return
 	}
+	if levels == 0 {
+		s.WriteString(indent + "  ...\n")
+		return
+	}
 	for i, child := range n.children {
 		if child != nil {
-			child.printNode(s, byte(i), indent+"  ")
+			child.printNode(s, byte(i), indent+"  ", levels-1)
+		}
+	}
+}
+
+func (n *arrayTrieNode[V]) stats() (int, int) {
+	size, depth := 0, 0
+	if n.isTerminal {
+		size = 1
+	}
+	if n.children == nil {
+		return size, len(n.suffix)
+	}
+	for _, child := range n.children {
+		if child != nil {
+			childSize, childDepth := child.stats()
+			size += childSize
+			depth = max(depth, childDepth+1)
 		}
 	}
+	return size, depth
 }
@@ -0,0 +1,111 @@
+package btrie
+
+import (
+	"bytes"
+	"iter"
+	"slices"
+)
+
+// Automaton is a deterministic automaton over key bytes, with states of type S.
+// It is used by [RangeAutomaton] to only traverse the parts of a trie which can contain a matching key.
+// An Automaton must not modify a state once it has been returned, so that states can be shared between branches.
+type Automaton[S any] interface {
+	// Start returns the state before any bytes have been read.
+	Start() S
+
+	// Step returns the state after reading b in state.
+	Step(state S, b byte) S
+
+	// IsMatch returns whether the bytes read to reach state form a matching key.
+	IsMatch(state S) bool
+
+	// CanMatch returns whether any key beginning with the bytes read to reach state can match.
+	// If it returns false, the traversal does not continue below the current key.
+	CanMatch(state S) bool
+}
+
+// RangeAutomaton returns a sequence of the entries in trie whose keys are matched by a, in increasing key order.
+// Only the keys whose prefixes a can match are visited, using [Children] to find the next bytes to read.
+// The returned sequence has the same constraints as those returned by trie.Range.
+// RangeAutomaton will panic if a is nil.
+func RangeAutomaton[V, S any](trie BTrie[V], a Automaton[S]) iter.Seq2[[]byte, V] {
+	if a == nil {
+		panic("automaton must be non-nil")
+	}
+	return func(yield func([]byte, V) bool) {
+		start := a.Start()
+		if a.CanMatch(start) {
+			rangeAutomatonRecurse(trie, a, []byte{}, start, yield)
+		}
+	}
+}
+
+// Returns true if done (some yield has returned false).
+func rangeAutomatonRecurse[V, S any](trie BTrie[V], a Automaton[S], key []byte, state S,
+	yield func([]byte, V) bool,
+) bool {
+	if a.IsMatch(state) {
+		if value, ok := trie.Get(key); ok && !yield(bytes.Clone(key), value) {
+			return true
+		}
+	}
+	for b := range Children(trie, key) {
+		next := a.Step(state, b)
+		if a.CanMatch(next) && rangeAutomatonRecurse(trie, a, append(key, b), next, yield) {
+			return true
+		}
+	}
+	return false
+}
+
+// EditDistanceAutomaton returns an Automaton matching the keys within Levenshtein distance k of key,
+// where each inserted, deleted, or substituted byte has a distance of 1.
+// The returned Automaton is immutable, and may be reused for any number of traversals.
+// Use it with [RangeAutomaton] to find the keys in a trie approximately matching key.
+// Distances are between bytes rather than runes, so a substituted multi-byte rune may count more than once.
+// EditDistanceAutomaton will panic if key is nil or k is negative.
+func EditDistanceAutomaton(key []byte, k int) Automaton[[]int] {
+	if key == nil {
+		panic("key must be non-nil")
+	}
+	if k < 0 {
+		panic("k must be non-negative")
+	}
+	return &editDistanceAutomaton{bytes.Clone(key), k}
+}
+
+// A state is a row of the Levenshtein distance matrix, whose ith entry is the distance between
+// the bytes read so far and key[:i]. Entries are capped at k+1, since larger distances never match.
+type editDistanceAutomaton struct {
+	key []byte
+	k   int
+}
+
+func (a *editDistanceAutomaton) Start() []int {
+	row := make([]int, len(a.key)+1)
+	for i := range row {
+		row[i] = min(i, a.k+1)
+	}
+	return row
+}
+
+func (a *editDistanceAutomaton) Step(state []int, b byte) []int {
+	row := make([]int, len(state))
+	row[0] = min(state[0]+1, a.k+1)
+	for i := 1; i < len(row); i++ {
+		cost := 1
+		if a.key[i-1] == b {
+			cost = 0
+		}
+		row[i] = min(state[i]+1, row[i-1]+1, state[i-1]+cost, a.k+1)
+	}
+	return row
+}
+
+func (a *editDistanceAutomaton) IsMatch(state []int) bool {
+	return state[len(state)-1] <= a.k
+}
+
+func (a *editDistanceAutomaton) CanMatch(state []int) bool {
+	return slices.Min(state) <= a.k
+}
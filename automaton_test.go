@@ -0,0 +1,93 @@
+package btrie_test
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/phiryll/btrie"
+	"github.com/stretchr/testify/assert"
+)
+
+// Returns the Levenshtein distance between a and b.
+func editDistance(a, b []byte) int {
+	row := make([]int, len(b)+1)
+	for j := range row {
+		row[j] = j
+	}
+	for i := range a {
+		prev := row[0]
+		row[0] = i + 1
+		for j := range b {
+			cost := 1
+			if a[i] == b[j] {
+				cost = 0
+			}
+			prev, row[j+1] = row[j+1], min(row[j+1]+1, row[j]+1, prev+cost)
+		}
+	}
+	return row[len(b)]
+}
+
+func TestEditDistanceAutomaton(t *testing.T) {
+	t.Parallel()
+	assert.Panics(t, func() { btrie.EditDistanceAutomaton(nil, 1) })
+	assert.Panics(t, func() { btrie.EditDistanceAutomaton([]byte{}, -1) })
+	assert.Panics(t, func() { btrie.RangeAutomaton[byte, []int](btrie.NewArrayTrie[byte](), nil) })
+	random := rand.New(rand.NewSource(2267))
+	randomKey := func() []byte {
+		key := make([]byte, random.Intn(6))
+		for i := range key {
+			key[i] = byte('a' + random.Intn(3))
+		}
+		return key
+	}
+	for name, factory := range map[string]func() btrie.BTrie[byte]{
+		"array":    btrie.NewArrayTrie[byte],
+		"pointer":  btrie.NewPointerTrie[byte],
+		"weighted": newWeightedTrie,
+	} {
+		trie := factory()
+		ref := newReference()
+		for i := range 300 {
+			key := randomKey()
+			trie.Put(key, byte(i))
+			ref.Put(key, byte(i))
+		}
+		for range 50 {
+			query := randomKey()
+			for k := range 3 {
+				automaton := btrie.EditDistanceAutomaton(query, k)
+				expected := []entry{}
+				for key, value := range ref.Range(forwardAll) {
+					if editDistance(query, key) <= k {
+						expected = append(expected, entry{key, value})
+					}
+				}
+				// The automaton is reusable, so ranging twice must give the same result.
+				for range 2 {
+					assert.Equal(t, expected, collect(btrie.RangeAutomaton(trie, automaton)),
+						fmt.Sprintf("%s %q %d", name, query, k))
+				}
+			}
+		}
+	}
+}
+
+func TestRangeAutomatonEarlyYield(t *testing.T) {
+	t.Parallel()
+	trie := btrie.NewArrayTrie[byte]()
+	for i, key := range []string{"", "cat", "cot", "cut", "dog"} {
+		trie.Put([]byte(key), byte(i))
+	}
+	automaton := btrie.EditDistanceAutomaton([]byte("cat"), 1)
+	var got []entry
+	for k, v := range btrie.RangeAutomaton(trie, automaton) {
+		got = append(got, entry{k, v})
+		if len(got) == 2 {
+			break
+		}
+	}
+	assert.Equal(t, []entry{{[]byte("cat"), 1}, {[]byte("cot"), 2}}, got)
+	assert.Equal(t, []entry{{[]byte{}, 0}}, collect(btrie.RangeAutomaton(trie, btrie.EditDistanceAutomaton([]byte{}, 0))))
+}
@@ -0,0 +1,100 @@
+package btrie
+
+import "iter"
+
+// Store is an external key-value store backing a trie, see [WithBackingStore].
+// Implementations must not retain key.
+type Store[V any] interface {
+	// Load returns the value stored for key, and whether it was found.
+	Load(key []byte) (V, bool, error)
+
+	// Store stores value for key.
+	Store(key []byte, value V) error
+
+	// Delete removes key, which is not an error if key is absent.
+	Delete(key []byte) error
+}
+
+// Backed is a BTrie acting as an ordered in-memory cache in front of a [Store].
+// Gets which miss the trie are read through to the store, and Puts and Deletes are written through to it.
+// Range only traverses the entries in the trie, not those only in the store.
+//
+// A Put or Delete is applied to the trie only if the store succeeds,
+// and the first error returned by the store is reported by [Backed.Err].
+// If the store fails, Put and Delete return the trie's current value for the key, as if it had been replaced by itself.
+// Backed implements [BTrie], and is not safe for concurrent use.
+type Backed[V any] struct {
+	trie     BTrie[V]
+	store    Store[V]
+	populate bool
+	err      error
+}
+
+// WithBackingStore returns a Backed wrapping trie, which reads through and writes through to store.
+// Existing entries in trie are not written to store.
+func WithBackingStore[V any](trie BTrie[V], store Store[V]) *Backed[V] {
+	return &Backed[V]{trie: trie, store: store}
+}
+
+// PopulateOnMiss causes later calls to Get which find a value in the store to also put it into the trie,
+// if populate is true. The default is false, so that the trie only contains entries put through b.
+func (b *Backed[V]) PopulateOnMiss(populate bool) {
+	b.populate = populate
+}
+
+// Err returns the first error returned by the store, or nil if there was none.
+func (b *Backed[V]) Err() error {
+	return b.err
+}
+
+func (b *Backed[V]) Get(key []byte) (V, bool) {
+	if value, ok := b.trie.Get(key); ok {
+		return value, true
+	}
+	value, ok, err := b.store.Load(key)
+	if err != nil {
+		b.setErr(err)
+		var zero V
+		return zero, false
+	}
+	if ok && b.populate {
+		b.trie.Put(key, value)
+	}
+	return value, ok
+}
+
+// Put puts value into the store, and into the trie if that succeeds.
+// The returned previous value is only from the trie.
+func (b *Backed[V]) Put(key []byte, value V) (V, bool) {
+	if key == nil {
+		panic("key must be non-nil")
+	}
+	if err := b.store.Store(key, value); err != nil {
+		b.setErr(err)
+		return b.trie.Get(key)
+	}
+	return b.trie.Put(key, value)
+}
+
+// Delete deletes key from the store, and from the trie if that succeeds.
+// The returned previous value is only from the trie.
+func (b *Backed[V]) Delete(key []byte) (V, bool) {
+	if key == nil {
+		panic("key must be non-nil")
+	}
+	if err := b.store.Delete(key); err != nil {
+		b.setErr(err)
+		return b.trie.Get(key)
+	}
+	return b.trie.Delete(key)
+}
+
+func (b *Backed[V]) Range(bounds *Bounds) iter.Seq2[[]byte, V] {
+	return b.trie.Range(bounds)
+}
+
+func (b *Backed[V]) setErr(err error) {
+	if b.err == nil {
+		b.err = err
+	}
+}
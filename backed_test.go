@@ -0,0 +1,99 @@
+package btrie_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/phiryll/btrie"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errStore = errors.New("store failed")
+
+// mapStore is a btrie.Store which fails every operation after failAfter operations, if it is positive.
+type mapStore struct {
+	entries   map[string]byte
+	ops       int
+	failAfter int
+}
+
+func (m *mapStore) fail() bool {
+	m.ops++
+	return m.failAfter > 0 && m.ops > m.failAfter
+}
+
+func (m *mapStore) Load(key []byte) (byte, bool, error) {
+	if m.fail() {
+		return 0, false, errStore
+	}
+	value, ok := m.entries[string(key)]
+	return value, ok, nil
+}
+
+func (m *mapStore) Store(key []byte, value byte) error {
+	if m.fail() {
+		return errStore
+	}
+	m.entries[string(key)] = value
+	return nil
+}
+
+func (m *mapStore) Delete(key []byte) error {
+	if m.fail() {
+		return errStore
+	}
+	delete(m.entries, string(key))
+	return nil
+}
+
+func TestBackingStore(t *testing.T) {
+	t.Parallel()
+	store := &mapStore{entries: map[string]byte{"a": 1, "b": 2}}
+	trie := btrie.WithBackingStore(btrie.NewArrayTrie[byte](), store)
+
+	value, ok := trie.Get([]byte("a"))
+	assert.True(t, ok)
+	assert.Equal(t, byte(1), value)
+	assert.Empty(t, collect(btrie.All[byte](trie)), "not populated by default")
+
+	trie.PopulateOnMiss(true)
+	trie.Get([]byte("b"))
+	_, ok = trie.Get([]byte("c"))
+	assert.False(t, ok)
+	assert.Equal(t, []entry{{[]byte("b"), 2}}, collect(btrie.All[byte](trie)))
+
+	trie.Put([]byte("c"), 3)
+	trie.Delete([]byte("a"))
+	prev, ok := trie.Delete([]byte("b"))
+	assert.True(t, ok)
+	assert.Equal(t, byte(2), prev)
+	assert.Equal(t, map[string]byte{"c": 3}, store.entries)
+	assert.Equal(t, []entry{{[]byte("c"), 3}}, collect(btrie.All[byte](trie)))
+	require.NoError(t, trie.Err())
+
+	assert.Panics(t, func() { trie.Put(nil, 0) })
+	assert.Panics(t, func() { trie.Delete(nil) })
+}
+
+func TestBackingStoreError(t *testing.T) {
+	t.Parallel()
+	store := &mapStore{entries: map[string]byte{}, failAfter: 1}
+	trie := btrie.WithBackingStore(btrie.NewArrayTrie[byte](), store)
+	trie.Put([]byte("a"), 1)
+	require.NoError(t, trie.Err())
+
+	prev, ok := trie.Put([]byte("a"), 2)
+	assert.True(t, ok)
+	assert.Equal(t, byte(1), prev)
+	require.ErrorIs(t, trie.Err(), errStore)
+	prev, ok = trie.Delete([]byte("a"))
+	assert.True(t, ok, "a failed delete must not report the key as absent")
+	assert.Equal(t, byte(1), prev)
+	_, ok = trie.Delete([]byte("c"))
+	assert.False(t, ok)
+	_, ok = trie.Get([]byte("b"))
+	assert.False(t, ok)
+	assert.Equal(t, []entry{{[]byte("a"), 1}}, collect(btrie.All[byte](trie)), "failed changes must not be applied")
+	assert.Equal(t, map[string]byte{"a": 1}, store.entries)
+}
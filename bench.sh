@@ -8,6 +8,10 @@
 # Or if "Foo" unintentionally matches multiple benchmarks:
 #
 #   $ ./bench.sh "BenchmarkFoo$" 3
+#
+# Any further arguments are passed to the test binary, for example:
+#
+#   $ ./bench.sh Corpus 3 -corpus=/path/to/keys.txt
 
 set -e
 
@@ -17,4 +21,4 @@ go test -c
 
 tests=${1:-.}
 count=${2:-10}
-go test -bench "${tests}" -benchmem -timeout 0 -count=${count}
+go test -bench "${tests}" -benchmem -timeout 0 -count=${count} "${@:3}"
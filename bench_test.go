@@ -3,16 +3,21 @@ package btrie_test
 import (
 	"bytes"
 	"encoding/binary"
+	"flag"
 	"fmt"
 	"iter"
 	"maps"
 	"math/rand"
+	"os"
+	"path/filepath"
 	"reflect"
+	"runtime"
 	"slices"
 	"testing"
 
 	"github.com/phiryll/btrie"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // No benchmark can have a truly random element, random seeds must be constants!
@@ -33,6 +38,10 @@ var (
 	maxBenchKeySize = benchKeySizes[len(benchKeySizes)-1]
 
 	benchTrieConfigs = createBenchTrieConfigs()
+
+	// Random keys hide the prefix sharing of real keys, so a corpus of real keys can be benchmarked as well.
+	// For example, ./bench.sh Corpus 3 -corpus=/path/to/domains.txt
+	benchCorpus = flag.String("corpus", "", "file of newline-delimited keys used by BenchmarkCorpus")
 )
 
 // Does not work for single-use iterators.
@@ -49,13 +58,13 @@ func repeat2[K, V any](itr iter.Seq2[K, V]) iter.Seq2[K, V] {
 }
 
 func BenchmarkTraverser(b *testing.B) {
-	benchTraverser(b, "kind=pre-order", btrie.TestingPreOrder)
-	benchTraverser(b, "kind=post-order", btrie.TestingPostOrder)
+	benchTraverser(b, "kind=pre-order", btrie.PreOrder[int])
+	benchTraverser(b, "kind=post-order", btrie.PostOrder[int])
 }
 
-func benchTraverser(b *testing.B, name string, traverser btrie.TestingTraverser) {
+func benchTraverser(b *testing.B, name string, traverser btrie.Traverser[int]) {
 	b.Run(name, func(b *testing.B) {
-		for _, adj := range []btrie.TestingAdjFunction{
+		for _, adj := range []btrie.AdjFunction[int]{
 			emptyAdjInt,
 			adjInt(0),
 			adjInt(1 << 4),
@@ -81,13 +90,13 @@ func benchTraverser(b *testing.B, name string, traverser btrie.TestingTraverser)
 }
 
 func BenchmarkTraverserPaths(b *testing.B) {
-	benchTraverserPaths(b, "kind=pre-order", btrie.TestingPreOrderPaths)
-	benchTraverserPaths(b, "kind=post-order", btrie.TestingPostOrderPaths)
+	benchTraverserPaths(b, "kind=pre-order", btrie.PreOrderPaths[int])
+	benchTraverserPaths(b, "kind=post-order", btrie.PostOrderPaths[int])
 }
 
-func benchTraverserPaths(b *testing.B, name string, pathTraverser btrie.TestingPathTraverser) {
+func benchTraverserPaths(b *testing.B, name string, pathTraverser btrie.PathTraverser[int]) {
 	b.Run(name, func(b *testing.B) {
-		for _, pathAdj := range []btrie.TestingPathAdjFunction{
+		for _, pathAdj := range []btrie.PathAdjFunction[int]{
 			emptyPathAdjInt,
 			pathAdjInt(0),
 			pathAdjInt(1 << 4),
@@ -242,7 +251,7 @@ func createBounds(keys keySet) ([]Bounds, []Bounds) {
 		end := keys[(2*i+1)%len(keys)]
 		switch cmp := bytes.Compare(begin, end); {
 		case cmp == 0:
-			end = append(end, 0)
+			end = btrie.NextKey(end)
 		case cmp > 0:
 			begin, end = end, begin
 		case cmp < 0:
@@ -369,6 +378,26 @@ func BenchmarkDenseTries(b *testing.B) {
 	}
 }
 
+// Measures a full garbage collection while a trie with 1<<20 entries is live,
+// which is mostly the time taken to mark the trie.
+func BenchmarkGC(b *testing.B) {
+	random := rand.New(rand.NewSource(70921553))
+	entries, _ := createEntries(1<<20, random)
+	for _, def := range implDefs {
+		trie := def.factory()
+		for k, v := range entries {
+			trie.Put([]byte(k), v)
+		}
+		b.Run("impl="+def.name, func(b *testing.B) {
+			b.ResetTimer()
+			for range b.N {
+				runtime.GC()
+			}
+		})
+		runtime.KeepAlive(trie)
+	}
+}
+
 // For mutable implementations, Clone() should be efficient, but not absurdly efficient.
 // If it is, that's a sign it's sharing storage instead of creating new storage.
 func BenchmarkClone(b *testing.B) {
@@ -441,6 +470,56 @@ func BenchmarkGet(b *testing.B) {
 	}
 }
 
+// Like BenchmarkGet, but with every trie wrapped by WithHashIndex.
+func BenchmarkHashIndexGet(b *testing.B) {
+	for _, bench := range createTestTries(benchTrieConfigs) {
+		original := bench.trie
+		b.Run(bench.name, func(b *testing.B) {
+			for _, keySize := range benchKeySizes {
+				present := bench.config.present[keySize]
+				b.Run(fmt.Sprintf("keyLen=%d/existing=true", keySize), func(b *testing.B) {
+					trie := btrie.WithHashIndex[byte](original.Clone())
+					b.ResetTimer()
+					for i := range b.N {
+						trie.Get(present[i%len(present)])
+					}
+				})
+			}
+		})
+	}
+}
+
+// Gets keys of length 2 from tries in which every node has the same number of children,
+// to measure how searching a node's children scales with its fan-out.
+func BenchmarkFanoutGet(b *testing.B) {
+	random := rand.New(rand.NewSource(5830271))
+	for _, fanout := range []int{4, 16, 64, 256} {
+		keyBytes := make([]byte, fanout)
+		for i := range keyBytes {
+			keyBytes[i] = byte(i * 256 / fanout)
+		}
+		var keys keySet
+		for _, first := range keyBytes {
+			for _, second := range keyBytes {
+				keys = append(keys, []byte{first, second})
+			}
+		}
+		shuffle(keys, random)
+		for _, def := range implDefs {
+			trie := def.factory()
+			for _, key := range keys {
+				trie.Put(key, 0)
+			}
+			b.Run(fmt.Sprintf("impl=%s/fanout=%d", def.name, fanout), func(b *testing.B) {
+				b.ResetTimer()
+				for i := range b.N {
+					trie.Get(keys[i%len(keys)])
+				}
+			})
+		}
+	}
+}
+
 //nolint:gocognit
 func BenchmarkDelete(b *testing.B) {
 	for _, bench := range createTestTries(benchTrieConfigs) {
@@ -537,3 +616,236 @@ func BenchmarkRandomRange(b *testing.B) {
 		return tt.config.forward, tt.config.reverse
 	})
 }
+
+// Range should allocate only the cloned keys it yields, plus a small constant overhead.
+// This guards against regressions to allocating per traversed node.
+const maxRangeAllocOverhead = 8
+
+//nolint:paralleltest // testing.AllocsPerRun cannot be called during a parallel test
+func TestRangeAllocs(t *testing.T) {
+	for _, test := range createTestTries(rangeTestConfigs) {
+		switch test.trie.(type) {
+		case *reference:
+			continue
+		case *btrie.MapTrie[byte]:
+			// Its Range adds one closure to the array trie's.
+			continue
+		}
+		t.Run(test.name, func(t *testing.T) {
+			numEntries := float64(len(test.config.entries))
+			for _, bounds := range []*Bounds{forwardAll, reverseAll} {
+				allocs := testing.AllocsPerRun(10, func() {
+					for range test.trie.Range(bounds) {
+					}
+				})
+				assert.LessOrEqual(t, allocs, numEntries+maxRangeAllocOverhead, "%s", bounds)
+			}
+		})
+	}
+}
+
+// With a max depth hint covering every key, Range should allocate only the cloned keys it yields,
+// plus a constant overhead independent of the key length.
+const maxHintedRangeAllocOverhead = 5
+
+//nolint:paralleltest // testing.AllocsPerRun cannot be called during a parallel test
+func TestWithMaxDepthAllocs(t *testing.T) {
+	const keyLen = 16
+	random := rand.New(rand.NewSource(4471))
+	for _, def := range implDefs {
+		if def.name == "reference" || def.name == "map-trie" {
+			continue
+		}
+		t.Run(def.name, func(t *testing.T) {
+			trie := def.factory()
+			for range 100 {
+				key := make([]byte, keyLen)
+				random.Read(key)
+				trie.Put(key, 0)
+			}
+			hinted := btrie.WithMaxDepth(trie, keyLen)
+			for _, bounds := range []*Bounds{forwardAll, reverseAll} {
+				allocs := testing.AllocsPerRun(10, func() {
+					for range hinted.Range(bounds) {
+					}
+				})
+				assert.LessOrEqual(t, allocs, float64(100+maxHintedRangeAllocOverhead), "%s", bounds)
+			}
+		})
+	}
+}
+
+// With a key buffer large enough for every key, Range over an array trie should not allocate per key.
+const maxKeyBufferRangeAllocs = 10
+
+//nolint:paralleltest // testing.AllocsPerRun cannot be called during a parallel test
+func TestWithKeyBufferAllocs(t *testing.T) {
+	const keyLen = 16
+	random := rand.New(rand.NewSource(6529))
+	trie := btrie.WithKeyBuffer(btrie.NewArrayTrie[byte](), make([]byte, keyLen))
+	for range 100 {
+		key := make([]byte, keyLen)
+		random.Read(key)
+		trie.Put(key, 0)
+	}
+	for _, bounds := range []*Bounds{forwardAll, reverseAll} {
+		allocs := testing.AllocsPerRun(10, func() {
+			for range trie.Range(bounds) {
+			}
+		})
+		assert.LessOrEqual(t, allocs, float64(maxKeyBufferRangeAllocs), "%s", bounds)
+	}
+}
+
+// Putting a long key into the array trie should allocate a constant number of nodes, not one per byte.
+const maxLongKeyPutAllocs = 4
+
+//nolint:paralleltest // testing.AllocsPerRun cannot be called during a parallel test
+func TestLongKeyPutAllocs(t *testing.T) {
+	key := make([]byte, 4096)
+	rand.New(rand.NewSource(9386)).Read(key)
+	trie := btrie.NewArrayTrie[byte]()
+	allocs := testing.AllocsPerRun(10, func() {
+		trie.Delete(key)
+		trie.Put(key, 1)
+	})
+	assert.LessOrEqual(t, allocs, float64(maxLongKeyPutAllocs))
+}
+
+func BenchmarkLongKeyPut(b *testing.B) {
+	for _, keySize := range []int{64, 1024, 16384} {
+		key := make([]byte, keySize)
+		rand.New(rand.NewSource(int64(keySize))).Read(key)
+		for _, def := range implDefs {
+			if def.name == "reference" {
+				continue
+			}
+			b.Run(fmt.Sprintf("impl=%s/keySize=%d", def.name, keySize), func(b *testing.B) {
+				b.ReportAllocs()
+				for range b.N {
+					trie := def.factory()
+					trie.Put(key, 1)
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkFullRange(b *testing.B) {
+	for _, bench := range createTestTries(benchTrieConfigs) {
+		if _, ok := bench.trie.(*reference); ok {
+			continue
+		}
+		b.Run(bench.name, func(b *testing.B) {
+			for _, bounds := range []*Bounds{forwardAll, reverseAll} {
+				b.Run("dir="+map[bool]string{false: "forward", true: "reverse"}[bounds.IsReverse], func(b *testing.B) {
+					b.ReportAllocs()
+					for range b.N {
+						for k, v := range bench.trie.Range(bounds) {
+							_, _ = k, v
+						}
+					}
+				})
+			}
+		})
+	}
+}
+
+// Returns a trieConfig whose entries are the keys in the file at path, delimited by newlines, with random values.
+// Only the entries, present keys, and bounds are populated.
+func createCorpusTrieConfig(path string) (*trieConfig, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	random := rand.New(rand.NewSource(68203573))
+	var config trieConfig
+	config.name = "corpus=" + filepath.Base(path)
+	config.entries = map[string]byte{}
+	keys := keySet{}
+	for key, err := range btrie.LoadKeys(file, '\n') {
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := config.entries[string(key)]; ok {
+			continue
+		}
+		config.entries[string(key)] = randomByte(random)
+		for len(config.present) <= len(key) {
+			config.present = append(config.present, keySet{})
+		}
+		config.present[len(key)] = append(config.present[len(key)], key)
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no keys in %s", path)
+	}
+	config.trieSize = len(keys)
+	shuffle(keys, random)
+	config.forward, config.reverse = createBounds(keys)
+	return &config, nil
+}
+
+func TestCorpusTrieConfig(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "corpus.txt")
+	require.NoError(t, os.WriteFile(path, []byte("usr/bin\nusr/lib\nusr/bin\n\nusr/local/bin\n"), 0o600))
+	config, err := createCorpusTrieConfig(path)
+	require.NoError(t, err)
+	assert.Equal(t, 4, config.trieSize)
+	assert.Equal(t, map[string]byte{"usr/bin": config.entries["usr/bin"], "usr/lib": config.entries["usr/lib"],
+		"": config.entries[""], "usr/local/bin": config.entries["usr/local/bin"]}, config.entries)
+	assert.Equal(t, keySet{[]byte("usr/bin"), []byte("usr/lib")}, config.present[7])
+	assert.Len(t, config.forward, maxGenSize)
+
+	_, err = createCorpusTrieConfig(filepath.Join(t.TempDir(), "missing.txt"))
+	require.Error(t, err)
+	empty := filepath.Join(t.TempDir(), "empty.txt")
+	require.NoError(t, os.WriteFile(empty, []byte{}, 0o600))
+	_, err = createCorpusTrieConfig(empty)
+	require.Error(t, err)
+}
+
+// BenchmarkCorpus measures building, getting from, and ranging over tries whose keys come from -corpus.
+//
+//nolint:gocognit
+func BenchmarkCorpus(b *testing.B) {
+	if *benchCorpus == "" {
+		b.Skip("no -corpus file given")
+	}
+	config, err := createCorpusTrieConfig(*benchCorpus)
+	if err != nil {
+		b.Fatal(err)
+	}
+	present := slices.Concat(config.present...)
+	for _, bench := range createTestTries([]*trieConfig{config}) {
+		if _, ok := bench.trie.(*reference); ok {
+			continue
+		}
+		b.Run(bench.name, func(b *testing.B) {
+			b.Run("op=build", func(b *testing.B) {
+				b.ReportAllocs()
+				for range b.N {
+					trie := bench.def.factory()
+					for _, key := range present {
+						trie.Put(key, 0)
+					}
+				}
+			})
+			b.Run("op=get", func(b *testing.B) {
+				for i := range b.N {
+					bench.trie.Get(present[i%len(present)])
+				}
+			})
+			b.Run("op=range", func(b *testing.B) {
+				b.ReportAllocs()
+				for i := range b.N {
+					for k, v := range bench.trie.Range(&config.forward[i%len(config.forward)]) {
+						_, _ = k, v
+					}
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,265 @@
+package btrie
+
+import (
+	"iter"
+)
+
+// BitKey is a key for a BitTrie, the first Len bits of Bytes, most significant bit first.
+// Any bits in Bytes beyond Len are ignored.
+// Unlike the keys of a BTrie, a BitKey can end in the middle of a byte.
+//
+// BitKeys are ordered bit by bit, with 0 before 1, and a BitKey before any longer BitKey it is a prefix of.
+// For BitKeys whose lengths are multiples of 8, this is the same as the ordering of the BTrie keys in Bytes.
+type BitKey struct {
+	Bytes []byte
+	Len   int
+}
+
+const bitsPerByte = 8
+
+// Bit returns the i'th bit of k, 0 or 1.
+// Bit will panic if i is not in [0, k.Len).
+func (k BitKey) Bit(i int) byte {
+	if i < 0 || i >= k.Len {
+		panic("bit index out of range")
+	}
+	return (k.Bytes[i/bitsPerByte] >> (bitsPerByte - 1 - i%bitsPerByte)) & 1
+}
+
+// Compare returns -1, 0, or +1 if k is less than, equal to, or greater than other.
+func (k BitKey) Compare(other BitKey) int {
+	for i := range min(k.Len, other.Len) {
+		if a, b := k.Bit(i), other.Bit(i); a != b {
+			return int(a) - int(b)
+		}
+	}
+	switch {
+	case k.Len < other.Len:
+		return -1
+	case k.Len > other.Len:
+		return +1
+	default:
+		return 0
+	}
+}
+
+// Returns true if k is a prefix of other, including if they are equal.
+func (k BitKey) isPrefixOf(other BitKey) bool {
+	if k.Len > other.Len {
+		return false
+	}
+	for i := range k.Len {
+		if k.Bit(i) != other.Bit(i) {
+			return false
+		}
+	}
+	return true
+}
+
+func (k BitKey) check() {
+	if k.Len < 0 || k.Len > bitsPerByte*len(k.Bytes) {
+		panic("bit key length out of range")
+	}
+}
+
+// Appends bit to a key of the given length, which must have enough capacity or be extendable.
+func appendBit(key []byte, length int, bit byte) []byte {
+	if length%bitsPerByte == 0 {
+		key = append(key, 0)
+	}
+	key[length/bitsPerByte] |= bit << (bitsPerByte - 1 - length%bitsPerByte)
+	return key
+}
+
+// Clears the bits at and after length, removing unneeded bytes.
+func truncateBits(key []byte, length int) []byte {
+	key = key[:(length+bitsPerByte-1)/bitsPerByte]
+	if rem := length % bitsPerByte; rem != 0 {
+		key[len(key)-1] &^= 0xFF >> rem
+	}
+	return key
+}
+
+type bitTrieNode[V any] struct {
+	children   [2]*bitTrieNode[V]
+	value      V // valid only if isTerminal is true
+	isTerminal bool
+}
+
+// BitTrie is essentially an ordered map[BitKey]V, stored as a binary trie with one bit per node.
+// This is useful for keys which are not whole bytes, such as the network prefixes used for IP routing.
+// A BitTrie does not retain the BitKeys passed to its methods, and a BitTrie is not safe for concurrent use.
+// Methods taking a BitKey will panic if its Len is negative or greater than the number of bits in its Bytes.
+type BitTrie[V any] struct {
+	root bitTrieNode[V]
+}
+
+// NewBitTrie returns a new, empty BitTrie.
+func NewBitTrie[V any]() *BitTrie[V] {
+	return &BitTrie[V]{}
+}
+
+// Get returns the value for key and whether or not it exists.
+func (t *BitTrie[V]) Get(key BitKey) (V, bool) {
+	key.check()
+	var zero V
+	n := &t.root
+	for i := range key.Len {
+		n = n.children[key.Bit(i)]
+		if n == nil {
+			return zero, false
+		}
+	}
+	if n.isTerminal {
+		return n.value, true
+	}
+	return zero, false
+}
+
+// Put sets the value for key, returning the previous value and whether or not the previous value existed.
+func (t *BitTrie[V]) Put(key BitKey, value V) (V, bool) {
+	key.check()
+	var zero V
+	n := &t.root
+	for i := range key.Len {
+		bit := key.Bit(i)
+		if n.children[bit] == nil {
+			n.children[bit] = &bitTrieNode[V]{}
+		}
+		n = n.children[bit]
+	}
+	prev, ok := n.value, n.isTerminal
+	n.value = value
+	n.isTerminal = true
+	if ok {
+		return prev, true
+	}
+	return zero, false
+}
+
+// Delete removes the value for key, returning the previous value and whether or not the previous value existed.
+func (t *BitTrie[V]) Delete(key BitKey) (V, bool) {
+	key.check()
+	var zero V
+	// If the deleted node has no children, remove the subtree rooted at prune.children[pruneBit].
+	var prune *bitTrieNode[V]
+	var pruneBit byte
+	n := &t.root
+	for i := range key.Len {
+		bit := key.Bit(i)
+		if n.children[bit] == nil {
+			return zero, false
+		}
+		// If either n is the root, or n has a value, or n has another child, then n itself cannot be pruned.
+		if i == 0 || n.isTerminal || n.children[1-bit] != nil {
+			prune, pruneBit = n, bit
+		}
+		n = n.children[bit]
+	}
+	if !n.isTerminal {
+		return zero, false
+	}
+	prev := n.value
+	n.value = zero
+	n.isTerminal = false
+	if key.Len > 0 && n.children[0] == nil && n.children[1] == nil {
+		prune.children[pruneBit] = nil
+	}
+	return prev, true
+}
+
+// Range returns a sequence of the keys and values in [begin, end), in increasing order.
+// A nil begin or end is unbounded.
+// The yielded keys are new slices, and this BitTrie must not be mutated during the iteration.
+func (t *BitTrie[V]) Range(begin, end *BitKey) iter.Seq2[BitKey, V] {
+	if begin != nil {
+		begin.check()
+		b := *begin
+		begin = &b
+	}
+	if end != nil {
+		end.check()
+		e := *end
+		end = &e
+	}
+	return func(yield func(BitKey, V) bool) {
+		t.root.rangeNode(BitKey{[]byte{}, 0}, begin, end, yield)
+	}
+}
+
+// Yields the entries in n's subtree within [begin, end) in pre-order, where key is the path to n.
+// Returns true if done, either because some yield returned false or end was reached.
+func (n *bitTrieNode[V]) rangeNode(key BitKey, begin, end *BitKey, yield func(BitKey, V) bool) bool {
+	// Every key in this subtree is >= key.
+	if end != nil && key.Compare(*end) >= 0 {
+		return true
+	}
+	// Every key in this subtree is < begin, unless key is a prefix of begin.
+	if begin != nil && key.Compare(*begin) < 0 && !key.isPrefixOf(*begin) {
+		return false
+	}
+	if n.isTerminal && (begin == nil || key.Compare(*begin) >= 0) {
+		clone := BitKey{append([]byte{}, key.Bytes...), key.Len}
+		if !yield(clone, n.value) {
+			return true
+		}
+	}
+	for bit, child := range n.children {
+		if child == nil {
+			continue
+		}
+		childKey := BitKey{appendBit(key.Bytes, key.Len, byte(bit)), key.Len + 1}
+		done := child.rangeNode(childKey, begin, end, yield)
+		key.Bytes = truncateBits(childKey.Bytes, key.Len)
+		if done {
+			return true
+		}
+	}
+	return false
+}
+
+// WithPrefix returns a sequence of the keys and values in this BitTrie having prefix, including prefix itself,
+// in increasing order.
+// The yielded keys are new slices, and this BitTrie must not be mutated during the iteration.
+func (t *BitTrie[V]) WithPrefix(prefix BitKey) iter.Seq2[BitKey, V] {
+	prefix.check()
+	prefix = BitKey{truncateBits(append([]byte{}, prefix.Bytes...), prefix.Len), prefix.Len}
+	return func(yield func(BitKey, V) bool) {
+		n := &t.root
+		for i := range prefix.Len {
+			n = n.children[prefix.Bit(i)]
+			if n == nil {
+				return
+			}
+		}
+		key := BitKey{append([]byte{}, prefix.Bytes...), prefix.Len}
+		n.rangeNode(key, nil, nil, yield)
+	}
+}
+
+// PrefixesOf returns a sequence of the keys and values in this BitTrie which are prefixes of key,
+// including key itself, from shortest to longest.
+// The last prefix yielded is the longest prefix match for key.
+// The yielded keys are new slices, and this BitTrie must not be mutated during the iteration.
+func (t *BitTrie[V]) PrefixesOf(key BitKey) iter.Seq2[BitKey, V] {
+	key.check()
+	key = BitKey{append([]byte{}, key.Bytes...), key.Len}
+	return func(yield func(BitKey, V) bool) {
+		n := &t.root
+		for i := 0; ; i++ {
+			if n.isTerminal {
+				prefix := BitKey{truncateBits(append([]byte{}, key.Bytes...), i), i}
+				if !yield(prefix, n.value) {
+					return
+				}
+			}
+			if i == key.Len {
+				return
+			}
+			n = n.children[key.Bit(i)]
+			if n == nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package btrie_test
+
+import (
+	"iter"
+	"math/rand"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/phiryll/btrie"
+	"github.com/stretchr/testify/assert"
+)
+
+// Converts "0110..." to a BitKey, with the given garbage in any unused trailing bits.
+func bitKey(bits string, garbage byte) btrie.BitKey {
+	key := make([]byte, (len(bits)+7)/8)
+	for i := range key {
+		key[i] = garbage
+	}
+	for i, c := range bits {
+		mask := byte(0x80) >> (i % 8)
+		if c == '1' {
+			key[i/8] |= mask
+		} else {
+			key[i/8] &^= mask
+		}
+	}
+	return btrie.BitKey{Bytes: key, Len: len(bits)}
+}
+
+func bitString(key btrie.BitKey) string {
+	var s strings.Builder
+	for i := range key.Len {
+		s.WriteByte('0' + key.Bit(i))
+	}
+	return s.String()
+}
+
+type bitEntry struct {
+	key   string
+	value int
+}
+
+func collectBits(t *testing.T, itr iter.Seq2[btrie.BitKey, int]) []bitEntry {
+	result := []bitEntry{}
+	for k, v := range itr {
+		// yielded keys must be minimal and have no garbage
+		assert.Equal(t, bitKey(bitString(k), 0), k)
+		result = append(result, bitEntry{bitString(k), v})
+	}
+	return result
+}
+
+func TestBitKey(t *testing.T) {
+	t.Parallel()
+	key := bitKey("1011", 0xFF)
+	assert.Equal(t, []byte{0xBF}, key.Bytes)
+	assert.Equal(t, byte(1), key.Bit(0))
+	assert.Equal(t, byte(0), key.Bit(1))
+	assert.Panics(t, func() { key.Bit(4) })
+	assert.Panics(t, func() { key.Bit(-1) })
+	for _, test := range []struct {
+		a, b     string
+		expected int
+	}{
+		{"", "", 0},
+		{"", "0", -1},
+		{"1", "0", +1},
+		{"10", "1", +1},
+		{"0111", "1", -1},
+		{"101", "101", 0},
+	} {
+		assert.Equal(t, test.expected, bitKey(test.a, 0x55).Compare(bitKey(test.b, 0xAA)), "%s %s", test.a, test.b)
+		assert.Equal(t, -test.expected, bitKey(test.b, 0).Compare(bitKey(test.a, 0xFF)), "%s %s", test.b, test.a)
+	}
+	trie := btrie.NewBitTrie[int]()
+	assert.Panics(t, func() { trie.Get(btrie.BitKey{Bytes: []byte{0}, Len: 9}) })
+	assert.Panics(t, func() { trie.Put(btrie.BitKey{Bytes: []byte{0}, Len: -1}, 0) })
+}
+
+func TestBitTrie(t *testing.T) {
+	t.Parallel()
+	random := rand.New(rand.NewSource(5830273))
+	trie := btrie.NewBitTrie[int]()
+	expected := map[string]int{}
+	randomBits := func() string {
+		var s strings.Builder
+		for range random.Intn(20) {
+			s.WriteByte('0' + byte(random.Intn(2)))
+		}
+		return s.String()
+	}
+	for i := range 500 {
+		bits := randomBits()
+		prev, ok := trie.Put(bitKey(bits, byte(random.Intn(256))), i)
+		expectedPrev, expectedOk := expected[bits]
+		assert.Equal(t, expectedOk, ok)
+		assert.Equal(t, expectedPrev, prev)
+		expected[bits] = i
+	}
+	for range 200 {
+		bits := randomBits()
+		prev, ok := trie.Delete(bitKey(bits, byte(random.Intn(256))))
+		expectedPrev, expectedOk := expected[bits]
+		assert.Equal(t, expectedOk, ok)
+		assert.Equal(t, expectedPrev, prev)
+		delete(expected, bits)
+	}
+	for range 200 {
+		bits := randomBits()
+		value, ok := trie.Get(bitKey(bits, 0xFF))
+		expectedValue, expectedOk := expected[bits]
+		assert.Equal(t, expectedOk, ok)
+		assert.Equal(t, expectedValue, value)
+	}
+
+	// The bit ordering is the same as the ordering of the bit strings.
+	sorted := []bitEntry{}
+	for k, v := range expected {
+		sorted = append(sorted, bitEntry{k, v})
+	}
+	slices.SortFunc(sorted, func(a, b bitEntry) int { return strings.Compare(a.key, b.key) })
+	assert.Equal(t, sorted, collectBits(t, trie.Range(nil, nil)))
+
+	for range 100 {
+		begin, end := randomBits(), randomBits()
+		beginKey, endKey := bitKey(begin, 0xFF), bitKey(end, 0xFF)
+		within := []bitEntry{}
+		for _, e := range sorted {
+			if e.key >= begin && e.key < end {
+				within = append(within, e)
+			}
+		}
+		assert.Equal(t, within, collectBits(t, trie.Range(&beginKey, &endKey)), "[%s, %s)", begin, end)
+
+		prefixed := []bitEntry{}
+		prefixes := []bitEntry{}
+		for _, e := range sorted {
+			if strings.HasPrefix(e.key, begin) {
+				prefixed = append(prefixed, e)
+			}
+			if strings.HasPrefix(begin, e.key) {
+				prefixes = append(prefixes, e)
+			}
+		}
+		assert.Equal(t, prefixed, collectBits(t, trie.WithPrefix(beginKey)), "%s", begin)
+		assert.Equal(t, prefixes, collectBits(t, trie.PrefixesOf(beginKey)), "%s", begin)
+	}
+
+	// need an early yield for test coverage
+	for range trie.Range(nil, nil) {
+		break
+	}
+	for range trie.PrefixesOf(bitKey("", 0)) {
+		break
+	}
+
+	for _, e := range sorted {
+		trie.Delete(bitKey(e.key, 0))
+	}
+	assert.Empty(t, collectBits(t, trie.Range(nil, nil)))
+}
@@ -0,0 +1,93 @@
+package btrie_test
+
+import (
+	"testing"
+
+	"github.com/phiryll/btrie"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportPrefixBloom(t *testing.T) {
+	t.Parallel()
+	for _, def := range implDefs {
+		t.Run(def.name, func(t *testing.T) {
+			t.Parallel()
+			trie := def.factory()
+			for i, key := range presentTestKeys {
+				trie.Put(key, byte(i))
+			}
+			for depth := range 4 {
+				bloom, err := btrie.ParsePrefixBloom(btrie.ExportPrefixBloom(trie, depth, 10))
+				require.NoError(t, err)
+				assert.Equal(t, depth, bloom.Depth())
+				// No false negatives, for keys or anything sharing their prefixes.
+				for _, key := range presentTestKeys {
+					assert.True(t, bloom.MayContain(key), "%d %X", depth, key)
+					if len(key) >= depth {
+						assert.True(t, bloom.MayContain(append(key[:depth:depth], 0xFF)), "%d %X", depth, key)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestExportPrefixBloomFalsePositives(t *testing.T) {
+	t.Parallel()
+	trie := btrie.NewArrayTrie[byte]()
+	for i := range 1000 {
+		trie.Put([]byte{byte(i >> 8), byte(i), 0}, 0)
+	}
+	bloom, err := btrie.ParsePrefixBloom(btrie.ExportPrefixBloom(trie, 2, 10))
+	require.NoError(t, err)
+	falsePositives := 0
+	for i := 1000; i < 11000; i++ {
+		if bloom.MayContain([]byte{byte(i >> 8), byte(i)}) {
+			falsePositives++
+		}
+	}
+	// Expect about 1%, but allow for an unlucky hash.
+	assert.Less(t, falsePositives, 200)
+
+	empty, err := btrie.ParsePrefixBloom(btrie.ExportPrefixBloom(btrie.NewArrayTrie[byte](), 2, 10))
+	require.NoError(t, err)
+	assert.False(t, empty.MayContain([]byte{}))
+	assert.False(t, empty.MayContain([]byte{1, 2, 3}))
+}
+
+func TestExportPrefixBloomPanics(t *testing.T) {
+	t.Parallel()
+	trie := btrie.NewArrayTrie[byte]()
+	assert.Panics(t, func() { btrie.ExportPrefixBloom(trie, -1, 10) })
+	assert.Panics(t, func() { btrie.ExportPrefixBloom(trie, 1, 0) })
+	bloom, err := btrie.ParsePrefixBloom(btrie.ExportPrefixBloom(trie, 1, 10))
+	require.NoError(t, err)
+	assert.Panics(t, func() { bloom.MayContain(nil) })
+}
+
+func TestParsePrefixBloomErrors(t *testing.T) {
+	t.Parallel()
+	trie := btrie.NewArrayTrie[byte]()
+	trie.Put([]byte{1, 2}, 0)
+	data := btrie.ExportPrefixBloom(trie, 1, 10)
+	for i := range data {
+		_, err := btrie.ParsePrefixBloom(data[:i])
+		assert.ErrorIs(t, err, btrie.ErrInvalidFormat, "truncated at %d", i)
+	}
+	for _, tt := range []struct {
+		index int
+		value byte
+	}{
+		{0, 'X'},  // magic
+		{4, 0x7F}, // version
+		{6, 0},    // number of hashes
+		{7, 0},    // size
+		{7, 3},    // size
+	} {
+		bad := append([]byte{}, data...)
+		bad[tt.index] = tt.value
+		_, err := btrie.ParsePrefixBloom(bad)
+		assert.ErrorIs(t, err, btrie.ErrInvalidFormat, "%d", tt.index)
+	}
+}
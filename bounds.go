@@ -2,10 +2,38 @@ package btrie
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"math"
 )
 
+var (
+	// ErrInvertedBounds is wrapped by the error returned from [Bounds.Validate]
+	// if Begin and End are in the wrong order for the direction given by IsReverse.
+	ErrInvertedBounds = errors.New("bounds begin and end are inverted")
+
+	// ErrEmptyBounds is wrapped by the error returned from [Bounds.Validate]
+	// if no key can be within the bounds.
+	ErrEmptyBounds = errors.New("bounds are empty")
+)
+
+// BoundsError is the type of error returned by [Bounds.Validate].
+type BoundsError struct {
+	// Bounds is the invalid Bounds.
+	Bounds *Bounds
+
+	// Err is either [ErrInvertedBounds] or [ErrEmptyBounds].
+	Err error
+}
+
+func (e *BoundsError) Error() string {
+	return fmt.Sprintf("invalid bounds %s: %s", e.Bounds, e.Err)
+}
+
+func (e *BoundsError) Unwrap() error {
+	return e.Err
+}
+
 // Bounds is the argument type for [BTrie.Range].
 // A nil value for [Bounds.Begin] or [Bounds.End] represents +/-Inf;
 // which one depends on the value of [Bounds.IsReverse].
@@ -64,6 +92,136 @@ func (b *Bounds) DownTo(end []byte) *Bounds {
 	return &Bounds{b.Begin, end, true}
 }
 
+// Reverse returns the range of the same keys as b, in the opposite direction, such as for a "previous page".
+// Because Begin is always inclusive and End is always exclusive, the endpoints cannot simply be swapped.
+// From(b).DownTo(a) becomes From(NextKey(a)).To(NextKey(b)), which is always exact.
+// From(a).To(b) becomes From(c).DownTo(d), where c and d are the greatest keys less than b and a,
+// but those only exist if b and a are non-empty or end with a zero byte, respectively.
+// If c does not exist, the result's Bounds begins at b with ExcludeBegin true,
+// and if d does not exist, it ends at a with IncludeEnd true, also beginning at b if c is a.
+// Use [RangeByExpr] to range over the result. Nil Begin and End values remain nil.
+// The returned Bounds may reference the same slices as b.
+func (b *Bounds) Reverse() *RangeExpr {
+	if b.IsReverse {
+		result := &Bounds{nil, nil, false}
+		if b.End != nil {
+			result.Begin = NextKey(b.End)
+		}
+		if b.Begin != nil {
+			result.End = NextKey(b.Begin)
+		}
+		return &RangeExpr{result, false, false}
+	}
+	result := &RangeExpr{&Bounds{nil, nil, true}, false, false}
+	if b.End != nil {
+		// The greatest key less than {} is -Inf, which would be +Inf as a reverse Begin.
+		if prev, ok := prevKey(b.End); ok && len(b.End) > 0 {
+			result.Bounds.Begin = prev
+		} else {
+			result.Bounds.Begin, result.ExcludeBegin = b.End, true
+		}
+	}
+	if b.Begin != nil {
+		// The greatest key less than {} is -Inf, which is also nil as a reverse End.
+		if prev, ok := prevKey(b.Begin); ok {
+			result.Bounds.End = prev
+		} else {
+			result.Bounds.End, result.IncludeEnd = b.Begin, true
+		}
+	}
+	if result.IncludeEnd && !result.ExcludeBegin && bytes.Equal(result.Bounds.Begin, result.Bounds.End) {
+		// From(a).To(a+"\x00") only contains a, and a Bounds cannot begin and end at the same key.
+		result.Bounds.Begin, result.ExcludeBegin = b.End, true
+	}
+	return result
+}
+
+// Validate returns a [*BoundsError] if b cannot be created by [From] and [Bounds.To] or [Bounds.DownTo],
+// either because Begin and End are inverted or because no key can be within b.
+// Bounds with exported fields set directly, rather than by the builder methods, may be invalid.
+// Validate returns nil if b is valid.
+func (b *Bounds) Validate() error {
+	if b.Begin == nil || b.End == nil {
+		// Only From(nil).To({}) is empty, nothing is less than {}.
+		if !b.IsReverse && b.Begin == nil && b.End != nil && len(b.End) == 0 {
+			return &BoundsError{b, ErrEmptyBounds}
+		}
+		return nil
+	}
+	cmp := bytes.Compare(b.Begin, b.End)
+	if cmp == 0 {
+		return &BoundsError{b, ErrEmptyBounds}
+	}
+	if (cmp > 0) != b.IsReverse {
+		return &BoundsError{b, ErrInvertedBounds}
+	}
+	return nil
+}
+
+// Normalize returns a new Bounds with the same direction as b, and with Begin and End swapped if they are inverted.
+// Normalize does not change empty bounds; they are valid arguments to [BTrie.Range], which will yield nothing.
+// The returned Bounds references the same slices as b.
+func (b *Bounds) Normalize() *Bounds {
+	if errors.Is(b.Validate(), ErrInvertedBounds) {
+		return &Bounds{b.End, b.Begin, b.IsReverse}
+	}
+	return &Bounds{b.Begin, b.End, b.IsReverse}
+}
+
+// Intersect returns the Bounds containing only the keys within both b and other, and whether that Bounds is non-empty.
+// If the intersection is empty, Intersect returns (nil, false).
+// The returned Bounds has the same direction as b, and references the slices of b and other.
+// Intersect will panic if b and other have different directions.
+func (b *Bounds) Intersect(other *Bounds) (*Bounds, bool) {
+	if b.IsReverse != other.IsReverse {
+		panic("bounds directions differ")
+	}
+	var result *Bounds
+	if b.IsReverse {
+		result = &Bounds{minKey(b.Begin, other.Begin), maxKey(b.End, other.End), true}
+	} else {
+		result = &Bounds{maxKey(b.Begin, other.Begin), minKey(b.End, other.End), false}
+	}
+	if result.Validate() != nil {
+		return nil, false
+	}
+	return result, true
+}
+
+// Clamp returns the Bounds containing only the keys within b having the given prefix,
+// and whether that Bounds is non-empty.
+// If there are no such keys, Clamp returns (nil, false).
+// The returned Bounds may reference prefix and the slices of b.
+// Clamp will panic if prefix is nil, or if b is reverse.
+// A reverse Bounds cannot represent the set of keys having a given prefix,
+// because there is no greatest key having that prefix.
+func (b *Bounds) Clamp(prefix []byte) (*Bounds, bool) {
+	if prefix == nil {
+		panic("prefix cannot be nil")
+	}
+	if b.IsReverse {
+		panic("cannot clamp reverse bounds")
+	}
+	end, _ := PrefixSuccessor(prefix)
+	return b.Intersect(&Bounds{prefix, end, false})
+}
+
+// Returns the smaller of two keys, where nil is +Inf.
+func minKey(a, b []byte) []byte {
+	if a == nil || (b != nil && bytes.Compare(b, a) < 0) {
+		return b
+	}
+	return a
+}
+
+// Returns the larger of two keys, where nil is -Inf.
+func maxKey(a, b []byte) []byte {
+	if a == nil || (b != nil && bytes.Compare(b, a) > 0) {
+		return b
+	}
+	return a
+}
+
 // Compare returns 0 if key is within this Bounds, -1 if beyond Begin, and +1 if beyond End.
 // Compare will panic if key is nil.
 // -Inf < {} < {0}.
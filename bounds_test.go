@@ -1,7 +1,9 @@
 package btrie_test
 
 import (
+	"bytes"
 	"fmt"
+	"slices"
 	"testing"
 
 	"github.com/phiryll/btrie"
@@ -495,3 +497,165 @@ func TestChildBounds(t *testing.T) {
 		})
 	}
 }
+
+func TestBoundsValidate(t *testing.T) {
+	t.Parallel()
+	for _, tt := range []struct {
+		bounds   *Bounds
+		expected error
+	}{
+		{From(low).To(high), nil},
+		{From(nil).To(nil), nil},
+		{From(empty).To(nil), nil},
+		{From(nil).To(afterEmpty), nil},
+		{From(high).DownTo(low), nil},
+		{From(nil).DownTo(nil), nil},
+		{From(empty).DownTo(nil), nil},
+		{From(nil).DownTo(empty), nil},
+		{&Bounds{high, low, false}, btrie.ErrInvertedBounds},
+		{&Bounds{low, high, true}, btrie.ErrInvertedBounds},
+		{&Bounds{low, low, false}, btrie.ErrEmptyBounds},
+		{&Bounds{low, low, true}, btrie.ErrEmptyBounds},
+		{&Bounds{nil, empty, false}, btrie.ErrEmptyBounds},
+	} {
+		t.Run(tt.bounds.String(), func(t *testing.T) {
+			t.Parallel()
+			err := tt.bounds.Validate()
+			if tt.expected == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, tt.expected)
+			var boundsErr *btrie.BoundsError
+			if assert.ErrorAs(t, err, &boundsErr) {
+				assert.Same(t, tt.bounds, boundsErr.Bounds)
+			}
+		})
+	}
+}
+
+func TestBoundsNormalize(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, From(low).To(high), (&Bounds{high, low, false}).Normalize())
+	assert.Equal(t, From(high).DownTo(low), (&Bounds{low, high, true}).Normalize())
+	assert.Equal(t, From(low).To(high), From(low).To(high).Normalize())
+	assert.Equal(t, From(high).DownTo(low), From(high).DownTo(low).Normalize())
+	assert.Equal(t, &Bounds{low, low, false}, (&Bounds{low, low, false}).Normalize())
+	for _, bounds := range []*Bounds{
+		{high, low, false},
+		{low, high, true},
+		{low, low, false},
+		{nil, empty, false},
+	} {
+		assert.NotErrorIs(t, bounds.Normalize().Validate(), btrie.ErrInvertedBounds)
+	}
+}
+
+func TestBoundsIntersect(t *testing.T) {
+	t.Parallel()
+	for _, tt := range []struct {
+		a, b, expected *Bounds
+	}{
+		{From(nil).To(nil), From(low).To(high), From(low).To(high)},
+		{From(low).To(high), From(nil).To(nil), From(low).To(high)},
+		{From(before).To(within), From(low).To(high), From(low).To(within)},
+		{From(low).To(high), From(within).To(after), From(within).To(high)},
+		{From(low).To(within), From(within).To(high), nil},
+		{From(before).To(low), From(high).To(after), nil},
+		{From(nil).To(afterEmpty), From(nil).To(empty), nil},
+		{From(nil).DownTo(nil), From(high).DownTo(low), From(high).DownTo(low)},
+		{From(after).DownTo(within), From(high).DownTo(low), From(high).DownTo(within)},
+		{From(high).DownTo(within), From(within).DownTo(low), nil},
+		{From(empty).DownTo(nil), From(nil).DownTo(nil), From(empty).DownTo(nil)},
+	} {
+		t.Run(fmt.Sprintf("%s&%s", tt.a, tt.b), func(t *testing.T) {
+			t.Parallel()
+			actual, ok := tt.a.Intersect(tt.b)
+			assert.Equal(t, tt.expected, actual)
+			assert.Equal(t, tt.expected != nil, ok)
+		})
+	}
+	assert.Panics(t, func() {
+		forwardAll.Intersect(reverseAll)
+	})
+}
+
+func TestBoundsClamp(t *testing.T) {
+	t.Parallel()
+	for _, tt := range []struct {
+		bounds   *Bounds
+		prefix   []byte
+		expected *Bounds
+	}{
+		{From(nil).To(nil), empty, From(empty).To(nil)},
+		{From(nil).To(nil), []byte{0x04, 0x99}, From([]byte{0x04, 0x99}).To([]byte{0x04, 0x9A})},
+		{From(nil).To(nil), []byte{0x04, 0xFF}, From([]byte{0x04, 0xFF}).To([]byte{0x05})},
+		{From(nil).To(nil), []byte{0xFF, 0xFF}, From([]byte{0xFF, 0xFF}).To(nil)},
+		{From(low).To(high), []byte{0x04, 0x99}, From(low).To([]byte{0x04, 0x9A})},
+		{From(low).To(high), []byte{0x42}, From([]byte{0x42}).To(high)},
+		{From(low).To(high), []byte{0x27}, From([]byte{0x27}).To([]byte{0x28})},
+		{From(low).To(high), []byte{0x02}, nil},
+		{From(low).To(high), afterHigh, nil},
+	} {
+		t.Run(fmt.Sprintf("%s/%s", tt.bounds, keyName(tt.prefix)), func(t *testing.T) {
+			t.Parallel()
+			prefix := bytes.Clone(tt.prefix)
+			actual, ok := tt.bounds.Clamp(prefix)
+			assert.Equal(t, tt.expected, actual)
+			assert.Equal(t, tt.expected != nil, ok)
+			assert.Equal(t, tt.prefix, prefix, "prefix was modified")
+		})
+	}
+	assert.Panics(t, func() {
+		forwardAll.Clamp(nil)
+	})
+	assert.Panics(t, func() {
+		reverseAll.Clamp(empty)
+	})
+}
+
+func TestBoundsReverse(t *testing.T) {
+	t.Parallel()
+	keys := keySet{empty, afterEmpty, before, beforeLow, low, afterLow, within, beforeHigh, high, afterHigh, after}
+	trie := btrie.NewArrayTrie[byte]()
+	for i, key := range keys {
+		trie.Put(key, byte(i))
+	}
+	a, b := []byte("a"), []byte("b")
+	trie.Put(a, 100)
+	trie.Put(b, 101)
+	for _, tt := range []struct {
+		bounds   *Bounds
+		expected *btrie.RangeExpr
+	}{
+		{From(nil).To(nil), &btrie.RangeExpr{Bounds: From(nil).DownTo(nil)}},
+		{From(afterLow).To(afterHigh), &btrie.RangeExpr{Bounds: From(high).DownTo(low)}},
+		{From(empty).To(afterHigh), &btrie.RangeExpr{Bounds: From(high).DownTo(nil)}},
+		{From(nil).To(afterLow), &btrie.RangeExpr{Bounds: From(low).DownTo(nil)}},
+		{From(afterEmpty).To(nil), &btrie.RangeExpr{Bounds: From(nil).DownTo(empty)}},
+		{From(a).To(b), &btrie.RangeExpr{Bounds: From(b).DownTo(a), ExcludeBegin: true, IncludeEnd: true}},
+		{From(low).To(high), &btrie.RangeExpr{Bounds: From(high).DownTo(low), ExcludeBegin: true, IncludeEnd: true}},
+		{From(afterLow).To(high), &btrie.RangeExpr{Bounds: From(high).DownTo(low), ExcludeBegin: true}},
+		{From(low).To(afterHigh), &btrie.RangeExpr{Bounds: From(high).DownTo(low), IncludeEnd: true}},
+		{From(low).To(afterLow), &btrie.RangeExpr{
+			Bounds: From(afterLow).DownTo(low), ExcludeBegin: true, IncludeEnd: true,
+		}},
+		{From(nil).To(high), &btrie.RangeExpr{Bounds: From(high).DownTo(nil), ExcludeBegin: true}},
+		{From(low).To(nil), &btrie.RangeExpr{Bounds: From(nil).DownTo(low), IncludeEnd: true}},
+		{From(nil).To(empty), &btrie.RangeExpr{Bounds: From(empty).DownTo(nil), ExcludeBegin: true}},
+		{From(nil).DownTo(nil), &btrie.RangeExpr{Bounds: From(nil).To(nil)}},
+		{From(high).DownTo(low), &btrie.RangeExpr{Bounds: From(afterLow).To(afterHigh)}},
+		{From(b).DownTo(a), &btrie.RangeExpr{Bounds: From([]byte("a\x00")).To([]byte("b\x00"))}},
+		{From(low).DownTo(nil), &btrie.RangeExpr{Bounds: From(nil).To(afterLow)}},
+		{From(nil).DownTo(empty), &btrie.RangeExpr{Bounds: From(afterEmpty).To(nil)}},
+	} {
+		t.Run(tt.bounds.String(), func(t *testing.T) {
+			t.Parallel()
+			actual := tt.bounds.Reverse()
+			assert.Equal(t, tt.expected, actual)
+			expected := collect(trie.Range(tt.bounds))
+			slices.Reverse(expected)
+			assert.Equal(t, expected, collect(btrie.RangeByExpr(trie, actual)))
+		})
+	}
+}
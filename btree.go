@@ -0,0 +1,178 @@
+package btrie
+
+import (
+	"bytes"
+	"iter"
+)
+
+// ItemIterator is called by the iteration methods of [BTreeAdapter] for each item in order,
+// and iteration stops when it returns false.
+// It corresponds to ItemIteratorG in github.com/google/btree.
+type ItemIterator[V any] func(item Entry[V]) bool
+
+// BTreeAdapter wraps a BTrie with the methods of the generic BTreeG type in github.com/google/btree,
+// ordering items by their keys, so that a BTrie can replace a B-tree of key/value items with few changes.
+// Pivot and range arguments are items, as in google/btree, but only their keys are used.
+// Items passed to an ItemIterator or returned by a method have their own copies of their keys, which may be retained.
+//
+// The adapter tracks the number of entries for [BTreeAdapter.Len],
+// so the wrapped trie must not be mutated other than through the adapter.
+// As with a B-tree, the adapter must not be mutated during an iteration.
+type BTreeAdapter[V any] struct {
+	trie   BTrie[V]
+	length int
+}
+
+// NewBTreeAdapter returns a BTreeAdapter wrapping trie, which may already have entries.
+func NewBTreeAdapter[V any](trie BTrie[V]) *BTreeAdapter[V] {
+	length := 0
+	for range All(trie) {
+		length++
+	}
+	return &BTreeAdapter[V]{trie, length}
+}
+
+// Trie returns the wrapped trie.
+func (b *BTreeAdapter[V]) Trie() BTrie[V] {
+	return b.trie
+}
+
+// Len returns the number of items.
+func (b *BTreeAdapter[V]) Len() int {
+	return b.length
+}
+
+// ReplaceOrInsert adds item, replacing any item with the same key,
+// and returns the replaced item and whether there was one.
+func (b *BTreeAdapter[V]) ReplaceOrInsert(item Entry[V]) (Entry[V], bool) {
+	prev, ok := b.trie.Put(item.Key, item.Value)
+	if !ok {
+		b.length++
+		return Entry[V]{}, false
+	}
+	return Entry[V]{bytes.Clone(item.Key), prev}, true
+}
+
+// Delete removes the item with the same key as item, and returns the removed item and whether there was one.
+func (b *BTreeAdapter[V]) Delete(item Entry[V]) (Entry[V], bool) {
+	prev, ok := b.trie.Delete(item.Key)
+	if !ok {
+		return Entry[V]{}, false
+	}
+	b.length--
+	return Entry[V]{bytes.Clone(item.Key), prev}, true
+}
+
+// Get returns the item with the same key as item, and whether there is one.
+func (b *BTreeAdapter[V]) Get(item Entry[V]) (Entry[V], bool) {
+	value, ok := b.trie.Get(item.Key)
+	if !ok {
+		return Entry[V]{}, false
+	}
+	return Entry[V]{bytes.Clone(item.Key), value}, true
+}
+
+// Has returns whether there is an item with the same key as item.
+func (b *BTreeAdapter[V]) Has(item Entry[V]) bool {
+	_, ok := b.trie.Get(item.Key)
+	return ok
+}
+
+// Min returns the item with the smallest key, and whether there is one.
+func (b *BTreeAdapter[V]) Min() (Entry[V], bool) {
+	return b.first(All(b.trie))
+}
+
+// Max returns the item with the largest key, and whether there is one.
+func (b *BTreeAdapter[V]) Max() (Entry[V], bool) {
+	return b.first(Backward(b.trie))
+}
+
+// DeleteMin removes the item with the smallest key, and returns it and whether there was one.
+func (b *BTreeAdapter[V]) DeleteMin() (Entry[V], bool) {
+	item, ok := b.Min()
+	if ok {
+		b.Delete(item)
+	}
+	return item, ok
+}
+
+// DeleteMax removes the item with the largest key, and returns it and whether there was one.
+func (b *BTreeAdapter[V]) DeleteMax() (Entry[V], bool) {
+	item, ok := b.Max()
+	if ok {
+		b.Delete(item)
+	}
+	return item, ok
+}
+
+// Clear removes all items. The argument is ignored; it exists for compatibility with google/btree.
+func (b *BTreeAdapter[V]) Clear(_ bool) {
+	var keys [][]byte
+	for key := range All(b.trie) {
+		keys = append(keys, bytes.Clone(key))
+	}
+	for _, key := range keys {
+		b.trie.Delete(key)
+	}
+	b.length = 0
+}
+
+// Ascend calls iterator for every item, in increasing key order.
+func (b *BTreeAdapter[V]) Ascend(iterator ItemIterator[V]) {
+	b.iterate(All(b.trie), iterator)
+}
+
+// AscendGreaterOrEqual calls iterator for every item with a key >= pivot's, in increasing key order.
+func (b *BTreeAdapter[V]) AscendGreaterOrEqual(pivot Entry[V], iterator ItemIterator[V]) {
+	b.iterate(b.trie.Range(From(pivot.Key).To(nil)), iterator)
+}
+
+// AscendLessThan calls iterator for every item with a key < pivot's, in increasing key order.
+func (b *BTreeAdapter[V]) AscendLessThan(pivot Entry[V], iterator ItemIterator[V]) {
+	b.iterate(b.trie.Range(From(nil).To(pivot.Key)), iterator)
+}
+
+// AscendRange calls iterator for every item with a key >= greaterOrEqual's and < lessThan's, in increasing key order.
+func (b *BTreeAdapter[V]) AscendRange(greaterOrEqual, lessThan Entry[V], iterator ItemIterator[V]) {
+	if bytes.Compare(greaterOrEqual.Key, lessThan.Key) < 0 {
+		b.iterate(b.trie.Range(From(greaterOrEqual.Key).To(lessThan.Key)), iterator)
+	}
+}
+
+// Descend calls iterator for every item, in decreasing key order.
+func (b *BTreeAdapter[V]) Descend(iterator ItemIterator[V]) {
+	b.iterate(Backward(b.trie), iterator)
+}
+
+// DescendLessOrEqual calls iterator for every item with a key <= pivot's, in decreasing key order.
+func (b *BTreeAdapter[V]) DescendLessOrEqual(pivot Entry[V], iterator ItemIterator[V]) {
+	b.iterate(b.trie.Range(From(pivot.Key).DownTo(nil)), iterator)
+}
+
+// DescendGreaterThan calls iterator for every item with a key > pivot's, in decreasing key order.
+func (b *BTreeAdapter[V]) DescendGreaterThan(pivot Entry[V], iterator ItemIterator[V]) {
+	b.iterate(b.trie.Range(From(nil).DownTo(pivot.Key)), iterator)
+}
+
+// DescendRange calls iterator for every item with a key <= lessOrEqual's and > greaterThan's, in decreasing key order.
+func (b *BTreeAdapter[V]) DescendRange(lessOrEqual, greaterThan Entry[V], iterator ItemIterator[V]) {
+	if bytes.Compare(lessOrEqual.Key, greaterThan.Key) > 0 {
+		b.iterate(b.trie.Range(From(lessOrEqual.Key).DownTo(greaterThan.Key)), iterator)
+	}
+}
+
+func (b *BTreeAdapter[V]) iterate(entries iter.Seq2[[]byte, V], iterator ItemIterator[V]) {
+	for key, value := range entries {
+		if !iterator(Entry[V]{bytes.Clone(key), value}) {
+			return
+		}
+	}
+}
+
+func (b *BTreeAdapter[V]) first(entries iter.Seq2[[]byte, V]) (Entry[V], bool) {
+	for key, value := range entries {
+		return Entry[V]{bytes.Clone(key), value}, true
+	}
+	return Entry[V]{}, false
+}
@@ -0,0 +1,116 @@
+package btrie_test
+
+import (
+	"testing"
+
+	"github.com/phiryll/btrie"
+	"github.com/stretchr/testify/assert"
+)
+
+func item(key string) btrie.Entry[byte] {
+	return btrie.Entry[byte]{Key: []byte(key)}
+}
+
+func TestBTreeAdapter(t *testing.T) {
+	t.Parallel()
+	for _, def := range implDefs {
+		if def.name == "reference" {
+			continue
+		}
+		t.Run(def.name, func(t *testing.T) {
+			t.Parallel()
+			trie := def.factory()
+			trie.Put([]byte("c"), 3)
+			tree := btrie.NewBTreeAdapter[byte](trie)
+			assert.Equal(t, 1, tree.Len())
+			for i, key := range []string{"a", "ab", "b", "d"} {
+				_, ok := tree.ReplaceOrInsert(btrie.Entry[byte]{Key: []byte(key), Value: byte(i)})
+				assert.False(t, ok)
+			}
+			prev, ok := tree.ReplaceOrInsert(btrie.Entry[byte]{Key: []byte("b"), Value: 20})
+			assert.True(t, ok)
+			assert.Equal(t, btrie.Entry[byte]{Key: []byte("b"), Value: 2}, prev)
+			assert.Equal(t, 5, tree.Len())
+
+			got, ok := tree.Get(item("ab"))
+			assert.True(t, ok)
+			assert.Equal(t, byte(1), got.Value)
+			assert.True(t, tree.Has(item("d")))
+			assert.False(t, tree.Has(item("e")))
+			_, ok = tree.Delete(item("e"))
+			assert.False(t, ok)
+
+			collectKeys := func(f func(btrie.ItemIterator[byte])) []string {
+				keys := []string{}
+				f(func(item btrie.Entry[byte]) bool {
+					keys = append(keys, string(item.Key))
+					return true
+				})
+				return keys
+			}
+			assert.Equal(t, []string{"a", "ab", "b", "c", "d"}, collectKeys(tree.Ascend))
+			assert.Equal(t, []string{"d", "c", "b", "ab", "a"}, collectKeys(tree.Descend))
+			for _, tt := range []struct {
+				name     string
+				f        func(btrie.ItemIterator[byte])
+				expected []string
+			}{
+				{"AscendGreaterOrEqual", func(it btrie.ItemIterator[byte]) { tree.AscendGreaterOrEqual(item("b"), it) },
+					[]string{"b", "c", "d"}},
+				{"AscendLessThan", func(it btrie.ItemIterator[byte]) { tree.AscendLessThan(item("b"), it) },
+					[]string{"a", "ab"}},
+				{"AscendRange", func(it btrie.ItemIterator[byte]) { tree.AscendRange(item("ab"), item("c"), it) },
+					[]string{"ab", "b"}},
+				{"AscendRange empty", func(it btrie.ItemIterator[byte]) { tree.AscendRange(item("c"), item("ab"), it) },
+					[]string{}},
+				{"DescendLessOrEqual", func(it btrie.ItemIterator[byte]) { tree.DescendLessOrEqual(item("b"), it) },
+					[]string{"b", "ab", "a"}},
+				{"DescendGreaterThan", func(it btrie.ItemIterator[byte]) { tree.DescendGreaterThan(item("b"), it) },
+					[]string{"d", "c"}},
+				{"DescendRange", func(it btrie.ItemIterator[byte]) { tree.DescendRange(item("c"), item("ab"), it) },
+					[]string{"c", "b"}},
+				{"DescendRange empty", func(it btrie.ItemIterator[byte]) { tree.DescendRange(item("ab"), item("c"), it) },
+					[]string{}},
+			} {
+				assert.Equal(t, tt.expected, collectKeys(tt.f), tt.name)
+			}
+
+			// Iteration stops when the iterator returns false.
+			count := 0
+			tree.Ascend(func(btrie.Entry[byte]) bool {
+				count++
+				return count < 2
+			})
+			assert.Equal(t, 2, count)
+
+			// Items may be retained.
+			var items []btrie.Entry[byte]
+			tree.Ascend(func(item btrie.Entry[byte]) bool {
+				items = append(items, item)
+				return true
+			})
+			assert.Equal(t, "d", string(items[4].Key))
+			assert.Equal(t, "a", string(items[0].Key))
+
+			minItem, ok := tree.DeleteMin()
+			assert.True(t, ok)
+			assert.Equal(t, "a", string(minItem.Key))
+			maxItem, ok := tree.DeleteMax()
+			assert.True(t, ok)
+			assert.Equal(t, btrie.Entry[byte]{Key: []byte("d"), Value: 3}, maxItem)
+			assert.Equal(t, 3, tree.Len())
+			minItem, _ = tree.Min()
+			maxItem, _ = tree.Max()
+			assert.Equal(t, "ab", string(minItem.Key))
+			assert.Equal(t, "c", string(maxItem.Key))
+
+			tree.Clear(false)
+			assert.Equal(t, 0, tree.Len())
+			assert.Empty(t, collect(btrie.All(tree.Trie())))
+			_, ok = tree.Min()
+			assert.False(t, ok)
+			_, ok = tree.DeleteMax()
+			assert.False(t, ok)
+		})
+	}
+}
@@ -4,13 +4,22 @@ package btrie
 import (
 	"fmt"
 	"iter"
+	"strings"
 )
 
 // BTrie is essentially an ordered map[[]byte]V.
 // Keys must be non-nil.
+// The empty key []byte{} is a valid key like any other, and is less than every other key.
+// It can be used to store a default or root value, and is treated like any other key by Range and Bounds.
 // Implementations must clearly document any additional constraints on keys and values.
 // Implementations must clearly document if any methods accept or return references to its internal storage.
+// In particular, implementations must document if they retain the key slices passed to their methods.
+// No BTrie in this package does, so callers may reuse or modify a key as soon as the method it was passed to returns.
+// [github.com/phiryll/btrie/btrietest.CheckKeysNotRetained] tests this.
 // Implementations must clearly document if the iterator returned by Range is single-use.
+// If an implementation can be cloned or snapshotted, a clone must be fully independent of the trie it was cloned from.
+// Mutating either one, even during a Range over the other, must never affect the other;
+// [github.com/phiryll/btrie/btrietest.CheckCloneIndependent] tests this.
 // Although nothing in this interface mandates it, all BTrie implementations in this package are tries.
 type BTrie[V any] interface {
 	// Get returns the value for key and whether or not it exists.
@@ -25,14 +34,38 @@ type BTrie[V any] interface {
 	Delete(key []byte) (previous V, ok bool)
 
 	// Range returns a sequence of key/value pairs over the given bounds.
+	// Keys are yielded in increasing order according to [bytes.Compare] if bounds is forward, and decreasing if reverse.
+	// In particular, the empty key is first when forward and last when reverse,
+	// and a key comes before every key it is a proper prefix of when forward, and after them when reverse.
+	// Every implementation must yield the same order, so that switching implementations never reorders output;
+	// [github.com/phiryll/btrie/btrietest.CheckIterationOrder] tests this.
 	// Implementations should make a defensive copy of bounds using [Bounds.Clone] if necessary.
 	// Most BTrie implementations should not be mutated while a Range iteration is in progress.
 	// Implementations should document if they can be safely mutated during iteration.
 	Range(bounds *Bounds) iter.Seq2[[]byte, V]
 }
 
+// ReadOnlyTrie is the subset of [BTrie] which does not mutate the trie.
+// Every BTrie is a ReadOnlyTrie, and the methods have the same semantics as those of BTrie.
+type ReadOnlyTrie[V any] interface {
+	// Get returns the value for key and whether or not it exists.
+	Get(key []byte) (value V, ok bool)
+
+	// Range returns a sequence of key/value pairs over the given bounds.
+	Range(bounds *Bounds) iter.Seq2[[]byte, V]
+}
+
+// Entry is a key/value pair from a BTrie.
+// This is useful when key/value pairs must be stored, sorted, or sent over a channel as single values.
+type Entry[V any] struct {
+	Key   []byte
+	Value V
+}
+
 func emptySeq[V any](_ func(V) bool) {}
 
+func emptySeq2[K, V any](_ func(K, V) bool) {}
+
 func keyName(key []byte) string {
 	if key == nil {
 		return "nil"
@@ -42,3 +75,42 @@ func keyName(key []byte) string {
 	}
 	return fmt.Sprintf("%X", key)
 }
+
+// The number of levels below the root printed by the %+v verb, if no precision is given.
+const defaultFormatLevels = 3
+
+// Implemented by the tries in this package which can print their structure.
+type printable interface {
+	// Prints up to levels below the root, or all levels if levels is negative.
+	printTo(s *strings.Builder, levels int)
+
+	// Returns the number of entries, and the length of the longest path from the root.
+	stats() (int, int)
+}
+
+// Implements fmt.Formatter for a printable trie, so that accidentally printing a large trie is not a disaster.
+//   - %v and %s print a summary of the trie's size and depth.
+//   - %+v prints the structure of the trie up to defaultFormatLevels below the root,
+//     or up to the precision if one is given, as in %+.5v.
+//   - %#v prints the entire structure of the trie, the same as String().
+func formatTrie(f fmt.State, verb rune, trie printable) {
+	switch {
+	case verb == 'v' && f.Flag('#'):
+		var s strings.Builder
+		trie.printTo(&s, -1)
+		_, _ = f.Write([]byte(s.String()))
+	case verb == 'v' && f.Flag('+'):
+		levels, ok := f.Precision()
+		if !ok {
+			levels = defaultFormatLevels
+		}
+		var s strings.Builder
+		trie.printTo(&s, levels)
+		_, _ = f.Write([]byte(s.String()))
+	case verb == 'v' || verb == 's':
+		size, depth := trie.stats()
+		fmt.Fprintf(f, "{size: %d, depth: %d}", size, depth)
+	default:
+		fmt.Fprintf(f, "%%!%c(%T)", verb, trie)
+	}
+}
@@ -3,14 +3,20 @@ package btrie_test
 import (
 	"bytes"
 	"fmt"
+	"io"
 	"iter"
+	"log/slog"
 	"math/bits"
 	"math/rand"
 	"reflect"
 	"slices"
+	"strings"
+	"sync/atomic"
 	"testing"
 
 	"github.com/phiryll/btrie"
+	"github.com/phiryll/btrie/btrietest"
+	"github.com/phiryll/btrie/memtable"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -25,6 +31,13 @@ type (
 		factory func() TestBTrie
 	}
 
+	// A wrapper or other BTrie which is not Cloneable, for tests which run over every BTrie accepting any key.
+	// RuneTrie and FixedKeyTrie constrain their keys, and are tested separately.
+	wrapperDef struct {
+		name    string
+		factory func() btrie.BTrie[byte]
+	}
+
 	// A description of a trie to be tested or benchmarked.
 	// Some fields may not be populated depending on the use case.
 	//
@@ -72,6 +85,9 @@ const (
 
 	// The max key length from presentKeys, absentKeys, and nearKeys.
 	maxTestKeySize = 3
+
+	// Small, so that tests allocate several chunks.
+	arenaChunkSize = 16
 )
 
 var (
@@ -79,8 +95,50 @@ var (
 		{"reference", newReference},
 		{"pointer-trie", asCloneable(btrie.NewPointerTrie[byte])},
 		{"array-trie", asCloneable(btrie.NewArrayTrie[byte])},
+		{"arena-array-trie", asCloneable(newArenaArrayTrie)},
+		{"hat-trie", asCloneable(btrie.NewHATTrie[byte])},
+		{"weighted-trie", asCloneable(newWeightedTrie)},
+		{"map-trie", asCloneable(newMapTrie)},
 	}
 
+	wrapperDefs = []*wrapperDef{
+		{"overlay", func() btrie.BTrie[byte] { return btrie.NewOverlay(btrie.NewArrayTrie[byte]()) }},
+		{"synchronized", func() btrie.BTrie[byte] { return btrie.NewSynchronized(btrie.NewArrayTrie[byte]()) }},
+		{"striped", func() btrie.BTrie[byte] { return btrie.NewStriped(4, btrie.NewArrayTrie[byte]) }},
+		{"journaled", func() btrie.BTrie[byte] {
+			return btrie.WithJournal(btrie.NewArrayTrie[byte](), io.Discard, btrie.Codec[byte](byteCodec{}))
+		}},
+		{"arena", func() btrie.BTrie[byte] { return btrie.NewArenaTrie[byte](nil, byteCodec{}) }},
+		{"annotated", func() btrie.BTrie[byte] { return btrie.NewAnnotated[byte]() }},
+		{"hybrid", func() btrie.BTrie[byte] { return btrie.NewHybridTrie[byte]() }},
+		{"frozen", func() btrie.BTrie[byte] { return compactingHybrid{btrie.NewHybridTrie[byte]()} }},
+		{"depth-hint", func() btrie.BTrie[byte] { return btrie.WithMaxDepth(btrie.NewArrayTrie[byte](), 2) }},
+		{"key-buffer", func() btrie.BTrie[byte] { return btrie.WithKeyBuffer(btrie.NewArrayTrie[byte](), nil) }},
+		{"memtable", func() btrie.BTrie[byte] { return memtable.New[byte]() }},
+		{"hash-indexed", func() btrie.BTrie[byte] { return btrie.WithHashIndex(btrie.NewArrayTrie[byte]()) }},
+		{"value-codec", func() btrie.BTrie[byte] {
+			return btrie.WithValueCodec[byte](btrie.NewArrayTrie[[]byte](), byteCodec{})
+		}},
+		{"namespace", newNamespace},
+		{"txn", func() btrie.BTrie[byte] { return btrie.NewSynchronized(btrie.NewArrayTrie[byte]()).Begin() }},
+		{"logged", func() btrie.BTrie[byte] {
+			logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+			return btrie.WithLogger(btrie.NewArrayTrie[byte](), logger, slog.LevelInfo)
+		}},
+		{"pinned", func() btrie.BTrie[byte] { return btrie.WithPins(btrie.NewArrayTrie[byte]()) }},
+		{"backed", func() btrie.BTrie[byte] {
+			return btrie.WithBackingStore(btrie.NewArrayTrie[byte](), &mapStore{entries: map[string]byte{}})
+		}},
+		{"counted", newCounted},
+		{"recorded", func() btrie.BTrie[byte] {
+			return btrie.Record(btrie.NewArrayTrie[byte](), io.Discard, btrie.Codec[byte](byteCodec{}))
+		}},
+		{"unsafe", func() btrie.BTrie[byte] { return btrie.Unsafe(safeOnly{btrie.Safe(btrie.NewArrayTrie[byte]())}) }},
+	}
+
+	// Makes the names of the expvars published by newCounted unique.
+	countedID atomic.Int64
+
 	From       = btrie.From
 	forwardAll = From(nil).To(nil)
 	reverseAll = From(nil).DownTo(nil)
@@ -152,6 +210,44 @@ var (
 	testTrieConfigs = createTestTrieConfigs()
 )
 
+func newArenaArrayTrie() btrie.BTrie[byte] {
+	trie, _ := btrie.NewArrayTrieWithArena[byte](arenaChunkSize)
+	return trie
+}
+
+func newWeightedTrie() btrie.BTrie[byte] {
+	return btrie.NewWeightedTrie(func(v byte) float64 { return float64(v) })
+}
+
+func newMapTrie() btrie.BTrie[byte] {
+	return btrie.NewMapTrie[byte]()
+}
+
+// Another namespace shares the trie, so that the namespace's prefix is not the only one.
+func newNamespace() btrie.BTrie[byte] {
+	spaces := btrie.NewNamespaces[byte](btrie.NewArrayTrie[byte](), nil)
+	spaces.Open([]byte("other")).Put([]byte{}, 1)
+	return spaces.Open([]byte("ns"))
+}
+
+func newCounted() btrie.BTrie[byte] {
+	return btrie.PublishExpvar(fmt.Sprintf("btrie_test_counted_%d", countedID.Add(1)), btrie.NewArrayTrie[byte]())
+}
+
+// Hides the SafeBTrie returned by btrie.Safe, so that btrie.Unsafe wraps it instead of unwrapping it.
+type safeOnly struct {
+	btrie.SafeBTrie[byte]
+}
+
+// Returns implDefs followed by wrapperDefs, for tests which run over every BTrie.
+func allDefs() []*wrapperDef {
+	defs := make([]*wrapperDef, 0, len(implDefs)+len(wrapperDefs))
+	for _, def := range implDefs {
+		defs = append(defs, &wrapperDef{def.name, func() btrie.BTrie[byte] { return def.factory() }})
+	}
+	return append(defs, wrapperDefs...)
+}
+
 func asCloneable(factory func() btrie.BTrie[byte]) func() TestBTrie {
 	return func() TestBTrie {
 		trie := factory()
@@ -181,10 +277,11 @@ func cmpEntryReverse(a, b entry) int {
 	return bytes.Compare(b.key, a.key)
 }
 
+// Keys are copied, since some tries reuse a yielded key's memory, see [btrie.KeyBuffered].
 func collect(itr iter.Seq2[[]byte, byte]) []entry {
 	entries := []entry{}
 	for k, v := range itr {
-		entries = append(entries, entry{k, v})
+		entries = append(entries, entry{bytes.Clone(k), v})
 	}
 	return entries
 }
@@ -289,7 +386,7 @@ func assertPresent(t *testing.T, key []byte, value byte, trie TestBTrie) {
 }
 */
 
-func assertAbsent(t *testing.T, key []byte, trie TestBTrie) {
+func assertAbsent(t *testing.T, key []byte, trie btrie.BTrie[byte]) {
 	actual, ok := trie.Get(key)
 	assert.False(t, ok)
 	assert.Equal(t, zero, actual)
@@ -306,7 +403,7 @@ func assertAbsent(t *testing.T, key []byte, trie TestBTrie) {
 
 // Test that trie contains only the key/value pairs in entries,
 // and that Range(forward/reverse) returns them in the correct order.
-func assertSame(t *testing.T, entries map[string]byte, trie TestBTrie) {
+func assertSame(t *testing.T, entries map[string]byte, trie btrie.BTrie[byte]) {
 	sliceEntries := []entry{}
 	for key, expected := range entries {
 		actual, ok := trie.Get([]byte(key))
@@ -345,7 +442,7 @@ func TestNilArgPanics(t *testing.T) {
 // Tests Get/Put/Delete/Range with a specific key and trie, which should not contain key.
 // The trie should be the same after invoking this function.
 // Assumes trie.Range(forwardAll) works.
-func testKey(t *testing.T, key []byte, trie TestBTrie) {
+func testKey(t *testing.T, key []byte, trie btrie.BTrie[byte]) {
 	const value = byte(43)
 	const replacement = byte(57)
 	existing := map[string]byte{}
@@ -381,7 +478,7 @@ func testKey(t *testing.T, key []byte, trie TestBTrie) {
 func TestEmptyKey(t *testing.T) {
 	t.Parallel()
 	key := []byte{}
-	for _, def := range implDefs {
+	for _, def := range allDefs() {
 		t.Run(def.name, func(t *testing.T) {
 			t.Parallel()
 			trie := def.factory()
@@ -393,6 +490,170 @@ func TestEmptyKey(t *testing.T) {
 	}
 }
 
+// compactingHybrid compacts after every Put, so its entries are all frozen.
+type compactingHybrid struct {
+	*btrie.HybridTrie[byte]
+}
+
+func (c compactingHybrid) Put(key []byte, value byte) (byte, bool) {
+	prev, ok := c.HybridTrie.Put(key, value)
+	c.Compact()
+	return prev, ok
+}
+
+// Every implementation and wrapper must iterate in the same order.
+func TestIterationOrder(t *testing.T) {
+	t.Parallel()
+	for _, def := range allDefs() {
+		t.Run(def.name, func(t *testing.T) {
+			t.Parallel()
+			btrietest.CheckIterationOrder(t, def.factory(), 1)
+		})
+	}
+}
+
+// The empty key is less than every other key, and is within bounds like any other key.
+func TestEmptyKeyBounds(t *testing.T) {
+	t.Parallel()
+	empty := []byte{}
+	for _, def := range allDefs() {
+		t.Run(def.name, func(t *testing.T) {
+			t.Parallel()
+			trie := def.factory()
+			trie.Put(empty, 1)
+			trie.Put([]byte{0}, 2)
+			trie.Put([]byte{5}, 3)
+			both := []entry{{empty, 1}, {[]byte{0}, 2}}
+			assert.Equal(t, both, collect(trie.Range(From(nil).To([]byte{1}))))
+			assert.Equal(t, both, collect(trie.Range(From(empty).To([]byte{1}))))
+			assert.Equal(t, []entry{}, collect(trie.Range(From(nil).To(empty))))
+			assert.Equal(t, []entry{{[]byte{0}, 2}}, collect(trie.Range(From([]byte{0}).To([]byte{1}))))
+			slices.Reverse(both)
+			assert.Equal(t, both, collect(trie.Range(From([]byte{0}).DownTo(nil))))
+			assert.Equal(t, []entry{{empty, 1}}, collect(trie.Range(From(empty).DownTo(nil))))
+			assert.Equal(t, []entry{{[]byte{0}, 2}}, collect(trie.Range(From([]byte{0}).DownTo(empty))))
+		})
+	}
+}
+
+// Long keys sharing long prefixes, which the array trie stores in suffix leaves until they are split.
+//
+//nolint:gocognit
+func TestLongKeys(t *testing.T) {
+	t.Parallel()
+	const longKeySize = 4096
+	random := rand.New(rand.NewSource(2749))
+	long := make([]byte, longKeySize)
+	random.Read(long)
+	half := long[:longKeySize/2]
+	changedLast := bytes.Clone(long)
+	changedLast[longKeySize-1]++
+	keys := keySet{
+		long,
+		half,
+		append(bytes.Clone(half), 0),
+		changedLast,
+		append(bytes.Clone(long), 1),
+		long[:1],
+	}
+	for _, def := range implDefs {
+		t.Run(def.name, func(t *testing.T) {
+			t.Parallel()
+			trie := def.factory()
+			ref := newReference()
+			existing := map[string]byte{}
+			for i, key := range keys {
+				trie.Put(key, byte(i))
+				ref.Put(key, byte(i))
+				existing[string(key)] = byte(i)
+				assertSame(t, existing, trie)
+			}
+			for _, begin := range append(keySet{long[:longKeySize-1], half[:len(half)-1]}, keys...) {
+				for _, end := range keys {
+					bounds := From(begin)
+					switch cmp := bytes.Compare(begin, end); {
+					case cmp < 0:
+						bounds = bounds.To(end)
+					case cmp > 0:
+						bounds = bounds.DownTo(end)
+					default:
+						continue
+					}
+					assert.Equal(t, collect(ref.Range(bounds)), collect(trie.Range(bounds)), "%s", bounds)
+				}
+				assert.Equal(t, slices.Collect(btrie.Children(ref, begin)), slices.Collect(btrie.Children(trie, begin)))
+			}
+			assert.Equal(t, collectStats(btrie.HeavyPrefixes(ref, 1, longKeySize)),
+				collectStats(btrie.HeavyPrefixes(trie, 1, longKeySize)))
+			for i, key := range keys {
+				_, ok := trie.Delete(long[:longKeySize-1])
+				assert.False(t, ok)
+				value, ok := trie.Delete(key)
+				assert.True(t, ok)
+				assert.Equal(t, byte(i), value)
+				delete(existing, string(key))
+				assertSame(t, existing, trie)
+			}
+		})
+	}
+}
+
+// Tries must not retain the key slices passed to their methods.
+func TestKeysNotRetained(t *testing.T) {
+	t.Parallel()
+	for _, def := range allDefs() {
+		t.Run(def.name, func(t *testing.T) {
+			t.Parallel()
+			btrietest.CheckKeysNotRetained(t, def.factory(), 1)
+		})
+	}
+}
+
+// Keys yielded by Range must not share memory with each other or with the trie.
+// Siblings sharing a parent are the likeliest to alias, if a child key is built by appending to the parent's key.
+func TestRangeKeysNotAliased(t *testing.T) {
+	t.Parallel()
+	keys := [][]byte{{}, {1}, {1, 2}, {1, 2, 3}, {1, 2, 4}, {1, 2, 4, 5}, {1, 3}, {1, 3, 0}, {2}, {2, 0, 0, 0, 0, 0, 0, 0, 0, 9}}
+	for _, def := range implDefs {
+		t.Run(def.name, func(t *testing.T) {
+			t.Parallel()
+			trie := def.factory()
+			for i, key := range keys {
+				trie.Put(key, byte(i))
+			}
+			forward := collect(trie.Range(forwardAll))
+			reverse := slices.Clone(forward)
+			slices.Reverse(reverse)
+			for _, test := range []struct {
+				itr      iter.Seq2[[]byte, byte]
+				expected []entry
+			}{
+				{trie.Range(forwardAll), forward},
+				{trie.Range(reverseAll), reverse},
+				{btrie.All[byte](trie), forward},
+				{btrie.Backward[byte](trie), reverse},
+				{btrie.MergeRanges(forwardAll, nil, btrie.BTrie[byte](trie)), forward},
+			} {
+				// Scribble over every yielded key, including any spare capacity, before the next is yielded.
+				actual := []entry{}
+				for k, v := range test.itr {
+					actual = append(actual, entry{bytes.Clone(k), v})
+					full := k[:cap(k)]
+					for i := range full {
+						full[i] = 0xEE
+					}
+				}
+				assert.Equal(t, test.expected, actual)
+			}
+			for i, key := range keys {
+				value, ok := trie.Get(key)
+				assert.True(t, ok)
+				assert.Equal(t, byte(i), value)
+			}
+		})
+	}
+}
+
 // If String() exists, make sure it doesn't crash.
 func TestTrieString(t *testing.T) {
 	t.Parallel()
@@ -412,6 +673,43 @@ func TestTrieString(t *testing.T) {
 	}
 }
 
+func TestTrieFormat(t *testing.T) {
+	t.Parallel()
+	for _, def := range implDefs {
+		t.Run(def.name, func(t *testing.T) {
+			t.Parallel()
+			trie := def.factory()
+			if _, ok := trie.(fmt.Formatter); !ok {
+				return
+			}
+			assert.Equal(t, "{size: 0, depth: 0}", fmt.Sprintf("%v", trie))
+			trie.Put([]byte{}, 73)
+			trie.Put([]byte{43, 15}, 94)
+			trie.Put([]byte{43, 16}, 95)
+			trie.Put([]byte{126, 73, 12, 7}, 45)
+			assert.Equal(t, "{size: 4, depth: 4}", fmt.Sprintf("%v", trie))
+			assert.Equal(t, "{size: 4, depth: 4}", fmt.Sprintf("%s", trie))
+			assert.Equal(t, "%!d("+fmt.Sprintf("%T", trie)+")", fmt.Sprintf("%d", trie))
+
+			sTrie, ok := trie.(fmt.Stringer)
+			require.True(t, ok)
+			full := sTrie.String()
+			assert.Equal(t, full, fmt.Sprintf("%#v", trie))
+			assert.Equal(t, full, fmt.Sprintf("%+.4v", trie))
+			assert.Equal(t, full, fmt.Sprintf("%+.100v", trie))
+			assert.Len(t, strings.Split(full, "\n"), 9)
+
+			// Each elided subtree is replaced by a single line.
+			lines := func(s string) int { return len(strings.Split(s, "\n")) }
+			assert.Equal(t, 1+1+1, lines(fmt.Sprintf("%+.0v", trie)))
+			assert.Equal(t, 3+2+1, lines(fmt.Sprintf("%+.1v", trie)))
+			assert.Equal(t, 6+1+1, lines(fmt.Sprintf("%+.2v", trie)))
+			assert.Equal(t, 7+1+1, lines(fmt.Sprintf("%+v", trie)))
+			assert.Contains(t, fmt.Sprintf("%+.1v", trie), "\n  2B\n    ...\n")
+		})
+	}
+}
+
 //nolint:gocognit
 func TestTrie(t *testing.T) {
 	t.Parallel()
@@ -514,6 +812,18 @@ func TestClone(t *testing.T) {
 	}
 }
 
+// Mutating either a trie or its clone, even during a Range over the other, must never affect the other.
+func TestCloneIndependent(t *testing.T) {
+	t.Parallel()
+	for _, test := range createTestTries(rangeTestConfigs) {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			original := test.trie.Clone()
+			btrietest.CheckCloneIndependent[byte](t, original, original.Clone(), 0xFF)
+		})
+	}
+}
+
 // Things that failed at one point or another during testing.
 
 func testFail1(t *testing.T, factory func() TestBTrie) {
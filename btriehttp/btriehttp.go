@@ -0,0 +1,178 @@
+// Package btriehttp provides a read-only HTTP debug handler for the tries in package btrie,
+// in the spirit of the handlers in net/http/pprof and expvar.
+//
+// The handler returned by [Handler] serves JSON responses to GET requests on these paths,
+// relative to where it is mounted (use [http.StripPrefix] to mount it under a prefix):
+//
+//	/get?key=K                 the entry for key K, or 404 Not Found if there is none
+//	/list?prefix=P&limit=N     up to N entries having prefix P, in increasing key order
+//	/list?token=T&limit=N      the next page of a previous listing
+//	/stats?prefix=P            the number of entries having prefix P, and their total and maximum key lengths
+//
+// Keys and prefixes are taken as the raw bytes of the query parameters,
+// or are hex-encoded if the parameter hex=true is also given.
+// The prefix parameter is optional, and defaults to the empty prefix matching every key.
+// A listing has a default limit of [DefaultLimit] and a maximum of [MaxLimit].
+// If there are more entries, the listing includes a "next" token for the following page.
+// Entries are returned with both a "key" string, which may not be valid UTF-8, and a hex-encoded "keyHex".
+package btriehttp
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/phiryll/btrie"
+)
+
+// Limits on the number of entries in a listing.
+const (
+	DefaultLimit = 100
+	MaxLimit     = 1000
+)
+
+type entryJSON struct {
+	Key    string `json:"key"`
+	KeyHex string `json:"keyHex"`
+	Value  any    `json:"value"`
+}
+
+type listJSON struct {
+	Entries []entryJSON `json:"entries"`
+	Next    string      `json:"next,omitempty"`
+}
+
+type statsJSON struct {
+	Entries   int `json:"entries"`
+	KeyBytes  int `json:"keyBytes"`
+	MaxKeyLen int `json:"maxKeyLen"`
+}
+
+// Handler returns an [http.Handler] serving read-only requests for the entries of t, as described in the package doc.
+// valueJSON converts values into something [json.Marshal] can encode; if it is nil, values are encoded as they are.
+// Requests read t concurrently, so t must be safe for concurrent reads, and must not be mutated during a request
+// unless it is safe for that as well, such as a [btrie.Synchronized] trie.
+func Handler[V any](t btrie.ReadOnlyTrie[V], valueJSON func(V) any) http.Handler {
+	if valueJSON == nil {
+		valueJSON = func(v V) any { return v }
+	}
+	h := &handler[V]{t, valueJSON}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /get", h.get)
+	mux.HandleFunc("GET /list", h.list)
+	mux.HandleFunc("GET /stats", h.stats)
+	return mux
+}
+
+type handler[V any] struct {
+	trie      btrie.ReadOnlyTrie[V]
+	valueJSON func(V) any
+}
+
+func (h *handler[V]) entry(key []byte, value V) entryJSON {
+	return entryJSON{string(key), hex.EncodeToString(key), h.valueJSON(value)}
+}
+
+func (h *handler[V]) get(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if !query.Has("key") {
+		http.Error(w, "missing key parameter", http.StatusBadRequest)
+		return
+	}
+	key, ok := keyParam(w, r, "key")
+	if !ok {
+		return
+	}
+	value, ok := h.trie.Get(key)
+	if !ok {
+		http.Error(w, "key not found", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, h.entry(key, value))
+}
+
+func (h *handler[V]) list(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	limit := DefaultLimit
+	if s := query.Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n <= 0 || n > MaxLimit {
+			http.Error(w, "limit must be between 1 and "+strconv.Itoa(MaxLimit), http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+	var bounds *btrie.Bounds
+	var resumeKey []byte
+	if token := query.Get("token"); token != "" {
+		var err error
+		bounds, resumeKey, err = btrie.DecodeResumeToken(token)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	} else {
+		prefix, ok := keyParam(w, r, "prefix")
+		if !ok {
+			return
+		}
+		bounds, ok = btrie.From(nil).To(nil).Clamp(prefix)
+		if !ok {
+			writeJSON(w, listJSON{Entries: []entryJSON{}})
+			return
+		}
+	}
+	result := listJSON{Entries: []entryJSON{}}
+	for key, value := range btrie.RangeFrom(h.trie, bounds, resumeKey) {
+		if len(result.Entries) == limit {
+			result.Next = btrie.EncodeResumeToken(bounds, resumeKey)
+			break
+		}
+		result.Entries = append(result.Entries, h.entry(key, value))
+		resumeKey = append(resumeKey[:0], key...)
+	}
+	writeJSON(w, result)
+}
+
+func (h *handler[V]) stats(w http.ResponseWriter, r *http.Request) {
+	prefix, ok := keyParam(w, r, "prefix")
+	if !ok {
+		return
+	}
+	var result statsJSON
+	if bounds, ok := btrie.From(nil).To(nil).Clamp(prefix); ok {
+		for key := range h.trie.Range(bounds) {
+			result.Entries++
+			result.KeyBytes += len(key)
+			result.MaxKeyLen = max(result.MaxKeyLen, len(key))
+		}
+	}
+	writeJSON(w, result)
+}
+
+// Returns the key in the named query parameter, which is empty if the parameter is absent.
+// If the key is malformed, keyParam writes an error response and returns false.
+func keyParam(w http.ResponseWriter, r *http.Request, name string) ([]byte, bool) {
+	query := r.URL.Query()
+	s := query.Get(name)
+	if query.Get("hex") != "true" {
+		return []byte(s), true
+	}
+	key, err := hex.DecodeString(s)
+	if err != nil {
+		http.Error(w, "malformed hex "+name+": "+err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+	return key, true
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(append(data, '\n'))
+}
@@ -0,0 +1,146 @@
+package btriehttp_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/phiryll/btrie"
+	"github.com/phiryll/btrie/btriehttp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type entry struct {
+	Key    string `json:"key"`
+	KeyHex string `json:"keyHex"`
+	Value  string `json:"value"`
+}
+
+type listing struct {
+	Entries []entry `json:"entries"`
+	Next    string  `json:"next"`
+}
+
+func newTestHandler() http.Handler {
+	trie := btrie.NewArrayTrie[int]()
+	for i, key := range []string{"a", "ab", "abc", "abd", "b", "ba", "\xff"} {
+		trie.Put([]byte(key), i)
+	}
+	return btriehttp.Handler[int](trie, func(v int) any { return strconv.Itoa(v) })
+}
+
+func serve(t *testing.T, h http.Handler, method, target string) *httptest.ResponseRecorder {
+	t.Helper()
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(method, target, nil))
+	return w
+}
+
+func decode[T any](t *testing.T, w *httptest.ResponseRecorder) T {
+	t.Helper()
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	var result T
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+	return result
+}
+
+func TestGet(t *testing.T) {
+	t.Parallel()
+	h := newTestHandler()
+	assert.Equal(t, entry{"abc", "616263", "2"}, decode[entry](t, serve(t, h, "GET", "/get?key=abc")))
+	assert.Equal(t, entry{"abc", "616263", "2"}, decode[entry](t, serve(t, h, "GET", "/get?key=616263&hex=true")))
+	assert.Equal(t, "ff", decode[entry](t, serve(t, h, "GET", "/get?key=%FF")).KeyHex)
+	assert.Equal(t, http.StatusNotFound, serve(t, h, "GET", "/get?key=abe").Code)
+	assert.Equal(t, http.StatusNotFound, serve(t, h, "GET", "/get?key=").Code)
+	assert.Equal(t, http.StatusBadRequest, serve(t, h, "GET", "/get").Code)
+	assert.Equal(t, http.StatusBadRequest, serve(t, h, "GET", "/get?key=xyz&hex=true").Code)
+	assert.Equal(t, http.StatusMethodNotAllowed, serve(t, h, "POST", "/get?key=abc").Code)
+	assert.Equal(t, http.StatusNotFound, serve(t, h, "GET", "/put?key=abc").Code)
+}
+
+func keysOf(l listing) []string {
+	keys := []string{}
+	for _, e := range l.Entries {
+		keys = append(keys, e.Key)
+	}
+	return keys
+}
+
+func TestList(t *testing.T) {
+	t.Parallel()
+	h := newTestHandler()
+	all := decode[listing](t, serve(t, h, "GET", "/list"))
+	assert.Equal(t, []string{"a", "ab", "abc", "abd", "b", "ba", "�"}, keysOf(all))
+	assert.Equal(t, "ff", all.Entries[6].KeyHex)
+	assert.Empty(t, all.Next)
+
+	assert.Equal(t, []string{"ab", "abc", "abd"}, keysOf(decode[listing](t, serve(t, h, "GET", "/list?prefix=ab"))))
+	assert.Equal(t, []string{"b", "ba"}, keysOf(decode[listing](t, serve(t, h, "GET", "/list?prefix=62&hex=true"))))
+	assert.Equal(t, []string{}, keysOf(decode[listing](t, serve(t, h, "GET", "/list?prefix=c"))))
+
+	for _, target := range []string{"/list?limit=0", "/list?limit=1001", "/list?limit=x", "/list?token=!", "/list?prefix=x&hex=true"} {
+		assert.Equal(t, http.StatusBadRequest, serve(t, h, "GET", target).Code, target)
+	}
+}
+
+func TestListPages(t *testing.T) {
+	t.Parallel()
+	h := newTestHandler()
+	for _, tt := range []struct {
+		target   string
+		expected []string
+	}{
+		{"/list?limit=2", []string{"a", "ab", "abc", "abd", "b", "ba", "�"}},
+		{"/list?limit=3&prefix=ab", []string{"ab", "abc", "abd"}},
+		{"/list?limit=1&prefix=ab", []string{"ab", "abc", "abd"}},
+	} {
+		var keys []string
+		pages := 0
+		limit := decode[listing](t, serve(t, h, "GET", tt.target))
+		for page := limit; ; {
+			pages++
+			keys = append(keys, keysOf(page)...)
+			if page.Next == "" {
+				break
+			}
+			page = decode[listing](t, serve(t, h, "GET", tt.target+"&token="+url.QueryEscape(page.Next)))
+		}
+		assert.Equal(t, tt.expected, keys, tt.target)
+		assert.Equal(t, (len(tt.expected)+len(limit.Entries)-1)/len(limit.Entries), pages, tt.target)
+	}
+}
+
+func TestStats(t *testing.T) {
+	t.Parallel()
+	h := newTestHandler()
+	type stats struct {
+		Entries   int `json:"entries"`
+		KeyBytes  int `json:"keyBytes"`
+		MaxKeyLen int `json:"maxKeyLen"`
+	}
+	assert.Equal(t, stats{7, 13, 3}, decode[stats](t, serve(t, h, "GET", "/stats")))
+	assert.Equal(t, stats{3, 8, 3}, decode[stats](t, serve(t, h, "GET", "/stats?prefix=ab")))
+	assert.Equal(t, stats{}, decode[stats](t, serve(t, h, "GET", "/stats?prefix=c")))
+}
+
+func TestHandlerNilValueJSON(t *testing.T) {
+	t.Parallel()
+	trie := btrie.NewArrayTrie[int]()
+	trie.Put([]byte("a"), 42)
+	h := btriehttp.Handler[int](trie, nil)
+	w := serve(t, h, "GET", "/get?key=a")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"key": "a", "keyHex": "61", "value": 42}`, w.Body.String())
+}
+
+func TestHandlerStripPrefix(t *testing.T) {
+	t.Parallel()
+	mux := http.NewServeMux()
+	mux.Handle("/debug/trie/", http.StripPrefix("/debug/trie", newTestHandler()))
+	assert.Equal(t, "abc", decode[entry](t, serve(t, mux, "GET", "/debug/trie/get?key=abc")).Key)
+}
@@ -0,0 +1,263 @@
+// Package btrietest provides invariant checks for use in the tests of code which wraps, extends, or implements
+// the tries in package btrie.
+//
+// Each check reports every violation it finds with t.Errorf, and returns whether there were none,
+// so that a test can stop early if a check fails.
+package btrietest
+
+import (
+	"bytes"
+	"fmt"
+	"iter"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/phiryll/btrie"
+)
+
+// CheckOrdered checks that trie.Range(bounds) yields only non-nil keys within bounds,
+// each strictly after the previous one in the direction of bounds,
+// and that trie.Get returns the same value for each of those keys, compared with reflect.DeepEqual.
+func CheckOrdered[V any](t testing.TB, trie btrie.ReadOnlyTrie[V], bounds *btrie.Bounds) bool {
+	t.Helper()
+	ok := true
+	var prev []byte
+	for key, value := range trie.Range(bounds) {
+		if key == nil {
+			t.Errorf("Range(%s) yielded a nil key", bounds)
+			ok = false
+			continue
+		}
+		if bounds.Compare(key) != 0 {
+			t.Errorf("Range(%s) yielded key %x outside of bounds", bounds, key)
+			ok = false
+		}
+		if prev != nil {
+			cmp := bytes.Compare(prev, key)
+			if bounds.IsReverse {
+				cmp = -cmp
+			}
+			if cmp >= 0 {
+				t.Errorf("Range(%s) yielded key %x after %x", bounds, key, prev)
+				ok = false
+			}
+		}
+		if got, found := trie.Get(key); !found {
+			t.Errorf("Range(%s) yielded key %x, which Get does not find", bounds, key)
+			ok = false
+		} else if !reflect.DeepEqual(got, value) {
+			t.Errorf("Range(%s) yielded %v for key %x, but Get returns %v", bounds, value, key, got)
+			ok = false
+		}
+		prev = append(prev[:0], key...)
+	}
+	return ok
+}
+
+// CheckRangeMatchesReference checks that trie.Range(bounds) yields exactly the entries of reference within bounds,
+// in the order given by bounds, with values compared by eq.
+// If eq is nil, values are compared with reflect.DeepEqual.
+// The reference map is keyed by string(key), and is not modified.
+func CheckRangeMatchesReference[V any](
+	t testing.TB,
+	trie btrie.ReadOnlyTrie[V],
+	reference map[string]V,
+	bounds *btrie.Bounds,
+	eq btrie.Equaler[V],
+) bool {
+	t.Helper()
+	return checkSeqMatchesReference(t, fmt.Sprintf("Range(%s)", bounds), trie.Range(bounds), reference, bounds, eq)
+}
+
+// Checks that seq yields exactly the entries of reference within bounds, in the order given by bounds.
+// name describes seq in error messages.
+func checkSeqMatchesReference[V any](
+	t testing.TB,
+	name string,
+	seq iter.Seq2[[]byte, V],
+	reference map[string]V,
+	bounds *btrie.Bounds,
+	eq btrie.Equaler[V],
+) bool {
+	t.Helper()
+	if eq == nil {
+		eq = btrie.EqualFunc[V](func(a, b V) bool { return reflect.DeepEqual(a, b) })
+	}
+	var expected []string
+	for key := range reference {
+		if bounds.Compare([]byte(key)) == 0 {
+			expected = append(expected, key)
+		}
+	}
+	slices.Sort(expected)
+	if bounds.IsReverse {
+		slices.Reverse(expected)
+	}
+	ok := true
+	i := 0
+	for key, value := range seq {
+		if i == len(expected) {
+			t.Errorf("%s yielded unexpected key %x after all expected keys", name, key)
+			ok = false
+			break
+		}
+		if string(key) != expected[i] {
+			t.Errorf("%s yielded key %x at index %d, expected %x", name, key, i, expected[i])
+			ok = false
+			break
+		}
+		if want := reference[expected[i]]; !eq.Equal(want, value) {
+			t.Errorf("%s yielded %v for key %x, expected %v", name, value, key, want)
+			ok = false
+		}
+		i++
+	}
+	if ok && i < len(expected) {
+		t.Errorf("%s yielded %d keys, expected %d; first missing key is %x", name, i, len(expected), expected[i])
+		ok = false
+	}
+	return ok
+}
+
+// CheckCloneIndependent checks that clone, which must have just been cloned from original, is fully independent of it.
+// That is, mutating original never affects clone, even during a Range over clone, and vice versa.
+// Every implementation with a way to clone or snapshot a trie must guarantee this,
+// since a clone sharing storage with its original silently corrupts one when the other is mutated.
+// During each Range over one of the tries, every yielded key is deleted from the other,
+// and the smallest key greater than it is put into the other with the given value.
+// Values are compared with reflect.DeepEqual. On return, the contents of both tries are unspecified.
+func CheckCloneIndependent[V any](t testing.TB, original, clone btrie.BTrie[V], value V) bool {
+	t.Helper()
+	ok := true
+	for _, tt := range []struct {
+		name          string
+		ranged, other btrie.BTrie[V]
+	}{
+		{"clone", clone, original},
+		{"original", original, clone},
+	} {
+		reference := map[string]V{}
+		for key, v := range tt.ranged.Range(btrie.From(nil).To(nil)) {
+			reference[string(key)] = v
+		}
+		for _, bounds := range []*btrie.Bounds{btrie.From(nil).To(nil), btrie.From(nil).DownTo(nil)} {
+			mutating := func(yield func([]byte, V) bool) {
+				for key, v := range tt.ranged.Range(bounds) {
+					if !yield(key, v) {
+						return
+					}
+					tt.other.Delete(key)
+					tt.other.Put(btrie.NextKey(key), value)
+				}
+			}
+			name := fmt.Sprintf("Range(%s) of the %s while mutating the other", bounds, tt.name)
+			ok = checkSeqMatchesReference(t, name, mutating, reference, bounds, nil) && ok
+		}
+		for key, want := range reference {
+			if got, found := tt.ranged.Get([]byte(key)); !found || !reflect.DeepEqual(want, got) {
+				t.Errorf("Get(%x) of the %s returned (%v, %t) after mutating the other, expected %v", key, tt.name, got, found, want)
+				ok = false
+			}
+		}
+	}
+	return ok
+}
+
+// Keys exercising the ordering of the empty key, prefixes and their extensions, and the extreme byte values.
+var orderKeys = []string{
+	"", "\x00", "\x00\x00", "\x00\x01", "\x01",
+	"a", "ab", "abc", "abd", "ac", "b",
+	"\x7f", "\x80", "\xfe", "\xff", "\xff\x00", "\xff\xff", "\xff\xff\xff",
+}
+
+// CheckIterationOrder checks that trie iterates in the order required by [btrie.BTrie.Range],
+// for both Range and the package functions [btrie.All] and [btrie.Backward], in both directions,
+// over keys chosen to expose common mistakes, such as yielding a key after its extensions when forward.
+// trie must be empty, and on return it contains those keys, all with the given value.
+func CheckIterationOrder[V any](t testing.TB, trie btrie.BTrie[V], value V) bool {
+	t.Helper()
+	for range trie.Range(btrie.From(nil).To(nil)) {
+		t.Errorf("trie is not empty")
+		return false
+	}
+	reference := map[string]V{}
+	for _, key := range orderKeys {
+		trie.Put([]byte(key), value)
+		reference[key] = value
+	}
+	ok := true
+	for _, bounds := range []*btrie.Bounds{
+		btrie.From(nil).To(nil),
+		btrie.From(nil).DownTo(nil),
+		btrie.From([]byte{}).To([]byte("ab")),
+		btrie.From([]byte("ab")).DownTo([]byte{}),
+		btrie.From([]byte("a")).To([]byte("b")),
+		btrie.From([]byte("b")).DownTo([]byte("a")),
+		btrie.From([]byte("\x00")).To([]byte("\xff")),
+		btrie.From([]byte("\xff\xff")).DownTo([]byte("\x00")),
+		btrie.From([]byte("\xff")).To(nil),
+		btrie.From(nil).DownTo([]byte("\xff")),
+	} {
+		ok = CheckRangeMatchesReference(t, trie, reference, bounds, nil) && ok
+	}
+	expected := slices.Clone(orderKeys)
+	ok = checkKeys(t, "All", btrie.All(trie), expected) && ok
+	slices.Reverse(expected)
+	ok = checkKeys(t, "Backward", btrie.Backward(trie), expected) && ok
+	return ok
+}
+
+// CheckKeysNotRetained checks that trie does not retain the key slices passed to Put and Delete,
+// as every BTrie in package btrie guarantees, by reusing one slice for every key and scribbling over it,
+// including its spare capacity, after each call. The keys all have length 3 and are valid UTF-8,
+// so that tries constraining their keys, such as [btrie.RuneTrie] and [btrie.FixedKeyTrie], can be checked.
+// trie must be empty, and on return it contains some of those keys, all with the given value.
+func CheckKeysNotRetained[V any](t testing.TB, trie btrie.BTrie[V], value V) bool {
+	t.Helper()
+	for range trie.Range(btrie.From(nil).To(nil)) {
+		t.Errorf("trie is not empty")
+		return false
+	}
+	key := make([]byte, 3, 8)
+	scribble := func() {
+		full := key[:cap(key)]
+		for i := range full {
+			full[i] = 0x7E
+		}
+	}
+	reference := map[string]V{}
+	for i := range byte(10) {
+		key[0], key[1], key[2] = 'a'+i, 'b'+i, 'c'+i
+		trie.Put(key, value)
+		reference[string(key)] = value
+		scribble()
+	}
+	ok := CheckRangeMatchesReference(t, trie, reference, btrie.From(nil).To(nil), nil)
+	key[0], key[1], key[2] = 'd', 'e', 'f'
+	trie.Delete(key)
+	delete(reference, "def")
+	scribble()
+	ok = CheckRangeMatchesReference(t, trie, reference, btrie.From(nil).To(nil), nil) && ok
+	ok = CheckRangeMatchesReference(t, trie, reference, btrie.From(nil).DownTo(nil), nil) && ok
+	for k := range reference {
+		if _, found := trie.Get([]byte(k)); !found {
+			t.Errorf("Get does not find key %x", k)
+			ok = false
+		}
+	}
+	return ok
+}
+
+func checkKeys[V any](t testing.TB, name string, seq iter.Seq2[[]byte, V], expected []string) bool {
+	t.Helper()
+	actual := []string{}
+	for key := range seq {
+		actual = append(actual, string(key))
+	}
+	if !slices.Equal(expected, actual) {
+		t.Errorf("%s yielded keys %q, expected %q", name, actual, expected)
+		return false
+	}
+	return true
+}
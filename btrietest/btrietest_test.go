@@ -0,0 +1,229 @@
+package btrietest_test
+
+import (
+	"bytes"
+	"fmt"
+	"iter"
+	"testing"
+
+	"github.com/phiryll/btrie"
+	"github.com/phiryll/btrie/btrietest"
+	"github.com/stretchr/testify/assert"
+)
+
+// recorder is a testing.TB which records errors instead of failing.
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+// broken is a ReadOnlyTrie whose Range yields entries as given, ignoring bounds and direction,
+// and whose Get finds only the keys in get.
+type broken struct {
+	entries []btrie.Entry[int]
+	get     map[string]int
+}
+
+func (b *broken) Get(key []byte) (int, bool) {
+	value, ok := b.get[string(key)]
+	return value, ok
+}
+
+func (b *broken) Range(*btrie.Bounds) iter.Seq2[[]byte, int] {
+	return func(yield func([]byte, int) bool) {
+		for _, e := range b.entries {
+			if !yield(e.Key, e.Value) {
+				return
+			}
+		}
+	}
+}
+
+var (
+	forwardAll = btrie.From(nil).To(nil)
+	reverseAll = btrie.From(nil).DownTo(nil)
+)
+
+func entry(key string, value int) btrie.Entry[int] {
+	return btrie.Entry[int]{Key: []byte(key), Value: value}
+}
+
+func entries(keys ...string) []btrie.Entry[int] {
+	result := []btrie.Entry[int]{}
+	for i, key := range keys {
+		result = append(result, btrie.Entry[int]{Key: []byte(key), Value: i})
+	}
+	return result
+}
+
+func TestCheckOrdered(t *testing.T) {
+	t.Parallel()
+	trie := btrie.NewArrayTrie[int]()
+	reference := map[string]int{}
+	for i, key := range []string{"", "a", "ab", "b", "ba", "c"} {
+		trie.Put([]byte(key), i)
+		reference[key] = i
+	}
+	for _, bounds := range []*btrie.Bounds{
+		forwardAll, reverseAll,
+		btrie.From([]byte("a")).To([]byte("b")),
+		btrie.From([]byte("b")).DownTo([]byte("a")),
+	} {
+		assert.True(t, btrietest.CheckOrdered(t, trie, bounds))
+		assert.True(t, btrietest.CheckRangeMatchesReference(t, trie, reference, bounds, nil))
+		assert.True(t, btrietest.CheckRangeMatchesReference(t, trie, reference, bounds, btrie.Comparable[int]()))
+	}
+}
+
+func TestCheckOrderedFailures(t *testing.T) {
+	t.Parallel()
+	get := map[string]int{"a": 0, "b": 1, "c": 2}
+	for _, tt := range []struct {
+		name    string
+		trie    *broken
+		bounds  *btrie.Bounds
+		numErrs int
+	}{
+		{"ordered", &broken{entries("a", "b", "c"), get}, forwardAll, 0},
+		{"out of order", &broken{entries("a", "c", "b"), map[string]int{"a": 0, "c": 1, "b": 2}}, forwardAll, 1},
+		{"repeated", &broken{entries("a", "a"), map[string]int{"a": 0}}, forwardAll, 2},
+		{"wrong direction", &broken{entries("a", "b", "c"), get}, reverseAll, 2},
+		{"out of bounds", &broken{entries("a", "b", "c"), get}, btrie.From([]byte("b")).To(nil), 1},
+		{"missing from Get", &broken{entries("a", "b", "c", "d"), get}, forwardAll, 1},
+		{"wrong value", &broken{entries("a", "b", "c"), map[string]int{"a": 0, "b": 5, "c": 2}}, forwardAll, 1},
+		{"nil key", &broken{[]btrie.Entry[int]{{Key: nil}}, get}, forwardAll, 1},
+	} {
+		r := &recorder{TB: t}
+		assert.Equal(t, tt.numErrs == 0, btrietest.CheckOrdered[int](r, tt.trie, tt.bounds), tt.name)
+		assert.Len(t, r.errors, tt.numErrs, "%s: %v", tt.name, r.errors)
+	}
+}
+
+func TestCheckRangeMatchesReferenceFailures(t *testing.T) {
+	t.Parallel()
+	reference := map[string]int{"a": 0, "b": 1, "c": 2}
+	for _, tt := range []struct {
+		name    string
+		entries []btrie.Entry[int]
+		bounds  *btrie.Bounds
+		ok      bool
+	}{
+		{"same", entries("a", "b", "c"), forwardAll, true},
+		{"bounded", []btrie.Entry[int]{entry("b", 1), entry("c", 2)}, btrie.From([]byte("b")).To(nil), true},
+		{"reverse", []btrie.Entry[int]{entry("c", 2), entry("b", 1), entry("a", 0)}, reverseAll, true},
+		{"extra", entries("a", "b", "c", "d"), forwardAll, false},
+		{"missing", entries("a", "b"), forwardAll, false},
+		{"different", entries("a", "bb", "c"), forwardAll, false},
+		{"wrong direction", entries("a", "b", "c"), reverseAll, false},
+		{"wrong value", []btrie.Entry[int]{entry("a", 0), entry("b", 7), entry("c", 2)}, forwardAll, false},
+	} {
+		r := &recorder{TB: t}
+		ok := btrietest.CheckRangeMatchesReference[int](r, &broken{tt.entries, nil}, reference, tt.bounds, nil)
+		assert.Equal(t, tt.ok, ok, "%s: %v", tt.name, r.errors)
+		assert.Equal(t, tt.ok, len(r.errors) == 0, "%s: %v", tt.name, r.errors)
+	}
+}
+
+// swapping is a BTrie whose Range yields the first two entries in the wrong order.
+type swapping struct {
+	btrie.BTrie[int]
+}
+
+func (s swapping) Range(bounds *btrie.Bounds) iter.Seq2[[]byte, int] {
+	return func(yield func([]byte, int) bool) {
+		var first []byte
+		i := 0
+		for k, v := range s.BTrie.Range(bounds) {
+			i++
+			switch i {
+			case 1:
+				first = bytes.Clone(k)
+				continue
+			case 2:
+				if !yield(k, v) || !yield(first, v) {
+					return
+				}
+				continue
+			}
+			if !yield(k, v) {
+				return
+			}
+		}
+		if i == 1 {
+			yield(first, 0)
+		}
+	}
+}
+
+func TestCheckIterationOrder(t *testing.T) {
+	t.Parallel()
+	assert.True(t, btrietest.CheckIterationOrder(t, btrie.NewArrayTrie[int](), 1))
+
+	r := &recorder{TB: t}
+	assert.False(t, btrietest.CheckIterationOrder(r, swapping{btrie.NewArrayTrie[int]()}, 1))
+	assert.NotEmpty(t, r.errors)
+
+	r = &recorder{TB: t}
+	trie := btrie.NewArrayTrie[int]()
+	trie.Put([]byte{1}, 1)
+	assert.False(t, btrietest.CheckIterationOrder(r, trie, 1))
+	assert.Equal(t, []string{"trie is not empty"}, r.errors)
+}
+
+// retaining is a BTrie which retains the keys passed to Put, and puts them again when Range is called,
+// as a trie deferring work to its next Range might.
+type retaining struct {
+	btrie.BTrie[int]
+	keys [][]byte
+}
+
+func (r *retaining) Put(key []byte, value int) (int, bool) {
+	r.keys = append(r.keys, key)
+	return r.BTrie.Put(key, value)
+}
+
+func (r *retaining) Range(bounds *btrie.Bounds) iter.Seq2[[]byte, int] {
+	for _, key := range r.keys {
+		r.BTrie.Put(key, 0)
+	}
+	r.keys = nil
+	return r.BTrie.Range(bounds)
+}
+
+func TestCheckKeysNotRetained(t *testing.T) {
+	t.Parallel()
+	assert.True(t, btrietest.CheckKeysNotRetained(t, btrie.NewArrayTrie[int](), 1))
+
+	r := &recorder{TB: t}
+	assert.False(t, btrietest.CheckKeysNotRetained(r, &retaining{BTrie: btrie.NewArrayTrie[int]()}, 1))
+	assert.NotEmpty(t, r.errors)
+
+	r = &recorder{TB: t}
+	trie := btrie.NewArrayTrie[int]()
+	trie.Put([]byte{1}, 1)
+	assert.False(t, btrietest.CheckKeysNotRetained(r, trie, 1))
+	assert.Equal(t, []string{"trie is not empty"}, r.errors)
+}
+
+func TestCheckCloneIndependent(t *testing.T) {
+	t.Parallel()
+	newTrie := func() btrie.BTrie[int] {
+		trie := btrie.NewArrayTrie[int]()
+		for i, key := range []string{"", "a", "ab", "b", "\xff"} {
+			trie.Put([]byte(key), i)
+		}
+		return trie
+	}
+	assert.True(t, btrietest.CheckCloneIndependent(t, newTrie(), newTrie(), -1))
+
+	r := &recorder{TB: t}
+	shared := newTrie()
+	assert.False(t, btrietest.CheckCloneIndependent(r, shared, shared, -1))
+	assert.NotEmpty(t, r.errors)
+}
@@ -0,0 +1,75 @@
+package btrie
+
+import (
+	"bytes"
+	"slices"
+)
+
+// Builder accumulates entries in any order, then builds them into an immutable trie.
+// This is faster and more compact than putting each entry into a BTrie,
+// when a trie is built once and then only read.
+// Builder is not safe for concurrent use.
+type Builder[V any] struct {
+	entries []Entry[V]
+	built   bool
+}
+
+// NewBuilder returns a new, empty Builder.
+func NewBuilder[V any]() *Builder[V] {
+	return &Builder[V]{}
+}
+
+// Add adds an entry for key with value.
+// If key is added more than once, the last value added is used.
+// The key is copied, and can be modified after Add returns.
+// Add will panic if key is nil, or if Build has been called.
+func (b *Builder[V]) Add(key []byte, value V) {
+	if key == nil {
+		panic("key must be non-nil")
+	}
+	if b.built {
+		panic("builder has already been built")
+	}
+	b.entries = append(b.entries, Entry[V]{bytes.Clone(key), value})
+}
+
+// Build returns an immutable trie containing the added entries, which is safe for concurrent use.
+// The entries are sorted once in bulk, and stored in exactly sized slices.
+// The Builder releases its entries, and cannot be used again.
+// Build will panic if it has already been called.
+func (b *Builder[V]) Build() ReadOnlyTrie[V] {
+	if b.built {
+		panic("builder has already been built")
+	}
+	entries := b.entries
+	b.entries, b.built = nil, true
+	cmp := func(a, b Entry[V]) int {
+		return bytes.Compare(a.Key, b.Key)
+	}
+	if !slices.IsSortedFunc(entries, cmp) {
+		// Stable, so the last of equal keys is still the last value added.
+		slices.SortStableFunc(entries, cmp)
+	}
+	numKeys, numBytes := 0, 0
+	for i, entry := range entries {
+		if i+1 < len(entries) && bytes.Equal(entry.Key, entries[i+1].Key) {
+			continue
+		}
+		numKeys++
+		numBytes += len(entry.Key)
+	}
+	t := &frozenTrie[V]{
+		keys:   make([]byte, 0, numBytes),
+		ends:   make([]int, 0, numKeys),
+		values: make([]V, 0, numKeys),
+	}
+	for i, entry := range entries {
+		if i+1 < len(entries) && bytes.Equal(entry.Key, entries[i+1].Key) {
+			continue
+		}
+		t.keys = append(t.keys, entry.Key...)
+		t.ends = append(t.ends, len(t.keys))
+		t.values = append(t.values, entry.Value)
+	}
+	return t
+}
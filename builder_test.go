@@ -0,0 +1,67 @@
+package btrie_test
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/phiryll/btrie"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuilder(t *testing.T) {
+	t.Parallel()
+	for _, test := range createTestTries(rangeTestConfigs) {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			builder := btrie.NewBuilder[byte]()
+			var keys []string
+			for k := range test.config.entries {
+				keys = append(keys, k)
+			}
+			shuffle(keys, rand.New(rand.NewSource(1)))
+			for _, k := range keys {
+				// Later values win.
+				builder.Add([]byte(k), test.config.entries[k]+1)
+				builder.Add([]byte(k), test.config.entries[k])
+			}
+			trie := builder.Build()
+			for k, v := range test.config.entries {
+				actual, ok := trie.Get([]byte(k))
+				assert.True(t, ok)
+				assert.Equal(t, v, actual)
+			}
+			for _, bounds := range append(test.config.forward, test.config.reverse...) {
+				assert.Equal(t, collect(test.trie.Range(&bounds)), collect(trie.Range(&bounds)), "%s", bounds)
+			}
+		})
+	}
+}
+
+func TestBuilderSorted(t *testing.T) {
+	t.Parallel()
+	builder := btrie.NewBuilder[byte]()
+	key := []byte{1}
+	builder.Add(key, 1)
+	key[0] = 2 // must not affect the added key
+	builder.Add(key, 2)
+	builder.Add(key, 3)
+	builder.Add([]byte{}, 0)
+	trie := builder.Build()
+	assert.Equal(t, []entry{{[]byte{}, 0}, {[]byte{1}, 1}, {[]byte{2}, 3}}, collect(trie.Range(forwardAll)))
+	_, ok := trie.Get([]byte{3})
+	assert.False(t, ok)
+
+	empty := btrie.NewBuilder[byte]().Build()
+	assert.Empty(t, collect(empty.Range(forwardAll)))
+	_, ok = empty.Get([]byte{})
+	assert.False(t, ok)
+}
+
+func TestBuilderPanics(t *testing.T) {
+	t.Parallel()
+	builder := btrie.NewBuilder[byte]()
+	assert.Panics(t, func() { builder.Add(nil, 0) })
+	builder.Build()
+	assert.Panics(t, func() { builder.Add([]byte{}, 0) })
+	assert.Panics(t, func() { builder.Build() })
+}
@@ -0,0 +1,191 @@
+package btrie
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// The checkpoint written by Journaled.CheckpointSince is:
+//
+//	magic   [4]byte  "BTRC"
+//	version uvarint  currently 1
+//	base    uvarint  the sequence number the checkpoint is relative to, 0 for a full checkpoint
+//	seq     uvarint  the sequence number of the last mutation included in the checkpoint
+//	records          zero or more, in increasing key order
+//	end     byte     0
+//
+// where each record is:
+//
+//	op        byte     1 = put, 2 = delete
+//	keySize   uvarint
+//	key       [keySize]byte
+//	valueSize uvarint          put only
+//	value     [valueSize]byte  put only, as encoded by the Codec
+const (
+	checkpointMagic   = "BTRC"
+	checkpointVersion = 1
+)
+
+// ErrCheckpointMismatch is wrapped by errors returned when restoring a checkpoint
+// which cannot be applied to a trie at the given sequence number.
+var ErrCheckpointMismatch = errors.New("checkpoint does not apply to trie")
+
+// TrackVersions causes j to remember the sequence number of the last recorded mutation of each key,
+// which allows [Journaled.CheckpointSince] to write incremental checkpoints.
+// This costs roughly one additional trie entry per key ever mutated, including deleted keys.
+// TrackVersions panics if any mutations have already been recorded.
+func (j *Journaled[V]) TrackVersions() {
+	if j.seq != 0 {
+		panic("mutations have already been recorded")
+	}
+	if j.versions == nil {
+		j.versions = NewArrayTrie[uint64]()
+	}
+}
+
+// CheckpointSince writes a checkpoint of j to w, using the Codec given to [WithJournal] to encode the values.
+// If baseVersion is 0, the checkpoint contains every entry of j.
+// Otherwise, the checkpoint contains only the keys mutated after the mutation with sequence number baseVersion,
+// with their current values or as deletions, and can only be restored over an earlier checkpoint;
+// this requires that [Journaled.TrackVersions] was called.
+// Checkpoints can be restored with [RestoreCheckpoint].
+//
+// CheckpointSince panics if baseVersion is greater than [Journaled.Seq],
+// or if baseVersion is not 0 and versions are not being tracked.
+// CheckpointSince returns [Journaled.Err] if it is not nil, since later mutations have no sequence number.
+func (j *Journaled[V]) CheckpointSince(w io.Writer, baseVersion uint64) error {
+	if baseVersion > j.seq {
+		panic(fmt.Sprintf("base version %d is after the current version %d", baseVersion, j.seq))
+	}
+	if baseVersion != 0 && j.versions == nil {
+		panic("versions are not being tracked")
+	}
+	if j.err != nil {
+		return j.err
+	}
+	bw := bufio.NewWriter(w)
+	buf := binary.AppendUvarint([]byte(checkpointMagic), checkpointVersion)
+	buf = binary.AppendUvarint(buf, baseVersion)
+	buf = binary.AppendUvarint(buf, j.seq)
+	if _, err := bw.Write(buf); err != nil {
+		return err
+	}
+	var valueBuf []byte
+	writePut := func(key []byte, value V) error {
+		var err error
+		valueBuf, err = j.codec.AppendValue(valueBuf[:0], value)
+		if err != nil {
+			return err
+		}
+		buf = append(buf[:0], journalPut)
+		buf = appendSized(buf, key)
+		buf = appendSized(buf, valueBuf)
+		_, err = bw.Write(buf)
+		return err
+	}
+	if baseVersion == 0 {
+		for key, value := range All(j.trie) {
+			if err := writePut(key, value); err != nil {
+				return err
+			}
+		}
+	} else {
+		for key, seq := range All(j.versions) {
+			if seq <= baseVersion {
+				continue
+			}
+			if value, ok := j.trie.Get(key); ok {
+				if err := writePut(key, value); err != nil {
+					return err
+				}
+				continue
+			}
+			buf = append(buf[:0], journalDelete)
+			buf = appendSized(buf, key)
+			if _, err := bw.Write(buf); err != nil {
+				return err
+			}
+		}
+	}
+	if err := bw.WriteByte(0); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// RestoreCheckpoint reads a checkpoint written by [Journaled.CheckpointSince] from r,
+// and applies it to trie, using codec to decode the values.
+// The sequence number of the checkpoint last applied to trie is given by version, which is 0 if there is none.
+// A full checkpoint should be restored into an empty trie, for which version is ignored,
+// and an incremental checkpoint can only be applied to a trie whose version is at least its base version,
+// and at most its own version. Incremental checkpoints can therefore be layered over a full checkpoint in order.
+// RestoreCheckpoint returns the sequence number of the restored checkpoint.
+// Records read before an error is encountered will have been applied.
+// If r does not implement [io.ByteReader], RestoreCheckpoint may read past the end of the checkpoint.
+func RestoreCheckpoint[V any](r io.Reader, trie BTrie[V], codec Codec[V], version uint64) (uint64, error) {
+	br, ok := r.(byteReader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+	magic := make([]byte, len(checkpointMagic))
+	if _, err := io.ReadFull(br, magic); err != nil {
+		return version, formatError(err)
+	}
+	if string(magic) != checkpointMagic {
+		return version, fmt.Errorf("%w: bad magic %q", ErrInvalidFormat, magic)
+	}
+	format, err := binary.ReadUvarint(br)
+	if err != nil {
+		return version, formatError(err)
+	}
+	if format != checkpointVersion {
+		return version, fmt.Errorf("%w: unsupported version %d", ErrInvalidFormat, format)
+	}
+	base, err := binary.ReadUvarint(br)
+	if err != nil {
+		return version, formatError(err)
+	}
+	seq, err := binary.ReadUvarint(br)
+	if err != nil {
+		return version, formatError(err)
+	}
+	if base > seq {
+		return version, fmt.Errorf("%w: base %d is after %d", ErrInvalidFormat, base, seq)
+	}
+	if base != 0 && (version < base || version > seq) {
+		return version, fmt.Errorf("%w: checkpoint from %d to %d, trie at %d", ErrCheckpointMismatch, base, seq, version)
+	}
+	var buf []byte
+	for {
+		op, err := br.ReadByte()
+		if err != nil {
+			return version, formatError(err)
+		}
+		if op == 0 {
+			return seq, nil
+		}
+		if op != journalPut && op != journalDelete {
+			return version, fmt.Errorf("%w: bad checkpoint op %d", ErrInvalidFormat, op)
+		}
+		key, err := readSized(br, nil)
+		if err != nil {
+			return version, err
+		}
+		if op == journalDelete {
+			trie.Delete(key)
+			continue
+		}
+		buf, err = readSized(br, buf[:0])
+		if err != nil {
+			return version, err
+		}
+		value, err := codec.DecodeValue(buf)
+		if err != nil {
+			return version, err
+		}
+		trie.Put(key, value)
+	}
+}
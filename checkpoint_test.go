@@ -0,0 +1,152 @@
+package btrie_test
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/phiryll/btrie"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckpointSince(t *testing.T) {
+	t.Parallel()
+	for _, def := range implDefs {
+		t.Run(def.name, func(t *testing.T) {
+			t.Parallel()
+			primary := btrie.WithJournal[byte](def.factory(), io.Discard, byteCodec{})
+			primary.TrackVersions()
+			expected := map[string]byte{}
+			for i, key := range presentTestKeys {
+				primary.Put(key, byte(i))
+				expected[string(key)] = byte(i)
+			}
+			var full bytes.Buffer
+			require.NoError(t, primary.CheckpointSince(&full, 0))
+			base := primary.Seq()
+
+			for i, key := range presentTestKeys {
+				if i%3 == 0 {
+					primary.Delete(key)
+					delete(expected, string(key))
+				}
+			}
+			var incr1 bytes.Buffer
+			require.NoError(t, primary.CheckpointSince(&incr1, base))
+			mid := primary.Seq()
+
+			primary.Put(presentTestKeys[0], 99)
+			expected[string(presentTestKeys[0])] = 99
+			primary.Put(presentTestKeys[1], 98)
+			expected[string(presentTestKeys[1])] = 98
+			var incr2 bytes.Buffer
+			require.NoError(t, primary.CheckpointSince(&incr2, mid))
+			assert.Less(t, incr2.Len(), incr1.Len())
+
+			restored := def.factory()
+			version, err := btrie.RestoreCheckpoint(&full, restored, byteCodec{}, 0)
+			require.NoError(t, err)
+			assert.Equal(t, base, version)
+			version, err = btrie.RestoreCheckpoint(&incr1, restored, byteCodec{}, version)
+			require.NoError(t, err)
+			assert.Equal(t, mid, version)
+			version, err = btrie.RestoreCheckpoint(&incr2, restored, byteCodec{}, version)
+			require.NoError(t, err)
+			assert.Equal(t, primary.Seq(), version)
+			assertSame(t, expected, restored)
+		})
+	}
+}
+
+func TestCheckpointSinceOverlapping(t *testing.T) {
+	t.Parallel()
+	primary := btrie.WithJournal(btrie.NewArrayTrie[byte](), io.Discard, byteCodec{})
+	primary.TrackVersions()
+	primary.Put([]byte{1}, 1)
+	primary.Put([]byte{2}, 2)
+	var full bytes.Buffer
+	require.NoError(t, primary.CheckpointSince(&full, 0))
+	primary.Put([]byte{3}, 3)
+	var incr1 bytes.Buffer
+	require.NoError(t, primary.CheckpointSince(&incr1, 2))
+	primary.Delete([]byte{1})
+	var incr2 bytes.Buffer
+	require.NoError(t, primary.CheckpointSince(&incr2, 2))
+
+	// Both increments are relative to 2, so the second can be applied with or without the first.
+	restored := btrie.NewArrayTrie[byte]()
+	version, err := btrie.RestoreCheckpoint(bytes.NewReader(full.Bytes()), restored, byteCodec{}, 0)
+	require.NoError(t, err)
+	version, err = btrie.RestoreCheckpoint(bytes.NewReader(incr1.Bytes()), restored, byteCodec{}, version)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), version)
+	version, err = btrie.RestoreCheckpoint(bytes.NewReader(incr2.Bytes()), restored, byteCodec{}, version)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(4), version)
+	assertSame(t, map[string]byte{"\x02": 2, "\x03": 3}, restored)
+
+	restored = btrie.NewArrayTrie[byte]()
+	version, err = btrie.RestoreCheckpoint(bytes.NewReader(full.Bytes()), restored, byteCodec{}, 0)
+	require.NoError(t, err)
+	version, err = btrie.RestoreCheckpoint(bytes.NewReader(incr2.Bytes()), restored, byteCodec{}, version)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(4), version)
+	assertSame(t, map[string]byte{"\x02": 2, "\x03": 3}, restored)
+
+	// An increment cannot be applied to a trie before its base, or after its end.
+	_, err = btrie.RestoreCheckpoint(bytes.NewReader(incr1.Bytes()), btrie.NewArrayTrie[byte](), byteCodec{}, 0)
+	require.ErrorIs(t, err, btrie.ErrCheckpointMismatch)
+	_, err = btrie.RestoreCheckpoint(bytes.NewReader(incr1.Bytes()), btrie.NewArrayTrie[byte](), byteCodec{}, 4)
+	require.ErrorIs(t, err, btrie.ErrCheckpointMismatch)
+}
+
+func TestCheckpointSinceMisuse(t *testing.T) {
+	t.Parallel()
+	trie := btrie.WithJournal(btrie.NewArrayTrie[byte](), io.Discard, byteCodec{})
+	trie.Put([]byte{1}, 1)
+	assert.Panics(t, func() { trie.TrackVersions() })
+	assert.Panics(t, func() { _ = trie.CheckpointSince(io.Discard, 1) })
+	assert.Panics(t, func() { _ = trie.CheckpointSince(io.Discard, 2) })
+	require.NoError(t, trie.CheckpointSince(io.Discard, 0))
+
+	failed := btrie.WithJournal(btrie.NewArrayTrie[byte](), &failingWriter{1}, byteCodec{})
+	failed.TrackVersions()
+	failed.Put([]byte{1}, 1)
+	require.ErrorIs(t, failed.CheckpointSince(io.Discard, 0), errWrite)
+}
+
+func TestRestoreCheckpointErrors(t *testing.T) {
+	t.Parallel()
+	trie := btrie.WithJournal(btrie.NewArrayTrie[byte](), io.Discard, byteCodec{})
+	trie.TrackVersions()
+	trie.Put([]byte{1, 2}, 1)
+	trie.Put([]byte{3}, 2)
+	trie.Delete([]byte{3})
+	var checkpoint bytes.Buffer
+	require.NoError(t, trie.CheckpointSince(&checkpoint, 1))
+	data := checkpoint.Bytes()
+
+	for i := range data {
+		_, err := btrie.RestoreCheckpoint(bytes.NewReader(data[:i]), btrie.NewArrayTrie[byte](), byteCodec{}, 1)
+		assert.ErrorIs(t, err, btrie.ErrInvalidFormat, "truncated at %d", i)
+	}
+	version, err := btrie.RestoreCheckpoint(bytes.NewReader(data), btrie.NewArrayTrie[byte](), byteCodec{}, 1)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), version)
+
+	for _, tt := range []struct {
+		index int
+		value byte
+	}{
+		{0, 'X'},  // magic
+		{4, 0x7F}, // version
+		{5, 9},    // base after seq
+		{7, 3},    // op
+	} {
+		bad := bytes.Clone(data)
+		bad[tt.index] = tt.value
+		_, err := btrie.RestoreCheckpoint(bytes.NewReader(bad), btrie.NewArrayTrie[byte](), byteCodec{}, 1)
+		assert.ErrorIs(t, err, btrie.ErrInvalidFormat, "%d", tt.index)
+	}
+}
@@ -0,0 +1,26 @@
+package btrie
+
+import "hash"
+
+// ChecksumRange returns a checksum of the entries of trie within bounds, using codec to encode the values,
+// computed by h in a single traversal. h is reset before it is used.
+// Two tries have the same checksum for the same bounds, hash, and codec if they have the same entries within bounds,
+// barring hash collisions, so replicas can verify that any sub-range matches without sending the entries.
+// Each entry is hashed as the uvarint length of the key, the key, the uvarint length of the encoded value,
+// and the encoded value, in the order they are yielded by Range; the checksum therefore depends on the direction of bounds.
+// If codec returns an error, ChecksumRange returns it, and the returned checksum is meaningless.
+func ChecksumRange[V any](trie ReadOnlyTrie[V], bounds *Bounds, codec Codec[V], h hash.Hash64) (uint64, error) {
+	h.Reset()
+	var buf, valueBuf []byte
+	for key, value := range trie.Range(bounds) {
+		var err error
+		valueBuf, err = codec.AppendValue(valueBuf[:0], value)
+		if err != nil {
+			return 0, err
+		}
+		buf = appendSized(buf[:0], key)
+		buf = appendSized(buf, valueBuf)
+		_, _ = h.Write(buf) // hash.Hash never returns an error
+	}
+	return h.Sum64(), nil
+}
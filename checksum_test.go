@@ -0,0 +1,69 @@
+package btrie_test
+
+import (
+	"hash/fnv"
+	"testing"
+
+	"github.com/phiryll/btrie"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChecksumRange(t *testing.T) {
+	t.Parallel()
+	checksum := func(trie btrie.BTrie[byte], bounds *btrie.Bounds) uint64 {
+		sum, err := btrie.ChecksumRange(trie, bounds, byteCodec{}, fnv.New64a())
+		require.NoError(t, err)
+		return sum
+	}
+	low := btrie.From([]byte{0x10}).To([]byte{0x20})
+	high := btrie.From([]byte{0x20}).To([]byte{0x30})
+	for _, test := range createTestTries(rangeTestConfigs) {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			replica := test.def.factory()
+			for k, v := range test.config.entries {
+				replica.Put([]byte(k), v)
+			}
+			for _, bounds := range []*btrie.Bounds{forwardAll, reverseAll, low, high} {
+				assert.Equal(t, checksum(test.trie, bounds), checksum(replica, bounds), "%s", bounds)
+			}
+
+			// Changing an entry changes the checksum only for bounds containing it.
+			trie := test.trie.Clone()
+			value, _ := trie.Get([]byte{0x10, 0x01})
+			trie.Put([]byte{0x10, 0x01}, value+1)
+			assert.NotEqual(t, checksum(replica, forwardAll), checksum(trie, forwardAll))
+			assert.NotEqual(t, checksum(replica, low), checksum(trie, low))
+			assert.Equal(t, checksum(replica, high), checksum(trie, high))
+		})
+	}
+}
+
+func TestChecksumRangeEmpty(t *testing.T) {
+	t.Parallel()
+	h := fnv.New64a()
+	sum, err := btrie.ChecksumRange(btrie.NewArrayTrie[byte](), forwardAll, byteCodec{}, h)
+	require.NoError(t, err)
+	h.Reset()
+	assert.Equal(t, h.Sum64(), sum)
+
+	// A key moving into the value must change the checksum.
+	a := btrie.NewArrayTrie[[]byte]()
+	a.Put([]byte{1, 2}, []byte{3})
+	b := btrie.NewArrayTrie[[]byte]()
+	b.Put([]byte{1}, []byte{2, 3})
+	sumA, err := btrie.ChecksumRange(a, forwardAll, btrie.BytesCodec{}, h)
+	require.NoError(t, err)
+	sumB, err := btrie.ChecksumRange(b, forwardAll, btrie.BytesCodec{}, h)
+	require.NoError(t, err)
+	assert.NotEqual(t, sumA, sumB)
+}
+
+func TestChecksumRangeError(t *testing.T) {
+	t.Parallel()
+	trie := btrie.NewArrayTrie[[]byte]()
+	trie.Put([]byte{1}, []byte{1})
+	_, err := btrie.ChecksumRange(trie, forwardAll, failingCodec{}, fnv.New64a())
+	require.ErrorIs(t, err, errEncode)
+}
@@ -0,0 +1,112 @@
+// Package cidr provides IP routing tables built on the tries in package btrie.
+//
+// A [Table] maps CIDR prefixes such as 10.0.0.0/8 or 2001:db8::/32 to values,
+// and finds the longest prefix containing an address, as a router does.
+// Prefixes of any length are supported, not only those ending on a byte boundary.
+// IPv4 and IPv6 prefixes are kept separate, and IPv4-mapped IPv6 addresses are not converted;
+// use [netip.Addr.Unmap] if that is needed.
+package cidr
+
+import (
+	"iter"
+	"net/netip"
+
+	"github.com/phiryll/btrie"
+)
+
+// Table is a map from CIDR prefixes to values, supporting longest-prefix matching.
+// A Table is not safe for concurrent use.
+type Table[V any] struct {
+	ipv4, ipv6 *btrie.BitTrie[V]
+}
+
+// New returns a new, empty Table.
+func New[V any]() *Table[V] {
+	return &Table[V]{btrie.NewBitTrie[V](), btrie.NewBitTrie[V]()}
+}
+
+func (t *Table[V]) trieFor(addr netip.Addr) *btrie.BitTrie[V] {
+	if addr.Is4() {
+		return t.ipv4
+	}
+	return t.ipv6
+}
+
+// Returns the trie and key for prefix.
+// The key for a prefix is a prefix of the keys of all the prefixes it contains.
+func (t *Table[V]) lookup(prefix netip.Prefix) (*btrie.BitTrie[V], btrie.BitKey) {
+	if !prefix.IsValid() {
+		panic("invalid prefix")
+	}
+	return t.trieFor(prefix.Addr()), btrie.BitKey{Bytes: prefix.Addr().AsSlice(), Len: prefix.Bits()}
+}
+
+// The inverse of lookup, given whether the key is from the IPv4 trie.
+func keyPrefix(key btrie.BitKey, is4 bool) netip.Prefix {
+	var addrBytes [16]byte
+	copy(addrBytes[:], key.Bytes)
+	var addr netip.Addr
+	if is4 {
+		addr = netip.AddrFrom4([4]byte(addrBytes[:4]))
+	} else {
+		addr = netip.AddrFrom16(addrBytes)
+	}
+	return netip.PrefixFrom(addr, key.Len)
+}
+
+// InsertCIDR sets the value for prefix, returning the previous value and whether or not it existed.
+// Any host bits in prefix are ignored, so 10.1.2.3/8 is the same as 10.0.0.0/8.
+// InsertCIDR will panic if prefix is not valid.
+func (t *Table[V]) InsertCIDR(prefix netip.Prefix, value V) (V, bool) {
+	trie, key := t.lookup(prefix)
+	return trie.Put(key, value)
+}
+
+// GetCIDR returns the value for exactly prefix, and whether or not it exists.
+// GetCIDR will panic if prefix is not valid.
+func (t *Table[V]) GetCIDR(prefix netip.Prefix) (V, bool) {
+	trie, key := t.lookup(prefix)
+	return trie.Get(key)
+}
+
+// DeleteCIDR removes the value for prefix, returning the previous value and whether or not it existed.
+// DeleteCIDR will panic if prefix is not valid.
+func (t *Table[V]) DeleteCIDR(prefix netip.Prefix) (V, bool) {
+	trie, key := t.lookup(prefix)
+	return trie.Delete(key)
+}
+
+// LookupIP returns the longest prefix in this Table containing addr, its value, and whether one exists.
+// Any zone in addr is ignored.
+// LookupIP will panic if addr is not valid.
+func (t *Table[V]) LookupIP(addr netip.Addr) (netip.Prefix, V, bool) {
+	if !addr.IsValid() {
+		panic("invalid address")
+	}
+	var longest netip.Prefix
+	var value V
+	var ok bool
+	key := btrie.BitKey{Bytes: addr.AsSlice(), Len: addr.BitLen()}
+	for prefix, v := range t.trieFor(addr).PrefixesOf(key) {
+		longest, value, ok = keyPrefix(prefix, addr.Is4()), v, true
+	}
+	return longest, value, ok
+}
+
+// Subnets returns a sequence of the prefixes in this Table contained by prefix, including prefix itself,
+// and their values. Prefixes are yielded in pre-order: each prefix is followed by the prefixes it contains,
+// and the two halves of a prefix are in address order.
+// This Table must not be mutated while the sequence is in use.
+// Subnets will panic if prefix is not valid.
+func (t *Table[V]) Subnets(prefix netip.Prefix) iter.Seq2[netip.Prefix, V] {
+	trie, key := t.lookup(prefix)
+	is4 := prefix.Addr().Is4()
+	itr := trie.WithPrefix(key)
+	return func(yield func(netip.Prefix, V) bool) {
+		for k, v := range itr {
+			if !yield(keyPrefix(k, is4), v) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package cidr_test
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/phiryll/btrie/cidr"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestTable() *cidr.Table[string] {
+	table := cidr.New[string]()
+	for _, s := range []string{
+		"0.0.0.0/0",
+		"10.0.0.0/8",
+		"10.1.0.0/16",
+		"10.1.16.0/20",
+		"10.1.20.0/22",
+		"10.1.20.7/32",
+		"192.168.0.0/24",
+		"2001:db8::/32",
+		"2001:db8:1::/48",
+	} {
+		table.InsertCIDR(netip.MustParsePrefix(s), s)
+	}
+	return table
+}
+
+func TestInsertGetDelete(t *testing.T) {
+	t.Parallel()
+	table := newTestTable()
+	value, ok := table.GetCIDR(netip.MustParsePrefix("10.1.16.0/20"))
+	assert.True(t, ok)
+	assert.Equal(t, "10.1.16.0/20", value)
+	_, ok = table.GetCIDR(netip.MustParsePrefix("10.1.16.0/21"))
+	assert.False(t, ok)
+	_, ok = table.GetCIDR(netip.MustParsePrefix("::/0"))
+	assert.False(t, ok)
+
+	// host bits are ignored
+	prev, ok := table.InsertCIDR(netip.MustParsePrefix("10.9.9.9/8"), "ten")
+	assert.True(t, ok)
+	assert.Equal(t, "10.0.0.0/8", prev)
+	value, ok = table.GetCIDR(netip.MustParsePrefix("10.0.0.0/8"))
+	assert.True(t, ok)
+	assert.Equal(t, "ten", value)
+
+	prev, ok = table.DeleteCIDR(netip.MustParsePrefix("10.0.0.0/8"))
+	assert.True(t, ok)
+	assert.Equal(t, "ten", prev)
+	_, ok = table.DeleteCIDR(netip.MustParsePrefix("10.0.0.0/8"))
+	assert.False(t, ok)
+
+	assert.Panics(t, func() { table.InsertCIDR(netip.Prefix{}, "") })
+	assert.Panics(t, func() { table.GetCIDR(netip.Prefix{}) })
+	assert.Panics(t, func() { table.DeleteCIDR(netip.Prefix{}) })
+	assert.Panics(t, func() { table.LookupIP(netip.Addr{}) })
+	assert.Panics(t, func() { table.Subnets(netip.Prefix{}) })
+}
+
+func TestLookupIP(t *testing.T) {
+	t.Parallel()
+	table := newTestTable()
+	for _, test := range []struct {
+		addr, expected string
+	}{
+		{"10.1.20.7", "10.1.20.7/32"},
+		{"10.1.20.8", "10.1.20.0/22"},
+		{"10.1.23.255", "10.1.20.0/22"},
+		{"10.1.24.0", "10.1.16.0/20"},
+		{"10.1.32.0", "10.1.0.0/16"},
+		{"10.2.0.0", "10.0.0.0/8"},
+		{"11.0.0.0", "0.0.0.0/0"},
+		{"192.168.0.200", "192.168.0.0/24"},
+		{"2001:db8:1:2::3", "2001:db8:1::/48"},
+		{"2001:db8:2::", "2001:db8::/32"},
+		{"fe80::1%eth0", ""},
+		{"::ffff:10.1.20.7", ""}, // IPv4-mapped addresses are not unmapped
+	} {
+		prefix, value, ok := table.LookupIP(netip.MustParseAddr(test.addr))
+		if test.expected == "" {
+			assert.False(t, ok, test.addr)
+			continue
+		}
+		if assert.True(t, ok, test.addr) {
+			assert.Equal(t, netip.MustParsePrefix(test.expected), prefix, test.addr)
+			assert.Equal(t, test.expected, value, test.addr)
+		}
+	}
+	table.InsertCIDR(netip.MustParsePrefix("fe80::/10"), "link-local")
+	prefix, _, ok := table.LookupIP(netip.MustParseAddr("fe80::1%eth0"))
+	assert.True(t, ok)
+	assert.Equal(t, netip.MustParsePrefix("fe80::/10"), prefix)
+}
+
+func TestSubnets(t *testing.T) {
+	t.Parallel()
+	table := newTestTable()
+	collect := func(s string) []string {
+		result := []string{}
+		for prefix, value := range table.Subnets(netip.MustParsePrefix(s)) {
+			assert.Equal(t, value, prefix.String())
+			result = append(result, value)
+		}
+		return result
+	}
+	assert.Equal(t, []string{"10.1.0.0/16", "10.1.16.0/20", "10.1.20.0/22", "10.1.20.7/32"}, collect("10.1.0.0/16"))
+	assert.Equal(t, []string{"10.1.16.0/20", "10.1.20.0/22", "10.1.20.7/32"}, collect("10.1.0.0/18"))
+	assert.Equal(t, []string{
+		"0.0.0.0/0", "10.0.0.0/8", "10.1.0.0/16", "10.1.16.0/20", "10.1.20.0/22", "10.1.20.7/32", "192.168.0.0/24",
+	}, collect("0.0.0.0/0"))
+	assert.Equal(t, []string{"2001:db8::/32", "2001:db8:1::/48"}, collect("::/0"))
+	assert.Equal(t, []string{}, collect("172.16.0.0/12"))
+
+	// need an early yield for test coverage
+	for range table.Subnets(netip.MustParsePrefix("0.0.0.0/0")) {
+		break
+	}
+}
@@ -0,0 +1,382 @@
+// Command btriebench runs configurable workloads against the BTrie implementations in package btrie,
+// so they can be compared without writing Go benchmarks.
+// Every combination of implementation, trie size, key distribution, and operation mix is benchmarked
+// with [testing.Benchmark], and the results are written as benchstat-compatible lines or as CSV.
+// For example:
+//
+//	btriebench -impls=array-trie,hat-trie -sizes=4096,65536 -keys=random,prefixed -mix=get=90,put=10 -count=5
+//
+// Each benchmark first fills a new trie with size keys from the key distribution,
+// then repeatedly performs a fixed, seeded sequence of operations drawn from the mix.
+// Half of the keys used by the operations are already in the trie, and half are new keys from the distribution.
+// A range operation yields up to 100 entries beginning at its key.
+// Run btriebench -h for the full list of flags.
+package main
+
+import (
+	"encoding/binary"
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"math/rand"
+	"os"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/phiryll/btrie"
+)
+
+// The registered implementations, by name.
+var implementations = map[string]func() btrie.BTrie[byte]{
+	"array-trie":   btrie.NewArrayTrie[byte],
+	"pointer-trie": btrie.NewPointerTrie[byte],
+	"hat-trie":     btrie.NewHATTrie[byte],
+	"hybrid-trie":  func() btrie.BTrie[byte] { return btrie.NewHybridTrie[byte]() },
+	"map-trie":     func() btrie.BTrie[byte] { return btrie.NewMapTrie[byte]() },
+	"weighted-trie": func() btrie.BTrie[byte] {
+		return btrie.NewWeightedTrie(func(v byte) float64 { return float64(v) })
+	},
+	"synchronized": func() btrie.BTrie[byte] { return btrie.NewSynchronized(btrie.NewArrayTrie[byte]()) },
+}
+
+// A key distribution, returning a new key for the ith key put into a trie.
+// Keys need not be distinct.
+type keyDist func(i int, random *rand.Rand) []byte
+
+// The number of common prefixes used by the prefixed key distribution.
+const numPrefixes = 16
+
+func keyDists(maxKeyLen int) map[string]keyDist {
+	randomBytes := func(random *rand.Rand) []byte {
+		key := make([]byte, 1+random.Intn(maxKeyLen))
+		random.Read(key)
+		return key
+	}
+	prefixes := make([][]byte, numPrefixes)
+	prefixRandom := rand.New(rand.NewSource(1))
+	for i := range prefixes {
+		prefixes[i] = randomBytes(prefixRandom)
+	}
+	return map[string]keyDist{
+		// Keys of random bytes, with random lengths from 1 to maxKeyLen.
+		"random": func(_ int, random *rand.Rand) []byte {
+			return randomBytes(random)
+		},
+		// Big-endian 8-byte integers, put into the trie in increasing order.
+		"sequential": func(i int, _ *rand.Rand) []byte {
+			return binary.BigEndian.AppendUint64(nil, uint64(i)) //nolint:gosec
+		},
+		// Random keys after one of a few common prefixes, like file paths or domain names.
+		"prefixed": func(_ int, random *rand.Rand) []byte {
+			return append(slices.Clone(prefixes[random.Intn(numPrefixes)]), randomBytes(random)...)
+		},
+	}
+}
+
+type opKind int
+
+const (
+	opGet opKind = iota
+	opPut
+	opDelete
+	opRange
+	numOpKinds
+)
+
+var opNames = [numOpKinds]string{"get", "put", "delete", "range"}
+
+// The relative weights of each kind of operation.
+type opMix [numOpKinds]int
+
+// Parses a mix like "get=90,put=10".
+func parseMix(s string) (opMix, error) {
+	var mix opMix
+	total := 0
+	for _, part := range strings.Split(s, ",") {
+		name, weightStr, found := strings.Cut(part, "=")
+		if !found {
+			return mix, fmt.Errorf("mix %q: missing weight for %q", s, part)
+		}
+		kind := slices.Index(opNames[:], name)
+		if kind < 0 {
+			return mix, fmt.Errorf("mix %q: unknown operation %q", s, name)
+		}
+		weight, err := strconv.Atoi(weightStr)
+		if err != nil || weight < 0 {
+			return mix, fmt.Errorf("mix %q: invalid weight %q", s, weightStr)
+		}
+		mix[kind] += weight
+		total += weight
+	}
+	if total == 0 {
+		return mix, fmt.Errorf("mix %q: weights must not all be zero", s)
+	}
+	return mix, nil
+}
+
+// Returns a name for mix usable in a benchmark name, like "get90-put10".
+func (m opMix) String() string {
+	var parts []string
+	for kind, weight := range m {
+		if weight > 0 {
+			parts = append(parts, fmt.Sprintf("%s%d", opNames[kind], weight))
+		}
+	}
+	return strings.Join(parts, "-")
+}
+
+// Returns a random kind of operation, chosen according to the weights of m.
+func (m opMix) choose(random *rand.Rand) opKind {
+	total := 0
+	for _, weight := range m {
+		total += weight
+	}
+	n := random.Intn(total)
+	for kind, weight := range m {
+		if n < weight {
+			return opKind(kind)
+		}
+		n -= weight
+	}
+	panic("unreachable")
+}
+
+type op struct {
+	kind opKind
+	key  []byte
+}
+
+// The number of operations in a workload, which are repeated as many times as needed.
+const numOps = 1 << 16
+
+// The maximum number of entries read by a range operation.
+const rangeLen = 100
+
+// A workload is the keys initially put into a trie, and the operations performed on it afterward.
+type workload struct {
+	keys [][]byte
+	ops  []op
+}
+
+func newWorkload(size int, dist keyDist, mix opMix, seed int64) *workload {
+	random := rand.New(rand.NewSource(seed))
+	keys := make([][]byte, size)
+	for i := range keys {
+		keys[i] = dist(i, random)
+	}
+	ops := make([]op, numOps)
+	for i := range ops {
+		key := dist(size+i, random)
+		if size > 0 && random.Intn(2) == 0 {
+			key = keys[random.Intn(size)]
+		}
+		ops[i] = op{mix.choose(random), key}
+	}
+	return &workload{keys, ops}
+}
+
+func (w *workload) run(b *testing.B, factory func() btrie.BTrie[byte]) {
+	trie := factory()
+	for i, key := range w.keys {
+		trie.Put(key, byte(i))
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := range b.N {
+		o := w.ops[i%len(w.ops)]
+		switch o.kind {
+		case opGet:
+			trie.Get(o.key)
+		case opPut:
+			trie.Put(o.key, byte(i))
+		case opDelete:
+			trie.Delete(o.key)
+		case opRange:
+			n := 0
+			for range trie.Range(btrie.From(o.key).To(nil)) {
+				n++
+				if n == rangeLen {
+					break
+				}
+			}
+		}
+	}
+}
+
+type result struct {
+	impl, keys string
+	size       int
+	mix        opMix
+	testing.BenchmarkResult
+}
+
+// Writes a header, then results as they are produced.
+type resultWriter interface {
+	header() error
+	write(r *result) error
+	flush() error
+}
+
+// Writes lines in the format of go test -bench, which benchstat can read.
+type benchWriter struct {
+	w io.Writer
+}
+
+func (bw *benchWriter) header() error {
+	_, err := fmt.Fprintf(bw.w, "goos: %s\ngoarch: %s\npkg: github.com/phiryll/btrie\n", runtime.GOOS, runtime.GOARCH)
+	return err
+}
+
+func (bw *benchWriter) write(r *result) error {
+	_, err := fmt.Fprintf(bw.w, "BenchmarkWorkload/impl=%s/size=%d/keys=%s/mix=%s\t%s\t%s\n",
+		r.impl, r.size, r.keys, r.mix, r.BenchmarkResult.String(), r.MemString())
+	return err
+}
+
+func (*benchWriter) flush() error {
+	return nil
+}
+
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func (cw *csvWriter) header() error {
+	return cw.w.Write([]string{"impl", "size", "keys", "mix", "n", "ns_per_op", "bytes_per_op", "allocs_per_op"})
+}
+
+func (cw *csvWriter) write(r *result) error {
+	return cw.w.Write([]string{
+		r.impl,
+		strconv.Itoa(r.size),
+		r.keys,
+		r.mix.String(),
+		strconv.Itoa(r.N),
+		strconv.FormatInt(r.NsPerOp(), 10),
+		strconv.FormatInt(r.AllocedBytesPerOp(), 10),
+		strconv.FormatInt(r.AllocsPerOp(), 10),
+	})
+}
+
+func (cw *csvWriter) flush() error {
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+// Splits a comma-separated flag value, returning the names which are not in valid as an error.
+func splitNames[T any](flagName, s string, valid map[string]T) ([]string, error) {
+	names := strings.Split(s, ",")
+	for _, name := range names {
+		if _, ok := valid[name]; !ok {
+			known := slices.Sorted(maps.Keys(valid))
+			return nil, fmt.Errorf("-%s: unknown name %q, must be one of %s", flagName, name, strings.Join(known, ","))
+		}
+	}
+	return names, nil
+}
+
+func run(args []string, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("btriebench", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	allImpls := slices.Sorted(maps.Keys(implementations))
+	implsFlag := flags.String("impls", strings.Join(allImpls, ","), "comma-separated implementations to benchmark")
+	sizesFlag := flags.String("sizes", "4096,65536", "comma-separated numbers of keys initially in each trie")
+	keysFlag := flags.String("keys", "random", "comma-separated key distributions: random, sequential, prefixed")
+	maxKeyLen := flags.Int("keylen", 8, "maximum length of random keys and suffixes")
+	var mixes []opMix
+	flags.Func("mix", "an operation mix like get=90,put=10 of get, put, delete, and range; may be repeated",
+		func(s string) error {
+			mix, err := parseMix(s)
+			mixes = append(mixes, mix)
+			return err
+		})
+	count := flags.Int("count", 1, "number of times to run each benchmark")
+	seed := flags.Int64("seed", 6133, "seed for generating workloads")
+	format := flags.String("format", "bench", "output format: bench or csv")
+	benchtime := flags.String("benchtime", "1s", "run time or iterations (like 1000x) of each benchmark")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments: %s", strings.Join(flags.Args(), " "))
+	}
+	if len(mixes) == 0 {
+		mixes = append(mixes, opMix{opGet: 100})
+	}
+	if *maxKeyLen < 1 {
+		return errors.New("-keylen must be positive")
+	}
+	if *count < 1 {
+		return errors.New("-count must be positive")
+	}
+	impls, err := splitNames("impls", *implsFlag, implementations)
+	if err != nil {
+		return err
+	}
+	dists := keyDists(*maxKeyLen)
+	distNames, err := splitNames("keys", *keysFlag, dists)
+	if err != nil {
+		return err
+	}
+	var sizes []int
+	for _, s := range strings.Split(*sizesFlag, ",") {
+		size, err := strconv.Atoi(s)
+		if err != nil || size < 0 {
+			return fmt.Errorf("-sizes: invalid size %q", s)
+		}
+		sizes = append(sizes, size)
+	}
+	// testing.Benchmark reads the run time of each benchmark from the testing package's flags.
+	testing.Init()
+	if err := flag.Set("test.benchtime", *benchtime); err != nil {
+		return fmt.Errorf("-benchtime: %w", err)
+	}
+
+	var out resultWriter
+	switch *format {
+	case "bench":
+		out = &benchWriter{stdout}
+	case "csv":
+		out = &csvWriter{csv.NewWriter(stdout)}
+	default:
+		return fmt.Errorf("-format: unknown format %q", *format)
+	}
+	if err := out.header(); err != nil {
+		return err
+	}
+	for _, size := range sizes {
+		for _, distName := range distNames {
+			for _, mix := range mixes {
+				w := newWorkload(size, dists[distName], mix, *seed)
+				for _, impl := range impls {
+					for range *count {
+						r := result{impl, distName, size, mix, testing.Benchmark(func(b *testing.B) {
+							w.run(b, implementations[impl])
+						})}
+						if err := out.write(&r); err != nil {
+							return err
+						}
+					}
+				}
+			}
+		}
+	}
+	return out.flush()
+}
+
+func main() {
+	err := run(os.Args[1:], os.Stdout, os.Stderr)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "btriebench:", err)
+		os.Exit(2)
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+//nolint:paralleltest // run sets the testing package's benchtime flag
+func TestRun(t *testing.T) {
+	var out bytes.Buffer
+	err := run([]string{
+		"-impls=array-trie,map-trie", "-sizes=0,100", "-keys=random,sequential,prefixed",
+		"-mix=get=2,put=1,delete=1", "-mix=range=1", "-benchtime=10x", "-format=csv",
+	}, &out, io.Discard)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	assert.Equal(t, "impl,size,keys,mix,n,ns_per_op,bytes_per_op,allocs_per_op", lines[0])
+	// 2 impls * 2 sizes * 3 key distributions * 2 mixes
+	assert.Len(t, lines, 1+24)
+	assert.True(t, strings.HasPrefix(lines[1], "array-trie,0,random,get2-put1-delete1,10,"), lines[1])
+
+	out.Reset()
+	err = run([]string{"-impls=hat-trie", "-sizes=10", "-benchtime=10x", "-count=2"}, &out, io.Discard)
+	require.NoError(t, err)
+	lines = strings.Split(strings.TrimSpace(out.String()), "\n")
+	assert.Len(t, lines, 3+2)
+	for _, line := range lines[3:] {
+		assert.True(t, strings.HasPrefix(line, "BenchmarkWorkload/impl=hat-trie/size=10/keys=random/mix=get100\t"), line)
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	t.Parallel()
+	for _, args := range [][]string{
+		{"-impls=nope"},
+		{"-keys=nope"},
+		{"-sizes=-1"},
+		{"-sizes=x"},
+		{"-keylen=0"},
+		{"-count=0"},
+		{"-format=xml"},
+		{"-mix=get"},
+		{"-mix=fly=1"},
+		{"-mix=get=-1"},
+		{"-mix=get=0,put=0"},
+		{"extra"},
+	} {
+		assert.Error(t, run(args, io.Discard, io.Discard), "%v", args)
+	}
+}
@@ -0,0 +1,23 @@
+package btrie
+
+// A Codec converts values to and from bytes, and is used wherever values must be serialized.
+type Codec[V any] interface {
+	// AppendValue appends the encoded form of value to buf and returns the extended buffer.
+	AppendValue(buf []byte, value V) ([]byte, error)
+
+	// DecodeValue returns the value encoded in data.
+	// Implementations must not retain data.
+	DecodeValue(data []byte) (V, error)
+}
+
+// BytesCodec is a Codec for []byte values, which are encoded as themselves.
+// A nil value is decoded as an empty slice.
+type BytesCodec struct{}
+
+func (BytesCodec) AppendValue(buf, value []byte) ([]byte, error) {
+	return append(buf, value...), nil
+}
+
+func (BytesCodec) DecodeValue(data []byte) ([]byte, error) {
+	return append([]byte{}, data...), nil
+}
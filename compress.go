@@ -0,0 +1,187 @@
+package btrie
+
+import (
+	"bufio"
+	"compress/flate"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// The serialized form of a BTrie written by EncodeCompressed is:
+//
+//	magic       [4]byte  "BTRZ"
+//	version     uvarint  currently 1
+//	compression byte     a Compression
+//	body                 compressed as given by compression
+//
+// where the body is zero or more entries in increasing key order, followed by an end uvarint 0.
+// Each key is prefix-delta encoded, as the length of the prefix it shares with the previous key
+// followed by the rest of the key, so an entry is:
+//
+//	suffixSize uvarint  len(suffix) + 1, so that it cannot be 0
+//	shared     uvarint  length of the prefix shared with the previous key, 0 for the first key
+//	suffix     [suffixSize-1]byte
+//	valueSize  uvarint
+//	value      [valueSize]byte  as encoded by the Codec
+const (
+	compressedMagic   = "BTRZ"
+	compressedVersion = 1
+)
+
+// Compression is the compression algorithm used by [EncodeCompressed].
+// Only algorithms in the standard library are supported.
+type Compression byte
+
+const (
+	// NoCompression only prefix-delta encodes the keys.
+	NoCompression Compression = iota
+
+	// FlateCompression compresses using [compress/flate] at its default level.
+	FlateCompression
+)
+
+// EncodeCompressed writes the entries of trie to w like [Encode], using codec to encode the values,
+// but with each key prefix-delta encoded against the previous key, and then compressed as given by compression.
+// For keys sharing long prefixes, such as URLs or paths, this is much smaller than the output of Encode.
+// The written data can be read by [DecodeCompressed].
+// EncodeCompressed will panic if compression is unknown.
+func EncodeCompressed[V any](w io.Writer, trie BTrie[V], codec Codec[V], compression Compression) error {
+	var body io.Writer
+	var finish func() error
+	switch compression {
+	case NoCompression:
+		bw := bufio.NewWriter(w)
+		body, finish = bw, bw.Flush
+	case FlateCompression:
+		// The error is only non-nil for an invalid level.
+		fw, _ := flate.NewWriter(w, flate.DefaultCompression)
+		body, finish = fw, fw.Close
+	default:
+		panic(fmt.Sprintf("unknown compression %d", compression))
+	}
+	buf := []byte(compressedMagic)
+	buf = binary.AppendUvarint(buf, compressedVersion)
+	buf = append(buf, byte(compression))
+	if _, err := w.Write(buf); err != nil {
+		return err
+	}
+	var prev, valueBuf []byte
+	for key, value := range All(trie) {
+		var err error
+		valueBuf, err = codec.AppendValue(valueBuf[:0], value)
+		if err != nil {
+			return err
+		}
+		shared := CommonPrefixLen(prev, key)
+		buf = binary.AppendUvarint(buf[:0], uint64(len(key)-shared)+1)
+		buf = binary.AppendUvarint(buf, uint64(shared))
+		buf = append(buf, key[shared:]...)
+		buf = binary.AppendUvarint(buf, uint64(len(valueBuf)))
+		buf = append(buf, valueBuf...)
+		if _, err := body.Write(buf); err != nil {
+			return err
+		}
+		prev = key
+	}
+	if _, err := body.Write([]byte{0}); err != nil {
+		return err
+	}
+	return finish()
+}
+
+// DecodeCompressed reads entries written by [EncodeCompressed] from r, using codec to decode the values,
+// and puts them into trie.
+// Entries read before an error is encountered will have been put into trie.
+// If r does not implement [io.ByteReader], DecodeCompressed may read past the end of the encoded data.
+func DecodeCompressed[V any](r io.Reader, trie BTrie[V], codec Codec[V]) error {
+	br, ok := r.(byteReader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+	magic := make([]byte, len(compressedMagic))
+	if _, err := io.ReadFull(br, magic); err != nil {
+		return formatError(err)
+	}
+	if string(magic) != compressedMagic {
+		return fmt.Errorf("%w: bad magic %q", ErrInvalidFormat, magic)
+	}
+	version, err := binary.ReadUvarint(br)
+	if err != nil {
+		return formatError(err)
+	}
+	if version != compressedVersion {
+		return fmt.Errorf("%w: unsupported version %d", ErrInvalidFormat, version)
+	}
+	compression, err := br.ReadByte()
+	if err != nil {
+		return formatError(err)
+	}
+	var body byteReader
+	switch Compression(compression) {
+	case NoCompression:
+		body = br
+	case FlateCompression:
+		fr := flate.NewReader(br)
+		defer fr.Close()
+		body = bufio.NewReader(fr)
+	default:
+		return fmt.Errorf("%w: unknown compression %d", ErrInvalidFormat, compression)
+	}
+	err = decodeDeltas(body, trie, codec)
+	if err == nil && body != br {
+		// The compressed stream must end with the body, or it may have been truncated.
+		if _, err = body.ReadByte(); err == nil {
+			return fmt.Errorf("%w: data after end of entries", ErrInvalidFormat)
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		err = formatError(err)
+	}
+	var corrupt flate.CorruptInputError
+	if errors.As(err, &corrupt) {
+		return fmt.Errorf("%w: %w", ErrInvalidFormat, err)
+	}
+	return err
+}
+
+func decodeDeltas[V any](br byteReader, trie BTrie[V], codec Codec[V]) error {
+	var key, buf []byte
+	for {
+		suffixSize, err := binary.ReadUvarint(br)
+		if err != nil {
+			return formatError(err)
+		}
+		if suffixSize == 0 {
+			return nil
+		}
+		shared, err := binary.ReadUvarint(br)
+		if err != nil {
+			return formatError(err)
+		}
+		if shared > uint64(len(key)) {
+			return fmt.Errorf("%w: shared prefix length %d exceeds previous key length %d",
+				ErrInvalidFormat, shared, len(key))
+		}
+		// A new slice, in case trie retains its keys.
+		key, err = readBytes(br, append([]byte{}, key[:shared]...), suffixSize-1)
+		if err != nil {
+			return err
+		}
+		valueSize, err := binary.ReadUvarint(br)
+		if err != nil {
+			return formatError(err)
+		}
+		buf, err = readBytes(br, buf[:0], valueSize)
+		if err != nil {
+			return err
+		}
+		value, err := codec.DecodeValue(buf)
+		if err != nil {
+			return err
+		}
+		trie.Put(key, value)
+	}
+}
@@ -0,0 +1,90 @@
+package btrie_test
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/phiryll/btrie"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var compressions = []btrie.Compression{btrie.NoCompression, btrie.FlateCompression}
+
+func TestEncodeDecodeCompressed(t *testing.T) {
+	t.Parallel()
+	for _, test := range createTestTries(rangeTestConfigs) {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			for _, compression := range compressions {
+				var buf bytes.Buffer
+				require.NoError(t, btrie.EncodeCompressed(&buf, test.trie, byteCodec{}, compression))
+				trie := test.def.factory()
+				require.NoError(t, btrie.DecodeCompressed(&buf, trie, byteCodec{}))
+				assertSame(t, test.config.entries, trie)
+				assert.Zero(t, buf.Len())
+			}
+		})
+	}
+	assert.Panics(t, func() {
+		_ = btrie.EncodeCompressed(&bytes.Buffer{}, btrie.NewArrayTrie[byte](), byteCodec{}, 99)
+	})
+}
+
+func TestEncodeCompressedSize(t *testing.T) {
+	t.Parallel()
+	trie := btrie.NewArrayTrie[byte]()
+	for i := range 1000 {
+		trie.Put(fmt.Appendf(nil, "https://example.com/users/%d/profile", i*7), byte(i))
+	}
+	var plain, delta, flated bytes.Buffer
+	require.NoError(t, btrie.Encode(&plain, trie, byteCodec{}))
+	require.NoError(t, btrie.EncodeCompressed(&delta, trie, byteCodec{}, btrie.NoCompression))
+	require.NoError(t, btrie.EncodeCompressed(&flated, trie, byteCodec{}, btrie.FlateCompression))
+	assert.Less(t, delta.Len()*2, plain.Len())
+	assert.Less(t, flated.Len()*5, plain.Len())
+}
+
+func TestDecodeCompressedErrors(t *testing.T) {
+	t.Parallel()
+	trie := btrie.NewArrayTrie[byte]()
+	trie.Put([]byte{}, 1)
+	trie.Put([]byte{0x23, 0x45}, 2)
+	trie.Put([]byte{0x23, 0x46}, 3)
+	for _, compression := range compressions {
+		var buf bytes.Buffer
+		require.NoError(t, btrie.EncodeCompressed(&buf, trie, byteCodec{}, compression))
+		data := buf.Bytes()
+
+		// Every truncation is an error.
+		for i := range data {
+			err := btrie.DecodeCompressed(bytes.NewReader(data[:i]), btrie.NewArrayTrie[byte](), byteCodec{})
+			assert.ErrorIs(t, err, btrie.ErrInvalidFormat, "%d truncated at %d", compression, i)
+		}
+
+		badMagic := bytes.Clone(data)
+		badMagic[0] = 'X'
+		assert.ErrorIs(t, btrie.DecodeCompressed(bytes.NewReader(badMagic), btrie.NewArrayTrie[byte](), byteCodec{}),
+			btrie.ErrInvalidFormat)
+
+		badVersion := bytes.Clone(data)
+		badVersion[4] = 0x7F
+		assert.ErrorIs(t, btrie.DecodeCompressed(bytes.NewReader(badVersion), btrie.NewArrayTrie[byte](), byteCodec{}),
+			btrie.ErrInvalidFormat)
+
+		badCompression := bytes.Clone(data)
+		badCompression[5] = 0x7F
+		assert.ErrorIs(t,
+			btrie.DecodeCompressed(bytes.NewReader(badCompression), btrie.NewArrayTrie[byte](), byteCodec{}),
+			btrie.ErrInvalidFormat)
+	}
+
+	// The second key claims to share 3 bytes with the 2 byte first key.
+	badShared := []byte("BTRZ\x01\x00" + "\x03\x00\x23\x45\x01\x02" + "\x02\x03\x46\x01\x03" + "\x00")
+	assert.ErrorIs(t, btrie.DecodeCompressed(bytes.NewReader(badShared), btrie.NewArrayTrie[byte](), byteCodec{}),
+		btrie.ErrInvalidFormat)
+	corrupt := []byte("BTRZ\x01\x01\xFF\xFF\xFF\xFF")
+	assert.ErrorIs(t, btrie.DecodeCompressed(bytes.NewReader(corrupt), btrie.NewArrayTrie[byte](), byteCodec{}),
+		btrie.ErrInvalidFormat)
+}
@@ -0,0 +1,189 @@
+package btrie
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"iter"
+)
+
+// Delta is the set of changes which transforms one trie into another.
+type Delta[V any] struct {
+	// Puts are the entries which were added or whose values changed, in increasing key order.
+	Puts []Entry[V]
+
+	// Deletes are the keys which were removed, in increasing key order.
+	Deletes [][]byte
+}
+
+// ComputeDelta returns the Delta which transforms oldTrie into newTrie, using eq to compare values.
+// Neither trie may be mutated while ComputeDelta is running.
+func ComputeDelta[V any](oldTrie, newTrie BTrie[V], eq Equaler[V]) *Delta[V] {
+	var delta Delta[V]
+	nextOld, stopOld := iter.Pull2(All(oldTrie))
+	defer stopOld()
+	nextNew, stopNew := iter.Pull2(All(newTrie))
+	defer stopNew()
+	oldKey, oldValue, oldOk := nextOld()
+	newKey, newValue, newOk := nextNew()
+	for oldOk || newOk {
+		cmp := 0
+		switch {
+		case !oldOk:
+			cmp = +1
+		case !newOk:
+			cmp = -1
+		default:
+			cmp = bytes.Compare(oldKey, newKey)
+		}
+		switch {
+		case cmp < 0:
+			delta.Deletes = append(delta.Deletes, oldKey)
+			oldKey, oldValue, oldOk = nextOld()
+		case cmp > 0:
+			delta.Puts = append(delta.Puts, Entry[V]{newKey, newValue})
+			newKey, newValue, newOk = nextNew()
+		default:
+			if !eq.Equal(oldValue, newValue) {
+				delta.Puts = append(delta.Puts, Entry[V]{newKey, newValue})
+			}
+			oldKey, oldValue, oldOk = nextOld()
+			newKey, newValue, newOk = nextNew()
+		}
+	}
+	return &delta
+}
+
+// ApplyDelta applies delta to trie.
+func ApplyDelta[V any](trie BTrie[V], delta *Delta[V]) {
+	for _, key := range delta.Deletes {
+		trie.Delete(key)
+	}
+	for _, entry := range delta.Puts {
+		trie.Put(entry.Key, entry.Value)
+	}
+}
+
+// The serialized form of a Delta written by EncodeDelta is:
+//
+//	magic      [4]byte  "BTRD"
+//	version    uvarint  currently 1
+//	numPuts    uvarint
+//	puts       [numPuts](keySize uvarint, key, valueSize uvarint, value)
+//	numDeletes uvarint
+//	deletes    [numDeletes](keySize uvarint, key)
+const (
+	deltaMagic   = "BTRD"
+	deltaVersion = 1
+)
+
+// EncodeDelta writes delta to w in a compact binary form, using codec to encode the values.
+// The written data can be read by [DecodeDelta].
+func EncodeDelta[V any](w io.Writer, delta *Delta[V], codec Codec[V]) error {
+	bw := bufio.NewWriter(w)
+	buf := binary.AppendUvarint([]byte(deltaMagic), deltaVersion)
+	buf = binary.AppendUvarint(buf, uint64(len(delta.Puts)))
+	var valueBuf []byte
+	for _, entry := range delta.Puts {
+		var err error
+		valueBuf, err = codec.AppendValue(valueBuf[:0], entry.Value)
+		if err != nil {
+			return err
+		}
+		buf = appendSized(buf, entry.Key)
+		buf = appendSized(buf, valueBuf)
+		if _, err := bw.Write(buf); err != nil {
+			return err
+		}
+		buf = buf[:0]
+	}
+	buf = binary.AppendUvarint(buf, uint64(len(delta.Deletes)))
+	for _, key := range delta.Deletes {
+		buf = appendSized(buf, key)
+		if _, err := bw.Write(buf); err != nil {
+			return err
+		}
+		buf = buf[:0]
+	}
+	if _, err := bw.Write(buf); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// DecodeDelta reads a Delta written by [EncodeDelta] from r, using codec to decode the values.
+// If r does not implement [io.ByteReader], DecodeDelta may read past the end of the encoded data.
+func DecodeDelta[V any](r io.Reader, codec Codec[V]) (*Delta[V], error) {
+	br, ok := r.(byteReader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+	magic := make([]byte, len(deltaMagic))
+	if _, err := io.ReadFull(br, magic); err != nil {
+		return nil, formatError(err)
+	}
+	if string(magic) != deltaMagic {
+		return nil, fmt.Errorf("%w: bad magic %q", ErrInvalidFormat, magic)
+	}
+	version, err := binary.ReadUvarint(br)
+	if err != nil {
+		return nil, formatError(err)
+	}
+	if version != deltaVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidFormat, version)
+	}
+	var delta Delta[V]
+	numPuts, err := binary.ReadUvarint(br)
+	if err != nil {
+		return nil, formatError(err)
+	}
+	var buf []byte
+	for range numPuts {
+		key, err := readSized(br, nil)
+		if err != nil {
+			return nil, err
+		}
+		buf, err = readSized(br, buf[:0])
+		if err != nil {
+			return nil, err
+		}
+		value, err := codec.DecodeValue(buf)
+		if err != nil {
+			return nil, err
+		}
+		delta.Puts = append(delta.Puts, Entry[V]{key, value})
+	}
+	numDeletes, err := binary.ReadUvarint(br)
+	if err != nil {
+		return nil, formatError(err)
+	}
+	for range numDeletes {
+		key, err := readSized(br, nil)
+		if err != nil {
+			return nil, err
+		}
+		delta.Deletes = append(delta.Deletes, key)
+	}
+	return &delta, nil
+}
+
+// Appends the uvarint size of data, followed by data.
+func appendSized(buf, data []byte) []byte {
+	buf = binary.AppendUvarint(buf, uint64(len(data)))
+	return append(buf, data...)
+}
+
+// Reads data written by appendSized, appending it to buf.
+// The result is never nil.
+func readSized(r byteReader, buf []byte) ([]byte, error) {
+	size, err := binary.ReadUvarint(r)
+	if err != nil {
+		return buf, formatError(err)
+	}
+	if buf == nil {
+		buf = []byte{}
+	}
+	return readBytes(r, buf, size)
+}
@@ -0,0 +1,82 @@
+package btrie_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/phiryll/btrie"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDelta(t *testing.T) {
+	t.Parallel()
+	configs := rangeTestConfigs
+	for _, oldConfig := range configs {
+		for _, newConfig := range configs {
+			oldTrie := createReferenceTrie(oldConfig)
+			newTrie := createReferenceTrie(newConfig)
+			// Change some values as well.
+			for k, v := range newConfig.entries {
+				if v%2 == 0 {
+					newTrie.Put([]byte(k), v+100)
+				}
+			}
+			expected := map[string]byte{}
+			for k, v := range newTrie.Range(forwardAll) {
+				expected[string(k)] = v
+			}
+			delta := btrie.ComputeDelta[byte](oldTrie, newTrie, btrie.Comparable[byte]())
+			for _, def := range implDefs {
+				trie := def.factory()
+				for k, v := range oldConfig.entries {
+					trie.Put([]byte(k), v)
+				}
+				btrie.ApplyDelta[byte](trie, delta)
+				assertSame(t, expected, trie)
+			}
+
+			var buf bytes.Buffer
+			require.NoError(t, btrie.EncodeDelta(&buf, delta, byteCodec{}))
+			decoded, err := btrie.DecodeDelta(&buf, btrie.Codec[byte](byteCodec{}))
+			require.NoError(t, err)
+			assert.Equal(t, len(delta.Puts), len(decoded.Puts))
+			assert.Equal(t, len(delta.Deletes), len(decoded.Deletes))
+			if len(delta.Puts) > 0 {
+				assert.Equal(t, delta.Puts, decoded.Puts)
+			}
+			if len(delta.Deletes) > 0 {
+				assert.Equal(t, delta.Deletes, decoded.Deletes)
+			}
+		}
+	}
+	delta := btrie.ComputeDelta[byte](createReferenceTrie(configs[1]), createReferenceTrie(configs[1]), btrie.Comparable[byte]())
+	assert.Empty(t, delta.Puts)
+	assert.Empty(t, delta.Deletes)
+}
+
+func TestDecodeDeltaErrors(t *testing.T) {
+	t.Parallel()
+	delta := &btrie.Delta[byte]{
+		Puts:    []btrie.Entry[byte]{{[]byte{}, 1}, {[]byte{1, 2}, 3}},
+		Deletes: [][]byte{{5}},
+	}
+	var buf bytes.Buffer
+	require.NoError(t, btrie.EncodeDelta(&buf, delta, byteCodec{}))
+	data := buf.Bytes()
+	decoded, err := btrie.DecodeDelta(bytes.NewReader(data), btrie.Codec[byte](byteCodec{}))
+	require.NoError(t, err)
+	assert.Equal(t, delta, decoded)
+	for i := range data {
+		_, err := btrie.DecodeDelta(bytes.NewReader(data[:i]), btrie.Codec[byte](byteCodec{}))
+		assert.ErrorIs(t, err, btrie.ErrInvalidFormat, "truncated at %d", i)
+	}
+	bad := bytes.Clone(data)
+	bad[0] = 'X'
+	_, err = btrie.DecodeDelta(bytes.NewReader(bad), btrie.Codec[byte](byteCodec{}))
+	assert.ErrorIs(t, err, btrie.ErrInvalidFormat)
+	bad = bytes.Clone(data)
+	bad[4] = 0x7F
+	_, err = btrie.DecodeDelta(bytes.NewReader(bad), btrie.Codec[byte](byteCodec{}))
+	assert.ErrorIs(t, err, btrie.ErrInvalidFormat)
+}
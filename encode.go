@@ -0,0 +1,199 @@
+package btrie
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// The serialized form of a BTrie written by Encode is:
+//
+//	magic   [4]byte  "BTRI"
+//	version uvarint  1 through FormatVersion
+//	entries          zero or more, in increasing key order, as defined by the version
+//	end              as defined by the version
+//
+// In version 1, each entry is:
+//
+//	keySize   uvarint  len(key) + 1, so that it cannot be 0
+//	key       [keySize-1]byte
+//	valueSize uvarint
+//	value     [valueSize]byte  as encoded by the Codec
+//
+// and end is a uvarint 0.
+//
+// A new version must be added to formatVersions, and FormatVersion incremented,
+// so that every earlier version can still be read by Decode and written by EncodeVersion.
+const (
+	formatMagic = "BTRI"
+
+	// FormatVersion is the version of the serialized form written by [Encode].
+	FormatVersion = 1
+)
+
+// The version-specific parts of the serialized form, which exclude the magic and version.
+type formatCodec struct {
+	// Appends an entry with key and the encoded value to buf.
+	appendEntry func(buf, key, value []byte) []byte
+
+	// Appends the end marker to buf.
+	appendEnd func(buf []byte) []byte
+
+	// Reads the next entry from br, appending the key and encoded value to buf[:0].
+	// Returns the possibly reallocated buf, the length of the key within it, and false if the end was read instead.
+	readEntry func(br byteReader, buf []byte) ([]byte, int, bool, error)
+}
+
+// The readable and writable versions of the serialized form.
+var formatVersions = map[uint64]formatCodec{
+	1: {appendEntryV1, appendEndV1, readEntryV1},
+}
+
+// ErrInvalidFormat is wrapped by errors returned when decoding malformed data.
+var ErrInvalidFormat = errors.New("invalid serialized trie")
+
+// Encode writes the entries of trie to w in a compact binary form, using codec to encode the values.
+// The written data can be read by [Decode].
+// Encode writes the current [FormatVersion].
+func Encode[V any](w io.Writer, trie BTrie[V], codec Codec[V]) error {
+	return EncodeVersion(w, trie, codec, FormatVersion)
+}
+
+// EncodeVersion is like [Encode], but writes the given version of the serialized form.
+// This lets data be written which can be read by older releases of this package,
+// such as during a rolling upgrade of processes exchanging serialized tries.
+// EncodeVersion returns an error, and writes nothing, if version is not between 1 and [FormatVersion].
+func EncodeVersion[V any](w io.Writer, trie BTrie[V], codec Codec[V], version int) error {
+	format, ok := formatVersions[uint64(version)]
+	if version < 1 || !ok {
+		return fmt.Errorf("btrie: unsupported format version %d", version)
+	}
+	bw := bufio.NewWriter(w)
+	buf := []byte(formatMagic)
+	buf = binary.AppendUvarint(buf, uint64(version))
+	if _, err := bw.Write(buf); err != nil {
+		return err
+	}
+	var valueBuf []byte
+	for key, value := range All(trie) {
+		var err error
+		valueBuf, err = codec.AppendValue(valueBuf[:0], value)
+		if err != nil {
+			return err
+		}
+		buf = format.appendEntry(buf[:0], key, valueBuf)
+		if _, err := bw.Write(buf); err != nil {
+			return err
+		}
+	}
+	if _, err := bw.Write(format.appendEnd(buf[:0])); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// Decode reads entries written by [Encode] or [EncodeVersion] from r, using codec to decode the values,
+// and puts them into trie. Every version up to [FormatVersion] can be read.
+// Entries read before an error is encountered will have been put into trie.
+// If r does not implement [io.ByteReader], Decode may read past the end of the encoded data.
+func Decode[V any](r io.Reader, trie BTrie[V], codec Codec[V]) error {
+	br, ok := r.(byteReader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+	magic := make([]byte, len(formatMagic))
+	if _, err := io.ReadFull(br, magic); err != nil {
+		return formatError(err)
+	}
+	if string(magic) != formatMagic {
+		return fmt.Errorf("%w: bad magic %q", ErrInvalidFormat, magic)
+	}
+	version, err := binary.ReadUvarint(br)
+	if err != nil {
+		return formatError(err)
+	}
+	format, ok := formatVersions[version]
+	if !ok {
+		return fmt.Errorf("%w: unsupported version %d", ErrInvalidFormat, version)
+	}
+	var buf []byte
+	for {
+		var keySize int
+		buf, keySize, ok, err = format.readEntry(br, buf)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return nil
+		}
+		key := make([]byte, keySize)
+		copy(key, buf)
+		value, err := codec.DecodeValue(buf[keySize:])
+		if err != nil {
+			return err
+		}
+		trie.Put(key, value)
+	}
+}
+
+func appendEntryV1(buf, key, value []byte) []byte {
+	buf = binary.AppendUvarint(buf, uint64(len(key))+1)
+	buf = append(buf, key...)
+	buf = binary.AppendUvarint(buf, uint64(len(value)))
+	return append(buf, value...)
+}
+
+func appendEndV1(buf []byte) []byte {
+	return append(buf, 0)
+}
+
+func readEntryV1(br byteReader, buf []byte) ([]byte, int, bool, error) {
+	keySize, err := binary.ReadUvarint(br)
+	if err != nil {
+		return buf, 0, false, formatError(err)
+	}
+	if keySize == 0 {
+		return buf, 0, false, nil
+	}
+	buf, err = readBytes(br, buf[:0], keySize-1)
+	if err != nil {
+		return buf, 0, false, err
+	}
+	valueSize, err := binary.ReadUvarint(br)
+	if err != nil {
+		return buf, 0, false, formatError(err)
+	}
+	buf, err = readBytes(br, buf, valueSize)
+	return buf, int(keySize - 1), true, err
+}
+
+type byteReader interface {
+	io.Reader
+	io.ByteReader
+}
+
+// Appends size bytes read from r to buf.
+func readBytes(r io.Reader, buf []byte, size uint64) ([]byte, error) {
+	// Don't trust size enough to allocate it all at once.
+	const chunkSize = 1 << 16
+	for size > 0 {
+		n := min(size, chunkSize)
+		start := len(buf)
+		buf = append(buf, make([]byte, n)...)
+		if _, err := io.ReadFull(r, buf[start:]); err != nil {
+			return buf, formatError(err)
+		}
+		size -= n
+	}
+	return buf, nil
+}
+
+// Converts an unexpected EOF into an ErrInvalidFormat.
+func formatError(err error) error {
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return fmt.Errorf("%w: unexpected EOF", ErrInvalidFormat)
+	}
+	return err
+}
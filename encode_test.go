@@ -0,0 +1,126 @@
+package btrie_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/phiryll/btrie"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// byteCodec is a btrie.Codec for the byte values used by TestBTrie.
+type byteCodec struct{}
+
+func (byteCodec) AppendValue(buf []byte, value byte) ([]byte, error) {
+	return append(buf, value), nil
+}
+
+func (byteCodec) DecodeValue(data []byte) (byte, error) {
+	if len(data) != 1 {
+		return 0, errors.New("byte value must have length 1")
+	}
+	return data[0], nil
+}
+
+func TestEncodeDecode(t *testing.T) {
+	t.Parallel()
+	for _, test := range createTestTries(rangeTestConfigs) {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			var buf bytes.Buffer
+			require.NoError(t, btrie.Encode(&buf, test.trie, byteCodec{}))
+			trie := test.def.factory()
+			require.NoError(t, btrie.Decode(&buf, trie, byteCodec{}))
+			assertSame(t, test.config.entries, trie)
+			assert.Zero(t, buf.Len())
+		})
+	}
+}
+
+func TestEncodeVersion(t *testing.T) {
+	t.Parallel()
+	trie := btrie.NewArrayTrie[byte]()
+	trie.Put([]byte{}, 1)
+	trie.Put([]byte{0x23, 0x45}, 2)
+	var current bytes.Buffer
+	require.NoError(t, btrie.Encode(&current, trie, byteCodec{}))
+	for version := 1; version <= btrie.FormatVersion; version++ {
+		var buf bytes.Buffer
+		require.NoError(t, btrie.EncodeVersion(&buf, trie, byteCodec{}, version))
+		if version == btrie.FormatVersion {
+			assert.Equal(t, current.Bytes(), buf.Bytes())
+		}
+		decoded := btrie.NewArrayTrie[byte]()
+		require.NoError(t, btrie.Decode(&buf, decoded, byteCodec{}), "version %d", version)
+		assertSame(t, map[string]byte{"": 1, "\x23\x45": 2}, decoded)
+	}
+
+	// Version 1 must never change, so that older releases can always read it.
+	var v1 bytes.Buffer
+	require.NoError(t, btrie.EncodeVersion(&v1, trie, byteCodec{}, 1))
+	assert.Equal(t, []byte("BTRI\x01\x01\x01\x01\x03\x23\x45\x01\x02\x00"), v1.Bytes())
+
+	for _, version := range []int{-1, 0, btrie.FormatVersion + 1} {
+		var buf bytes.Buffer
+		require.Error(t, btrie.EncodeVersion(&buf, trie, byteCodec{}, version), "version %d", version)
+		assert.Zero(t, buf.Len())
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	t.Parallel()
+	trie := btrie.NewArrayTrie[byte]()
+	trie.Put([]byte{}, 1)
+	trie.Put([]byte{0x23, 0x45}, 2)
+	var buf bytes.Buffer
+	require.NoError(t, btrie.Encode(&buf, trie, byteCodec{}))
+	data := buf.Bytes()
+
+	// Every truncation is an error.
+	for i := range data {
+		err := btrie.Decode(bytes.NewReader(data[:i]), btrie.NewArrayTrie[byte](), byteCodec{})
+		assert.ErrorIs(t, err, btrie.ErrInvalidFormat, "truncated at %d", i)
+	}
+
+	badMagic := bytes.Clone(data)
+	badMagic[0] = 'X'
+	assert.ErrorIs(t, btrie.Decode(bytes.NewReader(badMagic), btrie.NewArrayTrie[byte](), byteCodec{}),
+		btrie.ErrInvalidFormat)
+
+	badVersion := bytes.Clone(data)
+	badVersion[4] = 0x7F
+	assert.ErrorIs(t, btrie.Decode(bytes.NewReader(badVersion), btrie.NewArrayTrie[byte](), byteCodec{}),
+		btrie.ErrInvalidFormat)
+
+	// Codec errors are returned as-is.
+	var stringBuf bytes.Buffer
+	bytesTrie := btrie.NewArrayTrie[[]byte]()
+	bytesTrie.Put([]byte{1}, []byte{1, 2})
+	require.NoError(t, btrie.Encode(&stringBuf, bytesTrie, btrie.BytesCodec{}))
+	err := btrie.Decode(&stringBuf, btrie.NewArrayTrie[byte](), byteCodec{})
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, btrie.ErrInvalidFormat)
+}
+
+func TestBytesCodec(t *testing.T) {
+	t.Parallel()
+	trie := btrie.NewArrayTrie[[]byte]()
+	trie.Put([]byte{}, nil)
+	trie.Put([]byte{1}, []byte{})
+	trie.Put([]byte{1, 2}, []byte{3, 4, 5})
+	var buf bytes.Buffer
+	require.NoError(t, btrie.Encode(&buf, trie, btrie.BytesCodec{}))
+	decoded := btrie.NewArrayTrie[[]byte]()
+	require.NoError(t, btrie.Decode(&buf, decoded, btrie.BytesCodec{}))
+	value, ok := decoded.Get([]byte{})
+	assert.True(t, ok)
+	assert.Equal(t, []byte{}, value)
+	value, ok = decoded.Get([]byte{1})
+	assert.True(t, ok)
+	assert.Equal(t, []byte{}, value)
+	value, ok = decoded.Get([]byte{1, 2})
+	assert.True(t, ok)
+	assert.Equal(t, []byte{3, 4, 5}, value)
+}
@@ -0,0 +1,210 @@
+package btrie
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// The serialized form of a BTrie written by EncodeEncrypted is:
+//
+//	magic       [4]byte  "BTRE"
+//	version     byte     currently 1
+//	noncePrefix [8]byte  random
+//	chunks               one or more
+//
+// The plaintext is the output of EncodeCompressed with FlateCompression,
+// split into chunks of encryptedChunkSize bytes, except that the last chunk may be shorter or empty.
+// Each chunk is sealed with AES-GCM, and framed as:
+//
+//	frame       uint32   big-endian, len(ciphertext), with the high bit set for the last chunk
+//	ciphertext  [len]byte
+//
+// The nonce of chunk i is noncePrefix followed by i as a big-endian uint32,
+// and the additional data is the header followed by the frame, so chunks cannot be reordered, dropped, or truncated.
+// Because every chunk but the last has the same size, chunk i starts at a fixed offset,
+// and can be located and decrypted without reading the chunks before it.
+const (
+	encryptedMagic      = "BTRE"
+	encryptedVersion    = 1
+	encryptedHeaderSize = 4 + 1 + 8
+	encryptedChunkSize  = 1 << 16
+	encryptedLastChunk  = 1 << 31
+)
+
+// EncodeEncrypted writes the entries of trie to w like [EncodeCompressed] with [FlateCompression],
+// using codec to encode the values, and encrypts and authenticates the result with AES-GCM under key,
+// which must be 16, 24, or 32 bytes long to select AES-128, AES-192, or AES-256.
+// The data is sealed in fixed-size chunks, so it is never buffered in its entirety.
+// The written data can be read by [DecodeEncrypted] given the same key.
+// A random nonce prefix is generated for every call, so the same key can safely encrypt many tries.
+func EncodeEncrypted[V any](w io.Writer, trie BTrie[V], codec Codec[V], key []byte) error {
+	aead, err := newEncryptedAEAD(key)
+	if err != nil {
+		return err
+	}
+	header := make([]byte, encryptedHeaderSize)
+	copy(header, encryptedMagic)
+	header[4] = encryptedVersion
+	if _, err := rand.Read(header[5:]); err != nil {
+		return err
+	}
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	cw := &chunkWriter{w: w, aead: aead, header: header}
+	if err := EncodeCompressed(cw, trie, codec, FlateCompression); err != nil {
+		return err
+	}
+	return cw.Close()
+}
+
+// DecodeEncrypted reads entries written by [EncodeEncrypted] from r, decrypting them with key,
+// using codec to decode the values, and puts them into trie.
+// Every chunk is authenticated before any entries in it are put into trie, but
+// entries read before an error is encountered, including one caused by tampering or truncation, will have been put into trie.
+// Errors caused by malformed, tampered with, or truncated data, or by the wrong key, wrap [ErrInvalidFormat].
+// DecodeEncrypted never reads past the end of the encrypted data.
+func DecodeEncrypted[V any](r io.Reader, trie BTrie[V], codec Codec[V], key []byte) error {
+	aead, err := newEncryptedAEAD(key)
+	if err != nil {
+		return err
+	}
+	header := make([]byte, encryptedHeaderSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return formatError(err)
+	}
+	if magic := header[:4]; string(magic) != encryptedMagic {
+		return fmt.Errorf("%w: bad magic %q", ErrInvalidFormat, magic)
+	}
+	if header[4] != encryptedVersion {
+		return fmt.Errorf("%w: unsupported version %d", ErrInvalidFormat, header[4])
+	}
+	br := bufio.NewReader(&chunkReader{r: r, aead: aead, header: header})
+	if err := DecodeCompressed(br, trie, codec); err != nil {
+		return err
+	}
+	// Reading to the end authenticates the last chunk, or detects that it is missing.
+	if _, err := br.ReadByte(); !errors.Is(err, io.EOF) {
+		if err == nil {
+			return fmt.Errorf("%w: data after end of entries", ErrInvalidFormat)
+		}
+		return err
+	}
+	return nil
+}
+
+func newEncryptedAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("btrie: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// Returns the nonce and additional data for chunk i with the given frame.
+func encryptedChunkParams(header []byte, i uint32, frame uint32) ([]byte, []byte) {
+	nonce := binary.BigEndian.AppendUint32(append([]byte{}, header[5:]...), i)
+	ad := binary.BigEndian.AppendUint32(append([]byte{}, header...), frame)
+	return nonce, ad
+}
+
+// An io.WriteCloser which seals everything written to it in chunks, and seals the last chunk when closed.
+type chunkWriter struct {
+	w      io.Writer
+	aead   cipher.AEAD
+	header []byte
+	buf    []byte
+	out    []byte
+	chunk  uint32
+}
+
+func (c *chunkWriter) Write(p []byte) (int, error) {
+	c.buf = append(c.buf, p...)
+	// The last chunk must be written by Close, so a full chunk is only written once more data follows it.
+	for len(c.buf) > encryptedChunkSize {
+		if err := c.seal(c.buf[:encryptedChunkSize], false); err != nil {
+			return 0, err
+		}
+		c.buf = append(c.buf[:0], c.buf[encryptedChunkSize:]...)
+	}
+	return len(p), nil
+}
+
+func (c *chunkWriter) Close() error {
+	return c.seal(c.buf, true)
+}
+
+func (c *chunkWriter) seal(plaintext []byte, last bool) error {
+	if c.chunk == ^uint32(0) {
+		return errors.New("btrie: too many chunks to encrypt")
+	}
+	frame := uint32(len(plaintext) + c.aead.Overhead())
+	if last {
+		frame |= encryptedLastChunk
+	}
+	nonce, ad := encryptedChunkParams(c.header, c.chunk, frame)
+	c.out = binary.BigEndian.AppendUint32(c.out[:0], frame)
+	c.out = c.aead.Seal(c.out, nonce, plaintext, ad)
+	c.chunk++
+	_, err := c.w.Write(c.out)
+	return err
+}
+
+// An io.Reader which reads and opens the chunks written by a chunkWriter, returning io.EOF after the last chunk.
+type chunkReader struct {
+	r      io.Reader
+	aead   cipher.AEAD
+	header []byte
+	buf    []byte
+	// The unread plaintext of the current chunk is buf[next:].
+	next  int
+	chunk uint32
+	done  bool
+}
+
+func (c *chunkReader) Read(p []byte) (int, error) {
+	for c.next == len(c.buf) {
+		if c.done {
+			return 0, io.EOF
+		}
+		if err := c.open(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, c.buf[c.next:])
+	c.next += n
+	return n, nil
+}
+
+func (c *chunkReader) open() error {
+	var frameBuf [4]byte
+	if _, err := io.ReadFull(c.r, frameBuf[:]); err != nil {
+		return formatError(err)
+	}
+	frame := binary.BigEndian.Uint32(frameBuf[:])
+	size := int(frame &^ encryptedLastChunk)
+	last := frame&encryptedLastChunk != 0
+	if size < c.aead.Overhead() || size > encryptedChunkSize+c.aead.Overhead() ||
+		(!last && size != encryptedChunkSize+c.aead.Overhead()) {
+		return fmt.Errorf("%w: bad encrypted chunk size %d", ErrInvalidFormat, size)
+	}
+	ciphertext := make([]byte, size)
+	if _, err := io.ReadFull(c.r, ciphertext); err != nil {
+		return formatError(err)
+	}
+	nonce, ad := encryptedChunkParams(c.header, c.chunk, frame)
+	plaintext, err := c.aead.Open(c.buf[:0], nonce, ciphertext, ad)
+	if err != nil {
+		return fmt.Errorf("%w: encrypted chunk %d failed authentication", ErrInvalidFormat, c.chunk)
+	}
+	c.buf, c.next = plaintext, 0
+	c.chunk++
+	c.done = last
+	return nil
+}
@@ -0,0 +1,99 @@
+package btrie_test
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"github.com/phiryll/btrie"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var encryptionKey = bytes.Repeat([]byte{0x5A}, 32)
+
+func TestEncodeDecodeEncrypted(t *testing.T) {
+	t.Parallel()
+	for _, test := range createTestTries(rangeTestConfigs) {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			var buf bytes.Buffer
+			require.NoError(t, btrie.EncodeEncrypted(&buf, test.trie, byteCodec{}, encryptionKey))
+			trie := test.def.factory()
+			require.NoError(t, btrie.DecodeEncrypted(&buf, trie, byteCodec{}, encryptionKey))
+			assertSame(t, test.config.entries, trie)
+			assert.Zero(t, buf.Len())
+		})
+	}
+}
+
+func TestEncodeEncryptedChunks(t *testing.T) {
+	t.Parallel()
+	// Random keys don't compress, so the encrypted data has several chunks.
+	random := rand.New(rand.NewSource(42))
+	trie := btrie.NewArrayTrie[byte]()
+	entries := map[string]byte{}
+	for i := range 30000 {
+		key := make([]byte, 8)
+		random.Read(key)
+		trie.Put(key, byte(i))
+		entries[string(key)] = byte(i)
+	}
+	var buf bytes.Buffer
+	require.NoError(t, btrie.EncodeEncrypted(&buf, trie, byteCodec{}, encryptionKey))
+	data := buf.Bytes()
+	const headerSize, frameSize = 13, 4 + 1<<16 + 16
+	require.Greater(t, len(data), headerSize+3*frameSize)
+	decoded := btrie.NewArrayTrie[byte]()
+	require.NoError(t, btrie.DecodeEncrypted(bytes.NewReader(data), decoded, byteCodec{}, encryptionKey))
+	assertSame(t, entries, decoded)
+
+	// The same trie encrypts differently every time.
+	var again bytes.Buffer
+	require.NoError(t, btrie.EncodeEncrypted(&again, trie, byteCodec{}, encryptionKey))
+	assert.NotEqual(t, data[:100], again.Bytes()[:100])
+
+	decode := func(data, key []byte) error {
+		return btrie.DecodeEncrypted(bytes.NewReader(data), btrie.NewArrayTrie[byte](), byteCodec{}, key)
+	}
+	// Dropping whole chunks must be detected, even though the remaining chunks are authentic.
+	require.ErrorIs(t, decode(data[:headerSize+3*frameSize], encryptionKey), btrie.ErrInvalidFormat)
+	require.ErrorIs(t, decode(data[:len(data)-1], encryptionKey), btrie.ErrInvalidFormat)
+	swapped := bytes.Clone(data)
+	copy(swapped[headerSize:], data[headerSize+frameSize:headerSize+2*frameSize])
+	copy(swapped[headerSize+frameSize:], data[headerSize:headerSize+frameSize])
+	require.ErrorIs(t, decode(swapped, encryptionKey), btrie.ErrInvalidFormat)
+	tampered := bytes.Clone(data)
+	tampered[headerSize+frameSize+100] ^= 1
+	require.ErrorIs(t, decode(tampered, encryptionKey), btrie.ErrInvalidFormat)
+	require.ErrorIs(t, decode(data, bytes.Repeat([]byte{0x5B}, 32)), btrie.ErrInvalidFormat)
+}
+
+func TestDecodeEncryptedErrors(t *testing.T) {
+	t.Parallel()
+	trie := btrie.NewArrayTrie[byte]()
+	trie.Put([]byte{1}, 2)
+	err := btrie.EncodeEncrypted(&bytes.Buffer{}, trie, byteCodec{}, []byte{1, 2, 3})
+	require.Error(t, err)
+	require.NotErrorIs(t, err, btrie.ErrInvalidFormat)
+
+	var buf bytes.Buffer
+	require.NoError(t, btrie.EncodeEncrypted(&buf, trie, byteCodec{}, encryptionKey))
+	data := buf.Bytes()
+	for _, bad := range [][]byte{
+		{},
+		[]byte("BTRE"),
+		append([]byte("XXXX"), data[4:]...),
+		append([]byte("BTRE\x02"), data[5:]...),
+		data[:20],
+	} {
+		err := btrie.DecodeEncrypted(bytes.NewReader(bad), btrie.NewArrayTrie[byte](), byteCodec{}, encryptionKey)
+		require.ErrorIs(t, err, btrie.ErrInvalidFormat, "%q", bad)
+	}
+	// Nothing is read past the end.
+	reader := bytes.NewReader(append(bytes.Clone(data), 0xFF))
+	decoded := btrie.NewArrayTrie[byte]()
+	require.NoError(t, btrie.DecodeEncrypted(reader, decoded, byteCodec{}, encryptionKey))
+	assertSame(t, map[string]byte{"\x01": 2}, decoded)
+	assert.Equal(t, 1, reader.Len())
+}
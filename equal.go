@@ -0,0 +1,50 @@
+package btrie
+
+import (
+	"bytes"
+	"iter"
+)
+
+// Equaler reports whether two values are equal.
+// It is used by features which compare values, such as [Equal], [ComputeDelta], [Journaled.SkipUnchanged],
+// and the merges [ResolveUnequal] and [LWWTrie.SkipUnchanged], so that values need not be comparable.
+type Equaler[V any] interface {
+	Equal(a, b V) bool
+}
+
+// EqualFunc is an Equaler which calls itself.
+type EqualFunc[V any] func(a, b V) bool
+
+func (f EqualFunc[V]) Equal(a, b V) bool {
+	return f(a, b)
+}
+
+// Comparable returns an Equaler for comparable values, using ==.
+func Comparable[V comparable]() Equaler[V] {
+	return comparableEqualer[V]{}
+}
+
+type comparableEqualer[V comparable] struct{}
+
+func (comparableEqualer[V]) Equal(a, b V) bool {
+	return a == b
+}
+
+// Equal returns whether a and b contain the same keys, with values equal according to eq.
+// Neither trie may be mutated while Equal is running.
+func Equal[V any](a, b BTrie[V], eq Equaler[V]) bool {
+	nextA, stopA := iter.Pull2(All(a))
+	defer stopA()
+	nextB, stopB := iter.Pull2(All(b))
+	defer stopB()
+	for {
+		keyA, valueA, okA := nextA()
+		keyB, valueB, okB := nextB()
+		if !okA || !okB {
+			return okA == okB
+		}
+		if !bytes.Equal(keyA, keyB) || !eq.Equal(valueA, valueB) {
+			return false
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package btrie_test
+
+import (
+	"testing"
+
+	"github.com/phiryll/btrie"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEqual(t *testing.T) {
+	t.Parallel()
+	eq := btrie.Comparable[byte]()
+	assert.True(t, eq.Equal(3, 3))
+	assert.False(t, eq.Equal(3, 4))
+	sameParity := btrie.EqualFunc[byte](func(a, b byte) bool { return a%2 == b%2 })
+	assert.True(t, sameParity.Equal(3, 5))
+	assert.False(t, sameParity.Equal(3, 4))
+
+	for _, config := range rangeTestConfigs {
+		for _, aDef := range implDefs {
+			for _, bDef := range implDefs {
+				a := aDef.factory()
+				b := bDef.factory()
+				for k, v := range config.entries {
+					a.Put([]byte(k), v)
+					b.Put([]byte(k), v)
+				}
+				assert.True(t, btrie.Equal[byte](a, b, eq))
+				assert.True(t, btrie.Equal[byte](b, a, eq))
+				if len(config.entries) == 0 {
+					continue
+				}
+				first := collect(b.Range(forwardAll))[0]
+				key, value := first.key, first.value
+				b.Put(key, value+2)
+				assert.False(t, btrie.Equal[byte](a, b, eq))
+				assert.True(t, btrie.Equal[byte](a, b, sameParity))
+				b.Delete(key)
+				assert.False(t, btrie.Equal[byte](a, b, eq))
+				assert.False(t, btrie.Equal[byte](b, a, eq))
+				b.Put(append(key, 0), value)
+				assert.False(t, btrie.Equal[byte](a, b, eq))
+			}
+		}
+	}
+}
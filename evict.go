@@ -0,0 +1,113 @@
+package btrie
+
+import (
+	"math"
+	"math/rand"
+	"slices"
+)
+
+// EvictFraction deletes a uniformly random sample of the entries in trie having the given prefix,
+// and returns the number of deleted entries, which is the number of such entries times fraction, rounded.
+// With prefix counts, each sampled key is found by descending from the root, without ranging over the entries.
+// Otherwise, the entries having prefix are ranged over to collect their keys before any are deleted.
+// If trie is a [Pinned], its pinned entries are never evicted, and are not counted among the entries having prefix.
+// EvictFraction will panic if prefix or rnd is nil, or if fraction is not between 0 and 1 inclusive.
+func EvictFraction[V any](trie BTrie[V], prefix []byte, fraction float64, rnd *rand.Rand) int {
+	if prefix == nil {
+		panic("prefix must be non-nil")
+	}
+	if rnd == nil {
+		panic("rnd must be non-nil")
+	}
+	if !(fraction >= 0 && fraction <= 1) {
+		panic("fraction must be between 0 and 1")
+	}
+	pins, _ := trie.(pinChecker)
+	if t, ok := trie.(rankSelector); ok && pins == nil {
+		first, count := t.prefixRanks(prefix)
+		ranks := sampleRanks(count, fraction, rnd)
+		// Deleting in decreasing rank order does not change the ranks of the keys yet to be deleted.
+		for _, rank := range slices.Backward(ranks) {
+			trie.Delete(t.keyAt(first + rank))
+		}
+		return len(ranks)
+	}
+	var keys [][]byte
+	end, _ := PrefixSuccessor(prefix)
+	for key := range trie.Range(From(prefix).To(end)) {
+		if pins == nil || !pins.IsPinned(key) {
+			keys = append(keys, key)
+		}
+	}
+	ranks := sampleRanks(len(keys), fraction, rnd)
+	for _, rank := range ranks {
+		trie.Delete(keys[rank])
+	}
+	return len(ranks)
+}
+
+// Returns count times fraction distinct random ranks less than count, rounded, in increasing order.
+func sampleRanks(count int, fraction float64, rnd *rand.Rand) []int {
+	k := int(math.Round(float64(count) * fraction))
+	// Floyd's algorithm, which only needs space for the sample.
+	sample := make(map[int]struct{}, k)
+	for j := count - k; j < count; j++ {
+		rank := rnd.Intn(j + 1)
+		if _, ok := sample[rank]; ok {
+			rank = j
+		}
+		sample[rank] = struct{}{}
+	}
+	ranks := make([]int, 0, k)
+	for rank := range sample {
+		ranks = append(ranks, rank)
+	}
+	slices.Sort(ranks)
+	return ranks
+}
+
+// EvictToSize deletes uniformly random entries of trie having the given prefix
+// until the total size of the remaining such entries is at most budget, and returns the number of deleted entries.
+// The size of each entry is given by sizer, which might be the length of the key plus the size of the value in bytes,
+// so that eviction reflects the memory used by entries whose sizes vary widely, rather than just their number.
+// If trie is a [Pinned], its pinned entries are never evicted, but their sizes are included in the total,
+// so the total may still be more than budget after every other entry is deleted.
+// trie is ranged over to collect every key having prefix and its size before any are deleted.
+// EvictToSize will panic if prefix, sizer, or rnd is nil, or if budget is negative.
+func EvictToSize[V any](trie BTrie[V], prefix []byte, budget int64, sizer func([]byte, V) int64, rnd *rand.Rand) int {
+	if prefix == nil {
+		panic("prefix must be non-nil")
+	}
+	if sizer == nil {
+		panic("sizer must be non-nil")
+	}
+	if rnd == nil {
+		panic("rnd must be non-nil")
+	}
+	if budget < 0 {
+		panic("budget must be non-negative")
+	}
+	pins, _ := trie.(pinChecker)
+	var keys [][]byte
+	var sizes []int64
+	total := int64(0)
+	end, _ := PrefixSuccessor(prefix)
+	for key, value := range trie.Range(From(prefix).To(end)) {
+		size := sizer(key, value)
+		total += size
+		if pins == nil || !pins.IsPinned(key) {
+			keys = append(keys, key)
+			sizes = append(sizes, size)
+		}
+	}
+	count := 0
+	for _, i := range rnd.Perm(len(keys)) {
+		if total <= budget {
+			break
+		}
+		trie.Delete(keys[i])
+		total -= sizes[i]
+		count++
+	}
+	return count
+}
@@ -0,0 +1,160 @@
+package btrie_test
+
+import (
+	"math"
+	"math/rand"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/phiryll/btrie"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvictFraction(t *testing.T) {
+	t.Parallel()
+	for _, test := range createTestTries(rangeTestConfigs) {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			random := rand.New(rand.NewSource(2207))
+			for _, prefix := range [][]byte{{}, {0x23}, {0xC5, 0x42}} {
+				for _, fraction := range []float64{0, 0.3, 0.5, 1} {
+					for _, wrap := range []bool{false, true} {
+						var trie btrie.BTrie[byte] = test.trie.Clone()
+						if wrap {
+							trie = btrie.NewOverlay(trie)
+						}
+						count := 0
+						for k := range test.config.entries {
+							if strings.HasPrefix(k, string(prefix)) {
+								count++
+							}
+						}
+						evicted := btrie.EvictFraction(trie, prefix, fraction, random)
+						assert.Equal(t, int(math.Round(float64(count)*fraction)), evicted)
+						remaining := 0
+						for k, v := range test.config.entries {
+							actual, ok := trie.Get([]byte(k))
+							if !strings.HasPrefix(k, string(prefix)) {
+								assert.True(t, ok, "%s", keyName([]byte(k)))
+							} else if ok {
+								remaining++
+							}
+							if ok {
+								assert.Equal(t, v, actual)
+							}
+						}
+						assert.Equal(t, count-evicted, remaining)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestEvictFractionUniform(t *testing.T) {
+	t.Parallel()
+	const numKeys = 10
+	const trials = 5000
+	random := rand.New(rand.NewSource(8841))
+	for _, trie := range []btrie.BTrie[byte]{btrie.NewArrayTrie[byte](), btrie.NewOverlay(btrie.NewArrayTrie[byte]())} {
+		evictions := make([]int, numKeys)
+		for range trials {
+			for i := range numKeys {
+				trie.Put([]byte{1, byte(i)}, 0)
+			}
+			trie.Put([]byte{2}, 0)
+			assert.Equal(t, 1, btrie.EvictFraction(trie, []byte{1}, 1.0/numKeys, random))
+			for i := range numKeys {
+				if _, ok := trie.Get([]byte{1, byte(i)}); !ok {
+					evictions[i]++
+				}
+			}
+		}
+		// Each key is expected to be evicted 500 times, with a standard deviation of about 21.
+		for i, n := range evictions {
+			assert.InDelta(t, trials/numKeys, n, 100, "%d", i)
+		}
+	}
+}
+
+func TestEvictFractionPanics(t *testing.T) {
+	t.Parallel()
+	trie := btrie.NewArrayTrie[byte]()
+	random := rand.New(rand.NewSource(1))
+	assert.Panics(t, func() { btrie.EvictFraction(trie, nil, 0.5, random) })
+	assert.Panics(t, func() { btrie.EvictFraction(trie, []byte{}, 0.5, nil) })
+	assert.Panics(t, func() { btrie.EvictFraction(trie, []byte{}, -0.1, random) })
+	assert.Panics(t, func() { btrie.EvictFraction(trie, []byte{}, 1.1, random) })
+	assert.Panics(t, func() { btrie.EvictFraction(trie, []byte{}, math.NaN(), random) })
+}
+
+func TestEvictFractionPinned(t *testing.T) {
+	t.Parallel()
+	random := rand.New(rand.NewSource(5113))
+	trie := btrie.WithPins(btrie.NewArrayTrie[byte]())
+	for _, key := range presentTestKeys {
+		trie.Put(key, 0)
+	}
+	pinned := keySet{{}, {0x23, 0xA5}, {0xC5}}
+	for _, key := range pinned {
+		assert.True(t, trie.Pin(key))
+		assert.False(t, trie.Pin(key))
+	}
+	assert.True(t, trie.Pin([]byte{0x99}), "need not have an entry")
+	assert.Equal(t, len(presentTestKeys)-len(pinned), btrie.EvictFraction(trie, []byte{}, 1, random))
+	assert.Equal(t, []entry{{[]byte{}, 0}, {[]byte{0x23, 0xA5}, 0}, {[]byte{0xC5}, 0}}, collect(trie.Range(forwardAll)))
+	assert.Equal(t, keySet{{}, {0x23, 0xA5}, {0x99}, {0xC5}}, slices.Collect(trie.PinnedKeys(forwardAll)))
+
+	// Pinning does not prevent deletion.
+	_, ok := trie.Delete([]byte{0xC5})
+	assert.True(t, ok)
+	assert.True(t, trie.IsPinned([]byte{0xC5}))
+
+	assert.True(t, trie.Unpin([]byte{0x23, 0xA5}))
+	assert.False(t, trie.Unpin([]byte{0x23, 0xA5}))
+	assert.False(t, trie.IsPinned([]byte{0x23, 0xA5}))
+	assert.Equal(t, 1, btrie.EvictFraction(trie, []byte{0x23}, 1, random))
+	assert.Equal(t, []entry{{[]byte{}, 0}}, collect(trie.Range(forwardAll)))
+}
+
+func TestEvictToSize(t *testing.T) {
+	t.Parallel()
+	sizer := func(key []byte, value byte) int64 { return int64(len(key)) + int64(value) }
+	trie := btrie.NewArrayTrie[byte]()
+	assert.Panics(t, func() { btrie.EvictToSize(trie, nil, 0, sizer, rand.New(rand.NewSource(1))) })
+	assert.Panics(t, func() { btrie.EvictToSize(trie, []byte{}, 0, nil, rand.New(rand.NewSource(1))) })
+	assert.Panics(t, func() { btrie.EvictToSize(trie, []byte{}, 0, sizer, nil) })
+	assert.Panics(t, func() { btrie.EvictToSize(trie, []byte{}, -1, sizer, rand.New(rand.NewSource(1))) })
+
+	random := rand.New(rand.NewSource(6404))
+	for _, budget := range []int64{0, 10, 100, 1000, 5000} {
+		trie := btrie.NewArrayTrie[byte]()
+		outside := int64(0)
+		for i, key := range presentTestKeys {
+			value := byte(i * 25)
+			trie.Put(key, value)
+			if len(key) == 0 || key[0] != 0x23 {
+				outside += sizer(key, value)
+			}
+		}
+		before := len(collect(trie.Range(forwardAll)))
+		evicted := btrie.EvictToSize(trie, []byte{0x23}, budget, sizer, random)
+		total, remaining := int64(0), 0
+		for k, v := range trie.Range(forwardAll) {
+			total += sizer(k, v)
+			remaining++
+		}
+		assert.Equal(t, before-evicted, remaining)
+		assert.LessOrEqual(t, total-outside, budget)
+	}
+
+	// Pinned entries count toward the budget, but are not evicted.
+	pinned := btrie.WithPins(btrie.NewArrayTrie[byte]())
+	pinned.Put([]byte{1}, 99)
+	pinned.Put([]byte{2}, 1)
+	pinned.Put([]byte{3}, 1)
+	pinned.Pin([]byte{1})
+	assert.Equal(t, 2, btrie.EvictToSize(pinned, []byte{}, 50, sizer, random))
+	assert.Equal(t, []entry{{[]byte{1}, 99}}, collect(pinned.Range(forwardAll)))
+}
@@ -1,16 +1,17 @@
 package btrie
 
+import (
+	"maps"
+	"slices"
+)
+
 // Things that need to be exported for testing, but should not be part of the public API.
 // The identifiers are in the btrie package, but the filename ends in _test.go,
 // preventing their inclusion in the public API.
 
 var (
-	TestingKeyName        = keyName
-	TestingChildBounds    = (*Bounds).childBounds
-	TestingPreOrder       = preOrder[int]
-	TestingPostOrder      = postOrder[int]
-	TestingPreOrderPaths  = preOrderPaths[int]
-	TestingPostOrderPaths = postOrderPaths[int]
+	TestingKeyName     = keyName
+	TestingChildBounds = (*Bounds).childBounds
 )
 
 type (
@@ -18,24 +19,17 @@ type (
 		BTrie[V]
 		Clone() Cloneable[V]
 	}
-
-	TestingAdjFunction     = adjFunction[int]
-	TestingTraverser       = traverser[int]
-	TestingPathAdjFunction = pathAdjFunction[int]
-	TestingPathTraverser   = pathTraverser[int]
 )
 
 // Assumes V is not a reference type.
-func (n *ptrTrieNode[V]) Clone() Cloneable[V] {
-	return clonePointerTrie(n)
-}
-
-func clonePointerTrie[V any](n *ptrTrieNode[V]) *ptrTrieNode[V] {
-	clone := *n
-	clone.children = make([]*ptrTrieNode[V], len(n.children))
-	for i, child := range n.children {
-		clone.children[i] = clonePointerTrie(child)
+func (t *ptrTrie[V]) Clone() Cloneable[V] {
+	clone := *t
+	clone.slabs = make([][]ptrTrieNode[V], len(t.slabs))
+	for i, slab := range t.slabs {
+		clone.slabs[i] = slices.Clone(slab)
 	}
+	clone.keyBytes = slices.Clone(t.keyBytes)
+	clone.childNodes = slices.Clone(t.childNodes)
 	return &clone
 }
 
@@ -59,3 +53,47 @@ func cloneArrayTrie[V any](n *arrayTrieNode[V]) *arrayTrieNode[V] {
 	}
 	return &clone
 }
+
+// Assumes V is not a reference type.
+func (n *hatTrieNode[V]) Clone() Cloneable[V] {
+	return cloneHATTrie(n)
+}
+
+// Suffixes are never modified in place, so they are shared with the clone.
+func cloneHATTrie[V any](n *hatTrieNode[V]) *hatTrieNode[V] {
+	if n == nil {
+		return nil
+	}
+	clone := *n
+	clone.bucket = slices.Clone(n.bucket)
+	if n.children != nil {
+		clone.children = &[256]*hatTrieNode[V]{}
+		for i, child := range n.children {
+			clone.children[i] = cloneHATTrie(child)
+		}
+	}
+	return &clone
+}
+
+// Assumes V is not a reference type.
+func (t *MapTrie[V]) Clone() Cloneable[V] {
+	keys, ok := t.keys.(Cloneable[struct{}])
+	if !ok {
+		panic("keys are not Cloneable")
+	}
+	return &MapTrie[V]{maps.Clone(t.entries), keys.Clone()}
+}
+
+func (t *WeightedTrie[V]) Clone() Cloneable[V] {
+	return &WeightedTrie[V]{cloneWeightedTrie(t.root), t.weight}
+}
+
+func cloneWeightedTrie[V any](n *weightedTrieNode[V]) *weightedTrieNode[V] {
+	clone := *n
+	clone.keyBytes = slices.Clone(n.keyBytes)
+	clone.children = make([]*weightedTrieNode[V], len(n.children))
+	for i, child := range n.children {
+		clone.children[i] = cloneWeightedTrie(child)
+	}
+	return &clone
+}
@@ -0,0 +1,81 @@
+package btrie
+
+import (
+	"expvar"
+	"iter"
+	"sync/atomic"
+	"unsafe"
+)
+
+// Counted is a BTrie which counts the calls to each of its methods, see [PublishExpvar].
+// Counted implements [BTrie], and is as safe for concurrent use as the trie it wraps.
+type Counted[V any] struct {
+	trie    BTrie[V]
+	gets    atomic.Int64
+	puts    atomic.Int64
+	deletes atomic.Int64
+	ranges  atomic.Int64
+}
+
+// PublishExpvar returns a Counted wrapping trie, and publishes an [expvar.Var] with the given name
+// which reports the following metrics as a JSON object.
+//
+//   - "len": the number of entries
+//   - "nodes": the number of nodes in an uncompressed trie having the same keys, including the root
+//   - "bytes": an estimate of the bytes needed for the keys and values, not including any overhead
+//     of the trie's implementation, as nodes - 1 key bytes plus the size of V for every entry
+//   - "gets", "puts", "deletes", "ranges": the number of calls to each method of the returned Counted
+//
+// The metrics other than the counts are computed by traversing the entire trie whenever the Var is read,
+// so if the Var may be read while trie is being mutated, trie must be safe for concurrent use.
+// Mutations of trie not made through the returned Counted are not counted.
+// PublishExpvar will panic if name is already published, as [expvar.Publish] does.
+func PublishExpvar[V any](name string, trie BTrie[V]) *Counted[V] {
+	c := &Counted[V]{trie: trie}
+	expvar.Publish(name, expvar.Func(func() any {
+		length, nodes := countNodes(trie)
+		var zero V
+		return map[string]int64{
+			"len":     int64(length),
+			"nodes":   int64(nodes),
+			"bytes":   int64(nodes-1) + int64(length)*int64(unsafe.Sizeof(zero)),
+			"gets":    c.gets.Load(),
+			"puts":    c.puts.Load(),
+			"deletes": c.deletes.Load(),
+			"ranges":  c.ranges.Load(),
+		}
+	}))
+	return c
+}
+
+// Returns the number of entries in trie, and the number of nodes in an uncompressed trie having the same keys.
+func countNodes[V any](trie BTrie[V]) (int, int) {
+	length, nodes := 0, 1
+	var prev []byte
+	for key := range All(trie) {
+		length++
+		nodes += len(key) - CommonPrefixLen(prev, key)
+		prev = key
+	}
+	return length, nodes
+}
+
+func (c *Counted[V]) Get(key []byte) (V, bool) {
+	c.gets.Add(1)
+	return c.trie.Get(key)
+}
+
+func (c *Counted[V]) Put(key []byte, value V) (V, bool) {
+	c.puts.Add(1)
+	return c.trie.Put(key, value)
+}
+
+func (c *Counted[V]) Delete(key []byte) (V, bool) {
+	c.deletes.Add(1)
+	return c.trie.Delete(key)
+}
+
+func (c *Counted[V]) Range(bounds *Bounds) iter.Seq2[[]byte, V] {
+	c.ranges.Add(1)
+	return c.trie.Range(bounds)
+}
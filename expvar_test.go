@@ -0,0 +1,39 @@
+package btrie_test
+
+import (
+	"encoding/json"
+	"expvar"
+	"testing"
+
+	"github.com/phiryll/btrie"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPublishExpvar(t *testing.T) {
+	t.Parallel()
+	trie := btrie.PublishExpvar("btrie_test", btrie.NewArrayTrie[byte]())
+	trie.Put([]byte{1, 2, 3}, 1)
+	trie.Put([]byte{1, 2}, 2)
+	trie.Put([]byte{1, 5}, 3)
+	trie.Delete([]byte{1, 5})
+	trie.Put([]byte{4}, 4)
+	trie.Get([]byte{1, 2})
+	for range btrie.All[byte](trie) {
+	}
+
+	var metrics map[string]int64
+	require.NoError(t, json.Unmarshal([]byte(expvar.Get("btrie_test").String()), &metrics))
+	assert.Equal(t, map[string]int64{
+		"len":     3,
+		"nodes":   5, // root, 01, 0102, 010203, 04
+		"bytes":   4 + 3,
+		"gets":    1,
+		"puts":    4,
+		"deletes": 1,
+		"ranges":  1,
+	}, metrics)
+	assert.Panics(t, func() {
+		btrie.PublishExpvar("btrie_test", btrie.NewArrayTrie[byte]())
+	})
+}
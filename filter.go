@@ -0,0 +1,37 @@
+package btrie
+
+import "iter"
+
+// FilterView returns a read-only view of the entries in trie for which keep returns true.
+// FilterView will panic if keep is nil.
+func FilterView[V any](trie BTrie[V], keep func(key []byte, value V) bool) ReadOnlyTrie[V] {
+	if keep == nil {
+		panic("keep must be non-nil")
+	}
+	return &filteredTrie[V]{trie, keep}
+}
+
+type filteredTrie[V any] struct {
+	trie BTrie[V]
+	keep func([]byte, V) bool
+}
+
+func (t *filteredTrie[V]) Get(key []byte) (V, bool) {
+	value, ok := t.trie.Get(key)
+	if !ok || !t.keep(key, value) {
+		var zero V
+		return zero, false
+	}
+	return value, true
+}
+
+func (t *filteredTrie[V]) Range(bounds *Bounds) iter.Seq2[[]byte, V] {
+	itr := t.trie.Range(bounds)
+	return func(yield func([]byte, V) bool) {
+		for k, v := range itr {
+			if t.keep(k, v) && !yield(k, v) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package btrie_test
+
+import (
+	"testing"
+
+	"github.com/phiryll/btrie"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilterView(t *testing.T) {
+	t.Parallel()
+	assert.Panics(t, func() { btrie.FilterView(btrie.NewArrayTrie[byte](), nil) })
+	keep := func(key []byte, value byte) bool {
+		return len(key)%2 == 0 || value%3 == 0
+	}
+	for _, test := range createTestTries(rangeTestConfigs) {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			view := btrie.FilterView[byte](test.trie, keep)
+			for k, v := range test.config.entries {
+				actual, ok := view.Get([]byte(k))
+				assert.Equal(t, keep([]byte(k), v), ok)
+				if ok {
+					assert.Equal(t, v, actual)
+				} else {
+					assert.Zero(t, actual)
+				}
+			}
+			for _, bounds := range append(test.config.forward, test.config.reverse...) {
+				expected := []entry{}
+				for k, v := range test.trie.Range(&bounds) {
+					if keep(k, v) {
+						expected = append(expected, entry{k, v})
+					}
+				}
+				assert.Equal(t, expected, collect(view.Range(&bounds)), "%s", bounds)
+			}
+			// need an early yield for test coverage
+			for range view.Range(forwardAll) {
+				break
+			}
+		})
+	}
+}
@@ -0,0 +1,285 @@
+package btrie
+
+import (
+	"bytes"
+	"fmt"
+	"iter"
+	"slices"
+	"strings"
+)
+
+// FixedKeyTrie is a BTrie for keys which all have the same length, such as hashes or UUIDs.
+// Because no key is a prefix of another, interior nodes have no values or terminal flags,
+// and the nodes one level above the leaves hold the last key bytes and the values directly,
+// in slices sorted by key byte, instead of having a child node per key.
+//
+// Put will panic if the key does not have the trie's key length.
+// Get and Delete treat such a key as absent, and Range never yields one.
+// FixedKeyTrie implements [BTrie], and is not safe for concurrent use.
+type FixedKeyTrie[V any] struct {
+	root   *fixedKeyNode[V]
+	keyLen int
+}
+
+// Nodes at depth keyLen - 1 only use lastBytes and values, all others only use children and numChildren.
+type fixedKeyNode[V any] struct {
+	children    *[256]*fixedKeyNode[V]
+	lastBytes   []byte // sorted
+	values      []V    // values[i] is the value for lastBytes[i]
+	numChildren uint16
+}
+
+// NewFixedKeyTrie returns a new, empty FixedKeyTrie for keys of length keyLen.
+// NewFixedKeyTrie will panic if keyLen is not positive.
+func NewFixedKeyTrie[V any](keyLen int) *FixedKeyTrie[V] {
+	if keyLen < 1 {
+		panic("keyLen must be positive")
+	}
+	return &FixedKeyTrie[V]{&fixedKeyNode[V]{}, keyLen}
+}
+
+// KeyLen returns the length of every key in t.
+func (t *FixedKeyTrie[V]) KeyLen() int {
+	return t.keyLen
+}
+
+func (t *FixedKeyTrie[V]) Get(key []byte) (V, bool) {
+	if key == nil {
+		panic("key must be non-nil")
+	}
+	var zero V
+	if len(key) != t.keyLen {
+		return zero, false
+	}
+	n := t.root
+	for _, keyByte := range key[:t.keyLen-1] {
+		if n.children == nil {
+			return zero, false
+		}
+		n = n.children[keyByte]
+		if n == nil {
+			return zero, false
+		}
+	}
+	i, found := slices.BinarySearch(n.lastBytes, key[t.keyLen-1])
+	if !found {
+		return zero, false
+	}
+	return n.values[i], true
+}
+
+func (t *FixedKeyTrie[V]) Put(key []byte, value V) (V, bool) {
+	if key == nil {
+		panic("key must be non-nil")
+	}
+	if len(key) != t.keyLen {
+		panic(fmt.Sprintf("key length %d must be %d", len(key), t.keyLen))
+	}
+	n := t.root
+	for _, keyByte := range key[:t.keyLen-1] {
+		if n.children == nil {
+			n.children = &[256]*fixedKeyNode[V]{}
+		}
+		child := n.children[keyByte]
+		if child == nil {
+			child = &fixedKeyNode[V]{}
+			n.children[keyByte] = child
+			n.numChildren++
+		}
+		n = child
+	}
+	lastByte := key[t.keyLen-1]
+	i, found := slices.BinarySearch(n.lastBytes, lastByte)
+	if found {
+		prev := n.values[i]
+		n.values[i] = value
+		return prev, true
+	}
+	n.lastBytes = slices.Insert(n.lastBytes, i, lastByte)
+	n.values = slices.Insert(n.values, i, value)
+	var zero V
+	return zero, false
+}
+
+func (t *FixedKeyTrie[V]) Delete(key []byte) (V, bool) {
+	if key == nil {
+		panic("key must be non-nil")
+	}
+	var zero V
+	if len(key) != t.keyLen {
+		return zero, false
+	}
+	// If the last node becomes empty, remove the subtree rooted at prune.children[pruneByte].
+	var prune *fixedKeyNode[V]
+	var pruneByte byte
+	n := t.root
+	for i, keyByte := range key[:t.keyLen-1] {
+		if n.children == nil || n.children[keyByte] == nil {
+			return zero, false
+		}
+		// If either n is the root or n has more than one child, then n itself cannot be pruned.
+		if i == 0 || n.numChildren > 1 {
+			prune, pruneByte = n, keyByte
+		}
+		n = n.children[keyByte]
+	}
+	i, found := slices.BinarySearch(n.lastBytes, key[t.keyLen-1])
+	if !found {
+		return zero, false
+	}
+	prev := n.values[i]
+	n.lastBytes = slices.Delete(n.lastBytes, i, i+1)
+	n.values = slices.Delete(n.values, i, i+1)
+	if len(n.lastBytes) == 0 && prune != nil {
+		prune.children[pruneByte] = nil
+		prune.numChildren--
+		if prune.numChildren == 0 {
+			prune.children = nil
+		}
+	}
+	return prev, true
+}
+
+// A node being traversed by Range, and the remaining children of that node to traverse.
+// For the nodes holding values, next and stop are indexes into lastBytes instead of key bytes.
+// This serves the same purpose as arrayTrieFrame.
+type fixedKeyFrame[V any] struct {
+	node *fixedKeyNode[V]
+	next int
+	stop int // inclusive
+}
+
+func (t *FixedKeyTrie[V]) Range(bounds *Bounds) iter.Seq2[[]byte, V] {
+	bounds = bounds.Clone()
+	step := 1
+	if bounds.IsReverse {
+		step = -1
+	}
+	return func(yield func([]byte, V) bool) {
+		// invariant: key[:len(stack)-1] = path from root to top.node
+		key := make([]byte, t.keyLen)
+		stack := make([]fixedKeyFrame[V], 0, t.keyLen)
+		stack = append(stack, t.frame(t.root, bounds, key[:0]))
+		for len(stack) > 0 {
+			top := &stack[len(stack)-1]
+			if (top.next-top.stop)*step > 0 {
+				stack = stack[:len(stack)-1]
+				continue
+			}
+			next := top.next
+			top.next += step
+			depth := len(stack) - 1
+			if depth < t.keyLen-1 {
+				if child := top.node.children[next]; child != nil {
+					key[depth] = byte(next)
+					stack = append(stack, t.frame(child, bounds, key[:depth+1]))
+				}
+				continue
+			}
+			key[depth] = top.node.lastBytes[next]
+			cmp := bounds.Compare(key)
+			if cmp > 0 {
+				return
+			}
+			if cmp == 0 && !yield(bytes.Clone(key), top.node.values[next]) {
+				return
+			}
+		}
+	}
+}
+
+// Returns the frame for traversing the children of n, whose path from the root is partialKey.
+func (t *FixedKeyTrie[V]) frame(n *fixedKeyNode[V], bounds *Bounds, partialKey []byte) fixedKeyFrame[V] {
+	// An empty frame, next is already past stop in either direction.
+	empty := fixedKeyFrame[V]{n, 1, 0}
+	if bounds.IsReverse {
+		empty = fixedKeyFrame[V]{n, -1, 0}
+	}
+	start, stop, ok := bounds.childBounds(partialKey)
+	if !ok {
+		return empty
+	}
+	if len(partialKey) < t.keyLen-1 {
+		if n.children == nil {
+			return empty
+		}
+		return fixedKeyFrame[V]{n, int(start), int(stop)}
+	}
+	// Convert the key bytes to indexes into lastBytes.
+	if bounds.IsReverse {
+		next, found := slices.BinarySearch(n.lastBytes, start)
+		if !found {
+			next--
+		}
+		last, _ := slices.BinarySearch(n.lastBytes, stop)
+		return fixedKeyFrame[V]{n, next, last}
+	}
+	next, _ := slices.BinarySearch(n.lastBytes, start)
+	last, found := slices.BinarySearch(n.lastBytes, stop)
+	if !found {
+		last--
+	}
+	return fixedKeyFrame[V]{n, next, last}
+}
+
+func (t *FixedKeyTrie[V]) String() string {
+	var s strings.Builder
+	t.printTo(&s, -1)
+	return s.String()
+}
+
+func (t *FixedKeyTrie[V]) Format(f fmt.State, verb rune) {
+	formatTrie(f, verb, t)
+}
+
+func (t *FixedKeyTrie[V]) printTo(s *strings.Builder, levels int) {
+	s.WriteString("[]\n")
+	t.printChildren(s, t.root, "  ", 0, levels)
+}
+
+//nolint:revive
+func (t *FixedKeyTrie[V]) printChildren(s *strings.Builder, n *fixedKeyNode[V], indent string, depth, levels int) {
+	hasChildren := n.children != nil || len(n.lastBytes) > 0
+	if hasChildren && levels == 0 {
+		s.WriteString(indent + "...\n")
+		return
+	}
+	if depth == t.keyLen-1 {
+		for i, lastByte := range n.lastBytes {
+			fmt.Fprintf(s, "%s%02X: %v\n", indent, lastByte, n.values[i])
+		}
+		return
+	}
+	if n.children == nil {
+		return
+	}
+	for i, child := range n.children {
+		if child != nil {
+			fmt.Fprintf(s, "%s%02X\n", indent, i)
+			t.printChildren(s, child, indent+"  ", depth+1, levels-1)
+		}
+	}
+}
+
+func (t *FixedKeyTrie[V]) stats() (int, int) {
+	size := t.size(t.root, 0)
+	if size == 0 {
+		return 0, 0
+	}
+	return size, t.keyLen
+}
+
+func (t *FixedKeyTrie[V]) size(n *fixedKeyNode[V], depth int) int {
+	if depth == t.keyLen-1 {
+		return len(n.lastBytes)
+	}
+	size := 0
+	if n.children != nil {
+		for _, child := range n.children {
+			if child != nil {
+				size += t.size(child, depth+1)
+			}
+		}
+	}
+	return size
+}
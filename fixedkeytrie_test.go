@@ -0,0 +1,129 @@
+package btrie_test
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/phiryll/btrie"
+	"github.com/phiryll/btrie/btrietest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFixedKeyTrie(t *testing.T) {
+	t.Parallel()
+	for _, keyLen := range []int{1, 2, 3} {
+		t.Run(fmt.Sprintf("keyLen=%d", keyLen), func(t *testing.T) {
+			t.Parallel()
+			random := rand.New(rand.NewSource(int64(7193 + keyLen)))
+			randomKey := func(n int) []byte {
+				key := make([]byte, n)
+				for i := range key {
+					// A small alphabet, so that keys share prefixes.
+					key[i] = byte(random.Intn(6) * 50)
+				}
+				return key
+			}
+			trie := btrie.NewFixedKeyTrie[byte](keyLen)
+			assert.Equal(t, keyLen, trie.KeyLen())
+			ref := newReference()
+			for i := range 2000 {
+				key := randomKey(keyLen)
+				if random.Intn(3) == 0 {
+					prev, ok := trie.Delete(key)
+					refPrev, refOk := ref.Delete(key)
+					assert.Equal(t, refOk, ok)
+					assert.Equal(t, refPrev, prev)
+				} else {
+					prev, ok := trie.Put(key, byte(i))
+					refPrev, refOk := ref.Put(key, byte(i))
+					assert.Equal(t, refOk, ok)
+					assert.Equal(t, refPrev, prev)
+				}
+				key = randomKey(keyLen)
+				value, ok := trie.Get(key)
+				refValue, refOk := ref.Get(key)
+				assert.Equal(t, refOk, ok)
+				assert.Equal(t, refValue, value)
+			}
+			for range 200 {
+				begin, end := randomKey(random.Intn(keyLen+1)), randomKey(random.Intn(keyLen+1))
+				if random.Intn(4) == 0 {
+					begin = nil
+				}
+				for _, bounds := range []*btrie.Bounds{
+					btrie.From(begin).To(nil),
+					btrie.From(begin).DownTo(nil),
+				} {
+					assert.Equal(t, collect(ref.Range(bounds)), collect(trie.Range(bounds)), "%s", bounds)
+				}
+				if begin != nil && !bytes.Equal(begin, end) {
+					lo, hi := begin, end
+					if bytes.Compare(lo, hi) > 0 {
+						lo, hi = hi, lo
+					}
+					for _, bounds := range []*btrie.Bounds{btrie.From(lo).To(hi), btrie.From(hi).DownTo(lo)} {
+						assert.Equal(t, collect(ref.Range(bounds)), collect(trie.Range(bounds)), "%s", bounds)
+					}
+				}
+			}
+			// need an early yield for test coverage
+			for range trie.Range(forwardAll) {
+				break
+			}
+
+			// Printing must match an equivalent trie which is not specialized.
+			ptrTrie := btrie.NewPointerTrie[byte]()
+			for k, v := range ref.Range(forwardAll) {
+				ptrTrie.Put(k, v)
+			}
+			assert.Equal(t, fmt.Sprint(ptrTrie), fmt.Sprint(trie))
+			assert.Equal(t, fmt.Sprintf("%#v", ptrTrie), fmt.Sprintf("%#v", trie))
+			assert.Equal(t, fmt.Sprintf("%+.2v", ptrTrie), fmt.Sprintf("%+.2v", trie))
+
+			// Deleting everything must prune every node.
+			for k := range ref.Range(forwardAll) {
+				trie.Delete(k)
+			}
+			assert.Equal(t, "[]\n", trie.String())
+			assert.Equal(t, "{size: 0, depth: 0}", fmt.Sprint(trie))
+		})
+	}
+}
+
+func TestFixedKeyTrieKeyLength(t *testing.T) {
+	t.Parallel()
+	assert.Panics(t, func() { btrie.NewFixedKeyTrie[byte](0) })
+	trie := btrie.NewFixedKeyTrie[byte](2)
+	trie.Put([]byte{1, 2}, 3)
+	assert.Panics(t, func() { trie.Put([]byte{1}, 0) })
+	assert.Panics(t, func() { trie.Put([]byte{1, 2, 3}, 0) })
+	assert.Panics(t, func() { trie.Put(nil, 0) })
+	assert.Panics(t, func() { trie.Get(nil) })
+	assert.Panics(t, func() { trie.Delete(nil) })
+	for _, key := range [][]byte{{}, {1}, {1, 2, 3}} {
+		_, ok := trie.Get(key)
+		assert.False(t, ok)
+		_, ok = trie.Delete(key)
+		assert.False(t, ok)
+	}
+	assert.Equal(t, []entry{{[]byte{1, 2}, 3}}, collect(trie.Range(forwardAll)))
+}
+
+// FixedKeyTrie has its own key constraints, so it is not in allDefs.
+func TestFixedKeyTrieKeys(t *testing.T) {
+	t.Parallel()
+	// The empty key is never valid, but is still less than every other key when used in bounds.
+	trie := btrie.NewFixedKeyTrie[byte](3)
+	assert.Panics(t, func() { trie.Put([]byte{}, 0) })
+	trie.Put([]byte{0, 0, 0}, 1)
+	_, ok := trie.Get([]byte{})
+	assert.False(t, ok)
+	_, ok = trie.Delete([]byte{})
+	assert.False(t, ok)
+	assert.Equal(t, []entry{{[]byte{0, 0, 0}, 1}}, collect(trie.Range(From([]byte{}).To(nil))))
+	assert.Equal(t, []entry{}, collect(trie.Range(From(nil).To([]byte{}))))
+	assert.Equal(t, []entry{}, collect(trie.Range(From([]byte{0, 0}).DownTo([]byte{}))))
+	btrietest.CheckKeysNotRetained(t, btrie.NewFixedKeyTrie[byte](3), 1)
+}
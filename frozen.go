@@ -0,0 +1,315 @@
+package btrie
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"math"
+	"sort"
+)
+
+// The paged form of a BTrie written by EncodeFrozen is:
+//
+//	pages            one or more units of pageSize bytes each, per page
+//	index
+//	footer  [28]byte
+//
+// Each page holds a run of consecutive entries, so that the entries of a subtree are in as few pages as possible:
+//
+//	count  uvarint
+//	count entries, in increasing key order, each:
+//	  key    uvarint size, then bytes
+//	  value  uvarint size, then bytes as encoded by the Codec
+//	zero padding to a multiple of pageSize
+//
+// A page only spans more than one unit if its first entry alone does not fit in one unit.
+// The index locates the pages, so that a lookup only needs to read the page which could hold its key:
+//
+//	pageCount  uvarint
+//	pageCount pages, in increasing key order, each:
+//	  firstKey  uvarint size, then bytes
+//	  units     uvarint, the number of pageSize units the page spans
+//
+// The footer is fixed-size, so that it can be read first:
+//
+//	indexOffset  uint64, big-endian
+//	indexSize    uint64, big-endian
+//	pageSize     uint32, big-endian
+//	version      uint32, big-endian
+//	magic        [4]byte  "BTRP"
+const (
+	frozenMagic      = "BTRP"
+	frozenVersion    = 1
+	frozenFooterSize = 28
+)
+
+// EncodeFrozen writes the entries of trie to w in a paged form, using codec to encode the values.
+// Entries are packed in increasing key order into pages of pageSize bytes, followed by an index of the pages.
+// The written data can be opened by [OpenFrozen] without reading more than the index.
+// EncodeFrozen will panic if pageSize is not positive or does not fit in a uint32.
+func EncodeFrozen[V any](w io.Writer, trie BTrie[V], codec Codec[V], pageSize int) error {
+	if pageSize <= 0 || pageSize > math.MaxUint32 {
+		panic("pageSize must be positive and fit in a uint32")
+	}
+	bw := bufio.NewWriter(w)
+	var index, page, entryBuf, valueBuf []byte
+	var offset uint64
+	count, pageCount := 0, 0
+	flush := func() error {
+		if count == 0 {
+			return nil
+		}
+		buf := binary.AppendUvarint(nil, uint64(count))
+		buf = append(buf, page...)
+		units := (len(buf) + pageSize - 1) / pageSize
+		buf = append(buf, make([]byte, units*pageSize-len(buf))...)
+		if _, err := bw.Write(buf); err != nil {
+			return err
+		}
+		index = binary.AppendUvarint(index, uint64(units))
+		offset += uint64(len(buf))
+		page = page[:0]
+		count = 0
+		return nil
+	}
+	for key, value := range All(trie) {
+		var err error
+		valueBuf, err = codec.AppendValue(valueBuf[:0], value)
+		if err != nil {
+			return err
+		}
+		entryBuf = appendSized(entryBuf[:0], key)
+		entryBuf = appendSized(entryBuf, valueBuf)
+		if count > 0 && binary.MaxVarintLen64+len(page)+len(entryBuf) > pageSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+		if count == 0 {
+			index = appendSized(index, key)
+			pageCount++
+		}
+		page = append(page, entryBuf...)
+		count++
+	}
+	if err := flush(); err != nil {
+		return err
+	}
+	buf := binary.AppendUvarint(nil, uint64(pageCount))
+	buf = append(buf, index...)
+	footer := binary.BigEndian.AppendUint64(nil, offset)
+	footer = binary.BigEndian.AppendUint64(footer, uint64(len(buf)))
+	footer = binary.BigEndian.AppendUint32(footer, uint32(pageSize))
+	footer = binary.BigEndian.AppendUint32(footer, frozenVersion)
+	footer = append(footer, frozenMagic...)
+	if _, err := bw.Write(append(buf, footer...)); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// FrozenFile is an immutable trie over data written by [EncodeFrozen], which reads pages only when they are needed.
+// Get reads at most the one page which could hold its key, and Range reads only the pages overlapping its bounds,
+// so cold lookups in a large file cost a single page read instead of a scan.
+// Pages which have been read are decoded and kept in memory.
+//
+// Get and Range cannot return errors, so if reading or decoding a page fails,
+// Get reports the key as absent, Range stops early, and the first such error is reported by [FrozenFile.Err].
+// FrozenFile implements [ReadOnlyTrie], and is not safe for concurrent use.
+type FrozenFile[V any] struct {
+	r     io.ReaderAt
+	codec Codec[V]
+	// The first key of each page, and the offset of each page with a final offset for the end of the pages.
+	firstKeys [][]byte
+	offsets   []int64
+	pages     map[int]*frozenTrie[V]
+	err       error
+}
+
+// OpenFrozen returns a FrozenFile reading data written by [EncodeFrozen] from r, which has the given size,
+// using codec to decode the values. Only the footer and index are read by OpenFrozen.
+// r must not be modified while the FrozenFile is in use.
+// Errors caused by malformed data wrap [ErrInvalidFormat].
+func OpenFrozen[V any](r io.ReaderAt, size int64, codec Codec[V]) (*FrozenFile[V], error) {
+	if size < frozenFooterSize {
+		return nil, fmt.Errorf("%w: frozen file too short", ErrInvalidFormat)
+	}
+	footer := make([]byte, frozenFooterSize)
+	if err := readFullAt(r, footer, size-frozenFooterSize); err != nil {
+		return nil, err
+	}
+	if magic := footer[24:]; string(magic) != frozenMagic {
+		return nil, fmt.Errorf("%w: bad magic %q", ErrInvalidFormat, magic)
+	}
+	if version := binary.BigEndian.Uint32(footer[20:]); version != frozenVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidFormat, version)
+	}
+	indexOffset := binary.BigEndian.Uint64(footer)
+	indexSize := binary.BigEndian.Uint64(footer[8:])
+	pageSize := int64(binary.BigEndian.Uint32(footer[16:]))
+	if pageSize == 0 || indexOffset > uint64(size) || indexSize != uint64(size-frozenFooterSize)-indexOffset {
+		return nil, fmt.Errorf("%w: bad frozen file footer", ErrInvalidFormat)
+	}
+	br := bufio.NewReader(io.NewSectionReader(r, int64(indexOffset), int64(indexSize)))
+	pageCount, err := binary.ReadUvarint(br)
+	if err != nil {
+		return nil, formatError(err)
+	}
+	f := &FrozenFile[V]{r: r, codec: codec, offsets: []int64{0}, pages: map[int]*frozenTrie[V]{}}
+	for range pageCount {
+		firstKey, err := readSized(br, nil)
+		if err != nil {
+			return nil, err
+		}
+		units, err := binary.ReadUvarint(br)
+		if err != nil {
+			return nil, formatError(err)
+		}
+		end := f.offsets[len(f.offsets)-1] + int64(units)*pageSize
+		if units == 0 || end > int64(indexOffset) ||
+			(len(f.firstKeys) > 0 && bytes.Compare(f.firstKeys[len(f.firstKeys)-1], firstKey) >= 0) {
+			return nil, fmt.Errorf("%w: bad frozen file index", ErrInvalidFormat)
+		}
+		f.firstKeys = append(f.firstKeys, firstKey)
+		f.offsets = append(f.offsets, end)
+	}
+	return f, nil
+}
+
+// Err returns the first error encountered while reading a page, or nil if there was none.
+func (f *FrozenFile[V]) Err() error {
+	return f.err
+}
+
+// PagesLoaded returns the number of distinct pages which have been read.
+func (f *FrozenFile[V]) PagesLoaded() int {
+	return len(f.pages)
+}
+
+// Returns the index of the page which could hold key, or -1 if key is before every page.
+func (f *FrozenFile[V]) pageOf(key []byte) int {
+	return sort.Search(len(f.firstKeys), func(i int) bool {
+		return bytes.Compare(f.firstKeys[i], key) > 0
+	}) - 1
+}
+
+// Returns page i, reading it if it has not been read, or nil if that fails.
+func (f *FrozenFile[V]) page(i int) *frozenTrie[V] {
+	if page, ok := f.pages[i]; ok {
+		return page
+	}
+	page, err := f.readPage(i)
+	if err != nil {
+		if f.err == nil {
+			f.err = err
+		}
+		return nil
+	}
+	f.pages[i] = page
+	return page
+}
+
+func (f *FrozenFile[V]) readPage(i int) (*frozenTrie[V], error) {
+	data := make([]byte, f.offsets[i+1]-f.offsets[i])
+	if err := readFullAt(f.r, data, f.offsets[i]); err != nil {
+		return nil, err
+	}
+	br := bytes.NewReader(data)
+	count, err := binary.ReadUvarint(br)
+	if err != nil {
+		return nil, formatError(err)
+	}
+	// keys must be non-nil, so that empty keys are as well.
+	page := &frozenTrie[V]{keys: []byte{}}
+	var buf []byte
+	for range count {
+		begin := len(page.keys)
+		page.keys, err = readSized(br, page.keys)
+		if err != nil {
+			return nil, err
+		}
+		key := page.keys[begin:]
+		if (len(page.ends) == 0 && !bytes.Equal(key, f.firstKeys[i])) ||
+			(len(page.ends) > 0 && bytes.Compare(page.key(len(page.ends)-1), key) >= 0) {
+			return nil, fmt.Errorf("%w: keys out of order in frozen page %d", ErrInvalidFormat, i)
+		}
+		page.ends = append(page.ends, len(page.keys))
+		buf, err = readSized(br, buf[:0])
+		if err != nil {
+			return nil, err
+		}
+		value, err := f.codec.DecodeValue(buf)
+		if err != nil {
+			return nil, err
+		}
+		page.values = append(page.values, value)
+	}
+	return page, nil
+}
+
+func (f *FrozenFile[V]) Get(key []byte) (V, bool) {
+	if key == nil {
+		panic("key must be non-nil")
+	}
+	var zero V
+	i := f.pageOf(key)
+	if i < 0 {
+		return zero, false
+	}
+	page := f.page(i)
+	if page == nil {
+		return zero, false
+	}
+	return page.Get(key)
+}
+
+func (f *FrozenFile[V]) Range(bounds *Bounds) iter.Seq2[[]byte, V] {
+	bounds = bounds.Clone()
+	return func(yield func([]byte, V) bool) {
+		step, i := 1, 0
+		if bounds.IsReverse {
+			step, i = -1, len(f.firstKeys)-1
+		}
+		if bounds.Begin != nil {
+			i = f.pageOf(bounds.Begin)
+			if !bounds.IsReverse {
+				i = max(i, 0)
+			}
+		}
+		for ; i >= 0 && i < len(f.firstKeys); i += step {
+			// Forward, later pages only have keys beyond End if this page's first key is.
+			if !bounds.IsReverse && bounds.Compare(f.firstKeys[i]) > 0 {
+				return
+			}
+			page := f.page(i)
+			if page == nil {
+				return
+			}
+			for key, value := range page.Range(bounds) {
+				if !yield(key, value) {
+					return
+				}
+			}
+			// Reverse, earlier pages only have keys beyond End if this page's first key is.
+			if bounds.IsReverse && bounds.Compare(f.firstKeys[i]) > 0 {
+				return
+			}
+		}
+	}
+}
+
+// Reads exactly len(buf) bytes from r at offset, treating a short read as malformed data.
+func readFullAt(r io.ReaderAt, buf []byte, offset int64) error {
+	n, err := r.ReadAt(buf, offset)
+	if n == len(buf) {
+		return nil
+	}
+	if err == nil || errors.Is(err, io.EOF) {
+		return fmt.Errorf("%w: unexpected EOF", ErrInvalidFormat)
+	}
+	return err
+}
@@ -0,0 +1,149 @@
+package btrie_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/phiryll/btrie"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFrozenFile(t *testing.T) {
+	t.Parallel()
+	for _, test := range createTestTries(rangeTestConfigs) {
+		for _, pageSize := range []int{1, 16, 1 << 12} {
+			t.Run(fmt.Sprintf("%s/page=%d", test.name, pageSize), func(t *testing.T) {
+				t.Parallel()
+				var buf bytes.Buffer
+				require.NoError(t, btrie.EncodeFrozen(&buf, test.trie, byteCodec{}, pageSize))
+				frozen, err := btrie.OpenFrozen[byte](bytes.NewReader(buf.Bytes()), int64(buf.Len()), byteCodec{})
+				require.NoError(t, err)
+				assert.Zero(t, frozen.PagesLoaded())
+				for k, v := range test.config.entries {
+					actual, ok := frozen.Get([]byte(k))
+					assert.True(t, ok)
+					assert.Equal(t, v, actual)
+				}
+				for _, bounds := range append(test.config.forward, test.config.reverse...) {
+					assert.Equal(t, collect(test.trie.Range(&bounds)), collect(frozen.Range(&bounds)), "%s", bounds)
+				}
+				for range frozen.Range(forwardAll) {
+					break
+				}
+				require.NoError(t, frozen.Err())
+			})
+		}
+	}
+}
+
+func TestFrozenFileLazy(t *testing.T) {
+	t.Parallel()
+	trie := btrie.NewArrayTrie[byte]()
+	for i := range 100 {
+		trie.Put([]byte{byte(i), 0xFF}, byte(i))
+	}
+	var buf bytes.Buffer
+	// Each entry is 5 bytes, so each page holds 10 entries.
+	require.NoError(t, btrie.EncodeFrozen(&buf, trie, byteCodec{}, 60))
+	frozen, err := btrie.OpenFrozen[byte](bytes.NewReader(buf.Bytes()), int64(buf.Len()), byteCodec{})
+	require.NoError(t, err)
+
+	value, ok := frozen.Get([]byte{42, 0xFF})
+	assert.True(t, ok)
+	assert.Equal(t, byte(42), value)
+	_, ok = frozen.Get([]byte{43})
+	assert.False(t, ok)
+	assert.Equal(t, 1, frozen.PagesLoaded())
+	_, ok = frozen.Get([]byte{})
+	assert.False(t, ok)
+	assert.Equal(t, 1, frozen.PagesLoaded())
+
+	assert.Len(t, collect(frozen.Range(From([]byte{55}).To([]byte{65}))), 10)
+	assert.Equal(t, 3, frozen.PagesLoaded())
+	assert.Len(t, collect(frozen.Range(From([]byte{75}).DownTo([]byte{68}))), 7)
+	assert.Equal(t, 4, frozen.PagesLoaded())
+	assert.Len(t, collect(frozen.Range(forwardAll)), 100)
+	assert.Equal(t, 10, frozen.PagesLoaded())
+	require.NoError(t, frozen.Err())
+}
+
+func TestFrozenFileLargeValue(t *testing.T) {
+	t.Parallel()
+	trie := btrie.NewArrayTrie[[]byte]()
+	trie.Put([]byte{1}, bytes.Repeat([]byte{7}, 100))
+	trie.Put([]byte{2}, []byte{8})
+	var buf bytes.Buffer
+	require.NoError(t, btrie.EncodeFrozen(&buf, trie, btrie.BytesCodec{}, 16))
+	frozen, err := btrie.OpenFrozen[[]byte](bytes.NewReader(buf.Bytes()), int64(buf.Len()), btrie.BytesCodec{})
+	require.NoError(t, err)
+	assertSameBytes(t, map[string][]byte{"\x01": bytes.Repeat([]byte{7}, 100), "\x02": {8}}, frozenBTrie[[]byte]{frozen})
+}
+
+// frozenBTrie adapts a FrozenFile to the BTrie interface for test helpers which only read.
+type frozenBTrie[V any] struct {
+	*btrie.FrozenFile[V]
+}
+
+func (frozenBTrie[V]) Put([]byte, V) (V, bool) {
+	panic("immutable")
+}
+
+func (frozenBTrie[V]) Delete([]byte) (V, bool) {
+	panic("immutable")
+}
+
+func TestFrozenFileErrors(t *testing.T) {
+	t.Parallel()
+	trie := btrie.NewArrayTrie[byte]()
+	trie.Put([]byte{1}, 1)
+	trie.Put([]byte{2}, 2)
+	var buf bytes.Buffer
+	require.NoError(t, btrie.EncodeFrozen(&buf, trie, byteCodec{}, 8))
+	data := buf.Bytes()
+
+	_, err := btrie.OpenFrozen[byte](bytes.NewReader(data), 10, byteCodec{})
+	require.ErrorIs(t, err, btrie.ErrInvalidFormat)
+	bad := bytes.Clone(data)
+	bad[len(bad)-1] = 'X'
+	_, err = btrie.OpenFrozen[byte](bytes.NewReader(bad), int64(len(bad)), byteCodec{})
+	require.ErrorIs(t, err, btrie.ErrInvalidFormat)
+
+	// A corrupted page is only detected when it is read.
+	bad = bytes.Clone(data)
+	bad[1] = 3
+	frozen, err := btrie.OpenFrozen[byte](bytes.NewReader(bad), int64(len(bad)), byteCodec{})
+	require.NoError(t, err)
+	_, ok := frozen.Get([]byte{2})
+	assert.True(t, ok)
+	require.NoError(t, frozen.Err())
+	_, ok = frozen.Get([]byte{1})
+	assert.False(t, ok)
+	require.ErrorIs(t, frozen.Err(), btrie.ErrInvalidFormat)
+	assert.Len(t, collect(frozen.Range(forwardAll)), 0)
+
+	cause := errors.New("cause")
+	frozen, err = btrie.OpenFrozen[byte](failingReaderAt{bytes.NewReader(data), cause}, int64(len(data)), byteCodec{})
+	require.NoError(t, err)
+	_, ok = frozen.Get([]byte{1})
+	assert.False(t, ok)
+	require.ErrorIs(t, frozen.Err(), cause)
+
+	assert.Panics(t, func() { _ = btrie.EncodeFrozen(&buf, trie, byteCodec{}, 0) })
+	assert.Panics(t, func() { frozen.Get(nil) })
+}
+
+// failingReaderAt fails to read at offset 0, where the first page begins.
+type failingReaderAt struct {
+	r   *bytes.Reader
+	err error
+}
+
+func (f failingReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if off == 0 {
+		return 0, f.err
+	}
+	return f.r.ReadAt(p, off)
+}
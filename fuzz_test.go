@@ -8,6 +8,8 @@ import (
 	"testing"
 	"time"
 
+	"github.com/phiryll/btrie"
+	"github.com/phiryll/btrie/btrietest"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -163,7 +165,7 @@ func FuzzRange(f *testing.F) {
 		end := keyForFuzzInputs(endKey, endKeySize)
 		cmp := bytes.Compare(begin, end)
 		if cmp == 0 {
-			end = append(end, 0)
+			end = btrie.NextKey(end)
 		} else if cmp > 0 {
 			begin, end = end, begin
 		}
@@ -174,6 +176,8 @@ func FuzzRange(f *testing.F) {
 		for _, fuzz := range fuzzTries {
 			assert.Equal(t, refForward, collect(fuzz.trie.Range(forward)), "%s: %s", fuzz.def.name, forward)
 			assert.Equal(t, refReverse, collect(fuzz.trie.Range(reverse)), "%s: %s", fuzz.def.name, reverse)
+			btrietest.CheckOrdered[byte](t, fuzz.trie, forward)
+			btrietest.CheckOrdered[byte](t, fuzz.trie, reverse)
 		}
 	})
 }
@@ -0,0 +1,40 @@
+package btrie
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// GoldenDump writes the entries of trie within bounds to w in a canonical text form,
+// in the order they are yielded by Range, for golden-file tests of systems built on a BTrie.
+// Each entry is written as a single line containing the key in upper case hexadecimal, or "[]" for the empty key,
+// a space, and the value formatted by the %v verb of the fmt package and then quoted as if by [strconv.Quote].
+// For example, the entry {0x23, 0xA5}: "x" is written as
+//
+//	23A5 "x"
+//
+// This format is guaranteed not to change, so the output is identical for identical entries
+// regardless of the BTrie implementation or the version of this package.
+// The output is only as stable as the formatting of V by %v, which is stable for the predeclared types.
+// GoldenDump returns the first error from writing to w.
+func GoldenDump[V any](w io.Writer, trie ReadOnlyTrie[V], bounds *Bounds) error {
+	bw := bufio.NewWriter(w)
+	var line []byte
+	for key, value := range trie.Range(bounds) {
+		line = line[:0]
+		if len(key) == 0 {
+			line = append(line, "[]"...)
+		} else {
+			line = fmt.Appendf(line, "%X", key)
+		}
+		line = append(line, ' ')
+		line = strconv.AppendQuote(line, fmt.Sprint(value))
+		line = append(line, '\n')
+		if _, err := bw.Write(line); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,51 @@
+package btrie_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/phiryll/btrie"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGoldenDump(t *testing.T) {
+	t.Parallel()
+	for _, def := range implDefs {
+		t.Run("impl="+def.name, func(t *testing.T) {
+			t.Parallel()
+			trie := def.factory()
+			for _, key := range presentTestKeys {
+				trie.Put(key, byte(len(key)))
+			}
+			var s strings.Builder
+			require.NoError(t, btrie.GoldenDump[byte](&s, trie, forwardAll))
+			assert.Equal(t, `[] "0"
+00 "1"
+23 "1"
+2300 "2"
+23A5 "2"
+23A6 "2"
+C5 "1"
+C500 "2"
+C542 "2"
+C543 "2"
+`, s.String())
+			s.Reset()
+			require.NoError(t, btrie.GoldenDump[byte](&s, trie, From([]byte{0xC5, 0x42}).DownTo([]byte{0x23, 0xA5})))
+			assert.Equal(t, `C542 "2"
+C500 "2"
+C5 "1"
+23A6 "2"
+`, s.String())
+		})
+	}
+
+	trie := btrie.NewArrayTrie[string]()
+	trie.Put([]byte("a"), "two\nlines")
+	var s strings.Builder
+	require.NoError(t, btrie.GoldenDump(&s, trie, forwardAll))
+	assert.Equal(t, "61 \"two\\nlines\"\n", s.String())
+
+	require.ErrorIs(t, btrie.GoldenDump(&failingWriter{0}, trie, forwardAll), errWrite)
+}
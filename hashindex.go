@@ -0,0 +1,58 @@
+package btrie
+
+import "iter"
+
+// HashIndexed is a BTrie with a sidecar hash index from each key to its value, maintained by Put and Delete,
+// so that Get is a single map lookup instead of a traversal of the trie, while Range is still done by the trie.
+// This suits workloads dominated by point lookups which still need ordered scans,
+// at the cost of storing a copy of every key and value in the index.
+// Unlike [MapTrie], the wrapped trie still holds the values, so its other features remain available.
+//
+// Nodes are not indexed, because their addresses change as tries split and merge them.
+// Mutations of the wrapped trie not made through the HashIndexed are not seen by Get.
+// HashIndexed implements [BTrie], and is not safe for concurrent use.
+type HashIndexed[V any] struct {
+	trie  BTrie[V]
+	index map[string]V
+}
+
+// WithHashIndex returns a HashIndexed wrapping trie, whose index is built from the entries already in trie.
+// After this call, trie should only be mutated through the returned HashIndexed.
+func WithHashIndex[V any](trie BTrie[V]) *HashIndexed[V] {
+	index := map[string]V{}
+	for key, value := range All(trie) {
+		index[string(key)] = value
+	}
+	return &HashIndexed[V]{trie, index}
+}
+
+// Len returns the number of entries in h.
+func (h *HashIndexed[V]) Len() int {
+	return len(h.index)
+}
+
+func (h *HashIndexed[V]) Get(key []byte) (V, bool) {
+	if key == nil {
+		panic("key must be non-nil")
+	}
+	value, ok := h.index[string(key)]
+	return value, ok
+}
+
+func (h *HashIndexed[V]) Put(key []byte, value V) (V, bool) {
+	prev, ok := h.trie.Put(key, value)
+	h.index[string(key)] = value
+	return prev, ok
+}
+
+func (h *HashIndexed[V]) Delete(key []byte) (V, bool) {
+	prev, ok := h.trie.Delete(key)
+	if ok {
+		delete(h.index, string(key))
+	}
+	return prev, ok
+}
+
+func (h *HashIndexed[V]) Range(bounds *Bounds) iter.Seq2[[]byte, V] {
+	return h.trie.Range(bounds)
+}
@@ -0,0 +1,50 @@
+package btrie_test
+
+import (
+	"maps"
+	"testing"
+
+	"github.com/phiryll/btrie"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithHashIndex(t *testing.T) {
+	t.Parallel()
+	for _, test := range createTestTries(rangeTestConfigs) {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			indexed := btrie.WithHashIndex[byte](test.trie.Clone())
+			assert.Equal(t, len(test.config.entries), indexed.Len())
+			assertSame(t, test.config.entries, indexed)
+			for _, keys := range test.config.absent {
+				for _, key := range keys {
+					_, ok := indexed.Get(key)
+					assert.False(t, ok)
+				}
+			}
+
+			entries := maps.Clone(test.config.entries)
+			for k, v := range test.config.entries {
+				if v%2 == 0 {
+					prev, ok := indexed.Delete([]byte(k))
+					assert.True(t, ok)
+					assert.Equal(t, v, prev)
+					delete(entries, k)
+				} else {
+					prev, ok := indexed.Put([]byte(k), v+1)
+					assert.True(t, ok)
+					assert.Equal(t, v, prev)
+					entries[k] = v + 1
+				}
+			}
+			for _, keys := range test.config.absent {
+				for _, key := range keys {
+					_, ok := indexed.Delete(key)
+					assert.False(t, ok)
+				}
+			}
+			assert.Equal(t, len(entries), indexed.Len())
+			assertSame(t, entries, indexed)
+		})
+	}
+}
@@ -0,0 +1,573 @@
+package btrie
+
+import (
+	"bytes"
+	"fmt"
+	"iter"
+	"math"
+	"slices"
+	"strings"
+)
+
+// The largest number of entries a bucket may have before it is burst.
+const maxBucketSize = 32
+
+// A HAT trie node is either a bucket or burst.
+// A bucket holds the suffixes of its keys after the path to the node, in sorted order, with their values.
+// A burst node has children and possibly a value, like an array trie node.
+// Only the root may be an empty bucket, and every burst node has at least one child.
+//
+//nolint:govet  // govet wants V first, but that doesn't give the best alignment
+type hatTrieNode[V any] struct {
+	children    *[256]*hatTrieNode[V] // only non-nil if this node is burst
+	bucket      []hatTrieEntry[V]     // sorted by suffix, only used if this node is a bucket
+	value       V                     // valid only if isTerminal is true
+	numChildren uint16                // possible values 0-256, so need the extra byte
+	isTerminal  bool                  // whether value is valid, only used if this node is burst
+}
+
+type hatTrieEntry[V any] struct {
+	suffix []byte
+	value  V
+}
+
+// NewHATTrie returns a new BTrie which stores small subtrees as sorted buckets of key suffixes,
+// bursting a bucket into a node with children when it grows too large.
+// For keys with long distinct suffixes, such as strings, this is typically both faster and smaller
+// than a trie with a node per key byte.
+func NewHATTrie[V any]() BTrie[V] {
+	var zero V
+	return &hatTrieNode[V]{nil, nil, zero, 0, false}
+}
+
+func (n *hatTrieNode[V]) Get(key []byte) (V, bool) {
+	if key == nil {
+		panic("key must be non-nil")
+	}
+	var zero V
+	i := 0
+	for ; n.children != nil; i++ {
+		if i == len(key) {
+			if n.isTerminal {
+				return n.value, true
+			}
+			return zero, false
+		}
+		n = n.children[key[i]]
+		if n == nil {
+			return zero, false
+		}
+	}
+	// n = bucket which would contain key
+	if index, found := n.search(key[i:]); found {
+		return n.bucket[index].value, true
+	}
+	return zero, false
+}
+
+func (n *hatTrieNode[V]) getWithDepth(key []byte) (V, int, bool) {
+	var zero V
+	i := 0
+	for ; n.children != nil; i++ {
+		if i == len(key) {
+			if n.isTerminal {
+				return n.value, i, true
+			}
+			return zero, i, false
+		}
+		child := n.children[key[i]]
+		if child == nil {
+			return zero, i, false
+		}
+		n = child
+	}
+	// n = bucket which would contain key, the suffixes sharing the most with it are adjacent to where it would be
+	rest := key[i:]
+	index, found := n.search(rest)
+	if found {
+		return n.bucket[index].value, len(key), true
+	}
+	depth := 0
+	if index > 0 {
+		depth = CommonPrefixLen(rest, n.bucket[index-1].suffix)
+	}
+	if index < len(n.bucket) {
+		depth = max(depth, CommonPrefixLen(rest, n.bucket[index].suffix))
+	}
+	return zero, i + depth, false
+}
+
+func (n *hatTrieNode[V]) prefixesOf(key []byte) iter.Seq2[[]byte, V] {
+	return func(yield func([]byte, V) bool) {
+		node := n
+		i := 0
+		for ; node.children != nil; i++ {
+			if node.isTerminal && !yield(bytes.Clone(key[:i]), node.value) {
+				return
+			}
+			if i == len(key) {
+				return
+			}
+			node = node.children[key[i]]
+			if node == nil {
+				return
+			}
+		}
+		// The bucket is sorted, so the suffixes which are prefixes of the rest of key are in increasing length order.
+		for _, entry := range node.bucket {
+			if bytes.HasPrefix(key[i:], entry.suffix) && !yield(bytes.Clone(key[:i+len(entry.suffix)]), entry.value) {
+				return
+			}
+		}
+	}
+}
+
+func (n *hatTrieNode[V]) Put(key []byte, value V) (V, bool) {
+	if key == nil {
+		panic("key must be non-nil")
+	}
+	var zero V
+	i := 0
+	for ; n.children != nil; i++ {
+		if i == len(key) {
+			prev, ok := n.value, n.isTerminal
+			n.value = value
+			n.isTerminal = true
+			if ok {
+				return prev, true
+			}
+			return zero, false
+		}
+		child := n.children[key[i]]
+		if child == nil {
+			entry := hatTrieEntry[V]{bytes.Clone(key[i+1:]), value}
+			n.children[key[i]] = &hatTrieNode[V]{nil, []hatTrieEntry[V]{entry}, zero, 0, false}
+			n.numChildren++
+			return zero, false
+		}
+		n = child
+	}
+	// n = bucket which would contain key
+	index, found := n.search(key[i:])
+	if found {
+		prev := n.bucket[index].value
+		n.bucket[index].value = value
+		return prev, true
+	}
+	n.bucket = slices.Insert(n.bucket, index, hatTrieEntry[V]{bytes.Clone(key[i:]), value})
+	if len(n.bucket) > maxBucketSize {
+		n.burst()
+	}
+	return zero, false
+}
+
+func (n *hatTrieNode[V]) Delete(key []byte) (V, bool) {
+	if key == nil {
+		panic("key must be non-nil")
+	}
+	var zero V
+	// If the deleted entry empties its bucket, remove the bucket at prune.children[pruneIndex].
+	var prune *hatTrieNode[V]
+	var pruneIndex byte
+	i := 0
+	for ; n.children != nil; i++ {
+		if i == len(key) {
+			if !n.isTerminal {
+				return zero, false
+			}
+			prev := n.value
+			n.value = zero
+			n.isTerminal = false
+			return prev, true
+		}
+		child := n.children[key[i]]
+		if child == nil {
+			return zero, false
+		}
+		// If either n is the root, or n has a value, or n has more than one child, then n itself cannot be pruned.
+		// If so, move the maybe-pruned subtree to n.children[index].
+		if i == 0 || n.isTerminal || n.numChildren > 1 {
+			prune, pruneIndex = n, key[i]
+		}
+		n = child
+	}
+	// n = bucket which would contain key
+	index, found := n.search(key[i:])
+	if !found {
+		return zero, false
+	}
+	prev := n.bucket[index].value
+	n.bucket = slices.Delete(n.bucket, index, index+1)
+	if len(n.bucket) == 0 && prune != nil {
+		prune.children[pruneIndex] = nil
+		prune.numChildren--
+		if prune.numChildren == 0 {
+			prune.unburst()
+		}
+	}
+	return prev, true
+}
+
+// Returns the index of suffix in bucket n, or where it would be inserted, and whether it was found.
+func (n *hatTrieNode[V]) search(suffix []byte) (int, bool) {
+	return slices.BinarySearchFunc(n.bucket, suffix, func(entry hatTrieEntry[V], suffix []byte) int {
+		return bytes.Compare(entry.suffix, suffix)
+	})
+}
+
+// Converts bucket n into a burst node, recursively bursting any child that is still too large.
+func (n *hatTrieNode[V]) burst() {
+	var zero V
+	n.children = &[256]*hatTrieNode[V]{}
+	for _, entry := range n.bucket {
+		if len(entry.suffix) == 0 {
+			n.value = entry.value
+			n.isTerminal = true
+			continue
+		}
+		child := n.children[entry.suffix[0]]
+		if child == nil {
+			child = &hatTrieNode[V]{nil, nil, zero, 0, false}
+			n.children[entry.suffix[0]] = child
+			n.numChildren++
+		}
+		// The bucket is sorted, so each child's bucket is as well.
+		child.bucket = append(child.bucket, hatTrieEntry[V]{entry.suffix[1:], entry.value})
+	}
+	n.bucket = nil
+	for _, child := range n.children {
+		if child != nil && len(child.bucket) > maxBucketSize {
+			child.burst()
+		}
+	}
+}
+
+// Converts burst node n, which no longer has any children, back into a bucket.
+func (n *hatTrieNode[V]) unburst() {
+	var zero V
+	n.children = nil
+	if n.isTerminal {
+		n.bucket = []hatTrieEntry[V]{{[]byte{}, n.value}}
+	}
+	n.value = zero
+	n.isTerminal = false
+}
+
+// A node being traversed by Range, and the remaining children of that node to traverse.
+// This serves the same purpose as arrayTrieFrame.
+type hatTrieFrame[V any] struct {
+	node      *hatTrieNode[V]
+	next      int    // index of the next child to consider
+	stop      int    // index of the last child to consider, inclusive
+	remaining uint16 // number of children not yet traversed
+}
+
+func (n *hatTrieNode[V]) Range(bounds *Bounds) iter.Seq2[[]byte, V] {
+	return n.rangeWithMaxDepth(bounds, 0)
+}
+
+func (n *hatTrieNode[V]) rangeWithMaxDepth(bounds *Bounds, maxDepth int) iter.Seq2[[]byte, V] {
+	bounds = bounds.Clone()
+	if bounds.IsReverse {
+		return n.rangeReverse(bounds, maxDepth)
+	}
+	return n.rangeForward(bounds, maxDepth)
+}
+
+func (n *hatTrieNode[V]) all(isReverse bool) iter.Seq2[[]byte, V] {
+	if isReverse {
+		return n.rangeReverse(nil, 0)
+	}
+	return n.rangeForward(nil, 0)
+}
+
+func (n *hatTrieNode[V]) childBytes(prefix []byte) iter.Seq[byte] {
+	i := 0
+	for ; n.children != nil && i < len(prefix); i++ {
+		n = n.children[prefix[i]]
+		if n == nil {
+			return emptySeq
+		}
+	}
+	if n.children != nil {
+		children, numChildren := n.children, n.numChildren
+		return func(yield func(byte) bool) {
+			count := numChildren
+			for i, child := range children {
+				if child == nil {
+					continue
+				}
+				if !yield(byte(i)) {
+					return
+				}
+				count--
+				if count == 0 {
+					return
+				}
+			}
+		}
+	}
+	bucket, rest := n.bucket, prefix[i:]
+	return func(yield func(byte) bool) {
+		// The bucket is sorted, so equal child bytes are adjacent.
+		prev := -1
+		for _, entry := range bucket {
+			if len(entry.suffix) <= len(rest) || !bytes.HasPrefix(entry.suffix, rest) {
+				continue
+			}
+			if keyByte := entry.suffix[len(rest)]; int(keyByte) != prev {
+				prev = int(keyByte)
+				if !yield(keyByte) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Returns the comparison of key to bounds, or 0 (within bounds) if bounds is nil.
+func compareBounded(bounds *Bounds, key []byte) int {
+	if bounds == nil {
+		return 0
+	}
+	return bounds.Compare(key)
+}
+
+// Traverses in pre-order. If bounds is nil, all entries are traversed.
+func (n *hatTrieNode[V]) rangeForward(bounds *Bounds, maxDepth int) iter.Seq2[[]byte, V] {
+	return func(yield func([]byte, V) bool) {
+		key := make([]byte, 0, maxDepth)
+		stack := make([]hatTrieFrame[V], 0, maxDepth)
+		node := n
+		for {
+			// invariant: key = path from root to node, len(key) = len(stack)
+			if node.children == nil {
+				for _, entry := range node.bucket {
+					// Keep any grown buffer, but the suffix is not part of key.
+					entryKey := append(key, entry.suffix...)
+					key = entryKey[:len(key)]
+					cmp := compareBounded(bounds, entryKey)
+					if cmp > 0 {
+						return
+					}
+					if cmp == 0 && !yield(bytes.Clone(entryKey), entry.value) {
+						return
+					}
+				}
+			} else {
+				cmp := compareBounded(bounds, key)
+				if cmp > 0 {
+					return
+				}
+				if cmp == 0 && node.isTerminal && !yield(bytes.Clone(key), node.value) {
+					return
+				}
+				// Sometimes a child is not within the bounds, but one of its descendants is.
+				start, stop := byte(0), byte(math.MaxUint8)
+				if bounds != nil {
+					var ok bool
+					start, stop, ok = bounds.childBounds(key)
+					if !ok {
+						// Unreachable because of how the trie is traversed forward.
+						panic("unreachable")
+					}
+				}
+				stack = append(stack, hatTrieFrame[V]{node, int(start), int(stop), node.numChildren})
+			}
+			node = nil
+			for node == nil {
+				if len(stack) == 0 {
+					return
+				}
+				top := &stack[len(stack)-1]
+				for ; top.remaining > 0 && top.next <= top.stop; top.next++ {
+					if child := top.node.children[top.next]; child != nil {
+						node = child
+						key = append(key[:len(stack)-1], byte(top.next))
+						top.next++
+						top.remaining--
+						break
+					}
+				}
+				if node == nil {
+					stack = stack[:len(stack)-1]
+				}
+			}
+		}
+	}
+}
+
+// Traverses in post-order, visiting children in reverse. If bounds is nil, all entries are traversed.
+func (n *hatTrieNode[V]) rangeReverse(bounds *Bounds, maxDepth int) iter.Seq2[[]byte, V] {
+	return func(yield func([]byte, V) bool) {
+		key := make([]byte, 0, maxDepth)
+		stack := append(make([]hatTrieFrame[V], 0, maxDepth+1), n.reverseFrame(bounds, key))
+		for {
+			// invariant: key = path from root to top.node, len(key) = len(stack) - 1
+			top := &stack[len(stack)-1]
+			var child *hatTrieNode[V]
+			for ; top.remaining > 0 && top.next >= top.stop; top.next-- {
+				if child = top.node.children[top.next]; child != nil {
+					key = append(key, byte(top.next))
+					top.next--
+					top.remaining--
+					break
+				}
+			}
+			node, isLeaf := top.node, false
+			if child != nil {
+				if child.children != nil {
+					stack = append(stack, child.reverseFrame(bounds, key))
+					continue
+				}
+				// A leaf has no children to visit first, so visit it now instead of pushing a frame for it.
+				node, isLeaf = child, true
+			}
+			if node.children == nil {
+				for i := len(node.bucket) - 1; i >= 0; i-- {
+					entry := &node.bucket[i]
+					// Keep any grown buffer, but the suffix is not part of key.
+					entryKey := append(key, entry.suffix...)
+					key = entryKey[:len(key)]
+					cmp := compareBounded(bounds, entryKey)
+					if cmp > 0 {
+						return
+					}
+					if cmp == 0 && !yield(bytes.Clone(entryKey), entry.value) {
+						return
+					}
+				}
+			} else {
+				cmp := compareBounded(bounds, key)
+				if cmp > 0 {
+					return
+				}
+				if cmp == 0 && node.isTerminal && !yield(bytes.Clone(key), node.value) {
+					return
+				}
+			}
+			if !isLeaf {
+				stack = stack[:len(stack)-1]
+				if len(stack) == 0 {
+					return
+				}
+			}
+			key = key[:len(key)-1]
+		}
+	}
+}
+
+func (n *hatTrieNode[V]) reverseFrame(bounds *Bounds, key []byte) hatTrieFrame[V] {
+	if n.children == nil {
+		return hatTrieFrame[V]{n, -1, 0, 0}
+	}
+	// Sometimes a child is not within the bounds, but one of its descendants is.
+	start, stop := byte(math.MaxUint8), byte(0)
+	if bounds != nil {
+		var ok bool
+		start, stop, ok = bounds.childBounds(key)
+		if !ok {
+			return hatTrieFrame[V]{n, -1, 0, 0}
+		}
+	}
+	return hatTrieFrame[V]{n, int(start), int(stop), n.numChildren}
+}
+
+func (n *hatTrieNode[V]) String() string {
+	var s strings.Builder
+	n.printTo(&s, -1)
+	return s.String()
+}
+
+func (n *hatTrieNode[V]) Format(f fmt.State, verb rune) {
+	formatTrie(f, verb, n)
+}
+
+func (n *hatTrieNode[V]) printTo(s *strings.Builder, levels int) {
+	n.printNode(s, 0, "", levels)
+}
+
+//nolint:revive
+func (n *hatTrieNode[V]) printNode(s *strings.Builder, keyByte byte, indent string, levels int) {
+	if indent == "" {
+		s.WriteString("[]")
+	} else {
+		fmt.Fprintf(s, "%s%02X", indent, keyByte)
+	}
+	if n.children == nil {
+		n.printBucket(s, indent, levels)
+		return
+	}
+	if n.isTerminal {
+		fmt.Fprintf(s, ": %v\n", n.value)
+	} else {
+		s.WriteString("\n")
+	}
+	if levels == 0 {
+		s.WriteString(indent + "  ...\n")
+		return
+	}
+	for i, child := range n.children {
+		if child != nil {
+			child.printNode(s, byte(i), indent+"  ", levels-1)
+		}
+	}
+}
+
+// Prints bucket n as if it were burst into a node per suffix byte, so its output is the same as other tries.
+//
+//nolint:revive
+func (n *hatTrieNode[V]) printBucket(s *strings.Builder, indent string, levels int) {
+	entries := n.bucket
+	if len(entries) > 0 && len(entries[0].suffix) == 0 {
+		fmt.Fprintf(s, ": %v\n", entries[0].value)
+		entries = entries[1:]
+	} else {
+		s.WriteString("\n")
+	}
+	var prev []byte
+	for _, entry := range entries {
+		suffix := entry.suffix
+		// Nodes for the bytes shared with the previous suffix have already been printed.
+		common := CommonPrefixLen(prev, suffix)
+		end := len(suffix)
+		if levels >= 0 {
+			end = min(end, levels)
+		}
+		for depth := common; depth < end; depth++ {
+			fmt.Fprintf(s, "%s%02X", indent+strings.Repeat("  ", depth+1), suffix[depth])
+			if depth == len(suffix)-1 {
+				fmt.Fprintf(s, ": %v\n", entry.value)
+			} else {
+				s.WriteString("\n")
+			}
+		}
+		// Elide the subtree below depth levels, unless the previous suffix already has.
+		if levels >= 0 && len(suffix) > levels && (common < levels || len(prev) <= levels) {
+			s.WriteString(indent + strings.Repeat("  ", levels+1) + "...\n")
+		}
+		prev = suffix
+	}
+}
+
+func (n *hatTrieNode[V]) stats() (int, int) {
+	if n.children == nil {
+		depth := 0
+		for _, entry := range n.bucket {
+			depth = max(depth, len(entry.suffix))
+		}
+		return len(n.bucket), depth
+	}
+	size, depth := 0, 0
+	if n.isTerminal {
+		size = 1
+	}
+	for _, child := range n.children {
+		if child != nil {
+			childSize, childDepth := child.stats()
+			size += childSize
+			depth = max(depth, childDepth+1)
+		}
+	}
+	return size, depth
+}
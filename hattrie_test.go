@@ -0,0 +1,56 @@
+package btrie_test
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/phiryll/btrie"
+	"github.com/stretchr/testify/assert"
+)
+
+// Enough keys sharing prefixes to burst buckets at several depths, and to unburst them again as keys are deleted.
+// The HAT trie must print the same structure as a trie with a node per key byte throughout.
+func TestHATTrieBurst(t *testing.T) {
+	t.Parallel()
+	const numKeys = 2000
+	random := rand.New(rand.NewSource(6614))
+	keys := keySet{}
+	for range numKeys {
+		key := make([]byte, 1+random.Intn(6))
+		for i := range key {
+			// A small alphabet, so keys share prefixes.
+			key[i] = byte(random.Intn(4))
+		}
+		keys = append(keys, key)
+	}
+	trie := btrie.NewHATTrie[byte]()
+	ptrTrie := btrie.NewPointerTrie[byte]()
+	existing := map[string]byte{}
+	check := func() {
+		assert.Equal(t, fmt.Sprint(ptrTrie), fmt.Sprint(trie))
+		assert.Equal(t, fmt.Sprintf("%#v", ptrTrie), fmt.Sprintf("%#v", trie))
+		assert.Equal(t, fmt.Sprintf("%+.3v", ptrTrie), fmt.Sprintf("%+.3v", trie))
+	}
+	for i, key := range keys {
+		trie.Put(key, byte(i))
+		ptrTrie.Put(key, byte(i))
+		existing[string(key)] = byte(i)
+		if i%100 == 0 {
+			check()
+		}
+	}
+	assertSame(t, existing, trie)
+	check()
+	for i, key := range keys {
+		trie.Delete(key)
+		ptrTrie.Delete(key)
+		delete(existing, string(key))
+		if i%100 == 0 {
+			check()
+		}
+	}
+	assertSame(t, existing, trie)
+	check()
+	assert.Equal(t, "[]\n", fmt.Sprintf("%#v", trie))
+}
@@ -0,0 +1,132 @@
+package btrie
+
+import (
+	"bytes"
+	"iter"
+	"sort"
+)
+
+// HybridTrie is a BTrie whose contents are mostly frozen into compact, immutable sorted arrays,
+// with a small mutable trie of recent changes over them.
+// Writes only touch the mutable trie, so a frozen key is promoted to it when it is put or deleted.
+// [HybridTrie.Compact] freezes everything again, and should be called when enough changes have accumulated.
+//
+// A frozen entry costs its key bytes, one offset, and one value, instead of a node per key byte.
+// This suits long-lived tries that are mostly read, where most of the structure never changes.
+// HybridTrie implements [BTrie], and is not safe for concurrent use.
+// A HybridTrie must not be mutated during a Range iteration.
+type HybridTrie[V any] struct {
+	frozen *frozenTrie[V]
+	hot    *Overlay[V]
+}
+
+// NewHybridTrie returns a new, empty HybridTrie.
+func NewHybridTrie[V any]() *HybridTrie[V] {
+	frozen := newFrozenTrie[V](emptySeq2)
+	return &HybridTrie[V]{frozen, NewOverlay[V](frozen)}
+}
+
+func (t *HybridTrie[V]) Get(key []byte) (V, bool) {
+	return t.hot.Get(key)
+}
+
+func (t *HybridTrie[V]) Put(key []byte, value V) (V, bool) {
+	return t.hot.Put(key, value)
+}
+
+func (t *HybridTrie[V]) Delete(key []byte) (V, bool) {
+	return t.hot.Delete(key)
+}
+
+func (t *HybridTrie[V]) Range(bounds *Bounds) iter.Seq2[[]byte, V] {
+	return t.hot.Range(bounds)
+}
+
+// Compact freezes every entry, leaving no mutable entries.
+// This takes time proportional to the size of the trie.
+func (t *HybridTrie[V]) Compact() {
+	t.frozen = newFrozenTrie(All[V](t.hot))
+	t.hot = NewOverlay[V](t.frozen)
+}
+
+// An immutable BTrie with all keys concatenated into a single slice.
+// Entry i has key keys[ends[i-1]:ends[i]] (with ends[-1] = 0) and value values[i], in increasing key order.
+type frozenTrie[V any] struct {
+	keys   []byte
+	ends   []int
+	values []V
+}
+
+// Returns a frozenTrie with the entries of itr, which must be in increasing key order.
+func newFrozenTrie[V any](itr iter.Seq2[[]byte, V]) *frozenTrie[V] {
+	// keys must be non-nil, so that empty keys are as well.
+	t := &frozenTrie[V]{keys: []byte{}}
+	for k, v := range itr {
+		t.keys = append(t.keys, k...)
+		t.ends = append(t.ends, len(t.keys))
+		t.values = append(t.values, v)
+	}
+	return t
+}
+
+func (t *frozenTrie[V]) key(i int) []byte {
+	begin := 0
+	if i > 0 {
+		begin = t.ends[i-1]
+	}
+	return t.keys[begin:t.ends[i]:t.ends[i]]
+}
+
+// Returns the index of the first key >= key.
+func (t *frozenTrie[V]) search(key []byte) int {
+	return sort.Search(len(t.ends), func(i int) bool {
+		return bytes.Compare(t.key(i), key) >= 0
+	})
+}
+
+func (t *frozenTrie[V]) Get(key []byte) (V, bool) {
+	if key == nil {
+		panic("key must be non-nil")
+	}
+	if i := t.search(key); i < len(t.ends) && bytes.Equal(t.key(i), key) {
+		return t.values[i], true
+	}
+	var zero V
+	return zero, false
+}
+
+func (t *frozenTrie[V]) Put([]byte, V) (V, bool) {
+	panic("frozen trie is immutable")
+}
+
+func (t *frozenTrie[V]) Delete([]byte) (V, bool) {
+	panic("frozen trie is immutable")
+}
+
+func (t *frozenTrie[V]) Range(bounds *Bounds) iter.Seq2[[]byte, V] {
+	bounds = bounds.Clone()
+	return func(yield func([]byte, V) bool) {
+		if bounds.IsReverse {
+			i := len(t.ends) - 1
+			if bounds.Begin != nil {
+				// The last key <= Begin is just before the first key > Begin, which is the first key >= Begin+{0}.
+				i = t.search(NextKey(bounds.Begin)) - 1
+			}
+			for ; i >= 0 && bounds.Compare(t.key(i)) == 0; i-- {
+				if !yield(bytes.Clone(t.key(i)), t.values[i]) {
+					return
+				}
+			}
+			return
+		}
+		i := 0
+		if bounds.Begin != nil {
+			i = t.search(bounds.Begin)
+		}
+		for ; i < len(t.ends) && bounds.Compare(t.key(i)) == 0; i++ {
+			if !yield(bytes.Clone(t.key(i)), t.values[i]) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package btrie_test
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/phiryll/btrie"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHybridTrie(t *testing.T) {
+	t.Parallel()
+	for _, config := range rangeTestConfigs {
+		t.Run(config.name, func(t *testing.T) {
+			t.Parallel()
+			random := rand.New(rand.NewSource(4417))
+			trie := btrie.NewHybridTrie[byte]()
+			ref := newReference()
+			existing := map[string]byte{}
+			check := func() {
+				assertSame(t, existing, trie)
+				for _, bounds := range append(config.forward, config.reverse...) {
+					assert.Equal(t, collect(ref.Range(&bounds)), collect(trie.Range(&bounds)), "%s", bounds)
+				}
+			}
+			check()
+			trie.Compact()
+			check()
+			// Several rounds of changes, some to frozen keys and some to new keys, compacting after each.
+			for range 3 {
+				for key, value := range config.entries {
+					switch random.Intn(3) {
+					case 0:
+						value += byte(random.Intn(10))
+						prev, ok := ref.Put([]byte(key), value)
+						actualPrev, actualOk := trie.Put([]byte(key), value)
+						assert.Equal(t, prev, actualPrev)
+						assert.Equal(t, ok, actualOk)
+						existing[key] = value
+					case 1:
+						prev, ok := ref.Delete([]byte(key))
+						actualPrev, actualOk := trie.Delete([]byte(key))
+						assert.Equal(t, prev, actualPrev)
+						assert.Equal(t, ok, actualOk)
+						delete(existing, key)
+					}
+				}
+				check()
+				trie.Compact()
+				check()
+			}
+		})
+	}
+}
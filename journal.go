@@ -0,0 +1,214 @@
+package btrie
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+)
+
+// The journal written by a Journaled trie is:
+//
+//	magic   [4]byte  "BTRJ"
+//	version uvarint  currently 1
+//	records          zero or more, until EOF
+//
+// where each record is:
+//
+//	op        byte     1 = put, 2 = delete
+//	seq       uvarint  sequence number, starting at 1 and increasing by 1 for each record
+//	keySize   uvarint
+//	key       [keySize]byte
+//	valueSize uvarint          put only
+//	value     [valueSize]byte  put only, as encoded by the Codec
+const (
+	journalMagic   = "BTRJ"
+	journalVersion = 1
+
+	journalPut    byte = 1
+	journalDelete byte = 2
+)
+
+// Journaled is a BTrie which records every Put and every successful Delete to a journal,
+// which can be applied to another BTrie with [ApplyJournal].
+// This can be used to replicate a trie, or as an audit log.
+// Full and incremental checkpoints can be written with [Journaled.CheckpointSince].
+// Journaled implements [BTrie], and is as safe for concurrent use as the trie it wraps,
+// except that Put and Delete must not be called concurrently.
+type Journaled[V any] struct {
+	trie     BTrie[V]
+	w        io.Writer
+	codec    Codec[V]
+	seq      uint64
+	buf      []byte
+	valueBuf []byte
+	eq       Equaler[V]    // if non-nil, skip recording puts which do not change the value
+	versions BTrie[uint64] // if non-nil, the sequence number of the last recorded mutation of each key
+	err      error
+}
+
+// WithJournal returns a Journaled wrapping trie, which writes its journal to w using codec to encode values.
+// The journal header is written immediately.
+// Mutations of trie not made through the returned Journaled are not recorded.
+func WithJournal[V any](trie BTrie[V], w io.Writer, codec Codec[V]) *Journaled[V] {
+	j := &Journaled[V]{trie: trie, w: w, codec: codec}
+	j.buf = binary.AppendUvarint([]byte(journalMagic), journalVersion)
+	j.write()
+	return j
+}
+
+// SkipUnchanged causes later calls to Put to not be recorded if they replace a value with one equal to it,
+// according to eq. If eq is nil, every Put is recorded, which is the default.
+// Sequence numbers are only assigned to recorded mutations.
+func (j *Journaled[V]) SkipUnchanged(eq Equaler[V]) {
+	j.eq = eq
+}
+
+// Err returns the first error encountered while writing the journal, or nil if there was none.
+// After an error, mutations are still applied to the trie, but are no longer recorded.
+func (j *Journaled[V]) Err() error {
+	return j.err
+}
+
+// Seq returns the sequence number of the last recorded mutation, or 0 if none have been recorded.
+func (j *Journaled[V]) Seq() uint64 {
+	return j.seq
+}
+
+func (j *Journaled[V]) Get(key []byte) (V, bool) {
+	return j.trie.Get(key)
+}
+
+func (j *Journaled[V]) Put(key []byte, value V) (V, bool) {
+	prev, ok := j.trie.Put(key, value)
+	if ok && j.eq != nil && j.eq.Equal(prev, value) {
+		return prev, ok
+	}
+	if j.err == nil {
+		var err error
+		j.valueBuf, err = j.codec.AppendValue(j.valueBuf[:0], value)
+		if err != nil {
+			j.err = err
+			return prev, ok
+		}
+		j.buf = j.appendHeader(j.buf[:0], journalPut, key)
+		j.buf = binary.AppendUvarint(j.buf, uint64(len(j.valueBuf)))
+		j.buf = append(j.buf, j.valueBuf...)
+		j.write()
+		j.track(key)
+	}
+	return prev, ok
+}
+
+func (j *Journaled[V]) Delete(key []byte) (V, bool) {
+	prev, ok := j.trie.Delete(key)
+	if ok && j.err == nil {
+		j.buf = j.appendHeader(j.buf[:0], journalDelete, key)
+		j.write()
+		j.track(key)
+	}
+	return prev, ok
+}
+
+func (j *Journaled[V]) Range(bounds *Bounds) iter.Seq2[[]byte, V] {
+	return j.trie.Range(bounds)
+}
+
+func (j *Journaled[V]) appendHeader(buf []byte, op byte, key []byte) []byte {
+	j.seq++
+	buf = append(buf, op)
+	buf = binary.AppendUvarint(buf, j.seq)
+	buf = binary.AppendUvarint(buf, uint64(len(key)))
+	return append(buf, key...)
+}
+
+func (j *Journaled[V]) track(key []byte) {
+	if j.versions != nil && j.err == nil {
+		j.versions.Put(key, j.seq)
+	}
+}
+
+func (j *Journaled[V]) write() {
+	if j.err != nil {
+		return
+	}
+	_, j.err = j.w.Write(j.buf)
+}
+
+// ApplyJournal reads a journal written by a [Journaled] trie from r,
+// and applies its mutations to trie in order, using codec to decode the values.
+// ApplyJournal returns the sequence number of the last applied mutation.
+// Mutations read before an error is encountered will have been applied.
+// If r does not implement [io.ByteReader], ApplyJournal may read past the end of the journal.
+func ApplyJournal[V any](r io.Reader, trie BTrie[V], codec Codec[V]) (uint64, error) {
+	br, ok := r.(byteReader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+	magic := make([]byte, len(journalMagic))
+	if _, err := io.ReadFull(br, magic); err != nil {
+		return 0, formatError(err)
+	}
+	if string(magic) != journalMagic {
+		return 0, fmt.Errorf("%w: bad magic %q", ErrInvalidFormat, magic)
+	}
+	version, err := binary.ReadUvarint(br)
+	if err != nil {
+		return 0, formatError(err)
+	}
+	if version != journalVersion {
+		return 0, fmt.Errorf("%w: unsupported version %d", ErrInvalidFormat, version)
+	}
+	var lastSeq uint64
+	var buf []byte
+	for {
+		op, err := br.ReadByte()
+		if errors.Is(err, io.EOF) {
+			return lastSeq, nil
+		}
+		if err != nil {
+			return lastSeq, err
+		}
+		if op != journalPut && op != journalDelete {
+			return lastSeq, fmt.Errorf("%w: bad journal op %d", ErrInvalidFormat, op)
+		}
+		seq, err := binary.ReadUvarint(br)
+		if err != nil {
+			return lastSeq, formatError(err)
+		}
+		if lastSeq != 0 && seq != lastSeq+1 {
+			return lastSeq, fmt.Errorf("%w: journal sequence %d follows %d", ErrInvalidFormat, seq, lastSeq)
+		}
+		keySize, err := binary.ReadUvarint(br)
+		if err != nil {
+			return lastSeq, formatError(err)
+		}
+		buf, err = readBytes(br, buf[:0], keySize)
+		if err != nil {
+			return lastSeq, err
+		}
+		key := make([]byte, len(buf))
+		copy(key, buf)
+		if op == journalDelete {
+			trie.Delete(key)
+			lastSeq = seq
+			continue
+		}
+		valueSize, err := binary.ReadUvarint(br)
+		if err != nil {
+			return lastSeq, formatError(err)
+		}
+		buf, err = readBytes(br, buf[:0], valueSize)
+		if err != nil {
+			return lastSeq, err
+		}
+		value, err := codec.DecodeValue(buf)
+		if err != nil {
+			return lastSeq, err
+		}
+		trie.Put(key, value)
+		lastSeq = seq
+	}
+}
@@ -0,0 +1,130 @@
+package btrie_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/phiryll/btrie"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJournal(t *testing.T) {
+	t.Parallel()
+	for _, def := range implDefs {
+		t.Run(def.name, func(t *testing.T) {
+			t.Parallel()
+			var journal bytes.Buffer
+			primary := btrie.WithJournal[byte](def.factory(), &journal, byteCodec{})
+			expected := map[string]byte{}
+			for i, key := range presentTestKeys {
+				primary.Put(key, byte(i))
+				expected[string(key)] = byte(i)
+			}
+			for i, key := range presentTestKeys {
+				if i%3 == 0 {
+					primary.Delete(key)
+					delete(expected, string(key))
+				}
+			}
+			primary.Delete(absentTestKeys[0]) // not recorded
+			primary.Put(presentTestKeys[1], 99)
+			expected[string(presentTestKeys[1])] = 99
+			require.NoError(t, primary.Err())
+			assert.Equal(t, uint64(len(presentTestKeys)+4+1), primary.Seq())
+			assertSame(t, expected, primary)
+
+			follower := def.factory()
+			seq, err := btrie.ApplyJournal(&journal, follower, byteCodec{})
+			require.NoError(t, err)
+			assert.Equal(t, primary.Seq(), seq)
+			assertSame(t, expected, follower)
+		})
+	}
+}
+
+func TestJournalSkipUnchanged(t *testing.T) {
+	t.Parallel()
+	var journal bytes.Buffer
+	primary := btrie.WithJournal(btrie.NewArrayTrie[byte](), &journal, byteCodec{})
+	primary.Put([]byte{1}, 10)
+	primary.Put([]byte{1}, 10)
+	assert.Equal(t, uint64(2), primary.Seq())
+	primary.SkipUnchanged(btrie.Comparable[byte]())
+	primary.Put([]byte{1}, 10) // not recorded
+	primary.Put([]byte{2}, 0)  // a new key with the zero value is recorded
+	primary.Put([]byte{2}, 0)  // not recorded
+	primary.Put([]byte{1}, 11)
+	assert.Equal(t, uint64(4), primary.Seq())
+	primary.SkipUnchanged(nil)
+	primary.Put([]byte{1}, 11)
+	assert.Equal(t, uint64(5), primary.Seq())
+
+	follower := btrie.NewArrayTrie[byte]()
+	seq, err := btrie.ApplyJournal(&journal, follower, byteCodec{})
+	require.NoError(t, err)
+	assert.Equal(t, primary.Seq(), seq)
+	assertSame(t, map[string]byte{"\x01": 11, "\x02": 0}, follower)
+}
+
+// Fails all writes after the first n.
+type failingWriter struct {
+	n int
+}
+
+var errWrite = errors.New("write failed")
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if w.n == 0 {
+		return 0, errWrite
+	}
+	w.n--
+	return len(p), nil
+}
+
+func TestJournalWriteError(t *testing.T) {
+	t.Parallel()
+	trie := btrie.WithJournal(btrie.NewArrayTrie[byte](), &failingWriter{2}, byteCodec{})
+	trie.Put([]byte{1}, 1)
+	require.NoError(t, trie.Err())
+	trie.Put([]byte{2}, 2)
+	require.ErrorIs(t, trie.Err(), errWrite)
+	trie.Put([]byte{3}, 3)
+	assertSame(t, map[string]byte{"\x01": 1, "\x02": 2, "\x03": 3}, trie)
+}
+
+func TestApplyJournalErrors(t *testing.T) {
+	t.Parallel()
+	var journal bytes.Buffer
+	trie := btrie.WithJournal(btrie.NewArrayTrie[byte](), &journal, byteCodec{})
+	trie.Put([]byte{1, 2}, 1)
+	trie.Delete([]byte{1, 2})
+	data := journal.Bytes()
+
+	// Truncations are errors, except at record boundaries.
+	boundaries := map[int]bool{5: true, 12: true, len(data): true}
+	for i := range data {
+		_, err := btrie.ApplyJournal(bytes.NewReader(data[:i]), btrie.NewArrayTrie[byte](), byteCodec{})
+		if boundaries[i] {
+			assert.NoError(t, err, "truncated at %d", i)
+		} else {
+			assert.ErrorIs(t, err, btrie.ErrInvalidFormat, "truncated at %d", i)
+		}
+	}
+
+	for _, tt := range []struct {
+		index int
+		value byte
+	}{
+		{0, 'X'},  // magic
+		{4, 0x7F}, // version
+		{5, 3},    // op
+		{13, 3},   // seq
+	} {
+		bad := bytes.Clone(data)
+		bad[tt.index] = tt.value
+		_, err := btrie.ApplyJournal(bytes.NewReader(bad), btrie.NewArrayTrie[byte](), byteCodec{})
+		assert.ErrorIs(t, err, btrie.ErrInvalidFormat, "%d", tt.index)
+	}
+}
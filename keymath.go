@@ -0,0 +1,64 @@
+package btrie
+
+import (
+	"bytes"
+	"math"
+)
+
+// NextKey returns the smallest key greater than key, which is key followed by a zero byte.
+// There are no keys strictly between key and NextKey(key), so From(NextKey(key)) begins just after key,
+// and From(key).To(NextKey(key)) contains only key.
+// The returned key never references key.
+// NextKey will panic if key is nil.
+func NextKey(key []byte) []byte {
+	if key == nil {
+		panic("key must be non-nil")
+	}
+	next := make([]byte, len(key)+1)
+	copy(next, key)
+	return next
+}
+
+// Returns the greatest key less than key, and whether it exists.
+// The greatest key less than {} is -Inf, which is returned as nil.
+// Otherwise, it only exists if key ends with 0, and is key without its last byte.
+func prevKey(key []byte) ([]byte, bool) {
+	switch {
+	case len(key) == 0:
+		return nil, true
+	case key[len(key)-1] == 0:
+		return key[: len(key)-1 : len(key)-1], true
+	default:
+		return nil, false
+	}
+}
+
+// PrefixSuccessor returns the smallest key greater than every key having the given prefix, and whether it exists.
+// It is prefix with any trailing 0xFF bytes removed, and the last remaining byte incremented.
+// If it does not exist because prefix is empty or only contains 0xFF bytes, PrefixSuccessor returns (nil, false),
+// and since a nil End is +Inf, From(prefix).To(end) contains exactly the keys having prefix in either case.
+// The returned key never references prefix.
+// PrefixSuccessor will panic if prefix is nil.
+func PrefixSuccessor(prefix []byte) ([]byte, bool) {
+	if prefix == nil {
+		panic("prefix must be non-nil")
+	}
+	for i := len(prefix) - 1; i >= 0; i-- {
+		if prefix[i] != math.MaxUint8 {
+			succ := bytes.Clone(prefix[:i+1])
+			succ[i]++
+			return succ, true
+		}
+	}
+	return nil, false
+}
+
+// CommonPrefixLen returns the length of the longest common prefix of a and b.
+// A nil slice is treated like an empty one.
+func CommonPrefixLen(a, b []byte) int {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+	return i
+}
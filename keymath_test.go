@@ -0,0 +1,79 @@
+package btrie_test
+
+import (
+	"testing"
+
+	"github.com/phiryll/btrie"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNextKey(t *testing.T) {
+	t.Parallel()
+	assert.Panics(t, func() { btrie.NextKey(nil) })
+	assert.Equal(t, []byte{0}, btrie.NextKey([]byte{}))
+	assert.Equal(t, []byte{0xFF, 0}, btrie.NextKey([]byte{0xFF}))
+	key := make([]byte, 2, 10)
+	next := btrie.NextKey(key)
+	assert.Equal(t, []byte{0, 0, 0}, next)
+	next[0] = 1
+	assert.Equal(t, []byte{0, 0}, key, "must not alias")
+
+	trie := btrie.NewArrayTrie[byte]()
+	for _, key := range presentTestKeys {
+		trie.Put(key, 0)
+	}
+	for _, key := range nearTestKeys[1 : len(nearTestKeys)-1] {
+		expected, ok := trie.Get(key)
+		actual := collect(trie.Range(From(key).To(btrie.NextKey(key))))
+		if ok {
+			assert.Equal(t, []entry{{key, expected}}, actual)
+		} else {
+			assert.Empty(t, actual)
+		}
+	}
+}
+
+func TestPrefixSuccessor(t *testing.T) {
+	t.Parallel()
+	assert.Panics(t, func() { btrie.PrefixSuccessor(nil) })
+	for _, tt := range []struct {
+		prefix, expected []byte
+	}{
+		{[]byte{}, nil},
+		{[]byte{0}, []byte{1}},
+		{[]byte{0xFF}, nil},
+		{[]byte{0xFF, 0xFF}, nil},
+		{[]byte{0x12, 0xFE}, []byte{0x12, 0xFF}},
+		{[]byte{0x12, 0xFF}, []byte{0x13}},
+		{[]byte{0x12, 0xFF, 0xFF}, []byte{0x13}},
+		{[]byte{0x12, 0xFF, 0x00}, []byte{0x12, 0xFF, 0x01}},
+	} {
+		prefix := append([]byte{}, tt.prefix...)
+		actual, ok := btrie.PrefixSuccessor(prefix)
+		assert.Equal(t, tt.expected != nil, ok, "%X", tt.prefix)
+		assert.Equal(t, tt.expected, actual, "%X", tt.prefix)
+		if ok {
+			actual[0]++
+			assert.Equal(t, tt.prefix, prefix, "must not alias")
+		}
+	}
+}
+
+func TestCommonPrefixLen(t *testing.T) {
+	t.Parallel()
+	for _, tt := range []struct {
+		a, b     []byte
+		expected int
+	}{
+		{nil, nil, 0},
+		{nil, []byte{1}, 0},
+		{[]byte{}, []byte{1}, 0},
+		{[]byte{1}, []byte{1}, 1},
+		{[]byte{1}, []byte{1, 2}, 1},
+		{[]byte{1, 2, 3}, []byte{1, 2, 4}, 2},
+		{[]byte{2}, []byte{1, 2}, 0},
+	} {
+		assert.Equal(t, tt.expected, btrie.CommonPrefixLen(tt.a, tt.b))
+		assert.Equal(t, tt.expected, btrie.CommonPrefixLen(tt.b, tt.a))
+	}
+}
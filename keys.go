@@ -0,0 +1,67 @@
+package btrie
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+)
+
+// LoadKeys returns a sequence of keys read from r, each terminated by delim.
+// The delimiters are not included in the keys, and the last key need not be terminated.
+// Consecutive delimiters yield an empty key.
+// If reading from r fails with an error other than [io.EOF], the sequence yields (nil, err) and ends.
+// Each yielded key is a new slice, and the returned sequence is single-use.
+//
+// This is useful for loading a corpus of real keys, such as a list of domain names or file paths,
+// which will share prefixes in ways that randomly generated keys do not.
+func LoadKeys(r io.Reader, delim byte) iter.Seq2[[]byte, error] {
+	br := bufio.NewReader(r)
+	return func(yield func([]byte, error) bool) {
+		for {
+			key, err := br.ReadBytes(delim)
+			if err != nil {
+				if errors.Is(err, io.EOF) {
+					if len(key) > 0 {
+						yield(key, nil)
+					}
+					return
+				}
+				yield(nil, err)
+				return
+			}
+			if !yield(key[:len(key)-1], nil) {
+				return
+			}
+		}
+	}
+}
+
+// ErrDelimInKey is wrapped by the error returned from [StreamSortedKeys] if a key contains the delimiter.
+var ErrDelimInKey = errors.New("key contains the delimiter")
+
+// StreamSortedKeys writes the keys of trie to w in increasing order, each terminated by delim,
+// in the form read by [LoadKeys]. Keys are written directly from the traversal through a buffer,
+// without being copied or formatted, which makes this suitable as the input stage of an external sort or merge join.
+// If a key contains delim, StreamSortedKeys returns an error wrapping [ErrDelimInKey],
+// after writing the keys before it; choose a delimiter which cannot appear in keys, such as 0 for text.
+func StreamSortedKeys[V any](w io.Writer, trie BTrie[V], delim byte) error {
+	bw := bufio.NewWriter(w)
+	for key := range All(trie) {
+		if bytes.IndexByte(key, delim) >= 0 {
+			if err := bw.Flush(); err != nil {
+				return err
+			}
+			return fmt.Errorf("%w: %x", ErrDelimInKey, key)
+		}
+		if _, err := bw.Write(key); err != nil {
+			return err
+		}
+		if err := bw.WriteByte(delim); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,114 @@
+package btrie_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/phiryll/btrie"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func loadKeys(r io.Reader, delim byte) ([]string, error) {
+	keys := []string{}
+	for key, err := range btrie.LoadKeys(r, delim) {
+		if err != nil {
+			return keys, err
+		}
+		keys = append(keys, string(key))
+	}
+	return keys, nil
+}
+
+func TestLoadKeys(t *testing.T) {
+	t.Parallel()
+	for _, test := range []struct {
+		input    string
+		delim    byte
+		expected []string
+	}{
+		{"", '\n', []string{}},
+		{"\n", '\n', []string{""}},
+		{"a", '\n', []string{"a"}},
+		{"a\n", '\n', []string{"a"}},
+		{"a\nbc\n\nd", '\n', []string{"a", "bc", "", "d"}},
+		{"usr/bin\x00usr/lib\x00", 0, []string{"usr/bin", "usr/lib"}},
+	} {
+		keys, err := loadKeys(strings.NewReader(test.input), test.delim)
+		assert.NoError(t, err)
+		assert.Equal(t, test.expected, keys, "%q", test.input)
+		// one byte at a time
+		keys, err = loadKeys(iotest.OneByteReader(strings.NewReader(test.input)), test.delim)
+		assert.NoError(t, err)
+		assert.Equal(t, test.expected, keys, "%q", test.input)
+	}
+
+	errRead := errors.New("read failed")
+	keys, err := loadKeys(io.MultiReader(strings.NewReader("a\nb"), iotest.ErrReader(errRead)), '\n')
+	assert.ErrorIs(t, err, errRead)
+	assert.Equal(t, []string{"a"}, keys)
+
+	// need an early yield for test coverage
+	for range btrie.LoadKeys(strings.NewReader("a\nb\n"), '\n') {
+		break
+	}
+}
+
+func TestStreamSortedKeys(t *testing.T) {
+	t.Parallel()
+	for _, test := range createTestTries(rangeTestConfigs) {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			var buf bytes.Buffer
+			// Test keys are arbitrary bytes, so use a delimiter not in any of them.
+			delim, ok := unusedByte(test.config.entries)
+			if !ok {
+				t.Skip("every byte is used")
+			}
+			require.NoError(t, btrie.StreamSortedKeys(&buf, test.trie, delim))
+			keys, err := loadKeys(&buf, delim)
+			require.NoError(t, err)
+			expected := []string{}
+			for k := range test.trie.Range(forwardAll) {
+				expected = append(expected, string(k))
+			}
+			assert.Equal(t, expected, keys)
+		})
+	}
+}
+
+func unusedByte(entries map[string]byte) (byte, bool) {
+	var used [256]bool
+	for k := range entries {
+		for _, b := range []byte(k) {
+			used[b] = true
+		}
+	}
+	for b, u := range used {
+		if !u {
+			return byte(b), true
+		}
+	}
+	return 0, false
+}
+
+func TestStreamSortedKeysErrors(t *testing.T) {
+	t.Parallel()
+	trie := btrie.NewArrayTrie[byte]()
+	trie.Put([]byte("a"), 0)
+	trie.Put([]byte("b\nc"), 0)
+	trie.Put([]byte("d"), 0)
+	var buf bytes.Buffer
+	require.ErrorIs(t, btrie.StreamSortedKeys(&buf, trie, '\n'), btrie.ErrDelimInKey)
+	assert.Equal(t, "a\n", buf.String())
+
+	require.ErrorIs(t, btrie.StreamSortedKeys(&failingWriter{0}, trie, 0), errWrite)
+
+	buf.Reset()
+	require.NoError(t, btrie.StreamSortedKeys(&buf, btrie.NewArrayTrie[byte](), 0))
+	assert.Zero(t, buf.Len())
+}
@@ -0,0 +1,66 @@
+package btrie
+
+import (
+	"context"
+	"encoding/hex"
+	"iter"
+	"log/slog"
+)
+
+// Logged is a BTrie which logs every Put and every successful Delete to a [slog.Logger].
+// Each record has a "key" attribute with the key in hex, and a "delta" attribute with the change
+// in the number of entries, which is 1 for a Put of a new key, 0 for a Put replacing a value, and -1 for a Delete.
+// Values are not logged.
+// Logged implements [BTrie], and is as safe for concurrent use as the trie it wraps.
+type Logged[V any] struct {
+	trie   BTrie[V]
+	logger *slog.Logger
+	level  slog.Level
+}
+
+// Log messages of a [Logged] trie.
+const (
+	LogMsgPut    = "btrie put"
+	LogMsgDelete = "btrie delete"
+)
+
+// WithLogger returns a Logged wrapping trie, which logs mutations to logger at the given level.
+// If logger is not enabled for level, the cost of logging is only that of checking whether it is enabled.
+// Mutations of trie not made through the returned Logged are not logged.
+func WithLogger[V any](trie BTrie[V], logger *slog.Logger, level slog.Level) *Logged[V] {
+	return &Logged[V]{trie, logger, level}
+}
+
+func (l *Logged[V]) Get(key []byte) (V, bool) {
+	return l.trie.Get(key)
+}
+
+func (l *Logged[V]) Put(key []byte, value V) (V, bool) {
+	prev, ok := l.trie.Put(key, value)
+	delta := 1
+	if ok {
+		delta = 0
+	}
+	l.log(LogMsgPut, key, delta)
+	return prev, ok
+}
+
+func (l *Logged[V]) Delete(key []byte) (V, bool) {
+	prev, ok := l.trie.Delete(key)
+	if ok {
+		l.log(LogMsgDelete, key, -1)
+	}
+	return prev, ok
+}
+
+func (l *Logged[V]) Range(bounds *Bounds) iter.Seq2[[]byte, V] {
+	return l.trie.Range(bounds)
+}
+
+func (l *Logged[V]) log(msg string, key []byte, delta int) {
+	ctx := context.Background()
+	if !l.logger.Enabled(ctx, l.level) {
+		return
+	}
+	l.logger.LogAttrs(ctx, l.level, msg, slog.String("key", hex.EncodeToString(key)), slog.Int("delta", delta))
+}
@@ -0,0 +1,42 @@
+package btrie_test
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/phiryll/btrie"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithLogger(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	removeTime := func(_ []string, a slog.Attr) slog.Attr {
+		if a.Key == slog.TimeKey {
+			return slog.Attr{}
+		}
+		return a
+	}
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelInfo, ReplaceAttr: removeTime}))
+	trie := btrie.WithLogger(btrie.NewArrayTrie[byte](), logger, slog.LevelInfo)
+	trie.Put([]byte{0x12, 0xAB}, 1)
+	trie.Put([]byte{0x12, 0xAB}, 2)
+	trie.Put([]byte{}, 3)
+	trie.Delete([]byte{0x99}) // not logged
+	trie.Delete([]byte{0x12, 0xAB})
+	assert.Equal(t, []string{
+		`level=INFO msg="btrie put" key=12ab delta=1`,
+		`level=INFO msg="btrie put" key=12ab delta=0`,
+		`level=INFO msg="btrie put" key="" delta=1`,
+		`level=INFO msg="btrie delete" key=12ab delta=-1`,
+	}, strings.Split(strings.TrimSpace(buf.String()), "\n"))
+	assertSame(t, map[string]byte{"": 3}, trie)
+
+	buf.Reset()
+	debug := btrie.WithLogger(btrie.NewArrayTrie[byte](), logger, slog.LevelDebug)
+	debug.Put([]byte{1}, 1)
+	debug.Delete([]byte{1})
+	assert.Empty(t, buf.String())
+}
@@ -0,0 +1,64 @@
+package btrie
+
+import (
+	"bytes"
+	"iter"
+)
+
+// GetWithDepth returns the value associated with key and whether it exists, like trie.Get,
+// along with the length of the longest prefix of key which is a prefix of some key in trie.
+// That is, the length is how far along key a walk from the root of trie gets before it stops.
+// The length is len(key) if the value exists, and 0 if trie is empty.
+// For tries without a faster implementation, this performs a Get and two short Ranges over trie.
+// GetWithDepth will panic if key is nil.
+func GetWithDepth[V any](trie BTrie[V], key []byte) (V, int, bool) {
+	if key == nil {
+		panic("key must be non-nil")
+	}
+	if t, ok := trie.(keyWalker[V]); ok {
+		return t.getWithDepth(key)
+	}
+	value, ok := trie.Get(key)
+	if ok {
+		return value, len(key), true
+	}
+	// The keys sharing the longest prefix with key include the keys immediately before and after it.
+	depth := 0
+	for _, bounds := range []*Bounds{From(key).To(nil), From(key).DownTo(nil)} {
+		for k := range trie.Range(bounds) {
+			depth = max(depth, CommonPrefixLen(key, k))
+			break
+		}
+	}
+	return value, depth, false
+}
+
+// PrefixesOf returns a sequence of the entries in trie whose keys are prefixes of key, including key itself,
+// in increasing key order. The last entry yielded, if any, is the longest prefix match.
+// For tries without a faster implementation, this performs a Get for every prefix of key.
+// The returned sequence has the same constraints as those returned by trie.Range.
+// PrefixesOf will panic if key is nil.
+func PrefixesOf[V any](trie BTrie[V], key []byte) iter.Seq2[[]byte, V] {
+	if key == nil {
+		panic("key must be non-nil")
+	}
+	key = bytes.Clone(key)
+	if t, ok := trie.(keyWalker[V]); ok {
+		return t.prefixesOf(key)
+	}
+	return func(yield func([]byte, V) bool) {
+		for i := range len(key) + 1 {
+			if value, ok := trie.Get(key[:i]); ok && !yield(bytes.Clone(key[:i]), value) {
+				return
+			}
+		}
+	}
+}
+
+// Implemented by tries which can answer queries along a key's path in a single walk from the root.
+type keyWalker[V any] interface {
+	getWithDepth(key []byte) (V, int, bool)
+
+	// The caller has already cloned key.
+	prefixesOf(key []byte) iter.Seq2[[]byte, V]
+}
@@ -0,0 +1,124 @@
+package btrie_test
+
+import (
+	"testing"
+
+	"github.com/phiryll/btrie"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetWithDepth(t *testing.T) {
+	t.Parallel()
+	for _, def := range implDefs {
+		t.Run(def.name, func(t *testing.T) {
+			t.Parallel()
+			trie := def.factory()
+			test := func(key string, expectedDepth int, expectedOk bool) {
+				value, depth, ok := btrie.GetWithDepth(trie, []byte(key))
+				expectedValue, _ := trie.Get([]byte(key))
+				assert.Equal(t, expectedValue, value, "%q", key)
+				assert.Equal(t, expectedDepth, depth, "%q", key)
+				assert.Equal(t, expectedOk, ok, "%q", key)
+			}
+			test("", 0, false)
+			test("abc", 0, false)
+			trie.Put([]byte("abc"), 1)
+			trie.Put([]byte("abd"), 2)
+			trie.Put([]byte("ax"), 3)
+			trie.Put([]byte("b"), 4)
+			test("", 0, false)
+			test("a", 1, false)
+			test("ab", 2, false)
+			test("abc", 3, true)
+			test("abcd", 3, false)
+			test("abe", 2, false)
+			test("ax", 2, true)
+			test("ay", 1, false)
+			test("b", 1, true)
+			test("bb", 1, false)
+			test("c", 0, false)
+			trie.Put([]byte{}, 5)
+			test("", 0, true)
+			test("c", 0, false)
+			assert.Panics(t, func() { btrie.GetWithDepth(trie, nil) })
+		})
+	}
+}
+
+// Each trie must agree with the reference trie, which uses the generic implementation.
+func TestGetWithDepthConfigs(t *testing.T) {
+	t.Parallel()
+	for _, test := range createTestTries(testTrieConfigs) {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			ref := createReferenceTrie(test.config)
+			for _, keySets := range [][]keySet{test.config.present, test.config.absent} {
+				for _, keys := range keySets {
+					for _, key := range keys {
+						expectedValue, expectedDepth, expectedOk := btrie.GetWithDepth(ref, key)
+						value, depth, ok := btrie.GetWithDepth(test.trie, key)
+						assert.Equal(t, expectedValue, value, "%s", keyName(key))
+						assert.Equal(t, expectedDepth, depth, "%s", keyName(key))
+						assert.Equal(t, expectedOk, ok, "%s", keyName(key))
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestPrefixesOf(t *testing.T) {
+	t.Parallel()
+	for _, def := range implDefs {
+		t.Run(def.name, func(t *testing.T) {
+			t.Parallel()
+			trie := def.factory()
+			prefixesOf := func(key string) []entry {
+				return collect(btrie.PrefixesOf(trie, []byte(key)))
+			}
+			assert.Equal(t, []entry{}, prefixesOf(""))
+			assert.Equal(t, []entry{}, prefixesOf("abc"))
+			trie.Put([]byte("a"), 1)
+			trie.Put([]byte("abc"), 2)
+			trie.Put([]byte("abcdef"), 3)
+			trie.Put([]byte("abd"), 4)
+			trie.Put([]byte("b"), 5)
+			assert.Equal(t, []entry{}, prefixesOf(""))
+			assert.Equal(t, []entry{{[]byte("a"), 1}}, prefixesOf("a"))
+			assert.Equal(t, []entry{{[]byte("a"), 1}}, prefixesOf("ab"))
+			assert.Equal(t, []entry{{[]byte("a"), 1}, {[]byte("abc"), 2}}, prefixesOf("abcde"))
+			assert.Equal(t, []entry{{[]byte("a"), 1}, {[]byte("abc"), 2}, {[]byte("abcdef"), 3}},
+				prefixesOf("abcdefg"))
+			assert.Equal(t, []entry{{[]byte("a"), 1}, {[]byte("abd"), 4}}, prefixesOf("abd"))
+			assert.Equal(t, []entry{}, prefixesOf("c"))
+			trie.Put([]byte{}, 6)
+			assert.Equal(t, []entry{{[]byte{}, 6}}, prefixesOf(""))
+			assert.Equal(t, []entry{{[]byte{}, 6}, {[]byte("b"), 5}}, prefixesOf("bcd"))
+
+			// need an early yield for test coverage
+			for range btrie.PrefixesOf(trie, []byte("abcdef")) {
+				break
+			}
+			assert.Panics(t, func() { btrie.PrefixesOf(trie, nil) })
+		})
+	}
+}
+
+// Each trie must agree with the reference trie, which uses the generic implementation.
+func TestPrefixesOfConfigs(t *testing.T) {
+	t.Parallel()
+	for _, test := range createTestTries(testTrieConfigs) {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			ref := createReferenceTrie(test.config)
+			for _, keySets := range [][]keySet{test.config.present, test.config.absent} {
+				for _, keys := range keySets {
+					for _, key := range keys {
+						assert.Equal(t, collect(btrie.PrefixesOf(ref, key)), collect(btrie.PrefixesOf(test.trie, key)),
+							"%s", keyName(key))
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package btrie
+
+import (
+	"iter"
+)
+
+// Timestamped is the envelope stored for each key in an [LWWTrie].
+type Timestamped[V any] struct {
+	// Value is the value written at Time, and is the zero value if Deleted is true.
+	Value V
+
+	// Time is when the value was written or deleted, in caller-defined units.
+	Time int64
+
+	// Deleted is true if this is a tombstone.
+	Deleted bool
+}
+
+// LWWTrie is a last-writer-wins register per key, a simple conflict-free replicated data type (CRDT).
+// Every put and delete has a timestamp, and for each key the write with the latest timestamp wins,
+// regardless of the order in which writes are applied.
+// Deletes are kept as tombstones so they can win over older puts received later.
+// Replicas of an LWWTrie converge to the same state after merging each other's entries.
+//
+// For replicas to converge, two different writes to the same key should not have the same timestamp,
+// for example by using the low bits of the timestamp for a replica ID.
+// If timestamps are equal, a delete wins over a put, and otherwise the existing write is kept.
+//
+// An LWWTrie is not safe for concurrent use.
+type LWWTrie[V any] struct {
+	trie BTrie[Timestamped[V]]
+	eq   Equaler[V] // if non-nil, winning writes which do not change the value are reported as losing
+}
+
+// NewLWWTrie returns a new, empty LWWTrie.
+func NewLWWTrie[V any]() *LWWTrie[V] {
+	return &LWWTrie[V]{NewArrayTrie[Timestamped[V]](), nil}
+}
+
+// SkipUnchanged causes later writes which win, but leave key with the same value according to eq,
+// or leave it deleted, to be reported as losing by Put, Delete, Merge, and MergeEntries,
+// so that merging a replica only counts the writes which changed something.
+// Their timestamps are still recorded, which is required for replicas to converge.
+// If eq is nil, every winning write is reported, which is the default.
+func (t *LWWTrie[V]) SkipUnchanged(eq Equaler[V]) {
+	t.eq = eq
+}
+
+// Get returns the value for key and whether or not it exists.
+func (t *LWWTrie[V]) Get(key []byte) (V, bool) {
+	entry, ok := t.trie.Get(key)
+	if !ok || entry.Deleted {
+		var zero V
+		return zero, false
+	}
+	return entry.Value, true
+}
+
+// GetEntry returns the envelope for key, which may be a tombstone, and whether or not it exists.
+func (t *LWWTrie[V]) GetEntry(key []byte) (Timestamped[V], bool) {
+	return t.trie.Get(key)
+}
+
+// Put sets the value for key at the given time, returning whether this write won.
+func (t *LWWTrie[V]) Put(key []byte, value V, time int64) bool {
+	return t.apply(key, Timestamped[V]{value, time, false})
+}
+
+// Delete records a tombstone for key at the given time, returning whether this write won.
+func (t *LWWTrie[V]) Delete(key []byte, time int64) bool {
+	return t.apply(key, Timestamped[V]{Time: time, Deleted: true})
+}
+
+// Applies the write if it wins, returning whether it did, and whether it changed the value if t.eq is non-nil.
+func (t *LWWTrie[V]) apply(key []byte, write Timestamped[V]) bool {
+	existing, ok := t.trie.Get(key)
+	if ok && !wins(write, existing) {
+		return false
+	}
+	t.trie.Put(key, write)
+	if !ok || t.eq == nil || write.Deleted != existing.Deleted {
+		return true
+	}
+	return !write.Deleted && !t.eq.Equal(write.Value, existing.Value)
+}
+
+// Returns true if write should replace existing.
+func wins[V any](write, existing Timestamped[V]) bool {
+	if write.Time != existing.Time {
+		return write.Time > existing.Time
+	}
+	return write.Deleted && !existing.Deleted
+}
+
+// Range returns a sequence of key/value pairs over the given bounds, omitting tombstones.
+// This LWWTrie must not be mutated during a Range iteration.
+func (t *LWWTrie[V]) Range(bounds *Bounds) iter.Seq2[[]byte, V] {
+	itr := t.trie.Range(bounds)
+	return func(yield func([]byte, V) bool) {
+		for k, entry := range itr {
+			if entry.Deleted {
+				continue
+			}
+			if !yield(k, entry.Value) {
+				return
+			}
+		}
+	}
+}
+
+// Entries returns a sequence of key/envelope pairs over the given bounds, including tombstones.
+// This is what a replica needs to send to its peers.
+// This LWWTrie must not be mutated during an Entries iteration.
+func (t *LWWTrie[V]) Entries(bounds *Bounds) iter.Seq2[[]byte, Timestamped[V]] {
+	return t.trie.Range(bounds)
+}
+
+// Merge applies every entry of other to this LWWTrie, returning the number of writes that won.
+// other is not modified, and must not be the same as this LWWTrie.
+func (t *LWWTrie[V]) Merge(other *LWWTrie[V]) int {
+	return t.MergeEntries(other.Entries(From(nil).To(nil)))
+}
+
+// MergeEntries applies every entry in entries, such as those received from a peer's [LWWTrie.Entries],
+// to this LWWTrie, returning the number of writes that won.
+func (t *LWWTrie[V]) MergeEntries(entries iter.Seq2[[]byte, Timestamped[V]]) int {
+	count := 0
+	for k, entry := range entries {
+		if t.apply(k, entry) {
+			count++
+		}
+	}
+	return count
+}
+
+// PruneTombstones removes tombstones with a timestamp before the given time, returning the number removed.
+// This should only be done once every replica has seen those tombstones,
+// otherwise an older put from a replica may reappear.
+func (t *LWWTrie[V]) PruneTombstones(before int64) int {
+	var keys [][]byte
+	for k, entry := range All(t.trie) {
+		if entry.Deleted && entry.Time < before {
+			keys = append(keys, k)
+		}
+	}
+	for _, k := range keys {
+		t.trie.Delete(k)
+	}
+	return len(keys)
+}
@@ -0,0 +1,111 @@
+package btrie_test
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/phiryll/btrie"
+	"github.com/stretchr/testify/assert"
+)
+
+func collectLWW(trie *btrie.LWWTrie[byte]) []entry {
+	entries := []entry{}
+	for k, v := range trie.Range(forwardAll) {
+		entries = append(entries, entry{k, v})
+	}
+	return entries
+}
+
+func TestLWWTrie(t *testing.T) {
+	t.Parallel()
+	trie := btrie.NewLWWTrie[byte]()
+	key := []byte{1}
+	assert.True(t, trie.Put(key, 1, 10))
+	assert.False(t, trie.Put(key, 2, 5), "older put loses")
+	assert.False(t, trie.Put(key, 3, 10), "equal put loses")
+	value, ok := trie.Get(key)
+	assert.True(t, ok)
+	assert.Equal(t, byte(1), value)
+
+	assert.True(t, trie.Delete(key, 10), "equal delete wins over put")
+	_, ok = trie.Get(key)
+	assert.False(t, ok)
+	envelope, ok := trie.GetEntry(key)
+	assert.True(t, ok)
+	assert.Equal(t, btrie.Timestamped[byte]{0, 10, true}, envelope)
+	assert.False(t, trie.Put(key, 4, 9), "older put loses to tombstone")
+	assert.True(t, trie.Put(key, 5, 11))
+	value, ok = trie.Get(key)
+	assert.True(t, ok)
+	assert.Equal(t, byte(5), value)
+
+	trie.Delete([]byte{2}, 3)
+	trie.Delete([]byte{3}, 30)
+	assert.Equal(t, []entry{{[]byte{1}, 5}}, collectLWW(trie))
+	assert.Equal(t, 1, trie.PruneTombstones(20))
+	_, ok = trie.GetEntry([]byte{2})
+	assert.False(t, ok)
+	_, ok = trie.GetEntry([]byte{3})
+	assert.True(t, ok)
+}
+
+func TestLWWTrieSkipUnchanged(t *testing.T) {
+	t.Parallel()
+	replica, peer := btrie.NewLWWTrie[byte](), btrie.NewLWWTrie[byte]()
+	replica.SkipUnchanged(btrie.Comparable[byte]())
+	assert.True(t, replica.Put([]byte{1}, 1, 10))
+	assert.False(t, replica.Put([]byte{1}, 1, 11), "unchanged value")
+	assert.True(t, replica.Delete([]byte{2}, 10), "a new tombstone is a change")
+	assert.False(t, replica.Delete([]byte{2}, 11), "still deleted")
+	assert.True(t, replica.Put([]byte{2}, 2, 12))
+
+	peer.Put([]byte{1}, 1, 20)
+	peer.Put([]byte{2}, 3, 20)
+	peer.Put([]byte{3}, 3, 20)
+	assert.Equal(t, 2, replica.Merge(peer), "only the changes to keys 2 and 3 are counted")
+	envelope, _ := replica.GetEntry([]byte{1})
+	assert.Equal(t, int64(20), envelope.Time, "the timestamp of an unchanged write is still recorded")
+
+	replica.SkipUnchanged(nil)
+	assert.True(t, replica.Put([]byte{1}, 1, 21))
+}
+
+func TestLWWTrieMergeConverges(t *testing.T) {
+	t.Parallel()
+	// Three replicas receive writes with unique timestamps in different orders.
+	const numWrites = 500
+	random := rand.New(rand.NewSource(8924375))
+	type write struct {
+		key     []byte
+		value   byte
+		time    int64
+		deleted bool
+	}
+	writes := make([]write, numWrites)
+	for i, time := range random.Perm(numWrites) {
+		writes[i] = write{randomKey(2, random), randomByte(random), int64(time), random.Intn(4) == 0}
+	}
+	replicas := []*btrie.LWWTrie[byte]{btrie.NewLWWTrie[byte](), btrie.NewLWWTrie[byte](), btrie.NewLWWTrie[byte]()}
+	for i, w := range writes {
+		replica := replicas[i%len(replicas)]
+		if w.deleted {
+			replica.Delete(w.key, w.time)
+		} else {
+			replica.Put(w.key, w.value, w.time)
+		}
+	}
+	a, b, c := replicas[0], replicas[1], replicas[2]
+	a.Merge(b)
+	a.Merge(c)
+	c.Merge(b)
+	c.Merge(a)
+	b.MergeEntries(c.Entries(forwardAll))
+	assert.Equal(t, collectLWW(a), collectLWW(b))
+	assert.Equal(t, collectLWW(a), collectLWW(c))
+	assert.Zero(t, a.Merge(b), "merging again changes nothing")
+
+	// need an early yield for test coverage
+	for range a.Range(forwardAll) {
+		break
+	}
+}
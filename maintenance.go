@@ -0,0 +1,125 @@
+package btrie
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// MaintenanceTask is periodic work run by [StartMaintenance], such as compacting a [HybridTrie] or [ArenaTrie],
+// or evicting entries with [EvictToSize].
+// The task runs concurrently with other goroutines, so it must synchronize its access to any trie it uses,
+// for example by only using a trie wrapped by [NewSynchronized], or by building a new trie and publishing it
+// with [Synchronized.Swap] or [AtomicTrie.Store].
+type MaintenanceTask struct {
+	// Name identifies the task to [MaintenanceOptions.OnError].
+	Name string
+
+	// Interval is the average time from the end of one run of the task to the start of the next,
+	// and from the start of maintenance to the first run.
+	Interval time.Duration
+
+	// Run does the work. ctx is done when maintenance is stopped, and a long run should return early when it is.
+	// A panic in Run is recovered and reported to [MaintenanceOptions.OnError] as a [*PanicError].
+	Run func(ctx context.Context) error
+}
+
+// MaintenanceOptions configures [StartMaintenance].
+type MaintenanceOptions struct {
+	// Tasks are the tasks to run. Each is run in its own goroutine, so a slow task does not delay the others,
+	// but runs of the same task never overlap.
+	Tasks []MaintenanceTask
+
+	// Jitter randomizes each delay before a task runs, which must be from 0 up to but not including 1.
+	// Each delay is chosen uniformly between Interval*(1-Jitter) and Interval*(1+Jitter),
+	// so that tasks started together, possibly in many processes, do not keep running at the same time.
+	Jitter float64
+
+	// OnError is called with the name of a task and the error it returned, from the task's goroutine.
+	// Errors returned because maintenance was stopped are not reported.
+	// If OnError is nil, errors are ignored.
+	OnError func(name string, err error)
+}
+
+// Maintenance is a running set of maintenance tasks, see [StartMaintenance].
+type Maintenance struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// StartMaintenance starts running the tasks in opts periodically in background goroutines,
+// until ctx is done or [Maintenance.Stop] is called.
+// StartMaintenance will panic if a task has a nil Run or a non-positive Interval,
+// or if opts.Jitter is not from 0 up to but not including 1.
+func StartMaintenance(ctx context.Context, opts MaintenanceOptions) *Maintenance {
+	for _, task := range opts.Tasks {
+		if task.Run == nil {
+			panic("task Run must be non-nil")
+		}
+		if task.Interval <= 0 {
+			panic("task Interval must be positive")
+		}
+	}
+	if opts.Jitter < 0 || opts.Jitter >= 1 {
+		panic("jitter must be in [0, 1)")
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	m := &Maintenance{cancel, make(chan struct{})}
+	var wg sync.WaitGroup
+	for _, task := range opts.Tasks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			runMaintenance(ctx, task, opts.Jitter, opts.OnError)
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(m.done)
+	}()
+	return m
+}
+
+// Stop stops running tasks, and waits for any runs in progress to return.
+// Stop may be called more than once, and from more than one goroutine.
+func (m *Maintenance) Stop() {
+	m.cancel()
+	<-m.done
+}
+
+// Done returns a channel which is closed once every task has stopped,
+// either because the context passed to [StartMaintenance] is done or because Stop was called.
+func (m *Maintenance) Done() <-chan struct{} {
+	return m.done
+}
+
+// Runs task periodically until ctx is done.
+func runMaintenance(ctx context.Context, task MaintenanceTask, jitter float64, onError func(string, error)) {
+	delay := func() time.Duration {
+		return time.Duration(float64(task.Interval) * (1 + jitter*(2*rand.Float64()-1))) //nolint:gosec // not for security
+	}
+	timer := time.NewTimer(delay())
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+		err := runMaintenanceOnce(ctx, task)
+		if err != nil && onError != nil && !(ctx.Err() != nil && errors.Is(err, ctx.Err())) {
+			onError(task.Name, err)
+		}
+		timer.Reset(delay())
+	}
+}
+
+// Runs task once, returning a panic as a *PanicError.
+//
+//nolint:nonamedreturns
+func runMaintenanceOnce(ctx context.Context, task MaintenanceTask) (err error) {
+	defer recoverPanic(&err)
+	return task.Run(ctx)
+}
@@ -0,0 +1,103 @@
+package btrie_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/phiryll/btrie"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStartMaintenance(t *testing.T) {
+	t.Parallel()
+	var runs atomic.Int32
+	trie := btrie.NewSynchronized(btrie.NewArrayTrie[byte]())
+	m := btrie.StartMaintenance(context.Background(), btrie.MaintenanceOptions{
+		Tasks: []btrie.MaintenanceTask{{
+			Name:     "count",
+			Interval: time.Millisecond,
+			Run: func(context.Context) error {
+				n := runs.Add(1)
+				trie.Put([]byte{byte(n)}, byte(n))
+				return nil
+			},
+		}},
+		Jitter: 0.5,
+	})
+	require.Eventually(t, func() bool { return runs.Load() >= 3 }, 5*time.Second, time.Millisecond)
+	m.Stop()
+	m.Stop()
+	stopped := runs.Load()
+	select {
+	case <-m.Done():
+	default:
+		t.Fatal("Done must be closed after Stop")
+	}
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, stopped, runs.Load())
+	assert.Len(t, collect(trie.Range(forwardAll)), int(stopped))
+}
+
+func TestStartMaintenanceErrors(t *testing.T) {
+	t.Parallel()
+	cause := errors.New("cause")
+	type report struct {
+		name string
+		err  error
+	}
+	reports := make(chan report, 100)
+	ctx, cancel := context.WithCancel(context.Background())
+	m := btrie.StartMaintenance(ctx, btrie.MaintenanceOptions{
+		Tasks: []btrie.MaintenanceTask{
+			{"fail", time.Millisecond, func(context.Context) error { return cause }},
+			{"panic", time.Millisecond, func(context.Context) error { panic(cause) }},
+			// Returns when stopped, which is not reported.
+			{"block", time.Millisecond, func(ctx context.Context) error {
+				<-ctx.Done()
+				return ctx.Err()
+			}},
+		},
+		OnError: func(name string, err error) {
+			select {
+			case reports <- report{name, err}:
+			default:
+			}
+		},
+	})
+	seen := map[string]bool{}
+	for len(seen) < 2 {
+		r := <-reports
+		seen[r.name] = true
+		require.ErrorIs(t, r.err, cause)
+		if r.name == "panic" {
+			var panicErr *btrie.PanicError
+			require.ErrorAs(t, r.err, &panicErr)
+		}
+	}
+	cancel()
+	<-m.Done()
+	close(reports)
+	for r := range reports {
+		assert.NotEqual(t, "block", r.name)
+	}
+}
+
+func TestStartMaintenancePanics(t *testing.T) {
+	t.Parallel()
+	run := func(context.Context) error { return nil }
+	for _, opts := range []btrie.MaintenanceOptions{
+		{Tasks: []btrie.MaintenanceTask{{"nil", time.Second, nil}}},
+		{Tasks: []btrie.MaintenanceTask{{"zero", 0, run}}},
+		{Tasks: []btrie.MaintenanceTask{{"ok", time.Second, run}}, Jitter: -0.1},
+		{Tasks: []btrie.MaintenanceTask{{"ok", time.Second, run}}, Jitter: 1},
+	} {
+		assert.Panics(t, func() { btrie.StartMaintenance(context.Background(), opts) })
+	}
+	// No tasks is allowed.
+	m := btrie.StartMaintenance(context.Background(), btrie.MaintenanceOptions{})
+	m.Stop()
+}
@@ -0,0 +1,74 @@
+package btrie
+
+import "iter"
+
+// MapTrie is a BTrie which keeps its entries in a Go map, and its keys in a trie only for Range.
+// Get, and Put and Delete of existing keys, are map operations that do not touch the trie,
+// so workloads dominated by point lookups with occasional ordered scans are faster than with any pure trie,
+// at the cost of storing every key twice.
+// MapTrie implements [BTrie], and is not safe for concurrent use.
+type MapTrie[V any] struct {
+	entries map[string]V
+	keys    BTrie[struct{}]
+}
+
+// NewMapTrie returns a new, empty MapTrie.
+func NewMapTrie[V any]() *MapTrie[V] {
+	return &MapTrie[V]{map[string]V{}, NewArrayTrie[struct{}]()}
+}
+
+// Len returns the number of entries in t.
+func (t *MapTrie[V]) Len() int {
+	return len(t.entries)
+}
+
+func (t *MapTrie[V]) Get(key []byte) (V, bool) {
+	if key == nil {
+		panic("key must be non-nil")
+	}
+	value, ok := t.entries[string(key)]
+	return value, ok
+}
+
+func (t *MapTrie[V]) Put(key []byte, value V) (V, bool) {
+	if key == nil {
+		panic("key must be non-nil")
+	}
+	prev, ok := t.entries[string(key)]
+	t.entries[string(key)] = value
+	if !ok {
+		t.keys.Put(key, struct{}{})
+	}
+	return prev, ok
+}
+
+func (t *MapTrie[V]) Delete(key []byte) (V, bool) {
+	if key == nil {
+		panic("key must be non-nil")
+	}
+	prev, ok := t.entries[string(key)]
+	if ok {
+		delete(t.entries, string(key))
+		t.keys.Delete(key)
+	}
+	return prev, ok
+}
+
+func (t *MapTrie[V]) Range(bounds *Bounds) iter.Seq2[[]byte, V] {
+	return t.rangeKeys(t.keys.Range(bounds))
+}
+
+func (t *MapTrie[V]) rangeWithMaxDepth(bounds *Bounds, maxDepth int) iter.Seq2[[]byte, V] {
+	return t.rangeKeys(WithMaxDepth(t.keys, maxDepth).Range(bounds))
+}
+
+// Returns the entries for keys, which must be from t.keys.
+func (t *MapTrie[V]) rangeKeys(keys iter.Seq2[[]byte, struct{}]) iter.Seq2[[]byte, V] {
+	return func(yield func([]byte, V) bool) {
+		for key := range keys {
+			if !yield(key, t.entries[string(key)]) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package btrie
+
+import "iter"
+
+// MapValues returns a read-only view of trie, with every value transformed by f.
+// MapValues will panic if f is nil.
+func MapValues[V, W any](trie BTrie[V], f func(V) W) ReadOnlyTrie[W] {
+	if f == nil {
+		panic("f must be non-nil")
+	}
+	return &mappedTrie[V, W]{trie, f}
+}
+
+type mappedTrie[V, W any] struct {
+	trie BTrie[V]
+	f    func(V) W
+}
+
+func (t *mappedTrie[V, W]) Get(key []byte) (W, bool) {
+	value, ok := t.trie.Get(key)
+	if !ok {
+		var zero W
+		return zero, false
+	}
+	return t.f(value), true
+}
+
+func (t *mappedTrie[V, W]) Range(bounds *Bounds) iter.Seq2[[]byte, W] {
+	itr := t.trie.Range(bounds)
+	return func(yield func([]byte, W) bool) {
+		for k, v := range itr {
+			if !yield(k, t.f(v)) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package btrie_test
+
+import (
+	"testing"
+
+	"github.com/phiryll/btrie"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMapValues(t *testing.T) {
+	t.Parallel()
+	assert.Panics(t, func() { btrie.MapValues[byte, int](btrie.NewArrayTrie[byte](), nil) })
+	for _, test := range createTestTries(rangeTestConfigs) {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			calls := 0
+			view := btrie.MapValues(test.trie, func(v byte) int {
+				calls++
+				return -int(v)
+			})
+			assert.Zero(t, calls, "must be lazy")
+			for k, v := range test.config.entries {
+				actual, ok := view.Get([]byte(k))
+				assert.True(t, ok)
+				assert.Equal(t, -int(v), actual)
+			}
+			for _, keys := range test.config.absent {
+				for _, key := range keys {
+					actual, ok := view.Get(key)
+					assert.False(t, ok)
+					assert.Zero(t, actual)
+				}
+			}
+			for _, bounds := range append(test.config.forward, test.config.reverse...) {
+				var expected []entry
+				for k, v := range test.trie.Range(&bounds) {
+					expected = append(expected, entry{k, v})
+				}
+				i := 0
+				for k, v := range view.Range(&bounds) {
+					assert.Equal(t, expected[i].key, k)
+					assert.Equal(t, -int(expected[i].value), v)
+					i++
+				}
+				assert.Len(t, expected, i)
+			}
+			// need an early yield for test coverage
+			for range view.Range(forwardAll) {
+				break
+			}
+		})
+	}
+
+	// The view reflects later changes.
+	trie := btrie.NewArrayTrie[byte]()
+	view := btrie.MapValues(trie, func(v byte) bool { return v > 5 })
+	trie.Put([]byte{1}, 9)
+	value, ok := view.Get([]byte{1})
+	assert.True(t, ok)
+	assert.True(t, value)
+	var _ btrie.ReadOnlyTrie[byte] = trie
+}
@@ -0,0 +1,179 @@
+// Package memtable provides the in-memory layer of a log-structured merge (LSM) storage engine,
+// built on the tries in package btrie.
+//
+// A [Table] has one active, mutable trie and zero or more frozen tries, which are no longer modified.
+// Reads merge all of them, with newer tries taking precedence over older ones.
+// Deletions are recorded as tombstones, so that they also shadow entries in older, flushed data.
+// Frozen tries are flushed in the serialized format written by [btrie.Encode], oldest first.
+package memtable
+
+import (
+	"errors"
+	"io"
+	"iter"
+
+	"github.com/phiryll/btrie"
+)
+
+// Record is a value stored in a Table's tries, or a tombstone if Deleted is true.
+type Record[V any] struct {
+	Value   V
+	Deleted bool
+}
+
+// Table is an active trie plus frozen tries, with merged reads.
+// Table implements [btrie.BTrie].
+// A Table is not safe for concurrent use.
+type Table[V any] struct {
+	active btrie.BTrie[Record[V]]
+	frozen []btrie.BTrie[Record[V]] // newest first
+}
+
+// New returns a new, empty Table.
+func New[V any]() *Table[V] {
+	return &Table[V]{btrie.NewArrayTrie[Record[V]](), nil}
+}
+
+// Get returns the value for key and whether or not it exists,
+// consulting the active trie and then the frozen tries, newest first.
+func (t *Table[V]) Get(key []byte) (V, bool) {
+	var zero V
+	record, ok := t.get(key)
+	if !ok || record.Deleted {
+		return zero, false
+	}
+	return record.Value, true
+}
+
+func (t *Table[V]) get(key []byte) (Record[V], bool) {
+	if record, ok := t.active.Get(key); ok {
+		return record, true
+	}
+	for _, trie := range t.frozen {
+		if record, ok := trie.Get(key); ok {
+			return record, true
+		}
+	}
+	return Record[V]{}, false
+}
+
+// Put sets the value for key in the active trie,
+// returning the previous value and whether or not the previous value existed in this Table.
+func (t *Table[V]) Put(key []byte, value V) (V, bool) {
+	prev, ok := t.Get(key)
+	t.active.Put(key, Record[V]{value, false})
+	return prev, ok
+}
+
+// Delete records a tombstone for key in the active trie,
+// returning the previous value and whether or not the previous value existed in this Table.
+// A tombstone is recorded even if the previous value did not exist,
+// because the key may exist in older data that has already been flushed.
+func (t *Table[V]) Delete(key []byte) (V, bool) {
+	prev, ok := t.Get(key)
+	t.active.Put(key, Record[V]{Deleted: true})
+	return prev, ok
+}
+
+// Range returns a sequence of key/value pairs over the given bounds,
+// merging the active and frozen tries and omitting tombstones.
+// The Table must not be mutated during a Range iteration.
+func (t *Table[V]) Range(bounds *btrie.Bounds) iter.Seq2[[]byte, V] {
+	tries := append([]btrie.BTrie[Record[V]]{t.active}, t.frozen...)
+	merged := btrie.MergeRanges(bounds, newest, tries...)
+	return func(yield func([]byte, V) bool) {
+		for key, record := range merged {
+			if record.Deleted {
+				continue
+			}
+			if !yield(key, record.Value) {
+				return
+			}
+		}
+	}
+}
+
+func newest[V any](_ []byte, records []Record[V]) Record[V] {
+	return records[0]
+}
+
+// Freeze makes the active trie the newest frozen trie, and replaces it with a new, empty active trie.
+// Freeze does nothing if the active trie is empty.
+func (t *Table[V]) Freeze() {
+	for range btrie.All(t.active) {
+		t.frozen = append([]btrie.BTrie[Record[V]]{t.active}, t.frozen...)
+		t.active = btrie.NewArrayTrie[Record[V]]()
+		return
+	}
+}
+
+// NumFrozen returns the number of frozen tries in this Table.
+func (t *Table[V]) NumFrozen() int {
+	return len(t.frozen)
+}
+
+// ErrNothingToFlush is returned by [Table.Flush] if there are no frozen tries.
+var ErrNothingToFlush = errors.New("no frozen tries to flush")
+
+// Flush writes the oldest frozen trie, including its tombstones, to w using [btrie.Encode],
+// and removes it from this Table.
+// The written data can be read with [btrie.Decode] and [RecordCodec].
+// Flush returns [ErrNothingToFlush] if there are no frozen tries.
+// If writing fails, the frozen trie is not removed.
+func (t *Table[V]) Flush(w io.Writer, codec btrie.Codec[V]) error {
+	if len(t.frozen) == 0 {
+		return ErrNothingToFlush
+	}
+	last := len(t.frozen) - 1
+	if err := btrie.Encode(w, t.frozen[last], RecordCodec(codec)); err != nil {
+		return err
+	}
+	t.frozen[last] = nil
+	t.frozen = t.frozen[:last]
+	return nil
+}
+
+// RecordCodec returns a Codec for Records, using codec to encode the non-tombstone values.
+func RecordCodec[V any](codec btrie.Codec[V]) btrie.Codec[Record[V]] {
+	return recordCodec[V]{codec}
+}
+
+type recordCodec[V any] struct {
+	codec btrie.Codec[V]
+}
+
+const (
+	liveRecord byte = iota
+	deletedRecord
+)
+
+// ErrInvalidRecord is returned when decoding a malformed Record.
+var ErrInvalidRecord = errors.New("invalid record")
+
+func (c recordCodec[V]) AppendValue(buf []byte, record Record[V]) ([]byte, error) {
+	if record.Deleted {
+		return append(buf, deletedRecord), nil
+	}
+	return c.codec.AppendValue(append(buf, liveRecord), record.Value)
+}
+
+func (c recordCodec[V]) DecodeValue(data []byte) (Record[V], error) {
+	if len(data) == 0 {
+		return Record[V]{}, ErrInvalidRecord
+	}
+	switch data[0] {
+	case deletedRecord:
+		if len(data) != 1 {
+			return Record[V]{}, ErrInvalidRecord
+		}
+		return Record[V]{Deleted: true}, nil
+	case liveRecord:
+		value, err := c.codec.DecodeValue(data[1:])
+		if err != nil {
+			return Record[V]{}, err
+		}
+		return Record[V]{value, false}, nil
+	default:
+		return Record[V]{}, ErrInvalidRecord
+	}
+}
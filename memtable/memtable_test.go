@@ -0,0 +1,136 @@
+package memtable_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/phiryll/btrie"
+	"github.com/phiryll/btrie/memtable"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	forwardAll = btrie.From(nil).To(nil)
+	reverseAll = btrie.From(nil).DownTo(nil)
+)
+
+type entry struct {
+	key   string
+	value string
+}
+
+func collect(table *memtable.Table[[]byte], bounds *btrie.Bounds) []entry {
+	entries := []entry{}
+	for k, v := range table.Range(bounds) {
+		entries = append(entries, entry{string(k), string(v)})
+	}
+	return entries
+}
+
+func assertGet(t *testing.T, table *memtable.Table[[]byte], key, expected string, expectedOk bool) {
+	value, ok := table.Get([]byte(key))
+	assert.Equal(t, expectedOk, ok, "%q", key)
+	if expectedOk {
+		assert.Equal(t, expected, string(value), "%q", key)
+	}
+}
+
+func TestTable(t *testing.T) {
+	t.Parallel()
+	table := memtable.New[[]byte]()
+	var _ btrie.BTrie[[]byte] = table
+
+	prev, ok := table.Put([]byte("a"), []byte("1"))
+	assert.False(t, ok)
+	assert.Nil(t, prev)
+	table.Put([]byte("b"), []byte("2"))
+	table.Put([]byte("c"), []byte("3"))
+	table.Freeze()
+	assert.Equal(t, 1, table.NumFrozen())
+
+	prev, ok = table.Put([]byte("b"), []byte("20"))
+	assert.True(t, ok)
+	assert.Equal(t, "2", string(prev))
+	prev, ok = table.Delete([]byte("c"))
+	assert.True(t, ok)
+	assert.Equal(t, "3", string(prev))
+	_, ok = table.Delete([]byte("z"))
+	assert.False(t, ok)
+	table.Put([]byte(""), []byte("0"))
+	table.Freeze()
+	table.Put([]byte("d"), []byte("4"))
+	assert.Equal(t, 2, table.NumFrozen())
+
+	assertGet(t, table, "", "0", true)
+	assertGet(t, table, "a", "1", true)
+	assertGet(t, table, "b", "20", true)
+	assertGet(t, table, "c", "", false)
+	assertGet(t, table, "d", "4", true)
+	assertGet(t, table, "z", "", false)
+	assert.Equal(t, []entry{{"", "0"}, {"a", "1"}, {"b", "20"}, {"d", "4"}}, collect(table, forwardAll))
+	assert.Equal(t, []entry{{"d", "4"}, {"b", "20"}, {"a", "1"}, {"", "0"}}, collect(table, reverseAll))
+	assert.Equal(t, []entry{{"a", "1"}, {"b", "20"}}, collect(table, btrie.From([]byte("a")).To([]byte("c"))))
+
+	// need an early yield for test coverage
+	for range table.Range(forwardAll) {
+		break
+	}
+}
+
+func TestFreezeEmpty(t *testing.T) {
+	t.Parallel()
+	table := memtable.New[[]byte]()
+	table.Freeze()
+	assert.Equal(t, 0, table.NumFrozen())
+	table.Delete([]byte("a"))
+	table.Freeze()
+	assert.Equal(t, 1, table.NumFrozen(), "a tombstone is not empty")
+}
+
+func TestFlush(t *testing.T) {
+	t.Parallel()
+	table := memtable.New[[]byte]()
+	assert.ErrorIs(t, table.Flush(&bytes.Buffer{}, btrie.BytesCodec{}), memtable.ErrNothingToFlush)
+
+	table.Put([]byte("a"), []byte("1"))
+	table.Put([]byte("b"), []byte("2"))
+	table.Freeze()
+	table.Delete([]byte("a"))
+	table.Put([]byte("c"), []byte("3"))
+	table.Freeze()
+
+	// oldest first
+	var buf bytes.Buffer
+	require.NoError(t, table.Flush(&buf, btrie.BytesCodec{}))
+	assert.Equal(t, 1, table.NumFrozen())
+	flushed := btrie.NewArrayTrie[memtable.Record[[]byte]]()
+	require.NoError(t, btrie.Decode(&buf, flushed, memtable.RecordCodec[[]byte](btrie.BytesCodec{})))
+	record, ok := flushed.Get([]byte("a"))
+	assert.True(t, ok)
+	assert.Equal(t, memtable.Record[[]byte]{[]byte("1"), false}, record)
+
+	buf.Reset()
+	require.NoError(t, table.Flush(&buf, btrie.BytesCodec{}))
+	assert.Equal(t, 0, table.NumFrozen())
+	flushed = btrie.NewArrayTrie[memtable.Record[[]byte]]()
+	require.NoError(t, btrie.Decode(&buf, flushed, memtable.RecordCodec[[]byte](btrie.BytesCodec{})))
+	record, ok = flushed.Get([]byte("a"))
+	assert.True(t, ok)
+	assert.True(t, record.Deleted, "tombstones must be flushed")
+	record, ok = flushed.Get([]byte("c"))
+	assert.True(t, ok)
+	assert.Equal(t, memtable.Record[[]byte]{[]byte("3"), false}, record)
+
+	// flushed data is no longer visible
+	assertGet(t, table, "b", "", false)
+}
+
+func TestRecordCodecErrors(t *testing.T) {
+	t.Parallel()
+	codec := memtable.RecordCodec[[]byte](btrie.BytesCodec{})
+	for _, data := range [][]byte{{}, {2}, {1, 0}} {
+		_, err := codec.DecodeValue(data)
+		assert.ErrorIs(t, err, memtable.ErrInvalidRecord, "%v", data)
+	}
+}
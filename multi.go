@@ -0,0 +1,85 @@
+package btrie
+
+import (
+	"iter"
+	"slices"
+)
+
+// MultiTrie maps each key to an ordered collection of values, in the order they were added.
+// The same value may be added to a key more than once.
+// Keys without any values are not present.
+//
+// Values are appended in place, so adding a value does not copy the key's existing values.
+// Slices of values returned by a MultiTrie must not be modified,
+// and are only valid until the values for that key are next changed.
+//
+// A MultiTrie is not safe for concurrent use.
+type MultiTrie[V any] struct {
+	trie BTrie[*[]V]
+}
+
+// NewMultiTrie returns a new, empty MultiTrie.
+func NewMultiTrie[V any]() *MultiTrie[V] {
+	return &MultiTrie[V]{NewArrayTrie[*[]V]()}
+}
+
+// Get returns the values for key, in the order they were added, and whether or not any exist.
+func (t *MultiTrie[V]) Get(key []byte) ([]V, bool) {
+	values, ok := t.trie.Get(key)
+	if !ok {
+		return nil, false
+	}
+	return slices.Clip(*values), true
+}
+
+// Add appends value to the values for key, returning the number of values key now has.
+func (t *MultiTrie[V]) Add(key []byte, value V) int {
+	if values, ok := t.trie.Get(key); ok {
+		*values = append(*values, value)
+		return len(*values)
+	}
+	t.trie.Put(key, &[]V{value})
+	return 1
+}
+
+// RemoveValue removes the first of the values for key equal to value, returning whether one was removed.
+// If key has no values left, key is removed.
+func (t *MultiTrie[V]) RemoveValue(key []byte, value V, eq Equaler[V]) bool {
+	values, ok := t.trie.Get(key)
+	if !ok {
+		return false
+	}
+	index := slices.IndexFunc(*values, func(v V) bool {
+		return eq.Equal(v, value)
+	})
+	if index < 0 {
+		return false
+	}
+	*values = slices.Delete(*values, index, index+1)
+	if len(*values) == 0 {
+		t.trie.Delete(key)
+	}
+	return true
+}
+
+// Delete removes key and all of its values, returning the previous values and whether any existed.
+func (t *MultiTrie[V]) Delete(key []byte) ([]V, bool) {
+	values, ok := t.trie.Delete(key)
+	if !ok {
+		return nil, false
+	}
+	return *values, true
+}
+
+// Range returns a sequence of keys and their values over the given bounds.
+// This MultiTrie must not be mutated during a Range iteration.
+func (t *MultiTrie[V]) Range(bounds *Bounds) iter.Seq2[[]byte, []V] {
+	itr := t.trie.Range(bounds)
+	return func(yield func([]byte, []V) bool) {
+		for k, values := range itr {
+			if !yield(k, slices.Clip(*values)) {
+				return
+			}
+		}
+	}
+}
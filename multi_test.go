@@ -0,0 +1,65 @@
+package btrie_test
+
+import (
+	"testing"
+
+	"github.com/phiryll/btrie"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMultiTrie(t *testing.T) {
+	t.Parallel()
+	eq := btrie.Comparable[byte]()
+	trie := btrie.NewMultiTrie[byte]()
+	a, b, c := []byte("a"), []byte("b"), []byte("c")
+	values, ok := trie.Get(a)
+	assert.False(t, ok)
+	assert.Nil(t, values)
+
+	assert.Equal(t, 1, trie.Add(a, 1))
+	assert.Equal(t, 2, trie.Add(a, 2))
+	assert.Equal(t, 3, trie.Add(a, 1))
+	assert.Equal(t, 1, trie.Add(b, 3))
+	assert.Equal(t, 1, trie.Add(c, 4))
+	values, ok = trie.Get(a)
+	assert.True(t, ok)
+	assert.Equal(t, []byte{1, 2, 1}, values)
+
+	// Appending to a returned slice must not affect the trie.
+	_ = append(values, 9)
+	trie.Add(a, 5)
+	values, _ = trie.Get(a)
+	assert.Equal(t, []byte{1, 2, 1, 5}, values)
+
+	assert.True(t, trie.RemoveValue(a, 1, eq))
+	values, _ = trie.Get(a)
+	assert.Equal(t, []byte{2, 1, 5}, values)
+	assert.False(t, trie.RemoveValue(a, 7, eq))
+	assert.False(t, trie.RemoveValue([]byte("d"), 1, eq))
+
+	assert.True(t, trie.RemoveValue(b, 3, eq))
+	_, ok = trie.Get(b)
+	assert.False(t, ok, "key without values is removed")
+
+	type multiEntry struct {
+		key    string
+		values []byte
+	}
+	var entries []multiEntry
+	for k, v := range trie.Range(reverseAll) {
+		entries = append(entries, multiEntry{string(k), v})
+	}
+	assert.Equal(t, []multiEntry{{"c", []byte{4}}, {"a", []byte{2, 1, 5}}}, entries)
+
+	values, ok = trie.Delete(a)
+	assert.True(t, ok)
+	assert.Equal(t, []byte{2, 1, 5}, values)
+	values, ok = trie.Delete(a)
+	assert.False(t, ok)
+	assert.Nil(t, values)
+
+	// need an early yield for test coverage
+	for range trie.Range(forwardAll) {
+		break
+	}
+}
@@ -0,0 +1,236 @@
+package btrie
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"iter"
+	"slices"
+)
+
+// ErrQuotaExceeded is wrapped by the error returned from [Namespace.TryPut], and reported by [Namespace.Err]
+// after [Namespace.Put] rejects an entry, if the put would exceed the namespace's [Quota].
+var ErrQuotaExceeded = errors.New("namespace quota exceeded")
+
+// Quota limits the contents of a [Namespace]. A zero limit means there is no limit.
+type Quota struct {
+	// MaxEntries is the largest number of entries the namespace may have.
+	MaxEntries int
+
+	// MaxBytes is the largest total size of the entries in the namespace, as given by the sizer of its [Namespaces].
+	MaxBytes int64
+}
+
+// NamespaceStats is the number and total size of the entries in a [Namespace].
+type NamespaceStats struct {
+	Entries int
+	Bytes   int64
+}
+
+// Namespaces manages namespaces, such as one per tenant, which share a single BTrie.
+// Each namespace stores its entries under a reserved prefix of the shared trie's keys,
+// which is the uvarint length of the namespace's name followed by the name,
+// so that no namespace's keys are a prefix of another namespace's keys.
+// Namespaces is not safe for concurrent use, and the shared trie must only be mutated through its namespaces.
+type Namespaces[V any] struct {
+	trie   BTrie[V]
+	sizer  func(key []byte, value V) int64
+	spaces map[string]*Namespace[V]
+}
+
+// NewNamespaces returns a new Namespaces sharing trie.
+// The size of each entry, used by [Quota.MaxBytes] and [NamespaceStats.Bytes], is given by sizer,
+// which is passed the key within the namespace. If sizer is nil, the size of an entry is the length of its key.
+func NewNamespaces[V any](trie BTrie[V], sizer func(key []byte, value V) int64) *Namespaces[V] {
+	if sizer == nil {
+		sizer = func(key []byte, _ V) int64 { return int64(len(key)) }
+	}
+	return &Namespaces[V]{trie, sizer, map[string]*Namespace[V]{}}
+}
+
+// Open returns the namespace with the given name, creating it with no quota if it has not been opened before.
+// When a namespace is created, any entries already in the shared trie under its prefix are counted toward its stats,
+// so namespaces can be reopened over a trie restored from storage.
+// Open will panic if name is nil.
+func (n *Namespaces[V]) Open(name []byte) *Namespace[V] {
+	if name == nil {
+		panic("name must be non-nil")
+	}
+	if space, ok := n.spaces[string(name)]; ok {
+		return space
+	}
+	prefix := binary.AppendUvarint(nil, uint64(len(name)))
+	prefix = append(prefix, name...)
+	space := &Namespace[V]{n, bytes.Clone(name), prefix, Quota{}, NamespaceStats{}, nil}
+	for key, value := range space.Range(From(nil).To(nil)) {
+		space.stats.Entries++
+		space.stats.Bytes += n.sizer(key, value)
+	}
+	n.spaces[string(name)] = space
+	return space
+}
+
+// Names returns the names of the opened namespaces in increasing order.
+func (n *Namespaces[V]) Names() [][]byte {
+	names := make([][]byte, 0, len(n.spaces))
+	for _, space := range n.spaces {
+		names = append(names, bytes.Clone(space.name))
+	}
+	slices.SortFunc(names, bytes.Compare)
+	return names
+}
+
+// Drop deletes every entry in the namespace with the given name and forgets it, returning the number of deleted entries.
+// The namespace need not have been opened. Any [Namespace] previously returned for it must no longer be used.
+// Drop will panic if name is nil.
+func (n *Namespaces[V]) Drop(name []byte) int {
+	space := n.Open(name)
+	var keys [][]byte
+	for key := range space.Range(From(nil).To(nil)) {
+		keys = append(keys, key)
+	}
+	for _, key := range keys {
+		space.Delete(key)
+	}
+	delete(n.spaces, string(name))
+	return len(keys)
+}
+
+// Namespace is the view of one namespace of a [Namespaces], whose keys are those of the shared trie
+// having the namespace's reserved prefix, with the prefix removed.
+//
+// Exceeding a quota is not a bug, so a Put which would exceed this namespace's quota does not panic.
+// It is rejected, leaving the namespace unchanged, and returns the key's current value and whether it had one,
+// as though that value had been replaced by itself. The first such rejection is reported by [Namespace.Err].
+// Use [Namespace.TryPut] to learn whether each put succeeded.
+// Namespace implements [BTrie], and has the same constraints on concurrency as its Namespaces.
+type Namespace[V any] struct {
+	owner  *Namespaces[V]
+	name   []byte
+	prefix []byte
+	quota  Quota
+	stats  NamespaceStats
+	err    error
+}
+
+// Name returns the name of this namespace.
+func (s *Namespace[V]) Name() []byte {
+	return bytes.Clone(s.name)
+}
+
+// Quota returns the quota of this namespace.
+func (s *Namespace[V]) Quota() Quota {
+	return s.quota
+}
+
+// SetQuota sets the quota of this namespace, which is only enforced by later puts.
+// A namespace which already exceeds the new quota keeps its entries, but puts which would grow it fail.
+func (s *Namespace[V]) SetQuota(quota Quota) {
+	s.quota = quota
+}
+
+// Stats returns the number and total size of the entries in this namespace.
+func (s *Namespace[V]) Stats() NamespaceStats {
+	return s.stats
+}
+
+// Err returns an error wrapping [ErrQuotaExceeded] for the first put rejected by [Namespace.Put],
+// or nil if there was none. Puts rejected by [Namespace.TryPut] are not reported, since TryPut returns the error.
+func (s *Namespace[V]) Err() error {
+	return s.err
+}
+
+func (s *Namespace[V]) key(key []byte) []byte {
+	if key == nil {
+		panic("key must be non-nil")
+	}
+	return append(slices.Clip(s.prefix), key...)
+}
+
+func (s *Namespace[V]) Get(key []byte) (V, bool) {
+	return s.owner.trie.Get(s.key(key))
+}
+
+// Put rejects the entry if it would exceed this namespace's quota, as described by [Namespace].
+func (s *Namespace[V]) Put(key []byte, value V) (V, bool) {
+	prev, ok, err := s.TryPut(key, value)
+	if err != nil {
+		if s.err == nil {
+			s.err = err
+		}
+		return s.Get(key)
+	}
+	return prev, ok
+}
+
+// TryPut is like Put, but returns an error wrapping [ErrQuotaExceeded]
+// if the put would exceed this namespace's quota, in which case nothing is changed.
+// Replacing a value never fails because of MaxEntries, but may because of MaxBytes if the new value is larger.
+func (s *Namespace[V]) TryPut(key []byte, value V) (V, bool, error) {
+	fullKey := s.key(key)
+	stats := s.stats
+	prev, ok := s.owner.trie.Get(fullKey)
+	if ok {
+		stats.Bytes -= s.owner.sizer(key, prev)
+	} else {
+		stats.Entries++
+	}
+	stats.Bytes += s.owner.sizer(key, value)
+	if s.quota.MaxEntries > 0 && stats.Entries > s.quota.MaxEntries {
+		var zero V
+		return zero, false, fmt.Errorf("%w: namespace %q would have %d entries, limit is %d",
+			ErrQuotaExceeded, s.name, stats.Entries, s.quota.MaxEntries)
+	}
+	if s.quota.MaxBytes > 0 && stats.Bytes > s.quota.MaxBytes {
+		var zero V
+		return zero, false, fmt.Errorf("%w: namespace %q would have %d bytes, limit is %d",
+			ErrQuotaExceeded, s.name, stats.Bytes, s.quota.MaxBytes)
+	}
+	s.stats = stats
+	prev, ok = s.owner.trie.Put(fullKey, value)
+	return prev, ok, nil
+}
+
+func (s *Namespace[V]) Delete(key []byte) (V, bool) {
+	prev, ok := s.owner.trie.Delete(s.key(key))
+	if ok {
+		s.stats.Entries--
+		s.stats.Bytes -= s.owner.sizer(key, prev)
+	}
+	return prev, ok
+}
+
+func (s *Namespace[V]) Range(bounds *Bounds) iter.Seq2[[]byte, V] {
+	// A nil Begin or End becomes the corresponding end of this namespace, which is only exact when forward.
+	// When reverse, the key after this namespace may be included and the smallest key of this namespace would not be,
+	// so the translated bounds are wider, and keys not in this namespace are skipped below.
+	succ, _ := PrefixSuccessor(s.prefix)
+	translated := &Bounds{s.translate(bounds.Begin, s.prefix), s.translate(bounds.End, succ), bounds.IsReverse}
+	if bounds.IsReverse {
+		translated.Begin = s.translate(bounds.Begin, succ)
+		translated.End = s.translate(bounds.End, nil)
+	}
+	itr := s.owner.trie.Range(translated)
+	return func(yield func([]byte, V) bool) {
+		for key, value := range itr {
+			if !bytes.HasPrefix(key, s.prefix) {
+				if bytes.Compare(key, s.prefix) < 0 {
+					return
+				}
+				continue
+			}
+			if !yield(key[len(s.prefix):], value) {
+				return
+			}
+		}
+	}
+}
+
+// Returns the key in the shared trie for bound, or ifNil if bound is nil.
+func (s *Namespace[V]) translate(bound, ifNil []byte) []byte {
+	if bound == nil {
+		return ifNil
+	}
+	return s.key(bound)
+}
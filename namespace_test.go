@@ -0,0 +1,97 @@
+package btrie_test
+
+import (
+	"testing"
+
+	"github.com/phiryll/btrie"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNamespaces(t *testing.T) {
+	t.Parallel()
+	shared := btrie.NewArrayTrie[byte]()
+	spaces := btrie.NewNamespaces[byte](shared, nil)
+	// Neither name's keys may be a prefix of the other's.
+	a := spaces.Open([]byte("a"))
+	ab := spaces.Open([]byte("ab"))
+	assert.Same(t, a, spaces.Open([]byte("a")))
+	a.Put([]byte{}, 1)
+	a.Put([]byte("b"), 2)
+	a.Put([]byte("bc"), 3)
+	ab.Put([]byte{}, 4)
+	ab.Put([]byte("c"), 5)
+	assertSame(t, map[string]byte{"": 1, "b": 2, "bc": 3}, a)
+	assertSame(t, map[string]byte{"": 4, "c": 5}, ab)
+	assert.Equal(t, []entry{{[]byte("b"), 2}}, collect(a.Range(btrie.From([]byte("a")).To([]byte("bc")))))
+	assert.Equal(t, []entry{{[]byte("bc"), 3}, {[]byte("b"), 2}},
+		collect(a.Range(btrie.From(nil).DownTo([]byte{}))))
+	assert.Equal(t, btrie.NamespaceStats{Entries: 3, Bytes: 3}, a.Stats())
+	assert.Equal(t, [][]byte{[]byte("a"), []byte("ab")}, spaces.Names())
+
+	prev, ok := a.Delete([]byte("bc"))
+	assert.True(t, ok)
+	assert.Equal(t, byte(3), prev)
+	_, ok = a.Delete([]byte("bc"))
+	assert.False(t, ok)
+	assert.Equal(t, btrie.NamespaceStats{Entries: 2, Bytes: 1}, a.Stats())
+
+	// Reopening over the same trie recounts the existing entries.
+	reopened := btrie.NewNamespaces[byte](shared, nil).Open([]byte("ab"))
+	assert.Equal(t, btrie.NamespaceStats{Entries: 2, Bytes: 1}, reopened.Stats())
+
+	assert.Equal(t, 2, spaces.Drop([]byte("a")))
+	assert.Equal(t, [][]byte{[]byte("ab")}, spaces.Names())
+	assertSame(t, map[string]byte{"": 4, "c": 5}, ab)
+	assert.Equal(t, 2, spaces.Drop([]byte("ab")))
+	assert.Empty(t, collect(shared.Range(forwardAll)))
+}
+
+func TestNamespaceQuota(t *testing.T) {
+	t.Parallel()
+	sizer := func(key []byte, value byte) int64 { return int64(len(key)) + int64(value) }
+	spaces := btrie.NewNamespaces[byte](btrie.NewArrayTrie[byte](), sizer)
+	space := spaces.Open([]byte("tenant"))
+	space.SetQuota(btrie.Quota{MaxEntries: 2, MaxBytes: 10})
+	assert.Equal(t, btrie.Quota{MaxEntries: 2, MaxBytes: 10}, space.Quota())
+
+	_, _, err := space.TryPut([]byte{1}, 3)
+	require.NoError(t, err)
+	_, _, err = space.TryPut([]byte{2}, 3)
+	require.NoError(t, err)
+	_, _, err = space.TryPut([]byte{3}, 0)
+	require.ErrorIs(t, err, btrie.ErrQuotaExceeded)
+	_, _, err = space.TryPut([]byte{2}, 6)
+	require.ErrorIs(t, err, btrie.ErrQuotaExceeded)
+	prev, ok, err := space.TryPut([]byte{2}, 5)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, byte(3), prev)
+	assert.Equal(t, btrie.NamespaceStats{Entries: 2, Bytes: 10}, space.Stats())
+
+	// Put rejects the entry, returning the current value, and reports the first rejection through Err.
+	require.NoError(t, space.Err())
+	prev, ok = space.Put([]byte{3}, 0)
+	assert.False(t, ok)
+	assert.Equal(t, byte(0), prev)
+	prev, ok = space.Put([]byte{2}, 6)
+	assert.True(t, ok)
+	assert.Equal(t, byte(5), prev)
+	require.ErrorIs(t, space.Err(), btrie.ErrQuotaExceeded)
+	assert.Contains(t, space.Err().Error(), "3 entries")
+	assertSame(t, map[string]byte{"\x01": 3, "\x02": 5}, space)
+
+	space.SetQuota(btrie.Quota{})
+	space.Put([]byte{3}, 100)
+	assert.Equal(t, btrie.NamespaceStats{Entries: 3, Bytes: 111}, space.Stats())
+}
+
+func TestNamespacesPanics(t *testing.T) {
+	t.Parallel()
+	spaces := btrie.NewNamespaces[byte](btrie.NewArrayTrie[byte](), nil)
+	assert.Panics(t, func() { spaces.Open(nil) })
+	space := spaces.Open([]byte{})
+	assert.Panics(t, func() { space.Get(nil) })
+	assert.Panics(t, func() { space.Put(nil, 0) })
+	assert.Panics(t, func() { space.Delete(nil) })
+}
@@ -0,0 +1,105 @@
+package btrie
+
+import (
+	"iter"
+)
+
+// Overlay is a BTrie recording puts and deletes locally, over a base BTrie which is not modified until Commit.
+// Reads merge the local changes with base, so an Overlay behaves as if its changes had been made to base.
+// Deletes of keys in base are recorded locally as tombstones.
+// Overlay implements [BTrie], and is not safe for concurrent use.
+// Neither the Overlay nor base should be mutated during a Range iteration.
+type Overlay[V any] struct {
+	base    BTrie[V]
+	changes BTrie[change[V]]
+}
+
+// A local change in an Overlay, either a new value or a tombstone.
+type change[V any] struct {
+	value   V
+	deleted bool
+}
+
+// NewOverlay returns a new Overlay with no changes over base.
+func NewOverlay[V any](base BTrie[V]) *Overlay[V] {
+	return &Overlay[V]{base, NewArrayTrie[change[V]]()}
+}
+
+func (o *Overlay[V]) Get(key []byte) (V, bool) {
+	if c, ok := o.changes.Get(key); ok {
+		if c.deleted {
+			var zero V
+			return zero, false
+		}
+		return c.value, true
+	}
+	return o.base.Get(key)
+}
+
+func (o *Overlay[V]) Put(key []byte, value V) (V, bool) {
+	prev, ok := o.Get(key)
+	o.changes.Put(key, change[V]{value, false})
+	return prev, ok
+}
+
+func (o *Overlay[V]) Delete(key []byte) (V, bool) {
+	prev, ok := o.Get(key)
+	if !ok {
+		return prev, false
+	}
+	if _, inBase := o.base.Get(key); inBase {
+		o.changes.Put(key, change[V]{deleted: true})
+	} else {
+		o.changes.Delete(key)
+	}
+	return prev, true
+}
+
+func (o *Overlay[V]) Range(bounds *Bounds) iter.Seq2[[]byte, V] {
+	baseItr := o.base.Range(bounds)
+	baseChanges := func(yield func([]byte, change[V]) bool) {
+		for k, v := range baseItr {
+			if !yield(k, change[V]{v, false}) {
+				return
+			}
+		}
+	}
+	merged := mergeSeqs(bounds.IsReverse, localChange, o.changes.Range(bounds), baseChanges)
+	return func(yield func([]byte, V) bool) {
+		for k, c := range merged {
+			if c.deleted {
+				continue
+			}
+			if !yield(k, c.value) {
+				return
+			}
+		}
+	}
+}
+
+// The local change takes precedence over the base value.
+func localChange[V any](_ []byte, changes []change[V]) change[V] {
+	return changes[0]
+}
+
+// Commit applies all local changes to base, and then discards them.
+func (o *Overlay[V]) Commit() {
+	o.applyTo(o.base)
+	o.Discard()
+}
+
+// Applies all local changes to trie.
+func (o *Overlay[V]) applyTo(trie BTrie[V]) {
+	for k, c := range All(o.changes) {
+		if c.deleted {
+			trie.Delete(k)
+		} else {
+			trie.Put(k, c.value)
+		}
+	}
+}
+
+// Discard drops all local changes without applying them.
+func (o *Overlay[V]) Discard() {
+	o.changes = NewArrayTrie[change[V]]()
+}
@@ -0,0 +1,65 @@
+package btrie_test
+
+import (
+	"testing"
+
+	"github.com/phiryll/btrie"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOverlay(t *testing.T) {
+	t.Parallel()
+	for _, test := range createTestTries(rangeTestConfigs) {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			base := test.trie.Clone()
+			overlay := btrie.NewOverlay[byte](base)
+			expected := createReferenceTrie(test.config)
+			assertSame(t, test.config.entries, overlay)
+
+			// Change every present and absent test key, with some deletes.
+			for i, key := range nearTestKeys[1 : len(nearTestKeys)-1] {
+				if i%3 == 0 {
+					ePrev, eOk := expected.Delete(key)
+					prev, ok := overlay.Delete(key)
+					assert.Equal(t, eOk, ok)
+					assert.Equal(t, ePrev, prev)
+				} else {
+					ePrev, eOk := expected.Put(key, byte(i))
+					prev, ok := overlay.Put(key, byte(i))
+					assert.Equal(t, eOk, ok)
+					assert.Equal(t, ePrev, prev)
+				}
+			}
+			// Delete a key only put in the overlay.
+			expected.Delete(nearTestKeys[2])
+			overlay.Delete(nearTestKeys[2])
+
+			expectedEntries := map[string]byte{}
+			for k, v := range expected.Range(forwardAll) {
+				expectedEntries[string(k)] = v
+			}
+			assertSame(t, expectedEntries, overlay)
+			assertSame(t, test.config.entries, base)
+			for _, bounds := range test.config.forward {
+				assert.Equal(t, collect(expected.Range(&bounds)), collect(overlay.Range(&bounds)), "%s", bounds)
+			}
+			for _, bounds := range test.config.reverse {
+				assert.Equal(t, collect(expected.Range(&bounds)), collect(overlay.Range(&bounds)), "%s", bounds)
+			}
+
+			overlay.Commit()
+			assertSame(t, expectedEntries, base)
+			assertSame(t, expectedEntries, overlay)
+
+			overlay.Put([]byte{0x99}, 99)
+			overlay.Discard()
+			assertSame(t, expectedEntries, overlay)
+
+			// need an early yield for test coverage
+			for range overlay.Range(forwardAll) {
+				break
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package btrie
+
+import "iter"
+
+// Pinned is a BTrie with a set of pinned keys, whose entries are exempt from eviction by [EvictFraction].
+// Pinning only protects an entry from eviction; it can still be replaced by Put or removed by Delete.
+// A key may be pinned whether or not it has an entry, and remains pinned until it is unpinned.
+// Pinned implements [BTrie], and is not safe for concurrent use.
+type Pinned[V any] struct {
+	trie BTrie[V]
+	pins *ByteSet
+}
+
+// WithPins returns a Pinned wrapping trie, with no pinned keys.
+func WithPins[V any](trie BTrie[V]) *Pinned[V] {
+	return &Pinned[V]{trie, NewByteSet()}
+}
+
+// Pin pins key, returning true if it was not already pinned. Pin will panic if key is nil.
+func (p *Pinned[V]) Pin(key []byte) bool {
+	return p.pins.Add(key)
+}
+
+// Unpin unpins key, returning true if it was pinned. Unpin will panic if key is nil.
+func (p *Pinned[V]) Unpin(key []byte) bool {
+	return p.pins.Remove(key)
+}
+
+// IsPinned returns whether key is pinned. IsPinned will panic if key is nil.
+func (p *Pinned[V]) IsPinned(key []byte) bool {
+	return p.pins.Contains(key)
+}
+
+// PinnedKeys returns a sequence of the pinned keys over the given bounds, which need not have entries.
+func (p *Pinned[V]) PinnedKeys(bounds *Bounds) iter.Seq[[]byte] {
+	return p.pins.RangeKeys(bounds)
+}
+
+func (p *Pinned[V]) Get(key []byte) (V, bool) {
+	return p.trie.Get(key)
+}
+
+func (p *Pinned[V]) Put(key []byte, value V) (V, bool) {
+	return p.trie.Put(key, value)
+}
+
+func (p *Pinned[V]) Delete(key []byte) (V, bool) {
+	return p.trie.Delete(key)
+}
+
+func (p *Pinned[V]) Range(bounds *Bounds) iter.Seq2[[]byte, V] {
+	return p.trie.Range(bounds)
+}
+
+// Implemented by tries having entries which must not be evicted.
+type pinChecker interface {
+	IsPinned(key []byte) bool
+}
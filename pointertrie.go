@@ -4,36 +4,175 @@ import (
 	"bytes"
 	"fmt"
 	"iter"
+	"math"
+	"math/bits"
 	"strings"
 )
 
-//nolint:govet  // govet wants V first, but that doesn't give the best alignment
+const (
+	ptrSlabBits = 8
+	ptrSlabSize = 1 << ptrSlabBits
+	ptrSlabMask = ptrSlabSize - 1
+
+	// The root is never a child, so its index also means "no node".
+	ptrRoot int32 = 0
+	ptrNone int32 = 0
+
+	// A child range has a capacity of 1<<class, and no node has more than 256 children.
+	ptrMaxClass = 8
+)
+
+// Nodes refer to each other by index instead of by pointer, so a slab of nodes contains no pointers
+// unless V does, and the garbage collector does not need to scan it.
+// A node's children are a contiguous range of the trie's keyBytes and childNodes, sorted by key byte.
 type ptrTrieNode[V any] struct {
-	children   []*ptrTrieNode[V]
-	value      V // valid only if isTerminal is true
-	keyByte    byte
+	value      V      // valid only if isTerminal is true
+	children   int32  // offset of the child range if size > 0, also links the free list of nodes
+	size       uint16 // number of children
+	class      uint8  // the child range has a capacity of 1<<class
 	isTerminal bool
 }
 
-// NewPointerTrie returns a new, absurdly simple, and badly coded BTrie.
-// Pointers to children are stored densely in slices.
-// This is purely for fleshing out the unit tests, benchmarks, and fuzz tests.
+type ptrTrie[V any] struct {
+	slabs      [][]ptrTrieNode[V] // each of length ptrSlabSize, so nodes never move
+	keyBytes   []byte             // the key bytes of children, in ranges owned by their parents
+	childNodes []int32            // childNodes[i] is the index of the child whose key byte is keyBytes[i]
+
+	// Heads of the free lists of child ranges by class, linked through childNodes.
+	// Offset 0 is never allocated, so it also means "no range".
+	freeRanges [ptrMaxClass + 1]int32
+
+	count int32 // number of nodes ever allocated, including freed ones
+	free  int32 // head of the free list of nodes
+}
+
+// NewPointerTrie returns a new BTrie whose nodes are stored in slabs, and refer to their children by int32 index.
+// Because the slabs contain no pointers unless V does, the garbage collector does not scan them,
+// so a large trie adds little to the cost of a collection.
+// The children of a node are a contiguous range sorted by key byte, with the key bytes packed separately
+// from the indexes, so that a child is found by searching a few bytes without touching any other node.
+// Freed nodes and child ranges are reused by later puts.
 func NewPointerTrie[V any]() BTrie[V] {
-	var zero V
-	return &ptrTrieNode[V]{nil, zero, 0, false}
+	t := &ptrTrie[V]{keyBytes: []byte{0}, childNodes: []int32{0}}
+	t.alloc()
+	return t
+}
+
+func (t *ptrTrie[V]) node(index int32) *ptrTrieNode[V] {
+	return &t.slabs[index>>ptrSlabBits][index&ptrSlabMask]
+}
+
+// Returns the index of the i'th child of n.
+func (t *ptrTrie[V]) child(n *ptrTrieNode[V], i int) int32 {
+	return t.childNodes[int(n.children)+i]
+}
+
+// Returns the key bytes of n's children.
+// The result is only valid until the trie is next modified.
+func (t *ptrTrie[V]) childKeyBytes(n *ptrTrieNode[V]) []byte {
+	start := int(n.children)
+	return t.keyBytes[start : start+int(n.size)]
+}
+
+// Returns the index of a new node with no value or children.
+func (t *ptrTrie[V]) alloc() int32 {
+	index := t.free
+	if index != ptrNone {
+		t.free = t.node(index).children
+	} else {
+		index = t.count
+		if index&ptrSlabMask == 0 {
+			t.slabs = append(t.slabs, make([]ptrTrieNode[V], ptrSlabSize))
+		}
+		t.count++
+	}
+	*t.node(index) = ptrTrieNode[V]{}
+	return index
+}
+
+// Frees the node at index and its descendants, which must be a chain of only children.
+func (t *ptrTrie[V]) freeChain(index int32) {
+	for index != ptrNone {
+		n := t.node(index)
+		child := ptrNone
+		if n.size > 0 {
+			child = t.child(n, 0)
+			t.freeRange(n.children, n.class)
+		}
+		*n = ptrTrieNode[V]{children: t.free}
+		t.free = index
+		index = child
+	}
+}
+
+// Returns the offset of a new child range with a capacity of 1<<class.
+func (t *ptrTrie[V]) allocRange(class uint8) int32 {
+	offset := t.freeRanges[class]
+	if offset != 0 {
+		t.freeRanges[class] = t.childNodes[offset]
+		return offset
+	}
+	//nolint:gosec
+	offset = int32(len(t.keyBytes))
+	t.keyBytes = append(t.keyBytes, make([]byte, 1<<class)...)
+	t.childNodes = append(t.childNodes, make([]int32, 1<<class)...)
+	return offset
+}
+
+func (t *ptrTrie[V]) freeRange(offset int32, class uint8) {
+	t.childNodes[offset] = t.freeRanges[class]
+	t.freeRanges[class] = offset
+}
+
+// Inserts child as the i'th child of parent, moving parent's children to a larger range if needed.
+func (t *ptrTrie[V]) insertChild(parent int32, i int, keyByte byte, child int32) {
+	p := t.node(parent)
+	size := int(p.size)
+	switch {
+	case size == 0:
+		p.children, p.class = t.allocRange(0), 0
+	case size == 1<<p.class:
+		prev, class := p.children, p.class
+		p.children, p.class = t.allocRange(class+1), class+1
+		copy(t.keyBytes[p.children:], t.keyBytes[prev:int(prev)+size])
+		copy(t.childNodes[p.children:], t.childNodes[prev:int(prev)+size])
+		t.freeRange(prev, class)
+	}
+	start := int(p.children)
+	keyBytes := t.keyBytes[start : start+size+1]
+	copy(keyBytes[i+1:], keyBytes[i:size])
+	keyBytes[i] = keyByte
+	childNodes := t.childNodes[start : start+size+1]
+	copy(childNodes[i+1:], childNodes[i:size])
+	childNodes[i] = child
+	p.size++
+}
+
+// Removes the i'th child of parent, without freeing it.
+func (t *ptrTrie[V]) removeChild(parent int32, i int) {
+	p := t.node(parent)
+	start, size := int(p.children), int(p.size)
+	copy(t.keyBytes[start+i:start+size], t.keyBytes[start+i+1:start+size])
+	copy(t.childNodes[start+i:start+size], t.childNodes[start+i+1:start+size])
+	p.size--
+	if p.size == 0 {
+		t.freeRange(p.children, p.class)
+		p.children = 0
+	}
 }
 
-func (n *ptrTrieNode[V]) Get(key []byte) (V, bool) {
+func (t *ptrTrie[V]) Get(key []byte) (V, bool) {
 	if key == nil {
 		panic("key must be non-nil")
 	}
 	var zero V
+	n := t.node(ptrRoot)
 	for _, keyByte := range key {
-		index, found := n.search(keyByte)
+		i, found := searchKeyBytes(t.childKeyBytes(n), keyByte)
 		if !found {
 			return zero, false
 		}
-		n = n.children[index]
+		n = t.node(t.child(n, i))
 	}
 	// n = found key
 	if n.isTerminal {
@@ -42,27 +181,67 @@ func (n *ptrTrieNode[V]) Get(key []byte) (V, bool) {
 	return zero, false
 }
 
-func (n *ptrTrieNode[V]) Put(key []byte, value V) (V, bool) {
+func (t *ptrTrie[V]) getWithDepth(key []byte) (V, int, bool) {
+	var zero V
+	n := t.node(ptrRoot)
+	for i, keyByte := range key {
+		index, found := searchKeyBytes(t.childKeyBytes(n), keyByte)
+		if !found {
+			return zero, i, false
+		}
+		n = t.node(t.child(n, index))
+	}
+	// n = found key
+	if n.isTerminal {
+		return n.value, len(key), true
+	}
+	return zero, len(key), false
+}
+
+func (t *ptrTrie[V]) prefixesOf(key []byte) iter.Seq2[[]byte, V] {
+	return func(yield func([]byte, V) bool) {
+		node := t.node(ptrRoot)
+		for i := 0; ; i++ {
+			if node.isTerminal && !yield(bytes.Clone(key[:i]), node.value) {
+				return
+			}
+			if i == len(key) {
+				return
+			}
+			index, found := searchKeyBytes(t.childKeyBytes(node), key[i])
+			if !found {
+				return
+			}
+			node = t.node(t.child(node, index))
+		}
+	}
+}
+
+func (t *ptrTrie[V]) Put(key []byte, value V) (V, bool) {
 	if key == nil {
 		panic("key must be non-nil")
 	}
 	var zero V
+	parent := ptrRoot
 	for i, keyByte := range key {
-		index, found := n.search(keyByte)
+		p := t.node(parent)
+		index, found := searchKeyBytes(t.childKeyBytes(p), keyByte)
 		if !found {
-			k := len(key) - 1
-			child := &ptrTrieNode[V]{nil, value, key[k], true}
-			for k--; k >= i; k-- {
-				child = &ptrTrieNode[V]{[]*ptrTrieNode[V]{child}, zero, key[k], false}
+			child := t.alloc()
+			t.insertChild(parent, index, keyByte, child)
+			for _, keyByte := range key[i+1:] {
+				parent, child = child, t.alloc()
+				t.insertChild(parent, 0, keyByte, child)
 			}
-			n.children = append(n.children, child)
-			copy(n.children[index+1:], n.children[index:])
-			n.children[index] = child
+			n := t.node(child)
+			n.value = value
+			n.isTerminal = true
 			return zero, false
 		}
-		n = n.children[index]
+		parent = t.child(p, index)
 	}
-	// n = found key, replace value
+	// parent = found key, replace value
+	n := t.node(parent)
 	if n.isTerminal {
 		prev := n.value
 		n.value = value
@@ -73,172 +252,281 @@ func (n *ptrTrieNode[V]) Put(key []byte, value V) (V, bool) {
 	return zero, false
 }
 
-func (n *ptrTrieNode[V]) Delete(key []byte) (V, bool) {
+func (t *ptrTrie[V]) Delete(key []byte) (V, bool) {
 	if key == nil {
 		panic("key must be non-nil")
 	}
 	var zero V
-	// If the deleted node has no children, remove the subtree rooted at prune.children[pruneIndex].
-	var prune *ptrTrieNode[V]
+	// If the deleted node has no children, remove the subtree rooted at the pruneIndex'th child of prune.
+	var prune int32
 	var pruneIndex int
+	index := ptrRoot
 	for i, keyByte := range key {
-		index, found := n.search(keyByte)
+		n := t.node(index)
+		childIndex, found := searchKeyBytes(t.childKeyBytes(n), keyByte)
 		if !found {
 			return zero, false
 		}
 		// If either n is the root, or n has a value, or n has more than one child, then n itself cannot be pruned.
-		// If so, move the maybe-pruned subtree to n.children[index].
-		if i == 0 || n.isTerminal || len(n.children) > 1 {
-			prune, pruneIndex = n, index
+		// If so, move the maybe-pruned subtree to n's child.
+		if i == 0 || n.isTerminal || n.size > 1 {
+			prune, pruneIndex = index, childIndex
 		}
-		n = n.children[index]
+		index = t.child(n, childIndex)
 	}
-	// n = found key
+	// index = found key
+	n := t.node(index)
 	if !n.isTerminal {
 		return zero, false
 	}
 	prev := n.value
 	n.value = zero
 	n.isTerminal = false
-	if len(key) > 0 && len(n.children) == 0 {
-		children := prune.children
-		copy(children[pruneIndex:], children[pruneIndex+1:])
-		children[len(children)-1] = nil
-		prune.children = children[:len(children)-1]
+	if len(key) > 0 && n.size == 0 {
+		child := t.child(t.node(prune), pruneIndex)
+		t.removeChild(prune, pruneIndex)
+		t.freeChain(child)
 	}
 	return prev, true
 }
 
-// An iter.Seq of these is returned from the adjFunction used internally by Range.
-// key = path from root to node
-// It is cached here for efficiency, otherwise an iter.Seq of []*ptrTrieNode[V] would be used directly.
-// Note that the key must be cloned when yielded from Range.
-type ptrTrieRangePath[V any] struct {
-	node *ptrTrieNode[V]
-	key  []byte
+// A node being traversed by Range, and the remaining children of that node to traverse.
+// This serves the same purpose as arrayTrieFrame.
+type ptrTrieFrame struct {
+	node int32
+	next int  // index of the next child to consider
+	stop byte // key byte of the last child to consider, inclusive
 }
 
-func (n *ptrTrieNode[V]) Range(bounds *Bounds) iter.Seq2[[]byte, V] {
+func (t *ptrTrie[V]) Range(bounds *Bounds) iter.Seq2[[]byte, V] {
+	return t.rangeWithMaxDepth(bounds, 0)
+}
+
+func (t *ptrTrie[V]) rangeWithMaxDepth(bounds *Bounds, maxDepth int) iter.Seq2[[]byte, V] {
 	bounds = bounds.Clone()
-	root := ptrTrieRangePath[V]{n, []byte{}}
-	var pathItr iter.Seq[*ptrTrieRangePath[V]]
 	if bounds.IsReverse {
-		pathItr = postOrder(&root, ptrTrieReverseAdj[V](bounds))
-	} else {
-		pathItr = preOrder(&root, ptrTrieForwardAdj[V](bounds))
+		return t.rangeReverse(bounds, maxDepth)
 	}
-	return func(yield func([]byte, V) bool) {
-		for path := range pathItr {
-			cmp := bounds.Compare(path.key)
-			if cmp < 0 {
-				continue
-			}
-			if cmp > 0 {
-				return
-			}
-			if path.node.isTerminal && !yield(bytes.Clone(path.key), path.node.value) {
-				return
-			}
-		}
+	return t.rangeForward(bounds, maxDepth)
+}
+
+func (t *ptrTrie[V]) all(isReverse bool) iter.Seq2[[]byte, V] {
+	if isReverse {
+		return t.rangeReverse(nil, 0)
 	}
+	return t.rangeForward(nil, 0)
 }
 
-func ptrTrieForwardAdj[V any](bounds *Bounds) adjFunction[*ptrTrieRangePath[V]] {
-	// Sometimes a child is not within the bounds, but one of its descendants is.
-	return func(path *ptrTrieRangePath[V]) iter.Seq[*ptrTrieRangePath[V]] {
-		if len(path.node.children) == 0 {
+func (t *ptrTrie[V]) childBytes(prefix []byte) iter.Seq[byte] {
+	index := ptrRoot
+	for _, keyByte := range prefix {
+		n := t.node(index)
+		i, found := searchKeyBytes(t.childKeyBytes(n), keyByte)
+		if !found {
 			return emptySeq
 		}
-		start, stop, ok := bounds.childBounds(path.key)
-		if !ok {
-			// Unreachable because of how the trie is traversed forward.
-			panic("unreachable")
+		index = t.child(n, i)
+	}
+	return func(yield func(byte) bool) {
+		for _, keyByte := range t.childKeyBytes(t.node(index)) {
+			if !yield(keyByte) {
+				return
+			}
 		}
-		return func(yield func(*ptrTrieRangePath[V]) bool) {
-			for _, child := range path.node.children {
-				keyByte := child.keyByte
-				if keyByte < start {
-					continue
+	}
+}
+
+// Traverses in pre-order. If bounds is nil, all entries are traversed.
+func (t *ptrTrie[V]) rangeForward(bounds *Bounds, maxDepth int) iter.Seq2[[]byte, V] {
+	return func(yield func([]byte, V) bool) {
+		key := make([]byte, 0, maxDepth)
+		stack := make([]ptrTrieFrame, 0, maxDepth)
+		index := ptrRoot
+		for {
+			// invariant: key = path from root to node, len(key) = len(stack)
+			node := t.node(index)
+			if bounds != nil {
+				cmp := bounds.Compare(key)
+				if cmp > 0 {
+					return
 				}
-				if keyByte > stop {
+				if cmp == 0 && node.isTerminal && !yield(bytes.Clone(key), node.value) {
 					return
 				}
-				if !yield(&ptrTrieRangePath[V]{child, append(path.key, keyByte)}) {
+			} else if node.isTerminal && !yield(bytes.Clone(key), node.value) {
+				return
+			}
+			if node.size > 0 {
+				// Sometimes a child is not within the bounds, but one of its descendants is.
+				start, stop := byte(0), byte(math.MaxUint8)
+				if bounds != nil {
+					var ok bool
+					start, stop, ok = bounds.childBounds(key)
+					if !ok {
+						// Unreachable because of how the trie is traversed forward.
+						panic("unreachable")
+					}
+				}
+				next, _ := searchKeyBytes(t.childKeyBytes(node), start)
+				stack = append(stack, ptrTrieFrame{index, next, stop})
+			}
+			index = ptrNone
+			for index == ptrNone {
+				if len(stack) == 0 {
 					return
 				}
+				top := &stack[len(stack)-1]
+				parent := t.node(top.node)
+				if top.next < int(parent.size) && t.childKeyBytes(parent)[top.next] <= top.stop {
+					index = t.child(parent, top.next)
+					key = append(key[:len(stack)-1], t.childKeyBytes(parent)[top.next])
+					top.next++
+				} else {
+					stack = stack[:len(stack)-1]
+				}
 			}
 		}
 	}
 }
 
-func ptrTrieReverseAdj[V any](bounds *Bounds) adjFunction[*ptrTrieRangePath[V]] {
-	// Sometimes a child is not within the bounds, but one of its descendants is.
-	return func(path *ptrTrieRangePath[V]) iter.Seq[*ptrTrieRangePath[V]] {
-		if len(path.node.children) == 0 {
-			return emptySeq
-		}
-		start, stop, ok := bounds.childBounds(path.key)
-		if !ok {
-			return emptySeq
-		}
-		return func(yield func(*ptrTrieRangePath[V]) bool) {
-			for i := len(path.node.children) - 1; i >= 0; i-- {
-				child := path.node.children[i]
-				keyByte := child.keyByte
-				if keyByte > start {
+// Traverses in post-order, visiting children in reverse. If bounds is nil, all entries are traversed.
+func (t *ptrTrie[V]) rangeReverse(bounds *Bounds, maxDepth int) iter.Seq2[[]byte, V] {
+	return func(yield func([]byte, V) bool) {
+		key := make([]byte, 0, maxDepth)
+		stack := append(make([]ptrTrieFrame, 0, maxDepth+1), t.reverseFrame(ptrRoot, bounds, key))
+		for {
+			// invariant: key = path from root to top.node, len(key) = len(stack) - 1
+			top := &stack[len(stack)-1]
+			node, isLeaf := t.node(top.node), false
+			if top.next >= 0 && t.childKeyBytes(node)[top.next] >= top.stop {
+				index := t.child(node, top.next)
+				key = append(key, t.childKeyBytes(node)[top.next])
+				top.next--
+				child := t.node(index)
+				if child.size > 0 {
+					stack = append(stack, t.reverseFrame(index, bounds, key))
 					continue
 				}
-				if keyByte < stop {
+				// A leaf has no children to visit first, so visit it now instead of pushing a frame for it.
+				node, isLeaf = child, true
+			}
+			if bounds != nil {
+				cmp := bounds.Compare(key)
+				if cmp > 0 {
+					return
+				}
+				if cmp == 0 && node.isTerminal && !yield(bytes.Clone(key), node.value) {
 					return
 				}
-				if !yield(&ptrTrieRangePath[V]{child, append(path.key, keyByte)}) {
+			} else if node.isTerminal && !yield(bytes.Clone(key), node.value) {
+				return
+			}
+			if !isLeaf {
+				stack = stack[:len(stack)-1]
+				if len(stack) == 0 {
 					return
 				}
 			}
+			key = key[:len(key)-1]
+		}
+	}
+}
+
+func (t *ptrTrie[V]) reverseFrame(index int32, bounds *Bounds, key []byte) ptrTrieFrame {
+	n := t.node(index)
+	if n.size == 0 {
+		return ptrTrieFrame{index, -1, 0}
+	}
+	// Sometimes a child is not within the bounds, but one of its descendants is.
+	start, stop := byte(math.MaxUint8), byte(0)
+	if bounds != nil {
+		var ok bool
+		start, stop, ok = bounds.childBounds(key)
+		if !ok {
+			return ptrTrieFrame{index, -1, 0}
 		}
 	}
+	next, found := searchKeyBytes(t.childKeyBytes(n), start)
+	if !found {
+		next--
+	}
+	return ptrTrieFrame{index, next, stop}
 }
 
-func (n *ptrTrieNode[V]) String() string {
+func (t *ptrTrie[V]) String() string {
 	var s strings.Builder
-	n.printNode(&s, "")
+	t.printTo(&s, -1)
 	return s.String()
 }
 
+func (t *ptrTrie[V]) Format(f fmt.State, verb rune) {
+	formatTrie(f, verb, t)
+}
+
+func (t *ptrTrie[V]) printTo(s *strings.Builder, levels int) {
+	t.printNode(s, ptrRoot, 0, "", levels)
+}
+
 //nolint:revive
-func (n *ptrTrieNode[V]) printNode(s *strings.Builder, indent string) {
+func (t *ptrTrie[V]) printNode(s *strings.Builder, index int32, keyByte byte, indent string, levels int) {
+	n := t.node(index)
 	if indent == "" {
 		s.WriteString("[]")
 	} else {
-		fmt.Fprintf(s, "%s%02X", indent, n.keyByte)
+		fmt.Fprintf(s, "%s%02X", indent, keyByte)
 	}
 	if n.isTerminal {
 		fmt.Fprintf(s, ": %v\n", n.value)
 	} else {
 		s.WriteString("\n")
 	}
-	for _, child := range n.children {
-		child.printNode(s, indent+"  ")
+	if n.size > 0 && levels == 0 {
+		s.WriteString(indent + "  ...\n")
+		return
+	}
+	for i := range int(n.size) {
+		t.printNode(s, t.child(n, i), t.childKeyBytes(n)[i], indent+"  ", levels-1)
 	}
 }
 
-func (n *ptrTrieNode[V]) search(byt byte) (int, bool) {
-	// Copied and tweaked from sort.Search. Inlining this is much, much faster.
-	// Invariant: child[i-1] < byt <= child[j]
-	i, j := 0, len(n.children)
-	for i < j {
-		//nolint:gosec
-		h := int(uint(i+j) >> 1) // avoid overflow when computing h
-		// i ≤ h < j
-		childByte := n.children[h].keyByte
-		if childByte == byt {
-			return h, true
+func (t *ptrTrie[V]) stats() (int, int) {
+	return t.nodeStats(ptrRoot)
+}
+
+func (t *ptrTrie[V]) nodeStats(index int32) (int, int) {
+	n := t.node(index)
+	size, depth := 0, 0
+	if n.isTerminal {
+		size = 1
+	}
+	for i := range int(n.size) {
+		childSize, childDepth := t.nodeStats(t.child(n, i))
+		size += childSize
+		depth = max(depth, childDepth+1)
+	}
+	return size, depth
+}
+
+// Nodes with at most this many children are searched linearly.
+const linearSearchMax = 16
+
+// Returns the index of the first byte in the sorted keyBytes which is at least byt, and whether that byte is byt.
+func searchKeyBytes(keyBytes []byte, byt byte) (int, bool) {
+	base := 0
+	if len(keyBytes) <= linearSearchMax {
+		for base < len(keyBytes) && keyBytes[base] < byt {
+			base++
 		}
-		if childByte < byt {
-			i = h + 1 // preserves child[i-1] < byt
-		} else {
-			j = h // preserves byt <= child[j]
+	} else {
+		// A binary search with no branches on the key bytes, since they would be mispredicted about half the time.
+		// Invariant: the result is in [base, base+n]
+		for n := len(keyBytes); n > 0; {
+			half := n >> 1
+			// All ones if keyBytes[base+half] < byt, otherwise zero.
+			less := (int(keyBytes[base+half]) - int(byt)) >> (bits.UintSize - 1)
+			base += (n - half) & less
+			n = half
 		}
 	}
-	return i, false
+	return base, base < len(keyBytes) && keyBytes[base] == byt
 }
@@ -0,0 +1,201 @@
+package btrie
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"iter"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidRangeExpr is wrapped by the errors returned from [ParseRangeExpr].
+var ErrInvalidRangeExpr = errors.New("invalid range expression")
+
+// RangeExpr is a range of keys parsed by [ParseRangeExpr] or returned by [Bounds.Reverse].
+// A descending Bounds includes its greatest key and excludes its least key, the opposite of most descending ranges,
+// so a RangeExpr may have to adjust the keys its Bounds contains at either end.
+// Use [RangeByExpr] to range over the keys of a BTrie within a RangeExpr.
+type RangeExpr struct {
+	// Bounds contains exactly the keys in the range, unless ExcludeBegin or IncludeEnd is true.
+	Bounds *Bounds
+
+	// ExcludeBegin is true if Bounds.Begin is not in the range, even though Bounds contains it.
+	ExcludeBegin bool
+
+	// IncludeEnd is true if Bounds.End is in the range, even though Bounds does not contain it.
+	IncludeEnd bool
+}
+
+// ParseRangeExpr returns the RangeExpr described by s, which is meant for command line flags, query parameters,
+// and configuration files, where constructing a Bounds in code is not possible. The forms of s are:
+//
+//	[a, b)      an interval, where [ and ] are inclusive, ( and ) are exclusive, and an omitted key is unbounded
+//	prefix:a    the keys having the prefix a
+//	>= a        also >, <=, and <, comparing keys to a in increasing key order
+//
+// Each may be followed by "asc", the default, or "desc" to range in decreasing key order.
+// Keys are written either in hex with a leading "0x", such as 0x00ff, or as a Go quoted string, such as "abc".
+// The empty key is 0x or "". For example:
+//
+//	[0x00ff, 0x01)
+//	(0x10, "user/"] desc
+//	prefix:0xab desc
+//	>= 0x10 desc
+//
+// The result's Bounds is exact if it is ascending. If it is descending, the result is the exact ascending Bounds
+// reversed by [Bounds.Reverse].
+// ParseRangeExpr returns an error if no key is within the range.
+func ParseRangeExpr(s string) (*RangeExpr, error) {
+	body := strings.TrimSpace(s)
+	reverse := false
+	if i := strings.LastIndexAny(body, " \t\r\n"); i >= 0 {
+		switch word := body[i+1:]; {
+		case strings.EqualFold(word, "asc"):
+			body = strings.TrimSpace(body[:i])
+		case strings.EqualFold(word, "desc"):
+			body, reverse = strings.TrimSpace(body[:i]), true
+		}
+	}
+	var lo, hi rangeEndpoint
+	var err error
+	switch {
+	case strings.HasPrefix(body, "prefix:"):
+		lo.key, err = parseKeyLiteral(body[len("prefix:"):])
+		if err == nil {
+			lo.inclusive = true
+			hi.key, _ = PrefixSuccessor(lo.key)
+		}
+	case strings.HasPrefix(body, ">="):
+		lo.key, err = parseKeyLiteral(body[2:])
+		lo.inclusive = true
+	case strings.HasPrefix(body, ">"):
+		lo.key, err = parseKeyLiteral(body[1:])
+	case strings.HasPrefix(body, "<="):
+		hi.key, err = parseKeyLiteral(body[2:])
+		hi.inclusive = true
+	case strings.HasPrefix(body, "<"):
+		hi.key, err = parseKeyLiteral(body[1:])
+	case strings.HasPrefix(body, "[") || strings.HasPrefix(body, "("):
+		lo, hi, err = parseInterval(body)
+	default:
+		err = errors.New("expected an interval, prefix:, or a comparison")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w %q: %w", ErrInvalidRangeExpr, s, err)
+	}
+	// Every range is exactly some ascending Bounds.
+	bounds := &Bounds{lo.key, hi.key, false}
+	if lo.key != nil && !lo.inclusive {
+		bounds.Begin = NextKey(lo.key)
+	}
+	if hi.key != nil && hi.inclusive {
+		bounds.End = NextKey(hi.key)
+	}
+	if err := bounds.Validate(); err != nil {
+		return nil, fmt.Errorf("%w %q: %w", ErrInvalidRangeExpr, s, err)
+	}
+	if !reverse {
+		return &RangeExpr{bounds, false, false}, nil
+	}
+	return bounds.Reverse(), nil
+}
+
+// RangeByExpr returns the entries of trie within expr, in the direction of expr.Bounds.
+// It is trie.Range(expr.Bounds), skipping expr.Bounds.Begin if expr.ExcludeBegin is true,
+// and ending with expr.Bounds.End if expr.IncludeEnd is true and trie has that key.
+func RangeByExpr[V any](trie BTrie[V], expr *RangeExpr) iter.Seq2[[]byte, V] {
+	bounds := expr.Bounds.Clone()
+	excludeBegin, includeEnd := expr.ExcludeBegin, expr.IncludeEnd
+	return func(yield func([]byte, V) bool) {
+		first := true
+		for key, value := range trie.Range(bounds) {
+			// Begin can only be the first key.
+			if first && excludeBegin && bytes.Equal(key, bounds.Begin) {
+				first = false
+				continue
+			}
+			first = false
+			if !yield(key, value) {
+				return
+			}
+		}
+		if includeEnd {
+			if value, ok := trie.Get(bounds.End); ok {
+				yield(bytes.Clone(bounds.End), value)
+			}
+		}
+	}
+}
+
+// One end of a range in increasing key order, where a nil key is unbounded.
+type rangeEndpoint struct {
+	key       []byte
+	inclusive bool
+}
+
+// Parses an interval such as "[a, b)".
+func parseInterval(s string) (rangeEndpoint, rangeEndpoint, error) {
+	var lo, hi rangeEndpoint
+	last := s[len(s)-1]
+	if len(s) < 2 || (last != ']' && last != ')') {
+		return lo, hi, errors.New("interval must end with ] or )")
+	}
+	inner := s[1 : len(s)-1]
+	comma := indexUnquoted(inner, ',')
+	if comma < 0 {
+		return lo, hi, errors.New("interval must have a comma")
+	}
+	lo.inclusive, hi.inclusive = s[0] == '[', last == ']'
+	for _, end := range []struct {
+		endpoint *rangeEndpoint
+		literal  string
+	}{{&lo, inner[:comma]}, {&hi, inner[comma+1:]}} {
+		if strings.TrimSpace(end.literal) == "" {
+			continue
+		}
+		var err error
+		if end.endpoint.key, err = parseKeyLiteral(end.literal); err != nil {
+			return lo, hi, err
+		}
+	}
+	return lo, hi, nil
+}
+
+// Returns the index of the first b in s which is not within a Go quoted string, or -1 if there is none.
+func indexUnquoted(s string, b byte) int {
+	inQuote := false
+	for i := 0; i < len(s); i++ {
+		switch {
+		case inQuote && s[i] == '\\':
+			i++
+		case s[i] == '"':
+			inQuote = !inQuote
+		case !inQuote && s[i] == b:
+			return i
+		}
+	}
+	return -1
+}
+
+// Parses a key written in hex with a leading 0x, or as a Go quoted string. The result is never nil.
+func parseKeyLiteral(s string) ([]byte, error) {
+	s = strings.TrimSpace(s)
+	switch {
+	case strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X"):
+		key, err := hex.DecodeString(s[2:])
+		if err != nil {
+			return nil, fmt.Errorf("bad hex key %q: %w", s, err)
+		}
+		return key, nil
+	case strings.HasPrefix(s, `"`):
+		key, err := strconv.Unquote(s)
+		if err != nil {
+			return nil, fmt.Errorf("bad quoted key %s: %w", s, err)
+		}
+		return []byte(key), nil
+	default:
+		return nil, fmt.Errorf("key %q must be hex with a leading 0x, or a quoted string", s)
+	}
+}
@@ -0,0 +1,136 @@
+package btrie_test
+
+import (
+	"testing"
+
+	"github.com/phiryll/btrie"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRangeExpr(t *testing.T) {
+	t.Parallel()
+	exact := func(bounds *Bounds) *btrie.RangeExpr {
+		return &btrie.RangeExpr{Bounds: bounds}
+	}
+	for _, tt := range []struct {
+		expr     string
+		expected *btrie.RangeExpr
+	}{
+		{"[0x00ff, 0x01)", exact(From([]byte{0x00, 0xFF}).To([]byte{0x01}))},
+		{"  [ 0x00FF ,0x01 )  ASC ", exact(From([]byte{0x00, 0xFF}).To([]byte{0x01}))},
+		{"(0x00ff, 0x01]", exact(From([]byte{0x00, 0xFF, 0x00}).To([]byte{0x01, 0x00}))},
+		{"[, 0x10)", exact(From(nil).To([]byte{0x10}))},
+		{"[0x10,]", exact(From([]byte{0x10}).To(nil))},
+		{"[,]", exact(From(nil).To(nil))},
+		{"[0x, 0x00)", exact(From([]byte{}).To([]byte{0x00}))},
+		{`["a\"c", "a,b")`, exact(From([]byte(`a"c`)).To([]byte("a,b")))},
+		{"prefix:0xab", exact(From([]byte{0xAB}).To([]byte{0xAC}))},
+		{"prefix:0xffff", exact(From([]byte{0xFF, 0xFF}).To(nil))},
+		{`prefix:"user/"`, exact(From([]byte("user/")).To([]byte("user0")))},
+		{"prefix:0x desc", exact(From(nil).DownTo(nil))},
+		{">= 0x10", exact(From([]byte{0x10}).To(nil))},
+		{"> 0x10", exact(From([]byte{0x10, 0x00}).To(nil))},
+		{"<= 0x10", exact(From(nil).To([]byte{0x10, 0x00}))},
+		{"<0x10", exact(From(nil).To([]byte{0x10}))},
+		{"(0x10, 0x20] desc", exact(From([]byte{0x20}).DownTo([]byte{0x10}))},
+		{"[0x1000, 0x20] desc", exact(From([]byte{0x20}).DownTo([]byte{0x10}))},
+		{"[0x, 0x2000) desc", exact(From([]byte{0x20}).DownTo(nil))},
+		{"[, ] desc", exact(From(nil).DownTo(nil))},
+		{"<= 0x10 desc", exact(From([]byte{0x10}).DownTo(nil))},
+		{"> 0x10 desc", exact(From(nil).DownTo([]byte{0x10}))},
+		{">= 0x1000 desc", exact(From(nil).DownTo([]byte{0x10}))},
+		{"< 0x1000 DESC", exact(From([]byte{0x10}).DownTo(nil))},
+		// Descending ranges which cannot be represented exactly by a Bounds.
+		{">= 0x10 desc", &btrie.RangeExpr{Bounds: From(nil).DownTo([]byte{0x10}), IncludeEnd: true}},
+		{"< 0x10 desc", &btrie.RangeExpr{Bounds: From([]byte{0x10}).DownTo(nil), ExcludeBegin: true}},
+		{"prefix:0xab desc", &btrie.RangeExpr{
+			Bounds: From([]byte{0xAC}).DownTo([]byte{0xAB}), ExcludeBegin: true, IncludeEnd: true,
+		}},
+		{"prefix:0xffff desc", &btrie.RangeExpr{Bounds: From(nil).DownTo([]byte{0xFF, 0xFF}), IncludeEnd: true}},
+		{"[0x10, 0x20) desc", &btrie.RangeExpr{
+			Bounds: From([]byte{0x20}).DownTo([]byte{0x10}), ExcludeBegin: true, IncludeEnd: true,
+		}},
+		{"[0x10, 0x10] desc", &btrie.RangeExpr{
+			Bounds: From([]byte{0x10, 0x00}).DownTo([]byte{0x10}), ExcludeBegin: true, IncludeEnd: true,
+		}},
+	} {
+		t.Run(tt.expr, func(t *testing.T) {
+			t.Parallel()
+			actual, err := btrie.ParseRangeExpr(tt.expr)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, actual)
+		})
+	}
+}
+
+func TestRangeByExpr(t *testing.T) {
+	t.Parallel()
+	trie := btrie.NewArrayTrie[byte]()
+	for i, key := range []string{"", "\x0f", "\x10", "\x10\x00", "\x10\x01", "\x20", "\xab", "\xab\x01", "\xac"} {
+		trie.Put([]byte(key), byte(i))
+	}
+	for _, tt := range []struct {
+		expr     string
+		expected []string
+	}{
+		{">= 0x10 desc", []string{"\xac", "\xab\x01", "\xab", "\x20", "\x10\x01", "\x10\x00", "\x10"}},
+		{"< 0x10 desc", []string{"\x0f", ""}},
+		{"prefix:0xab desc", []string{"\xab\x01", "\xab"}},
+		{"[0x10, 0x20) desc", []string{"\x10\x01", "\x10\x00", "\x10"}},
+		{"[0x10, 0x10] desc", []string{"\x10"}},
+		{"(0x10, 0x20] desc", []string{"\x20", "\x10\x01", "\x10\x00"}},
+		{"prefix:0xab", []string{"\xab", "\xab\x01"}},
+		{"> 0xac desc", nil},
+	} {
+		expr, err := btrie.ParseRangeExpr(tt.expr)
+		require.NoError(t, err)
+		var actual []string
+		for key := range btrie.RangeByExpr(trie, expr) {
+			actual = append(actual, string(key))
+		}
+		assert.Equal(t, tt.expected, actual, tt.expr)
+		for range btrie.RangeByExpr(trie, expr) {
+			break
+		}
+	}
+}
+
+func TestParseRangeExprErrors(t *testing.T) {
+	t.Parallel()
+	for _, expr := range []string{
+		"",
+		"desc",
+		"0x10",
+		"[0x10, 0x20",
+		"[0x10 0x20)",
+		"[0x1, 0x20)",
+		"[abc, 0x20)",
+		`["abc, 0x20)`,
+		"prefix:",
+		">=",
+		"<< 0x10",
+	} {
+		t.Run(expr, func(t *testing.T) {
+			t.Parallel()
+			_, err := btrie.ParseRangeExpr(expr)
+			require.ErrorIs(t, err, btrie.ErrInvalidRangeExpr)
+		})
+	}
+	for _, expr := range []string{
+		"[0x20, 0x10)",
+		"(0x20, 0x10] desc",
+	} {
+		_, err := btrie.ParseRangeExpr(expr)
+		require.ErrorIs(t, err, btrie.ErrInvertedBounds, expr)
+	}
+	for _, expr := range []string{
+		"[0x10, 0x10)",
+		"(0x10, 0x1000)",
+		"< 0x",
+		"< 0x desc",
+	} {
+		_, err := btrie.ParseRangeExpr(expr)
+		require.ErrorIs(t, err, btrie.ErrEmptyBounds, expr)
+	}
+}
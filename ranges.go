@@ -0,0 +1,406 @@
+package btrie
+
+import (
+	"bytes"
+	"context"
+	"iter"
+	"math"
+)
+
+// All returns a sequence of all entries in trie, in increasing key order.
+// This is equivalent to trie.Range(From(nil).To(nil)), but may be faster for some implementations.
+// The returned sequence has the same constraints as those returned by trie.Range.
+func All[V any](trie BTrie[V]) iter.Seq2[[]byte, V] {
+	if t, ok := trie.(traversable[V]); ok {
+		return t.all(false)
+	}
+	return trie.Range(From(nil).To(nil))
+}
+
+// Backward returns a sequence of all entries in trie, in decreasing key order.
+// This is equivalent to trie.Range(From(nil).DownTo(nil)), but may be faster for some implementations.
+// The returned sequence has the same constraints as those returned by trie.Range.
+func Backward[V any](trie BTrie[V]) iter.Seq2[[]byte, V] {
+	if t, ok := trie.(traversable[V]); ok {
+		return t.all(true)
+	}
+	return trie.Range(From(nil).DownTo(nil))
+}
+
+// Children returns a sequence of the distinct bytes following prefix in trie's keys, in increasing order.
+// That is, byte b is yielded if and only if trie contains a key beginning with append(prefix, b).
+// For tries without a faster implementation,
+// this performs one short Range over trie per yielded byte rather than ranging over every key with prefix.
+// The returned sequence has the same constraints as those returned by trie.Range.
+// Children will panic if prefix is nil.
+func Children[V any](trie BTrie[V], prefix []byte) iter.Seq[byte] {
+	if prefix == nil {
+		panic("prefix must be non-nil")
+	}
+	if t, ok := trie.(traversable[V]); ok {
+		return t.childBytes(prefix)
+	}
+	prefix = bytes.Clone(prefix)
+	end, _ := PrefixSuccessor(prefix)
+	return func(yield func(byte) bool) {
+		begin := prefix
+		for {
+			var key []byte
+			for k := range trie.Range(From(begin).To(end)) {
+				if len(k) > len(prefix) {
+					key = k
+					break
+				}
+			}
+			if key == nil {
+				return
+			}
+			next := key[len(prefix)]
+			if !yield(next) || next == math.MaxUint8 {
+				return
+			}
+			begin = append(bytes.Clone(prefix), next+1)
+		}
+	}
+}
+
+// Implemented by tries with faster implementations of package-level traversals.
+type traversable[V any] interface {
+	all(isReverse bool) iter.Seq2[[]byte, V]
+	childBytes(prefix []byte) iter.Seq[byte]
+}
+
+// DepthHinted is a BTrie whose Range preallocates the buffers it needs for keys up to a maximum length,
+// so that the key buffer of each iteration is allocated only once, see [WithMaxDepth].
+// DepthHinted implements [BTrie], and is as safe for concurrent use as the trie it wraps.
+type DepthHinted[V any] struct {
+	trie     BTrie[V]
+	maxDepth int
+}
+
+// WithMaxDepth returns a DepthHinted wrapping trie, for which every key has a length of at most maxDepth.
+// This is only a hint; longer keys work, but may cause reallocations during a Range iteration as usual.
+// It has no effect if trie is not created by one of this package's constructors.
+// WithMaxDepth will panic if maxDepth is negative.
+func WithMaxDepth[V any](trie BTrie[V], maxDepth int) *DepthHinted[V] {
+	if maxDepth < 0 {
+		panic("maxDepth must be non-negative")
+	}
+	return &DepthHinted[V]{trie, maxDepth}
+}
+
+// Implemented by tries whose Range can preallocate its buffers for keys of a known maximum length.
+type depthHintable[V any] interface {
+	rangeWithMaxDepth(bounds *Bounds, maxDepth int) iter.Seq2[[]byte, V]
+}
+
+func (d *DepthHinted[V]) Get(key []byte) (V, bool) {
+	return d.trie.Get(key)
+}
+
+func (d *DepthHinted[V]) Put(key []byte, value V) (V, bool) {
+	return d.trie.Put(key, value)
+}
+
+func (d *DepthHinted[V]) Delete(key []byte) (V, bool) {
+	return d.trie.Delete(key)
+}
+
+func (d *DepthHinted[V]) Range(bounds *Bounds) iter.Seq2[[]byte, V] {
+	if t, ok := d.trie.(depthHintable[V]); ok {
+		return t.rangeWithMaxDepth(bounds, d.maxDepth)
+	}
+	return d.trie.Range(bounds)
+}
+
+// KeyBuffered is a BTrie whose Range writes each yielded key into a reused buffer instead of a new copy,
+// see [WithKeyBuffer]. A key yielded by Range is only valid until the next iteration, and must be copied to be retained.
+// Because the buffer is shared, only one Range iteration may be in progress at a time.
+// Otherwise, KeyBuffered is as safe for concurrent use as the trie it wraps.
+type KeyBuffered[V any] struct {
+	trie BTrie[V]
+	buf  []byte
+}
+
+// WithKeyBuffer returns a KeyBuffered wrapping trie, which writes the keys yielded by Range into buf.
+// The yielded keys are buf[:n], where n is the length of each key, and buf is replaced by a larger one if needed.
+// If trie is created by [NewArrayTrie], Range does not allocate any keys once buf is large enough,
+// and the capacity of buf is also used as a hint for the maximum key length, as with [WithMaxDepth].
+// Otherwise, keys are still copied into buf, but the allocations made by trie.Range are not avoided.
+func WithKeyBuffer[V any](trie BTrie[V], buf []byte) *KeyBuffered[V] {
+	if buf == nil {
+		// So that the empty key is not yielded as nil.
+		buf = []byte{}
+	}
+	return &KeyBuffered[V]{trie, buf[:0]}
+}
+
+// Implemented by tries whose Range can yield keys written into a given buffer, rather than new copies.
+type keyBufferable[V any] interface {
+	rangeIntoBuffer(bounds *Bounds, buf []byte) iter.Seq2[[]byte, V]
+}
+
+func (k *KeyBuffered[V]) Get(key []byte) (V, bool) {
+	return k.trie.Get(key)
+}
+
+func (k *KeyBuffered[V]) Put(key []byte, value V) (V, bool) {
+	return k.trie.Put(key, value)
+}
+
+func (k *KeyBuffered[V]) Delete(key []byte) (V, bool) {
+	return k.trie.Delete(key)
+}
+
+func (k *KeyBuffered[V]) Range(bounds *Bounds) iter.Seq2[[]byte, V] {
+	if t, ok := k.trie.(keyBufferable[V]); ok {
+		itr := t.rangeIntoBuffer(bounds, k.buf)
+		return func(yield func([]byte, V) bool) {
+			for key, value := range itr {
+				k.buf = key[:0]
+				if !yield(key, value) {
+					return
+				}
+			}
+		}
+	}
+	itr := k.trie.Range(bounds)
+	return func(yield func([]byte, V) bool) {
+		for key, value := range itr {
+			k.buf = append(k.buf[:0], key...)
+			if !yield(k.buf, value) {
+				return
+			}
+		}
+	}
+}
+
+// RangeEntries returns a sequence of entries from trie.Range(bounds).
+// The returned sequence has the same constraints as those returned by trie.Range.
+func RangeEntries[V any](trie BTrie[V], bounds *Bounds) iter.Seq[Entry[V]] {
+	itr := trie.Range(bounds)
+	return func(yield func(Entry[V]) bool) {
+		for k, v := range itr {
+			if !yield(Entry[V]{k, v}) {
+				return
+			}
+		}
+	}
+}
+
+// RangeInto returns a sequence of the results of project applied to each entry from trie.Range(bounds).
+// The key passed to project is only valid until project returns, and must be copied to be retained.
+// If trie is created by [NewArrayTrie], every key is written into a single reused buffer instead of a new copy,
+// so a projection which does not retain the key, such as one extracting a field of the value, avoids allocating keys.
+// Otherwise, the keys are the new copies yielded by trie.Range.
+// The returned sequence has the same constraints as those returned by trie.Range.
+// RangeInto will panic if project is nil.
+func RangeInto[V, T any](trie BTrie[V], bounds *Bounds, project func(key []byte, value V) T) iter.Seq[T] {
+	if project == nil {
+		panic("project must be non-nil")
+	}
+	var itr iter.Seq2[[]byte, V]
+	if t, ok := trie.(keyBufferable[V]); ok {
+		itr = t.rangeIntoBuffer(bounds, []byte{})
+	} else {
+		itr = trie.Range(bounds)
+	}
+	return func(yield func(T) bool) {
+		for k, v := range itr {
+			if !yield(project(k, v)) {
+				return
+			}
+		}
+	}
+}
+
+// AppendRange appends the entries from trie.Range(bounds) to dst, returning the extended slice.
+// Reusing dst across calls, as with dst[:0], avoids allocating a new slice for every query.
+func AppendRange[V any](dst []Entry[V], trie BTrie[V], bounds *Bounds) []Entry[V] {
+	for k, v := range trie.Range(bounds) {
+		dst = append(dst, Entry[V]{k, v})
+	}
+	return dst
+}
+
+// RangeChan iterates over trie.Range(bounds) in a new goroutine, sending each entry to the returned channel.
+// The channel has a buffer of size buf, and is closed when the iteration is finished or ctx is done,
+// whichever happens first.
+// Because the iteration happens in another goroutine, trie must not be mutated until the channel is closed,
+// unless trie's implementation explicitly supports that.
+// RangeChan will panic if buf is negative.
+func RangeChan[V any](ctx context.Context, trie BTrie[V], bounds *Bounds, buf int) <-chan Entry[V] {
+	if buf < 0 {
+		panic("buffer size cannot be negative")
+	}
+	ch := make(chan Entry[V], buf)
+	itr := trie.Range(bounds)
+	go func() {
+		defer close(ch)
+		for k, v := range itr {
+			select {
+			case ch <- Entry[V]{k, v}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch
+}
+
+// MergeRanges returns a sequence merging tries[i].Range(bounds) for all tries, in the order given by bounds.
+// If more than one trie contains the same key, the key is yielded once with the value returned by resolve.
+// The values passed to resolve are in the same order as tries, and the slice is only valid during that call.
+// For example, if tries are ordered newest to oldest, a resolve function returning values[0] gives the newest value.
+// resolve is not called if only one trie contains a key.
+// The returned sequence has the same constraints as those returned by the tries' Range methods.
+func MergeRanges[V any](bounds *Bounds, resolve func(key []byte, values []V) V, tries ...BTrie[V]) iter.Seq2[[]byte, V] {
+	seqs := make([]iter.Seq2[[]byte, V], len(tries))
+	for i, trie := range tries {
+		seqs[i] = trie.Range(bounds)
+	}
+	return mergeSeqs(bounds.IsReverse, resolve, seqs...)
+}
+
+// ResolveUnequal returns a resolve function for [MergeRanges] which only calls resolve
+// if the values for a key are not all equal according to eq, so that equal values are not treated as a conflict.
+// If they are all equal, the first value is used.
+// ResolveUnequal will panic if eq or resolve is nil.
+func ResolveUnequal[V any](eq Equaler[V], resolve func(key []byte, values []V) V) func(key []byte, values []V) V {
+	if eq == nil {
+		panic("eq must be non-nil")
+	}
+	if resolve == nil {
+		panic("resolve must be non-nil")
+	}
+	return func(key []byte, values []V) V {
+		for _, value := range values[1:] {
+			if !eq.Equal(values[0], value) {
+				return resolve(key, values)
+			}
+		}
+		return values[0]
+	}
+}
+
+// Merges seqs, which must all be in the same order.
+// See MergeRanges for details.
+func mergeSeqs[V any](isReverse bool, resolve func([]byte, []V) V, seqs ...iter.Seq2[[]byte, V]) iter.Seq2[[]byte, V] {
+	return func(yield func([]byte, V) bool) {
+		type head struct {
+			next  func() ([]byte, V, bool)
+			key   []byte
+			value V
+			ok    bool
+		}
+		heads := make([]head, len(seqs))
+		for i, seq := range seqs {
+			next, stop := iter.Pull2(seq)
+			defer stop()
+			key, value, ok := next()
+			heads[i] = head{next, key, value, ok}
+		}
+		var values []V
+		for {
+			// Find the next key in iteration order.
+			next := -1
+			for i := range heads {
+				if !heads[i].ok {
+					continue
+				}
+				if next == -1 {
+					next = i
+					continue
+				}
+				cmp := bytes.Compare(heads[i].key, heads[next].key)
+				if cmp != 0 && (cmp < 0) != isReverse {
+					next = i
+				}
+			}
+			if next == -1 {
+				return
+			}
+			key := heads[next].key
+			// Collect and advance every head with that key.
+			values = values[:0]
+			for i := range heads {
+				h := &heads[i]
+				if h.ok && bytes.Equal(h.key, key) {
+					values = append(values, h.value)
+					h.key, h.value, h.ok = h.next()
+				}
+			}
+			value := values[0]
+			if len(values) > 1 {
+				value = resolve(key, values)
+			}
+			if !yield(key, value) {
+				return
+			}
+		}
+	}
+}
+
+// IntersectKeys returns a sequence of the entries in trie whose keys are yielded by keys, in increasing key order.
+// keys must yield keys in strictly increasing order, which lets trie and keys be advanced together like a merge
+// instead of calling trie.Get for every key.
+// Whenever trie falls behind keys by more than one entry, it is advanced by starting a new Range at the next key,
+// skipping every subtree between the two.
+// The slices yielded by keys are not retained, and the returned sequence has the same constraints as trie.Range.
+// The sequence will panic if keys yields a nil key, or a key which is not greater than the previous key.
+func IntersectKeys[V any](trie BTrie[V], keys iter.Seq[[]byte]) iter.Seq2[[]byte, V] {
+	return func(yield func([]byte, V) bool) {
+		nextKey, stopKeys := iter.Pull(keys)
+		defer stopKeys()
+		var prev []byte
+		hasPrev := false
+		// Returns the next key from keys, or nil if there are no more.
+		advanceKeys := func() []byte {
+			key, ok := nextKey()
+			if !ok {
+				return nil
+			}
+			if key == nil {
+				panic("keys must be non-nil")
+			}
+			if hasPrev && bytes.Compare(prev, key) >= 0 {
+				panic("keys must be in increasing order")
+			}
+			prev, hasPrev = append(prev[:0], key...), true
+			return key
+		}
+		key := advanceKeys()
+		if key == nil {
+			return
+		}
+		// A trie cursor beginning at key.
+		next, stop := iter.Pull2(trie.Range(From(key).To(nil)))
+		defer func() { stop() }()
+		trieKey, value, ok := next()
+		for ok {
+			cmp := bytes.Compare(trieKey, key)
+			switch {
+			case cmp == 0:
+				if !yield(trieKey, value) {
+					return
+				}
+				if key = advanceKeys(); key == nil {
+					return
+				}
+				trieKey, value, ok = next()
+			case cmp > 0:
+				if key = advanceKeys(); key == nil {
+					return
+				}
+			default:
+				// Step once, in case the trie is dense, before seeking past everything below key.
+				trieKey, value, ok = next()
+				if ok && bytes.Compare(trieKey, key) < 0 {
+					stop()
+					next, stop = iter.Pull2(trie.Range(From(key).To(nil)))
+					trieKey, value, ok = next()
+				}
+			}
+		}
+	}
+}
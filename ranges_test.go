@@ -0,0 +1,416 @@
+package btrie_test
+
+import (
+	"bytes"
+	"context"
+	"iter"
+	"math/rand"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/phiryll/btrie"
+	"github.com/stretchr/testify/assert"
+)
+
+// A small subset of testTrieConfigs, for tests that don't need all of them.
+var rangeTestConfigs = []*trieConfig{
+	testTrieConfigs[0],
+	testTrieConfigs[0x0F0],
+	testTrieConfigs[0x2AA],
+	testTrieConfigs[len(testTrieConfigs)-1],
+}
+
+func fromEntries(entries []btrie.Entry[byte]) []entry {
+	result := []entry{}
+	for _, e := range entries {
+		result = append(result, entry{e.Key, e.Value})
+	}
+	return result
+}
+
+func TestRangeEntries(t *testing.T) {
+	t.Parallel()
+	for _, test := range createTestTries(rangeTestConfigs) {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			for _, bounds := range []*Bounds{forwardAll, reverseAll} {
+				entries := []btrie.Entry[byte]{}
+				for e := range btrie.RangeEntries(test.trie, bounds) {
+					entries = append(entries, e)
+				}
+				assert.Equal(t, collect(test.trie.Range(bounds)), fromEntries(entries), "%s", bounds)
+			}
+			// need an early yield for test coverage
+			for range btrie.RangeEntries(test.trie, forwardAll) {
+				break
+			}
+		})
+	}
+}
+
+func TestRangeInto(t *testing.T) {
+	t.Parallel()
+	assert.Panics(t, func() { btrie.RangeInto[byte, int](btrie.NewArrayTrie[byte](), forwardAll, nil) })
+	for _, test := range createTestTries(rangeTestConfigs) {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			for _, bounds := range append(test.config.forward, test.config.reverse...) {
+				expected := []entry{}
+				for k, v := range test.trie.Range(&bounds) {
+					expected = append(expected, entry{k, v + 1})
+				}
+				actual := []entry{}
+				for e := range btrie.RangeInto(test.trie, &bounds, func(k []byte, v byte) entry {
+					return entry{bytes.Clone(k), v + 1}
+				}) {
+					actual = append(actual, e)
+				}
+				assert.Equal(t, expected, actual, "%s", bounds)
+			}
+			// need an early yield for test coverage
+			for range btrie.RangeInto(test.trie, forwardAll, func(_ []byte, v byte) byte { return v }) {
+				break
+			}
+		})
+	}
+}
+
+func TestRangeIntoAllocs(t *testing.T) {
+	trie := btrie.NewArrayTrie[byte]()
+	for i := range 1000 {
+		trie.Put([]byte{byte(i >> 8), byte(i), byte(i)}, byte(i))
+	}
+	sum := 0
+	allocs := testing.AllocsPerRun(10, func() {
+		for v := range btrie.RangeInto(trie, forwardAll, func(_ []byte, v byte) int { return int(v) }) {
+			sum += v
+		}
+	})
+	// Only the key buffer, traversal stack, and iterator closures are allocated, not a key per entry.
+	assert.Less(t, allocs, 20.0)
+}
+
+func TestAppendRange(t *testing.T) {
+	t.Parallel()
+	for _, test := range createTestTries(rangeTestConfigs) {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			var buf []btrie.Entry[byte]
+			for _, bounds := range []*Bounds{forwardAll, reverseAll, From([]byte{0x80}).To(nil)} {
+				buf = btrie.AppendRange(buf[:0], test.trie, bounds)
+				assert.Equal(t, collect(test.trie.Range(bounds)), fromEntries(buf), "%s", bounds)
+			}
+			existing := btrie.Entry[byte]{Key: []byte{1, 2, 3}, Value: 42}
+			buf = btrie.AppendRange([]btrie.Entry[byte]{existing}, test.trie, forwardAll)
+			assert.Equal(t, existing, buf[0])
+			assert.Equal(t, collect(test.trie.Range(forwardAll)), fromEntries(buf[1:]))
+		})
+	}
+}
+
+func TestRangeChan(t *testing.T) {
+	t.Parallel()
+	for _, test := range createTestTries(rangeTestConfigs) {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			for _, bounds := range []*Bounds{forwardAll, reverseAll} {
+				for _, buf := range []int{0, 1, 100} {
+					entries := []btrie.Entry[byte]{}
+					for e := range btrie.RangeChan(context.Background(), test.trie, bounds, buf) {
+						entries = append(entries, e)
+					}
+					assert.Equal(t, collect(test.trie.Range(bounds)), fromEntries(entries), "%s", bounds)
+				}
+			}
+		})
+	}
+}
+
+func TestRangeChanCancel(t *testing.T) {
+	t.Parallel()
+	trie := btrie.NewArrayTrie[byte]()
+	for i := range 100 {
+		trie.Put([]byte{byte(i)}, byte(i))
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := btrie.RangeChan(ctx, trie, forwardAll, 0)
+	e := <-ch
+	assert.Equal(t, btrie.Entry[byte]{[]byte{0}, 0}, e)
+	cancel()
+	count := 0
+	for range ch {
+		count++
+	}
+	// At most one more entry could have been sent before cancel was noticed.
+	assert.LessOrEqual(t, count, 1)
+	assert.Panics(t, func() {
+		btrie.RangeChan(context.Background(), trie, forwardAll, -1)
+	})
+}
+
+func TestMergeRanges(t *testing.T) {
+	t.Parallel()
+	first := func(_ []byte, values []byte) byte {
+		return values[0]
+	}
+	sum := func(_ []byte, values []byte) byte {
+		total := byte(0)
+		for _, v := range values {
+			total += v
+		}
+		return total
+	}
+	for _, def := range implDefs {
+		t.Run(def.name, func(t *testing.T) {
+			t.Parallel()
+			a, b, c := def.factory(), def.factory(), def.factory()
+			expectedFirst, expectedSum := newReference(), newReference()
+			for i, key := range presentTestKeys {
+				value := byte(i + 1)
+				switch i % 3 {
+				case 0:
+					a.Put(key, value)
+					expectedFirst.Put(key, value)
+					expectedSum.Put(key, value)
+				case 1:
+					b.Put(key, value)
+					c.Put(key, 2*value)
+					expectedFirst.Put(key, value)
+					expectedSum.Put(key, 3*value)
+				case 2:
+					a.Put(key, value)
+					b.Put(key, 10*value)
+					c.Put(key, 100*value)
+					expectedFirst.Put(key, value)
+					expectedSum.Put(key, 111*value)
+				}
+			}
+			for _, bounds := range testTrieConfigs[0].forward {
+				assert.Equal(t, collect(expectedFirst.Range(&bounds)), collect(btrie.MergeRanges(&bounds, first, a, b, c)),
+					"%s", bounds)
+			}
+			for _, bounds := range testTrieConfigs[0].reverse {
+				assert.Equal(t, collect(expectedSum.Range(&bounds)), collect(btrie.MergeRanges(&bounds, sum, a, b, c)),
+					"%s", bounds)
+			}
+			assert.Equal(t, collect(a.Range(forwardAll)), collect(btrie.MergeRanges(forwardAll, first, a)))
+			assert.Empty(t, collect(btrie.MergeRanges(forwardAll, first)))
+
+			// need an early yield for test coverage
+			for range btrie.MergeRanges(forwardAll, first, a, b, c) {
+				break
+			}
+		})
+	}
+}
+
+func TestResolveUnequal(t *testing.T) {
+	t.Parallel()
+	a, b, c := btrie.NewArrayTrie[byte](), btrie.NewArrayTrie[byte](), btrie.NewArrayTrie[byte]()
+	a.Put([]byte{1}, 5)
+	b.Put([]byte{1}, 5)
+	a.Put([]byte{2}, 5)
+	b.Put([]byte{2}, 5)
+	c.Put([]byte{2}, 6)
+	var conflicts [][]byte
+	resolve := btrie.ResolveUnequal(btrie.Comparable[byte](), func(key []byte, values []byte) byte {
+		conflicts = append(conflicts, bytes.Clone(key))
+		return values[len(values)-1]
+	})
+	assert.Equal(t, []entry{{[]byte{1}, 5}, {[]byte{2}, 6}}, collect(btrie.MergeRanges(forwardAll, resolve, a, b, c)))
+	assert.Equal(t, [][]byte{{2}}, conflicts, "equal values are not a conflict")
+	assert.Panics(t, func() { btrie.ResolveUnequal[byte](nil, func([]byte, []byte) byte { return 0 }) })
+	assert.Panics(t, func() { btrie.ResolveUnequal(btrie.Comparable[byte](), nil) })
+}
+
+func TestIntersectKeys(t *testing.T) {
+	t.Parallel()
+	random := rand.New(rand.NewSource(8081))
+	for _, def := range implDefs {
+		t.Run(def.name, func(t *testing.T) {
+			t.Parallel()
+			trie := def.factory()
+			ref := newReference()
+			for i, key := range presentTestKeys {
+				trie.Put(key, byte(i))
+				ref.Put(key, byte(i))
+			}
+			for range 20 {
+				// A random sorted mix of present and absent keys.
+				keySet := newReference()
+				for _, key := range append(slices.Clone(presentTestKeys), absentTestKeys...) {
+					if random.Intn(3) == 0 {
+						keySet.Put(key, 0)
+					}
+				}
+				expected := []entry{}
+				var keys [][]byte
+				for key := range keySet.Range(forwardAll) {
+					keys = append(keys, key)
+					if value, ok := ref.Get(key); ok {
+						expected = append(expected, entry{key, value})
+					}
+				}
+				assert.Equal(t, expected, collect(btrie.IntersectKeys(trie, slices.Values(keys))))
+			}
+			assert.Empty(t, collect(btrie.IntersectKeys(trie, slices.Values([][]byte{}))))
+
+			// need an early yield for test coverage
+			for range btrie.IntersectKeys(trie, slices.Values(presentTestKeys)) {
+				break
+			}
+		})
+	}
+}
+
+func TestIntersectKeysPanics(t *testing.T) {
+	t.Parallel()
+	trie := btrie.NewArrayTrie[byte]()
+	trie.Put([]byte{}, 0)
+	trie.Put([]byte{1}, 1)
+	trie.Put([]byte{2}, 2)
+	for _, keys := range [][][]byte{
+		{{}, {}},
+		{{2}, {1}},
+		{{1}, nil},
+	} {
+		assert.Panics(t, func() {
+			for range btrie.IntersectKeys(trie, slices.Values(keys)) {
+			}
+		}, "%v", keys)
+	}
+}
+
+func TestAllBackward(t *testing.T) {
+	t.Parallel()
+	for _, test := range createTestTries(rangeTestConfigs) {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, collect(test.trie.Range(forwardAll)), collect(btrie.All[byte](test.trie)))
+			assert.Equal(t, collect(test.trie.Range(reverseAll)), collect(btrie.Backward[byte](test.trie)))
+			// A wrapper without a fast path uses Range.
+			overlay := btrie.NewOverlay[byte](test.trie)
+			assert.Equal(t, collect(test.trie.Range(forwardAll)), collect(btrie.All[byte](overlay)))
+			assert.Equal(t, collect(test.trie.Range(reverseAll)), collect(btrie.Backward[byte](overlay)))
+			// need an early yield for test coverage
+			for range btrie.All[byte](test.trie) {
+				break
+			}
+			for range btrie.Backward[byte](test.trie) {
+				break
+			}
+		})
+	}
+}
+
+// The max depth is only a hint, keys longer than it must still be yielded.
+func TestWithMaxDepth(t *testing.T) {
+	t.Parallel()
+	for _, test := range createTestTries(rangeTestConfigs) {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			for _, maxDepth := range []int{0, 2, 100} {
+				trie := btrie.WithMaxDepth[byte](test.trie, maxDepth)
+				for _, bounds := range append(test.config.forward, test.config.reverse...) {
+					assert.Equal(t, collect(test.trie.Range(&bounds)), collect(trie.Range(&bounds)), "%s", bounds)
+				}
+			}
+		})
+	}
+	trie := btrie.WithMaxDepth(btrie.NewArrayTrie[byte](), 3)
+	trie.Put([]byte{1, 2}, 5)
+	value, ok := trie.Get([]byte{1, 2})
+	assert.True(t, ok)
+	assert.Equal(t, byte(5), value)
+	prev, ok := trie.Delete([]byte{1, 2})
+	assert.True(t, ok)
+	assert.Equal(t, byte(5), prev)
+	assert.Panics(t, func() { btrie.WithMaxDepth(btrie.NewArrayTrie[byte](), -1) })
+}
+
+func TestWithKeyBuffer(t *testing.T) {
+	t.Parallel()
+	for _, test := range createTestTries(rangeTestConfigs) {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			trie := btrie.WithKeyBuffer[byte](test.trie, make([]byte, 1))
+			for _, bounds := range append(test.config.forward, test.config.reverse...) {
+				assert.Equal(t, collect(test.trie.Range(&bounds)), collect(cloneKeys(trie.Range(&bounds))), "%s", bounds)
+			}
+		})
+	}
+}
+
+// Returns itr with copies of its keys, which may then be retained.
+func cloneKeys[V any](itr iter.Seq2[[]byte, V]) iter.Seq2[[]byte, V] {
+	return func(yield func([]byte, V) bool) {
+		for k, v := range itr {
+			if !yield(bytes.Clone(k), v) {
+				return
+			}
+		}
+	}
+}
+
+func TestWithKeyBufferReused(t *testing.T) {
+	t.Parallel()
+	buf := make([]byte, 8)
+	trie := btrie.WithKeyBuffer(btrie.NewArrayTrie[byte](), buf)
+	trie.Put([]byte{}, 0)
+	trie.Put([]byte{1, 2}, 1)
+	trie.Put([]byte{1, 2, 3}, 2)
+	var keys [][]byte
+	for key := range trie.Range(forwardAll) {
+		assert.NotNil(t, key)
+		assert.Same(t, &buf[:1][0], &key[:1][0])
+		keys = append(keys, key)
+	}
+	// Every yielded key shares buf, so retained keys are overwritten.
+	assert.Equal(t, [][]byte{{}, {1, 2}, {1, 2, 3}}, keys)
+	assert.Equal(t, []byte{1, 2, 3}, buf[:3])
+}
+
+// Returns the expected result of btrie.Children(trie, prefix) for a trie with the given entries.
+func expectedChildren(entries map[string]byte, prefix []byte) []byte {
+	result := []byte{}
+	for k := range entries {
+		if len(k) > len(prefix) && strings.HasPrefix(k, string(prefix)) {
+			result = append(result, k[len(prefix)])
+		}
+	}
+	slices.Sort(result)
+	return slices.Compact(result)
+}
+
+func TestChildren(t *testing.T) {
+	t.Parallel()
+	for _, test := range createTestTries(rangeTestConfigs) {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Panics(t, func() { btrie.Children[byte](test.trie, nil) })
+			overlay := btrie.NewOverlay[byte](test.trie)
+			prefixes := [][]byte{{}, {0}, {0xFF}, {0xFF, 0xFF}, {1, 2, 3, 4, 5, 6, 7, 8}}
+			for k := range test.config.entries {
+				for i := range min(len(k), 3) {
+					prefixes = append(prefixes, []byte(k[:i]))
+				}
+				if len(prefixes) > 50 {
+					break
+				}
+			}
+			for _, prefix := range prefixes {
+				expected := expectedChildren(test.config.entries, prefix)
+				assert.Equal(t, expected, append([]byte{}, slices.Collect(btrie.Children[byte](test.trie, prefix))...), "%X", prefix)
+				assert.Equal(t, expected, append([]byte{}, slices.Collect(btrie.Children[byte](overlay, prefix))...), "%X", prefix)
+			}
+			// need an early yield for test coverage
+			for range btrie.Children[byte](test.trie, []byte{}) {
+				break
+			}
+			for range btrie.Children[byte](overlay, []byte{}) {
+				break
+			}
+		})
+	}
+}
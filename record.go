@@ -0,0 +1,314 @@
+package btrie
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"sync"
+)
+
+// The workload written by a Recorded trie is:
+//
+//	magic   [4]byte  "BTRW"
+//	version uvarint  currently 1
+//	records          zero or more, until EOF
+//
+// where each record is:
+//
+//	op        byte             1 = get, 2 = put, 3 = delete, 4 = range
+//	keySize   uvarint          get, put, and delete only
+//	key       [keySize]byte    get, put, and delete only
+//	valueSize uvarint          put only
+//	value     [valueSize]byte  put only, as encoded by the Codec
+//	flags     byte             range only, see below
+//	beginSize uvarint          range only, if the begin flag is set
+//	begin     [beginSize]byte  range only, if the begin flag is set
+//	endSize   uvarint          range only, if the end flag is set
+//	end       [endSize]byte    range only, if the end flag is set
+//	count     uvarint          range only, the number of entries yielded
+//
+// The range flags are 1 if the bounds are reverse, 2 if Begin is non-nil, 4 if End is non-nil,
+// and 8 if the iteration ended because there were no more entries, rather than being stopped after count entries.
+const (
+	recordMagic   = "BTRW"
+	recordVersion = 1
+
+	recordGet    byte = 1
+	recordPut    byte = 2
+	recordDelete byte = 3
+	recordRange  byte = 4
+
+	recordReverse   byte = 1
+	recordBegin     byte = 2
+	recordEnd       byte = 4
+	recordExhausted byte = 8
+)
+
+// Recorded is a BTrie which records every operation performed through it, so the workload can be re-run
+// against any BTrie with [Replay], for tuning or reproducing bugs.
+// Unlike a [Journaled] trie, which only records successful mutations, every Get, Put, Delete, and Range is recorded,
+// whether or not it finds its key.
+// A Range is recorded when its iteration ends, with the number of entries the caller consumed.
+// Recorded implements [BTrie], and is as safe for concurrent use as the trie it wraps.
+// Concurrent operations are recorded in the order they finish.
+type Recorded[V any] struct {
+	trie     BTrie[V]
+	w        io.Writer
+	codec    Codec[V]
+	mu       sync.Mutex // guards the following fields
+	buf      []byte
+	valueBuf []byte
+	err      error
+}
+
+// Record returns a Recorded wrapping trie, which writes its workload to w using codec to encode values.
+// The workload header is written immediately.
+// Operations on trie not made through the returned Recorded are not recorded.
+func Record[V any](trie BTrie[V], w io.Writer, codec Codec[V]) *Recorded[V] {
+	r := &Recorded[V]{trie: trie, w: w, codec: codec}
+	r.buf = binary.AppendUvarint([]byte(recordMagic), recordVersion)
+	r.write()
+	return r
+}
+
+// Err returns the first error encountered while writing the workload, or nil if there was none.
+// After an error, operations are still performed on the trie, but are no longer recorded.
+func (r *Recorded[V]) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+func (r *Recorded[V]) Get(key []byte) (V, bool) {
+	value, ok := r.trie.Get(key)
+	r.recordKey(recordGet, key)
+	return value, ok
+}
+
+func (r *Recorded[V]) Put(key []byte, value V) (V, bool) {
+	prev, ok := r.trie.Put(key, value)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return prev, ok
+	}
+	var err error
+	r.valueBuf, err = r.codec.AppendValue(r.valueBuf[:0], value)
+	if err != nil {
+		r.err = err
+		return prev, ok
+	}
+	r.buf = appendRecordKey(r.buf[:0], recordPut, key)
+	r.buf = binary.AppendUvarint(r.buf, uint64(len(r.valueBuf)))
+	r.buf = append(r.buf, r.valueBuf...)
+	r.write()
+	return prev, ok
+}
+
+func (r *Recorded[V]) Delete(key []byte) (V, bool) {
+	prev, ok := r.trie.Delete(key)
+	r.recordKey(recordDelete, key)
+	return prev, ok
+}
+
+func (r *Recorded[V]) Range(bounds *Bounds) iter.Seq2[[]byte, V] {
+	bounds = bounds.Clone()
+	itr := r.trie.Range(bounds)
+	return func(yield func([]byte, V) bool) {
+		var count uint64
+		exhausted := true
+		for key, value := range itr {
+			count++
+			if !yield(key, value) {
+				exhausted = false
+				break
+			}
+		}
+		r.recordRange(bounds, count, exhausted)
+	}
+}
+
+func (r *Recorded[V]) recordKey(op byte, key []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.buf = appendRecordKey(r.buf[:0], op, key)
+	r.write()
+}
+
+func (r *Recorded[V]) recordRange(bounds *Bounds, count uint64, exhausted bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var flags byte
+	if bounds.IsReverse {
+		flags |= recordReverse
+	}
+	if bounds.Begin != nil {
+		flags |= recordBegin
+	}
+	if bounds.End != nil {
+		flags |= recordEnd
+	}
+	if exhausted {
+		flags |= recordExhausted
+	}
+	r.buf = append(r.buf[:0], recordRange, flags)
+	if bounds.Begin != nil {
+		r.buf = binary.AppendUvarint(r.buf, uint64(len(bounds.Begin)))
+		r.buf = append(r.buf, bounds.Begin...)
+	}
+	if bounds.End != nil {
+		r.buf = binary.AppendUvarint(r.buf, uint64(len(bounds.End)))
+		r.buf = append(r.buf, bounds.End...)
+	}
+	r.buf = binary.AppendUvarint(r.buf, count)
+	r.write()
+}
+
+func appendRecordKey(buf []byte, op byte, key []byte) []byte {
+	buf = append(buf, op)
+	buf = binary.AppendUvarint(buf, uint64(len(key)))
+	return append(buf, key...)
+}
+
+// Must be called with r.mu held.
+func (r *Recorded[V]) write() {
+	if r.err != nil {
+		return
+	}
+	_, r.err = r.w.Write(r.buf)
+}
+
+// Replay reads a workload recorded by a [Recorded] trie from r, and performs its operations in order
+// on a new trie returned by factory, using codec to decode the values.
+// Each Range consumes the same number of entries as the recorded iteration did.
+// Replay returns the trie and the number of operations performed.
+// Operations read before an error is encountered will have been performed.
+// If r does not implement [io.ByteReader], Replay may read past the end of the workload.
+func Replay[V any](r io.Reader, factory func() BTrie[V], codec Codec[V]) (BTrie[V], int, error) {
+	trie := factory()
+	br, ok := r.(byteReader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+	magic := make([]byte, len(recordMagic))
+	if _, err := io.ReadFull(br, magic); err != nil {
+		return trie, 0, formatError(err)
+	}
+	if string(magic) != recordMagic {
+		return trie, 0, fmt.Errorf("%w: bad magic %q", ErrInvalidFormat, magic)
+	}
+	version, err := binary.ReadUvarint(br)
+	if err != nil {
+		return trie, 0, formatError(err)
+	}
+	if version != recordVersion {
+		return trie, 0, fmt.Errorf("%w: unsupported version %d", ErrInvalidFormat, version)
+	}
+	count := 0
+	var buf []byte
+	for {
+		op, err := br.ReadByte()
+		if errors.Is(err, io.EOF) {
+			return trie, count, nil
+		}
+		if err != nil {
+			return trie, count, err
+		}
+		switch op {
+		case recordGet, recordDelete:
+			key, err := readSizedBytes(br)
+			if err != nil {
+				return trie, count, err
+			}
+			if op == recordGet {
+				trie.Get(key)
+			} else {
+				trie.Delete(key)
+			}
+		case recordPut:
+			key, err := readSizedBytes(br)
+			if err != nil {
+				return trie, count, err
+			}
+			var value V
+			buf, value, err = readValue(br, buf, codec)
+			if err != nil {
+				return trie, count, err
+			}
+			trie.Put(key, value)
+		case recordRange:
+			if err := replayRange(br, trie); err != nil {
+				return trie, count, err
+			}
+		default:
+			return trie, count, fmt.Errorf("%w: bad workload op %d", ErrInvalidFormat, op)
+		}
+		count++
+	}
+}
+
+func replayRange[V any](br byteReader, trie BTrie[V]) error {
+	flags, err := br.ReadByte()
+	if err != nil {
+		return formatError(err)
+	}
+	if flags&^(recordReverse|recordBegin|recordEnd|recordExhausted) != 0 {
+		return fmt.Errorf("%w: bad range flags %d", ErrInvalidFormat, flags)
+	}
+	bounds := &Bounds{IsReverse: flags&recordReverse != 0}
+	if flags&recordBegin != 0 {
+		if bounds.Begin, err = readSizedBytes(br); err != nil {
+			return err
+		}
+	}
+	if flags&recordEnd != 0 {
+		if bounds.End, err = readSizedBytes(br); err != nil {
+			return err
+		}
+	}
+	count, err := binary.ReadUvarint(br)
+	if err != nil {
+		return formatError(err)
+	}
+	if flags&recordExhausted != 0 {
+		for range trie.Range(bounds) {
+		}
+		return nil
+	}
+	var n uint64
+	for range trie.Range(bounds) {
+		n++
+		if n >= count {
+			break
+		}
+	}
+	return nil
+}
+
+// Reads a uvarint size, then returns a new slice of that many bytes read from br.
+func readSizedBytes(br byteReader) ([]byte, error) {
+	size, err := binary.ReadUvarint(br)
+	if err != nil {
+		return nil, formatError(err)
+	}
+	return readBytes(br, []byte{}, size)
+}
+
+// Reads a uvarint size, then decodes a value from that many bytes read from br, using buf as scratch space.
+// Returns the possibly reallocated buf.
+func readValue[V any](br byteReader, buf []byte, codec Codec[V]) ([]byte, V, error) {
+	var zero V
+	size, err := binary.ReadUvarint(br)
+	if err != nil {
+		return buf, zero, formatError(err)
+	}
+	buf, err = readBytes(br, buf[:0], size)
+	if err != nil {
+		return buf, zero, err
+	}
+	value, err := codec.DecodeValue(buf)
+	return buf, value, err
+}
@@ -0,0 +1,120 @@
+package btrie_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/phiryll/btrie"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Performs a workload of every kind of operation on trie, returning the expected contents afterward.
+func recordWorkload(trie btrie.BTrie[byte]) map[string]byte {
+	expected := map[string]byte{}
+	for i, key := range presentTestKeys {
+		trie.Put(key, byte(i))
+		expected[string(key)] = byte(i)
+	}
+	for _, key := range absentTestKeys {
+		trie.Get(key)
+		trie.Delete(key)
+	}
+	for i, key := range presentTestKeys {
+		if i%3 == 0 {
+			trie.Delete(key)
+			delete(expected, string(key))
+		}
+	}
+	for range trie.Range(forwardAll) {
+	}
+	for range trie.Range(From([]byte{0x23}).DownTo([]byte{})) {
+		break
+	}
+	n := 0
+	for range trie.Range(From([]byte{}).To([]byte{0xC5, 0x43})) {
+		n++
+		if n == 3 {
+			break
+		}
+	}
+	return expected
+}
+
+func TestRecordReplay(t *testing.T) {
+	t.Parallel()
+	var workload bytes.Buffer
+	recorded := btrie.Record[byte](btrie.NewArrayTrie[byte](), &workload, byteCodec{})
+	expected := recordWorkload(recorded)
+	require.NoError(t, recorded.Err())
+	data := bytes.Clone(workload.Bytes())
+	assertSame(t, expected, recorded)
+	for _, def := range implDefs {
+		t.Run(def.name, func(t *testing.T) {
+			t.Parallel()
+			// Recording the replay must perform exactly the same operations, including how far each Range gets.
+			var rerecorded bytes.Buffer
+			var rerecorder *btrie.Recorded[byte]
+			factory := func() btrie.BTrie[byte] {
+				rerecorder = btrie.Record(btrie.BTrie[byte](def.factory()), &rerecorded, byteCodec{})
+				return rerecorder
+			}
+			trie, count, err := btrie.Replay(bytes.NewReader(data), factory, byteCodec{})
+			require.NoError(t, err)
+			assert.Equal(t, len(presentTestKeys)+2*len(absentTestKeys)+4+3, count)
+			assert.Same(t, rerecorder, trie)
+			require.NoError(t, rerecorder.Err())
+			assert.Equal(t, data, rerecorded.Bytes())
+			assertSame(t, expected, trie)
+		})
+	}
+}
+
+func TestRecordWriteError(t *testing.T) {
+	t.Parallel()
+	trie := btrie.Record(btrie.NewArrayTrie[byte](), &failingWriter{2}, byteCodec{})
+	trie.Put([]byte{1}, 1)
+	require.NoError(t, trie.Err())
+	trie.Get([]byte{1})
+	require.ErrorIs(t, trie.Err(), errWrite)
+	trie.Put([]byte{2}, 2)
+	for range trie.Range(forwardAll) {
+	}
+	assertSame(t, map[string]byte{"\x01": 1, "\x02": 2}, trie)
+}
+
+func TestReplayErrors(t *testing.T) {
+	t.Parallel()
+	var workload bytes.Buffer
+	trie := btrie.Record(btrie.NewArrayTrie[byte](), &workload, byteCodec{})
+	trie.Put([]byte{1, 2}, 1)
+	for range trie.Range(From([]byte{1}).To([]byte{2})) {
+	}
+	data := workload.Bytes()
+
+	// Truncations are errors, except at record boundaries.
+	boundaries := map[int]bool{5: true, 11: true, len(data): true}
+	for i := range data {
+		_, _, err := btrie.Replay(bytes.NewReader(data[:i]), btrie.NewArrayTrie[byte], byteCodec{})
+		if boundaries[i] {
+			assert.NoError(t, err, "truncated at %d", i)
+		} else {
+			assert.ErrorIs(t, err, btrie.ErrInvalidFormat, "truncated at %d", i)
+		}
+	}
+
+	for _, tt := range []struct {
+		index int
+		value byte
+	}{
+		{0, 'X'},  // magic
+		{4, 0x7F}, // version
+		{5, 5},    // op
+		{12, 16},  // range flags
+	} {
+		bad := bytes.Clone(data)
+		bad[tt.index] = tt.value
+		_, _, err := btrie.Replay(bytes.NewReader(bad), btrie.NewArrayTrie[byte], byteCodec{})
+		assert.ErrorIs(t, err, btrie.ErrInvalidFormat, "%d", tt.index)
+	}
+}
@@ -0,0 +1,47 @@
+package btrie
+
+// Rekey returns a new trie created by dst, containing every entry of src with its key rewritten by f,
+// for migrating from one key scheme to another, such as inserting a version byte after a prefix.
+// Entries are streamed from src in increasing key order and put into the new trie in a single pass.
+// If f returns nil, the entry is dropped. The key passed to f is only valid during that call,
+// but f may return a slice of it, since the new trie copies the key when it is put.
+//
+// If more than one key of src is rewritten to the same key, the value put is the one returned by resolve,
+// called with the rewritten key, the value already put for it, and the next colliding value in src.
+// When more than two keys collide, resolve is called again with its previous result and the next value.
+// If resolve is nil, the value of the last colliding key in src is used.
+// Collisions are found from the previous value returned by Put, so an entry which does not collide is only put once.
+// Rekey will panic if f or dst is nil.
+func Rekey[V any](src BTrie[V], f func(old []byte) []byte, dst func() BTrie[V],
+	resolve func(key []byte, prev, next V) V,
+) BTrie[V] {
+	if dst == nil {
+		panic("dst must be non-nil")
+	}
+	result := dst()
+	RekeyInto(src, f, func(key []byte, value V) {
+		if prev, ok := result.Put(key, value); ok && resolve != nil {
+			result.Put(key, resolve(key, prev, value))
+		}
+	})
+	return result
+}
+
+// RekeyInto is like [Rekey], but passes each rewritten entry to add instead of putting it into a new trie,
+// such as [Builder.Add] to build an immutable trie without the cost of a mutable one.
+// Colliding keys are all passed to add in the order of their keys in src, and it is up to add to resolve them.
+// The key passed to add is only valid during that call.
+// RekeyInto will panic if f or add is nil.
+func RekeyInto[V any](src BTrie[V], f func(old []byte) []byte, add func(key []byte, value V)) {
+	if f == nil {
+		panic("f must be non-nil")
+	}
+	if add == nil {
+		panic("add must be non-nil")
+	}
+	for k, v := range All(src) {
+		if key := f(k); key != nil {
+			add(key, v)
+		}
+	}
+}
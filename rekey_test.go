@@ -0,0 +1,87 @@
+package btrie_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/phiryll/btrie"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRekey(t *testing.T) {
+	t.Parallel()
+	for _, test := range createTestTries(rangeTestConfigs) {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			// Inserting a version byte after the first byte never causes a collision.
+			versioned := func(old []byte) []byte {
+				if len(old) == 0 {
+					return []byte{}
+				}
+				return append([]byte{old[0], 0xFF}, old[1:]...)
+			}
+			expected := map[string]byte{}
+			for k, v := range test.config.entries {
+				expected[string(versioned([]byte(k)))] = v
+			}
+			result := btrie.Rekey(test.trie, versioned, btrie.NewArrayTrie[byte], nil)
+			assertSame(t, expected, result)
+			assertSame(t, test.config.entries, test.trie)
+		})
+	}
+}
+
+func TestRekeyCollisions(t *testing.T) {
+	t.Parallel()
+	src := btrie.NewArrayTrie[byte]()
+	for _, s := range []string{"a1", "a2", "a3", "b1", "c"} {
+		src.Put([]byte(s), s[len(s)-1])
+	}
+	// Keep only the first byte, dropping "c".
+	first := func(old []byte) []byte {
+		if len(old) < 2 {
+			return nil
+		}
+		return old[:1]
+	}
+
+	assertSame(t, map[string]byte{"a": '3', "b": '1'}, btrie.Rekey(src, first, btrie.NewArrayTrie[byte], nil))
+
+	var calls [][]byte
+	sum := func(key []byte, prev, next byte) byte {
+		calls = append(calls, append(bytes.Clone(key), prev, next))
+		return prev + next - '0'
+	}
+	assertSame(t, map[string]byte{"a": '6', "b": '1'}, btrie.Rekey(src, first, btrie.NewArrayTrie[byte], sum))
+	assert.Equal(t, [][]byte{[]byte("a12"), []byte("a33")}, calls)
+
+	assert.Panics(t, func() { btrie.Rekey(src, nil, btrie.NewArrayTrie[byte], nil) })
+	assert.Panics(t, func() { btrie.Rekey(src, first, nil, nil) })
+}
+
+func TestRekeyInto(t *testing.T) {
+	t.Parallel()
+	src := btrie.NewArrayTrie[byte]()
+	for _, s := range []string{"a1", "a2", "b1", "c"} {
+		src.Put([]byte(s), s[len(s)-1])
+	}
+	first := func(old []byte) []byte {
+		if len(old) < 2 {
+			return nil
+		}
+		return old[:1]
+	}
+	var added []entry
+	btrie.RekeyInto(src, first, func(key []byte, value byte) {
+		added = append(added, entry{bytes.Clone(key), value})
+	})
+	assert.Equal(t, []entry{{[]byte("a"), '1'}, {[]byte("a"), '2'}, {[]byte("b"), '1'}}, added)
+
+	// A Builder keeps the last value added for a key, like Rekey without resolve.
+	builder := btrie.NewBuilder[byte]()
+	btrie.RekeyInto(src, first, builder.Add)
+	assert.Equal(t, []entry{{[]byte("a"), '2'}, {[]byte("b"), '1'}}, collect(builder.Build().Range(forwardAll)))
+
+	assert.Panics(t, func() { btrie.RekeyInto(src, nil, builder.Add) })
+	assert.Panics(t, func() { btrie.RekeyInto(src, first, nil) })
+}
@@ -0,0 +1,190 @@
+package btrie
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"iter"
+)
+
+// RangeFrom returns a sequence of the entries of trie within bounds that come strictly after resumeKey
+// in the direction of bounds, that is, greater than resumeKey if bounds is forward and less than it if reverse.
+// This is what is needed to continue a Range after resumeKey, the last key yielded by a previous iteration.
+// resumeKey need not be in trie or within bounds, and a nil resumeKey begins at the start of bounds.
+// The traversal is seeded at resumeKey, rather than skipping every entry before it.
+// The returned sequence has the same constraints as those returned by trie.Range.
+func RangeFrom[V any](trie ReadOnlyTrie[V], bounds *Bounds, resumeKey []byte) iter.Seq2[[]byte, V] {
+	if resumeKey == nil {
+		return trie.Range(bounds)
+	}
+	resumeKey = bytes.Clone(resumeKey)
+	if !bounds.IsReverse {
+		// Resume just after resumeKey.
+		resumed, ok := bounds.Intersect(From(NextKey(resumeKey)).To(nil))
+		if !ok {
+			return emptySeq2
+		}
+		return trie.Range(resumed)
+	}
+	// There is no greatest key less than resumeKey, so begin at resumeKey itself and skip it.
+	resumed, ok := bounds.Intersect(From(resumeKey).DownTo(nil))
+	if !ok {
+		return emptySeq2
+	}
+	itr := trie.Range(resumed)
+	return func(yield func([]byte, V) bool) {
+		for k, v := range itr {
+			if bytes.Equal(k, resumeKey) {
+				continue
+			}
+			if !yield(k, v) {
+				return
+			}
+		}
+	}
+}
+
+// SeekNth returns the n-th entry of trie within bounds, counting from 0 in the direction of bounds,
+// and whether there is one. This allows offset-based pagination without iterating over the skipped entries.
+// With prefix counts, the entry is found by descending from the root, in time proportional to the key lengths
+// and independent of n. Otherwise, the first n entries are ranged over and skipped.
+// SeekNth will panic if n is negative.
+func SeekNth[V any](trie BTrie[V], bounds *Bounds, n int) ([]byte, V, bool) {
+	if n < 0 {
+		panic("n must be non-negative")
+	}
+	var zero V
+	if t, ok := trie.(rankSelector); ok {
+		// Find the ranks of the first and last keys within bounds, in increasing order.
+		first, last := 0, t.len()-1
+		lower, upper := bounds.Begin, bounds.End
+		if bounds.IsReverse {
+			lower, upper = upper, lower
+		}
+		if lower != nil {
+			first = t.rankOf(lower)
+			if _, ok := trie.Get(lower); ok && bounds.IsReverse {
+				first++ // End is exclusive
+			}
+		}
+		if upper != nil {
+			last = t.rankOf(upper) - 1
+			if _, ok := trie.Get(upper); ok && bounds.IsReverse {
+				last++ // Begin is inclusive
+			}
+		}
+		if n > last-first {
+			return nil, zero, false
+		}
+		rank := first + n
+		if bounds.IsReverse {
+			rank = last - n
+		}
+		key := t.keyAt(rank)
+		value, _ := trie.Get(key)
+		return key, value, true
+	}
+	for key, value := range trie.Range(bounds) {
+		if n == 0 {
+			return bytes.Clone(key), value, true
+		}
+		n--
+	}
+	return nil, zero, false
+}
+
+// The form of a resume token, before it is base64url encoded without padding, is:
+//
+//	version  uvarint  currently 1
+//	flags    byte     a bitwise OR of the token flags below
+//	begin    sized    only if tokenHasBegin is set
+//	end      sized    only if tokenHasEnd is set
+//	resume   sized    only if tokenHasResume is set
+//
+// where each sized field is:
+//
+//	size  uvarint
+//	data  [size]byte
+const tokenVersion = 1
+
+// Token flags.
+const (
+	tokenIsReverse = 1 << iota
+	tokenHasBegin
+	tokenHasEnd
+	tokenHasResume
+	tokenFlags = tokenIsReverse | tokenHasBegin | tokenHasEnd | tokenHasResume
+)
+
+// ErrInvalidResumeToken is wrapped by errors returned from [DecodeResumeToken] for malformed tokens.
+var ErrInvalidResumeToken = errors.New("invalid resume token")
+
+// EncodeResumeToken returns an opaque, URL-safe string recording bounds and resumeKey,
+// which [DecodeResumeToken] turns back into the arguments for [RangeFrom].
+// This allows a stateless server to give a client a token for the next page of a Range.
+func EncodeResumeToken(bounds *Bounds, resumeKey []byte) string {
+	flags := byte(0)
+	if bounds.IsReverse {
+		flags |= tokenIsReverse
+	}
+	var fields []byte
+	for _, field := range []struct {
+		flag byte
+		data []byte
+	}{{tokenHasBegin, bounds.Begin}, {tokenHasEnd, bounds.End}, {tokenHasResume, resumeKey}} {
+		if field.data != nil {
+			flags |= field.flag
+			fields = appendSized(fields, field.data)
+		}
+	}
+	buf := binary.AppendUvarint(nil, tokenVersion)
+	buf = append(buf, flags)
+	buf = append(buf, fields...)
+	return base64.RawURLEncoding.EncodeToString(buf)
+}
+
+// DecodeResumeToken returns the bounds and resume key recorded in a token created by [EncodeResumeToken].
+// The returned error wraps [ErrInvalidResumeToken] if token is malformed,
+// and a [*BoundsError] wrapping [ErrInvertedBounds] if the recorded bounds are inverted.
+func DecodeResumeToken(token string) (*Bounds, []byte, error) {
+	buf, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %w", ErrInvalidResumeToken, err)
+	}
+	version, n := binary.Uvarint(buf)
+	if n <= 0 || len(buf) == n {
+		return nil, nil, fmt.Errorf("%w: truncated", ErrInvalidResumeToken)
+	}
+	if version != tokenVersion {
+		return nil, nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidResumeToken, version)
+	}
+	flags := buf[n]
+	if flags&^tokenFlags != 0 {
+		return nil, nil, fmt.Errorf("%w: unknown flags %#x", ErrInvalidResumeToken, flags)
+	}
+	buf = buf[n+1:]
+	var fields [3][]byte
+	for i, flag := range []byte{tokenHasBegin, tokenHasEnd, tokenHasResume} {
+		if flags&flag == 0 {
+			continue
+		}
+		size, n := binary.Uvarint(buf)
+		if n <= 0 || uint64(len(buf)-n) < size {
+			return nil, nil, fmt.Errorf("%w: truncated", ErrInvalidResumeToken)
+		}
+		end := n + int(size) //nolint:gosec // size <= len(buf) - n
+		fields[i] = bytes.Clone(buf[n:end])
+		buf = buf[end:]
+	}
+	if len(buf) != 0 {
+		return nil, nil, fmt.Errorf("%w: %d trailing bytes", ErrInvalidResumeToken, len(buf))
+	}
+	bounds := &Bounds{fields[0], fields[1], flags&tokenIsReverse != 0}
+	// Empty bounds are fine, Range yields nothing for them.
+	if err := bounds.Validate(); errors.Is(err, ErrInvertedBounds) {
+		return nil, nil, err
+	}
+	return bounds, fields[2], nil
+}
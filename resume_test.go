@@ -0,0 +1,121 @@
+package btrie_test
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/phiryll/btrie"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Paging through every configured bounds must yield the same entries as a single Range.
+func TestRangeFrom(t *testing.T) {
+	t.Parallel()
+	const pageSize = 7
+	for _, test := range createTestTries(rangeTestConfigs) {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			for _, bounds := range append(test.config.forward, test.config.reverse...) {
+				expected := collect(test.trie.Range(&bounds))
+				actual := []entry{}
+				token := btrie.EncodeResumeToken(&bounds, nil)
+				for {
+					pageBounds, resumeKey, err := btrie.DecodeResumeToken(token)
+					require.NoError(t, err)
+					page := 0
+					for k, v := range btrie.RangeFrom(test.trie, pageBounds, resumeKey) {
+						actual = append(actual, entry{k, v})
+						resumeKey = k
+						page++
+						if page == pageSize {
+							break
+						}
+					}
+					if page < pageSize {
+						break
+					}
+					token = btrie.EncodeResumeToken(pageBounds, resumeKey)
+				}
+				assert.Equal(t, expected, actual, "%s", bounds)
+			}
+		})
+	}
+}
+
+func TestSeekNth(t *testing.T) {
+	t.Parallel()
+	for _, test := range createTestTries(rangeTestConfigs) {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			for _, bounds := range append(test.config.forward, test.config.reverse...) {
+				expected := collect(test.trie.Range(&bounds))
+				for n := range len(expected) + 2 {
+					key, value, ok := btrie.SeekNth(test.trie, &bounds, n)
+					if n >= len(expected) {
+						assert.False(t, ok, "%s: %d", &bounds, n)
+						continue
+					}
+					if assert.True(t, ok, "%s: %d", &bounds, n) {
+						assert.Equal(t, expected[n], entry{key, value}, "%s: %d", &bounds, n)
+					}
+				}
+			}
+			assert.Panics(t, func() { btrie.SeekNth(test.trie, forwardAll, -1) })
+		})
+	}
+}
+
+func TestRangeFromAbsentKey(t *testing.T) {
+	t.Parallel()
+	trie := btrie.NewArrayTrie[byte]()
+	for i, key := range keys("a", "ab", "b", "c") {
+		trie.Put(key, byte(i))
+	}
+	rangeFrom := func(bounds *Bounds, resumeKey string) []entry {
+		return collect(btrie.RangeFrom(trie, bounds, []byte(resumeKey)))
+	}
+	assert.Equal(t, []entry{{[]byte("ab"), 1}, {[]byte("b"), 2}, {[]byte("c"), 3}}, rangeFrom(forwardAll, "a"))
+	assert.Equal(t, []entry{{[]byte("ab"), 1}, {[]byte("b"), 2}}, rangeFrom(From(nil).To([]byte("c")), "aa"))
+	assert.Equal(t, []entry{{[]byte("ab"), 1}, {[]byte("a"), 0}}, rangeFrom(reverseAll, "abc"))
+	assert.Equal(t, []entry{{[]byte("a"), 0}}, rangeFrom(reverseAll, "ab"))
+	assert.Equal(t, []entry{}, rangeFrom(From(nil).To([]byte("b")), "b"))
+	assert.Equal(t, []entry{}, rangeFrom(From(nil).DownTo([]byte("b")), "b"))
+	assert.Equal(t, []entry{{[]byte("b"), 2}, {[]byte("ab"), 1}, {[]byte("a"), 0}},
+		rangeFrom(From([]byte("b")).DownTo(nil), "d"))
+}
+
+func TestResumeToken(t *testing.T) {
+	t.Parallel()
+	for _, test := range []struct {
+		bounds    *Bounds
+		resumeKey []byte
+	}{
+		{forwardAll, nil},
+		{reverseAll, []byte{}},
+		{From([]byte{}).To([]byte{0, 0xFF}), []byte{0}},
+		{From([]byte{5, 6}).DownTo([]byte{}), []byte{5}},
+	} {
+		token := btrie.EncodeResumeToken(test.bounds, test.resumeKey)
+		bounds, resumeKey, err := btrie.DecodeResumeToken(token)
+		require.NoError(t, err)
+		assert.Equal(t, test.bounds, bounds)
+		assert.Equal(t, test.resumeKey, resumeKey)
+	}
+
+	encode := base64.RawURLEncoding.EncodeToString
+	for _, token := range []string{
+		"not base64!",
+		encode([]byte{}),
+		encode([]byte{1}),
+		encode([]byte{2, 0}),
+		encode([]byte{1, 0x80}),
+		encode([]byte{1, 2, 3, 1}),
+		encode([]byte{1, 0, 0}),
+	} {
+		_, _, err := btrie.DecodeResumeToken(token)
+		assert.ErrorIs(t, err, btrie.ErrInvalidResumeToken, "%q", token)
+	}
+	_, _, err := btrie.DecodeResumeToken(encode([]byte{1, 6, 1, 5, 1, 4}))
+	assert.ErrorIs(t, err, btrie.ErrInvertedBounds)
+}
@@ -0,0 +1,327 @@
+package btrie
+
+import (
+	"bytes"
+	"fmt"
+	"iter"
+	"strings"
+	"unicode/utf8"
+)
+
+// RuneTrie is a BTrie for UTF-8 encoded keys, which branches on runes instead of bytes.
+// A key with multi-byte runes has one node per rune, instead of a chain of nodes per rune as in a byte trie.
+// Runes are decoded from a key as it is traversed, and pointers to children are stored densely in slices.
+// Because UTF-8 preserves the order of code points, Range yields keys in code point order,
+// which is also the order required by [BTrie].
+//
+// Put will panic if the key is not valid UTF-8.
+// Get and Delete treat such a key as absent, and Range never yields one.
+// Bounds passed to Range need not be valid UTF-8.
+// RuneTrie implements [BTrie], and is not safe for concurrent use.
+type RuneTrie[V any] struct {
+	root *runeTrieNode[V]
+}
+
+type runeTrieNode[V any] struct {
+	children   []*runeTrieNode[V] // sorted by keyRune
+	value      V                  // valid only if isTerminal is true
+	keyRune    rune
+	isTerminal bool
+}
+
+// NewRuneTrie returns a new, empty RuneTrie.
+func NewRuneTrie[V any]() *RuneTrie[V] {
+	return &RuneTrie[V]{&runeTrieNode[V]{}}
+}
+
+// Returns the node for key, or nil if there is none or key is not valid UTF-8.
+func (t *RuneTrie[V]) find(key []byte) *runeTrieNode[V] {
+	n := t.root
+	for len(key) > 0 {
+		r, size := utf8.DecodeRune(key)
+		if r == utf8.RuneError && size <= 1 {
+			return nil
+		}
+		index, found := n.search(r)
+		if !found {
+			return nil
+		}
+		n = n.children[index]
+		key = key[size:]
+	}
+	return n
+}
+
+func (t *RuneTrie[V]) Get(key []byte) (V, bool) {
+	if key == nil {
+		panic("key must be non-nil")
+	}
+	var zero V
+	n := t.find(key)
+	if n == nil || !n.isTerminal {
+		return zero, false
+	}
+	return n.value, true
+}
+
+func (t *RuneTrie[V]) Put(key []byte, value V) (V, bool) {
+	if key == nil {
+		panic("key must be non-nil")
+	}
+	if !utf8.Valid(key) {
+		panic(fmt.Sprintf("key %s must be valid UTF-8", keyName(key)))
+	}
+	var zero V
+	n := t.root
+	for _, r := range string(key) {
+		index, found := n.search(r)
+		if !found {
+			n.children = append(n.children, nil)
+			copy(n.children[index+1:], n.children[index:])
+			n.children[index] = &runeTrieNode[V]{keyRune: r}
+		}
+		n = n.children[index]
+	}
+	// n = found or created key
+	prev, ok := n.value, n.isTerminal
+	n.value = value
+	n.isTerminal = true
+	if ok {
+		return prev, true
+	}
+	return zero, false
+}
+
+func (t *RuneTrie[V]) Delete(key []byte) (V, bool) {
+	if key == nil {
+		panic("key must be non-nil")
+	}
+	var zero V
+	if !utf8.Valid(key) {
+		return zero, false
+	}
+	path := make([]*runeTrieNode[V], 0, utf8.RuneCount(key)+1)
+	n := t.root
+	for _, r := range string(key) {
+		path = append(path, n)
+		index, found := n.search(r)
+		if !found {
+			return zero, false
+		}
+		n = n.children[index]
+	}
+	// n = found key
+	if !n.isTerminal {
+		return zero, false
+	}
+	prev := n.value
+	n.value = zero
+	n.isTerminal = false
+	// Remove any nodes which no longer have values in their subtrees.
+	for i := len(path) - 1; i >= 0 && !n.isTerminal && len(n.children) == 0; i-- {
+		parent := path[i]
+		index, _ := parent.search(n.keyRune)
+		children := parent.children
+		copy(children[index:], children[index+1:])
+		children[len(children)-1] = nil
+		parent.children = children[:len(children)-1]
+		n = parent
+	}
+	return prev, true
+}
+
+// A node being traversed by Range, and the remaining children of that node to traverse.
+// This serves the same purpose as arrayTrieFrame.
+type runeTrieFrame[V any] struct {
+	node  *runeTrieNode[V]
+	depth int // length of the key of node
+	next  int // index of the next child to consider
+	stop  int // index of the last child to consider, inclusive
+}
+
+func (t *RuneTrie[V]) Range(bounds *Bounds) iter.Seq2[[]byte, V] {
+	bounds = bounds.Clone()
+	if bounds.IsReverse {
+		return t.rangeReverse(bounds)
+	}
+	return t.rangeForward(bounds)
+}
+
+// Traverses in pre-order.
+func (t *RuneTrie[V]) rangeForward(bounds *Bounds) iter.Seq2[[]byte, V] {
+	return func(yield func([]byte, V) bool) {
+		key := []byte{}
+		var stack []runeTrieFrame[V]
+		node := t.root
+		for {
+			// invariant: key = path from root to node
+			cmp := bounds.Compare(key)
+			if cmp > 0 {
+				return
+			}
+			if cmp == 0 && node.isTerminal && !yield(bytes.Clone(key), node.value) {
+				return
+			}
+			if start, stop := node.childRange(bounds, key); start <= stop {
+				stack = append(stack, runeTrieFrame[V]{node, len(key), start, stop})
+			}
+			node = nil
+			for node == nil {
+				if len(stack) == 0 {
+					return
+				}
+				top := &stack[len(stack)-1]
+				if top.next <= top.stop {
+					node = top.node.children[top.next]
+					key = utf8.AppendRune(key[:top.depth], node.keyRune)
+					top.next++
+				} else {
+					stack = stack[:len(stack)-1]
+				}
+			}
+		}
+	}
+}
+
+// Traverses in post-order, visiting children in reverse.
+func (t *RuneTrie[V]) rangeReverse(bounds *Bounds) iter.Seq2[[]byte, V] {
+	return func(yield func([]byte, V) bool) {
+		key := []byte{}
+		start, stop := t.root.childRange(bounds, key)
+		stack := []runeTrieFrame[V]{{t.root, 0, stop, start}}
+		for {
+			// invariant: key = path from root to top.node
+			top := &stack[len(stack)-1]
+			if top.next >= top.stop {
+				child := top.node.children[top.next]
+				key = utf8.AppendRune(key, child.keyRune)
+				top.next--
+				start, stop := child.childRange(bounds, key)
+				stack = append(stack, runeTrieFrame[V]{child, len(key), stop, start})
+				continue
+			}
+			node := top.node
+			cmp := bounds.Compare(key)
+			if cmp > 0 {
+				return
+			}
+			if cmp == 0 && node.isTerminal && !yield(bytes.Clone(key), node.value) {
+				return
+			}
+			stack = stack[:len(stack)-1]
+			if len(stack) == 0 {
+				return
+			}
+			key = key[:stack[len(stack)-1].depth]
+		}
+	}
+}
+
+// Returns the indexes of the first and last children of n, inclusive, that a traversal should recurse into.
+// The path from the root to n is key. If no children should be recursed into, start > stop.
+// This serves the same purpose as [Bounds.childBounds], but for runes.
+//
+//nolint:nonamedreturns
+func (n *runeTrieNode[V]) childRange(bounds *Bounds, key []byte) (start, stop int) {
+	low, high := bounds.Begin, bounds.End
+	if bounds.IsReverse {
+		low, high = high, low
+	}
+	start, stop = 0, len(n.children)-1
+	var buf [utf8.UTFMax]byte
+	// A child is above low if its key is either greater than or a prefix of low.
+	if low != nil && bytes.HasPrefix(low, key) {
+		rest := low[len(key):]
+		start = n.searchFunc(func(r rune) bool {
+			encoded := buf[:utf8.EncodeRune(buf[:], r)]
+			return bytes.Compare(encoded, rest[:min(len(rest), len(encoded))]) >= 0
+		})
+	}
+	// A child is below high if its key is less than high, or also equal to high if it is inclusive.
+	if high != nil && bytes.HasPrefix(high, key) {
+		rest := high[len(key):]
+		limit := 0
+		if bounds.IsReverse {
+			limit = 1
+		}
+		stop = n.searchFunc(func(r rune) bool {
+			encoded := buf[:utf8.EncodeRune(buf[:], r)]
+			return bytes.Compare(encoded, rest) >= limit
+		}) - 1
+	}
+	return start, stop
+}
+
+func (t *RuneTrie[V]) String() string {
+	var s strings.Builder
+	t.printTo(&s, -1)
+	return s.String()
+}
+
+func (t *RuneTrie[V]) Format(f fmt.State, verb rune) {
+	formatTrie(f, verb, t)
+}
+
+func (t *RuneTrie[V]) printTo(s *strings.Builder, levels int) {
+	t.root.printNode(s, "", levels)
+}
+
+func (t *RuneTrie[V]) stats() (int, int) {
+	return t.root.stats()
+}
+
+//nolint:revive
+func (n *runeTrieNode[V]) printNode(s *strings.Builder, indent string, levels int) {
+	if indent == "" {
+		s.WriteString("[]")
+	} else {
+		fmt.Fprintf(s, "%s%q", indent, n.keyRune)
+	}
+	if n.isTerminal {
+		fmt.Fprintf(s, ": %v\n", n.value)
+	} else {
+		s.WriteString("\n")
+	}
+	if len(n.children) > 0 && levels == 0 {
+		s.WriteString(indent + "  ...\n")
+		return
+	}
+	for _, child := range n.children {
+		child.printNode(s, indent+"  ", levels-1)
+	}
+}
+
+func (n *runeTrieNode[V]) stats() (int, int) {
+	size, depth := 0, 0
+	if n.isTerminal {
+		size = 1
+	}
+	for _, child := range n.children {
+		childSize, childDepth := child.stats()
+		size += childSize
+		depth = max(depth, childDepth+1)
+	}
+	return size, depth
+}
+
+func (n *runeTrieNode[V]) search(r rune) (int, bool) {
+	i := n.searchFunc(func(childRune rune) bool { return childRune >= r })
+	return i, i < len(n.children) && n.children[i].keyRune == r
+}
+
+// Returns the index of the first child whose rune satisfies pred, or len(n.children) if there is none.
+// pred must be false for some prefix of the children and true for the rest.
+func (n *runeTrieNode[V]) searchFunc(pred func(rune) bool) int {
+	// Copied and tweaked from sort.Search.
+	i, j := 0, len(n.children)
+	for i < j {
+		//nolint:gosec
+		h := int(uint(i+j) >> 1) // avoid overflow when computing h
+		if pred(n.children[h].keyRune) {
+			j = h
+		} else {
+			i = h + 1
+		}
+	}
+	return i
+}
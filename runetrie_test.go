@@ -0,0 +1,142 @@
+package btrie_test
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/phiryll/btrie"
+	"github.com/phiryll/btrie/btrietest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRuneTrie(t *testing.T) {
+	t.Parallel()
+	random := rand.New(rand.NewSource(4417))
+	// A small alphabet of 1 to 4 byte runes, so that keys share prefixes and runes share leading bytes.
+	alphabet := []rune{'a', 'b', 'é', 'ê', 'ア', 'イ', '😀', '😁'}
+	randomKey := func() []byte {
+		key := []byte{}
+		for range random.Intn(4) {
+			key = utf8.AppendRune(key, alphabet[random.Intn(len(alphabet))])
+		}
+		return key
+	}
+	// Bounds may split a rune, or not be valid UTF-8 at all.
+	randomBound := func() []byte {
+		key := randomKey()
+		switch random.Intn(4) {
+		case 0:
+			return key[:random.Intn(len(key)+1)]
+		case 1:
+			return append(key, byte(random.Intn(256)))
+		default:
+			return key
+		}
+	}
+	trie := btrie.NewRuneTrie[byte]()
+	ref := newReference()
+	for i := range 2000 {
+		key := randomKey()
+		if random.Intn(3) == 0 {
+			prev, ok := trie.Delete(key)
+			refPrev, refOk := ref.Delete(key)
+			assert.Equal(t, refOk, ok)
+			assert.Equal(t, refPrev, prev)
+		} else {
+			prev, ok := trie.Put(key, byte(i))
+			refPrev, refOk := ref.Put(key, byte(i))
+			assert.Equal(t, refOk, ok)
+			assert.Equal(t, refPrev, prev)
+		}
+		key = randomKey()
+		value, ok := trie.Get(key)
+		refValue, refOk := ref.Get(key)
+		assert.Equal(t, refOk, ok)
+		assert.Equal(t, refValue, value)
+	}
+	assert.Equal(t, collect(ref.Range(forwardAll)), collect(trie.Range(forwardAll)))
+	assert.Equal(t, collect(ref.Range(reverseAll)), collect(trie.Range(reverseAll)))
+	for range 500 {
+		begin, end := randomBound(), randomBound()
+		for _, bounds := range []*btrie.Bounds{
+			btrie.From(begin).To(nil),
+			btrie.From(begin).DownTo(nil),
+		} {
+			assert.Equal(t, collect(ref.Range(bounds)), collect(trie.Range(bounds)), "%s", bounds)
+		}
+		if !bytes.Equal(begin, end) {
+			lo, hi := begin, end
+			if bytes.Compare(lo, hi) > 0 {
+				lo, hi = hi, lo
+			}
+			for _, bounds := range []*btrie.Bounds{btrie.From(lo).To(hi), btrie.From(hi).DownTo(lo)} {
+				assert.Equal(t, collect(ref.Range(bounds)), collect(trie.Range(bounds)), "%s", bounds)
+			}
+		}
+	}
+	// need an early yield for test coverage
+	for range trie.Range(forwardAll) {
+		break
+	}
+	for range trie.Range(reverseAll) {
+		break
+	}
+
+	// Deleting everything must prune every node.
+	for k := range ref.Range(forwardAll) {
+		trie.Delete(k)
+	}
+	assert.Equal(t, "[]\n", trie.String())
+	assert.Equal(t, "{size: 0, depth: 0}", fmt.Sprint(trie))
+}
+
+func TestRuneTrieInvalidUTF8(t *testing.T) {
+	t.Parallel()
+	trie := btrie.NewRuneTrie[byte]()
+	assert.Panics(t, func() { trie.Put(nil, 0) })
+	assert.Panics(t, func() { trie.Get(nil) })
+	assert.Panics(t, func() { trie.Delete(nil) })
+	trie.Put([]byte("é"), 1)
+	for _, key := range [][]byte{{0xC3}, {0xFF}, []byte("é\xFF"), {0xED, 0xA0, 0x80}} {
+		assert.Panics(t, func() { trie.Put(key, 0) })
+		_, ok := trie.Get(key)
+		assert.False(t, ok)
+		_, ok = trie.Delete(key)
+		assert.False(t, ok)
+	}
+	assert.Equal(t, []entry{{[]byte("é"), 1}}, collect(trie.Range(forwardAll)))
+}
+
+// RuneTrie has its own key constraints, so it is not in allDefs.
+func TestRuneTrieKeys(t *testing.T) {
+	t.Parallel()
+	trie := btrie.NewRuneTrie[byte]()
+	testKey(t, []byte{}, trie)
+	trie.Put([]byte("añ"), 94)
+	testKey(t, []byte{}, trie)
+	btrietest.CheckKeysNotRetained(t, btrie.NewRuneTrie[byte](), 1)
+}
+
+func TestRuneTrieString(t *testing.T) {
+	t.Parallel()
+	trie := btrie.NewRuneTrie[byte]()
+	for i, key := range []string{"", "añ", "año", "ア"} {
+		trie.Put([]byte(key), byte(i))
+	}
+	// One node per rune, regardless of its encoded length.
+	assert.Equal(t, `[]: 0
+  'a'
+    'ñ': 1
+      'o': 2
+  'ア': 3
+`, trie.String())
+	assert.Equal(t, "{size: 4, depth: 3}", fmt.Sprint(trie))
+	assert.Equal(t, `[]: 0
+  'a'
+    ...
+  'ア': 3
+`, fmt.Sprintf("%+.1v", trie))
+}
@@ -0,0 +1,166 @@
+package btrie
+
+import (
+	"errors"
+	"fmt"
+	"iter"
+)
+
+// SafeBTrie has the same semantics as [BTrie], except that its methods return errors instead of panicking.
+// This lets code which must never panic, such as a server handling untrusted keys, choose that discipline
+// at compile time by depending on SafeBTrie rather than BTrie.
+// Use [Safe] and [Unsafe] to convert between the two.
+type SafeBTrie[V any] interface {
+	// Get returns the value for key and whether or not it exists.
+	// Get returns [ErrNilKey] if key is nil.
+	Get(key []byte) (value V, ok bool, err error)
+
+	// Put sets the value for key, returning the previous value and whether or not the previous value existed.
+	// Put returns [ErrNilKey] if key is nil, or an error if this SafeBTrie does not support mutation.
+	Put(key []byte, value V) (previous V, ok bool, err error)
+
+	// Delete removes the value for key, returning the previous value and whether or not the previous value existed.
+	// Delete returns [ErrNilKey] if key is nil, or an error if this SafeBTrie does not support mutation.
+	Delete(key []byte) (previous V, ok bool, err error)
+
+	// Range returns a sequence of key/value pairs over the given bounds, in the same order as [BTrie.Range].
+	// Range returns [ErrNilBounds] if bounds is nil.
+	// Only errors detected before the iteration begins can be returned.
+	Range(bounds *Bounds) (iter.Seq2[[]byte, V], error)
+}
+
+var (
+	// ErrNilKey is returned by [SafeBTrie] methods given a nil key.
+	ErrNilKey = errors.New("key must be non-nil")
+
+	// ErrNilBounds is returned by [SafeBTrie.Range] given nil bounds.
+	ErrNilBounds = errors.New("bounds must be non-nil")
+)
+
+// PanicError is the type of error returned by a [SafeBTrie] created by [Safe] when the wrapped BTrie panics.
+type PanicError struct {
+	// Value is the value passed to panic.
+	Value any
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("btrie: panic: %v", e.Value)
+}
+
+// Unwrap returns Value if it is an error, and nil otherwise.
+func (e *PanicError) Unwrap() error {
+	if err, ok := e.Value.(error); ok {
+		return err
+	}
+	return nil
+}
+
+// Safe returns a SafeBTrie wrapping trie, which returns an error instead of panicking.
+// Nil keys and bounds are rejected before trie is called,
+// and any other panic by trie is recovered and returned as a [*PanicError].
+// A panic during a Range iteration, after Range has returned, is not recovered.
+// If trie was returned by [Unsafe], the SafeBTrie it wraps is returned.
+// The returned SafeBTrie is as safe for concurrent use as trie.
+func Safe[V any](trie BTrie[V]) SafeBTrie[V] {
+	if t, ok := trie.(*unsafeTrie[V]); ok {
+		return t.trie
+	}
+	return &safeTrie[V]{trie}
+}
+
+// Unsafe returns a BTrie wrapping trie, which panics with any error returned by trie.
+// If trie was returned by [Safe], the BTrie it wraps is returned.
+// The returned BTrie is as safe for concurrent use as trie.
+func Unsafe[V any](trie SafeBTrie[V]) BTrie[V] {
+	if t, ok := trie.(*safeTrie[V]); ok {
+		return t.trie
+	}
+	return &unsafeTrie[V]{trie}
+}
+
+type safeTrie[V any] struct {
+	trie BTrie[V]
+}
+
+// Sets *err to a *PanicError if the calling function is panicking.
+// This must be called directly by a deferred call.
+func recoverPanic(err *error) {
+	if r := recover(); r != nil {
+		*err = &PanicError{r}
+	}
+}
+
+//nolint:nonamedreturns
+func (t *safeTrie[V]) Get(key []byte) (value V, ok bool, err error) {
+	if key == nil {
+		return value, false, ErrNilKey
+	}
+	defer recoverPanic(&err)
+	value, ok = t.trie.Get(key)
+	return value, ok, nil
+}
+
+//nolint:nonamedreturns
+func (t *safeTrie[V]) Put(key []byte, value V) (previous V, ok bool, err error) {
+	if key == nil {
+		return previous, false, ErrNilKey
+	}
+	defer recoverPanic(&err)
+	previous, ok = t.trie.Put(key, value)
+	return previous, ok, nil
+}
+
+//nolint:nonamedreturns
+func (t *safeTrie[V]) Delete(key []byte) (previous V, ok bool, err error) {
+	if key == nil {
+		return previous, false, ErrNilKey
+	}
+	defer recoverPanic(&err)
+	previous, ok = t.trie.Delete(key)
+	return previous, ok, nil
+}
+
+//nolint:nonamedreturns
+func (t *safeTrie[V]) Range(bounds *Bounds) (seq iter.Seq2[[]byte, V], err error) {
+	if bounds == nil {
+		return nil, ErrNilBounds
+	}
+	defer recoverPanic(&err)
+	return t.trie.Range(bounds), nil
+}
+
+type unsafeTrie[V any] struct {
+	trie SafeBTrie[V]
+}
+
+func (t *unsafeTrie[V]) Get(key []byte) (V, bool) {
+	value, ok, err := t.trie.Get(key)
+	if err != nil {
+		panic(err)
+	}
+	return value, ok
+}
+
+func (t *unsafeTrie[V]) Put(key []byte, value V) (V, bool) {
+	prev, ok, err := t.trie.Put(key, value)
+	if err != nil {
+		panic(err)
+	}
+	return prev, ok
+}
+
+func (t *unsafeTrie[V]) Delete(key []byte) (V, bool) {
+	prev, ok, err := t.trie.Delete(key)
+	if err != nil {
+		panic(err)
+	}
+	return prev, ok
+}
+
+func (t *unsafeTrie[V]) Range(bounds *Bounds) iter.Seq2[[]byte, V] {
+	seq, err := t.trie.Range(bounds)
+	if err != nil {
+		panic(err)
+	}
+	return seq
+}
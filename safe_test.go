@@ -0,0 +1,123 @@
+package btrie_test
+
+import (
+	"errors"
+	"iter"
+	"testing"
+
+	"github.com/phiryll/btrie"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSafe(t *testing.T) {
+	t.Parallel()
+	trie := btrie.NewArrayTrie[byte]()
+	safe := btrie.Safe(trie)
+	_, ok, err := safe.Put([]byte{1}, 1)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	value, ok, err := safe.Get([]byte{1})
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, byte(1), value)
+	seq, err := safe.Range(forwardAll)
+	require.NoError(t, err)
+	assert.Equal(t, []entry{{[]byte{1}, 1}}, collect(seq))
+	prev, ok, err := safe.Delete([]byte{1})
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, byte(1), prev)
+
+	_, _, err = safe.Get(nil)
+	require.ErrorIs(t, err, btrie.ErrNilKey)
+	_, _, err = safe.Put(nil, 0)
+	require.ErrorIs(t, err, btrie.ErrNilKey)
+	_, _, err = safe.Delete(nil)
+	require.ErrorIs(t, err, btrie.ErrNilKey)
+	_, err = safe.Range(nil)
+	require.ErrorIs(t, err, btrie.ErrNilBounds)
+
+	// Converting back and forth returns the original tries.
+	assert.Same(t, trie, btrie.Unsafe(safe))
+	failing := failingSafeTrie{errors.New("cause")}
+	assert.Equal(t, failing, btrie.Safe(btrie.Unsafe[byte](failing)))
+}
+
+func TestSafeRecoversPanics(t *testing.T) {
+	t.Parallel()
+	safe := btrie.Safe[byte](btrie.NewFixedKeyTrie[byte](2))
+	_, _, err := safe.Put([]byte{1}, 0)
+	var panicErr *btrie.PanicError
+	require.ErrorAs(t, err, &panicErr)
+	assert.Equal(t, "key length 1 must be 2", panicErr.Value)
+	assert.Equal(t, "btrie: panic: key length 1 must be 2", err.Error())
+	assert.NoError(t, errors.Unwrap(err))
+
+	cause := errors.New("cause")
+	safe = btrie.Safe[byte](panickingTrie{cause})
+	_, _, err = safe.Get([]byte{})
+	require.ErrorIs(t, err, cause)
+	_, _, err = safe.Put([]byte{}, 0)
+	require.ErrorIs(t, err, cause)
+	_, _, err = safe.Delete([]byte{})
+	require.ErrorIs(t, err, cause)
+	_, err = safe.Range(forwardAll)
+	require.ErrorIs(t, err, cause)
+}
+
+func TestUnsafe(t *testing.T) {
+	t.Parallel()
+	cause := errors.New("cause")
+	trie := btrie.Unsafe[byte](failingSafeTrie{cause})
+	assert.PanicsWithError(t, "cause", func() { trie.Get([]byte{}) })
+	assert.PanicsWithError(t, "cause", func() { trie.Put([]byte{}, 0) })
+	assert.PanicsWithError(t, "cause", func() { trie.Delete([]byte{}) })
+	assert.PanicsWithError(t, "cause", func() { trie.Range(forwardAll) })
+
+	// A panic from Unsafe is recovered by Safe, wrapping the original error.
+	_, _, err := btrie.Safe[byte](btrie.NewSynchronized(trie)).Get([]byte{})
+	require.ErrorIs(t, err, cause)
+}
+
+// A SafeBTrie which returns err from every method.
+type failingSafeTrie struct {
+	err error
+}
+
+func (f failingSafeTrie) Get([]byte) (byte, bool, error) {
+	return 0, false, f.err
+}
+
+func (f failingSafeTrie) Put([]byte, byte) (byte, bool, error) {
+	return 0, false, f.err
+}
+
+func (f failingSafeTrie) Delete([]byte) (byte, bool, error) {
+	return 0, false, f.err
+}
+
+func (f failingSafeTrie) Range(*btrie.Bounds) (iter.Seq2[[]byte, byte], error) {
+	return nil, f.err
+}
+
+// A BTrie which panics with err in every method.
+type panickingTrie struct {
+	err error
+}
+
+func (p panickingTrie) Get([]byte) (byte, bool) {
+	panic(p.err)
+}
+
+func (p panickingTrie) Put([]byte, byte) (byte, bool) {
+	panic(p.err)
+}
+
+func (p panickingTrie) Delete([]byte) (byte, bool) {
+	panic(p.err)
+}
+
+func (p panickingTrie) Range(*btrie.Bounds) iter.Seq2[[]byte, byte] {
+	panic(p.err)
+}
@@ -0,0 +1,119 @@
+package btrie
+
+import (
+	"bytes"
+	"iter"
+)
+
+// ByteSet is an ordered set of byte slice keys.
+// It is backed by a BTrie with empty struct values, which take no space.
+// A ByteSet is not safe for concurrent use.
+type ByteSet struct {
+	trie BTrie[struct{}]
+	size int
+}
+
+// NewByteSet returns a new, empty ByteSet.
+func NewByteSet() *ByteSet {
+	return &ByteSet{NewArrayTrie[struct{}](), 0}
+}
+
+// Len returns the number of keys in this ByteSet.
+func (s *ByteSet) Len() int {
+	return s.size
+}
+
+// Add adds key to this ByteSet, returning true if it was not already present.
+func (s *ByteSet) Add(key []byte) bool {
+	_, ok := s.trie.Put(key, struct{}{})
+	if !ok {
+		s.size++
+	}
+	return !ok
+}
+
+// Contains returns whether key is in this ByteSet.
+func (s *ByteSet) Contains(key []byte) bool {
+	_, ok := s.trie.Get(key)
+	return ok
+}
+
+// Remove removes key from this ByteSet, returning true if it was present.
+func (s *ByteSet) Remove(key []byte) bool {
+	_, ok := s.trie.Delete(key)
+	if ok {
+		s.size--
+	}
+	return ok
+}
+
+// RangeKeys returns a sequence of the keys in this ByteSet over the given bounds.
+// This ByteSet must not be mutated during a RangeKeys iteration.
+func (s *ByteSet) RangeKeys(bounds *Bounds) iter.Seq[[]byte] {
+	itr := s.trie.Range(bounds)
+	return func(yield func([]byte) bool) {
+		for k := range itr {
+			if !yield(k) {
+				return
+			}
+		}
+	}
+}
+
+// Union returns a new ByteSet with the keys in either this ByteSet or other.
+func (s *ByteSet) Union(other *ByteSet) *ByteSet {
+	return s.merge(other, true, true, true)
+}
+
+// Intersection returns a new ByteSet with the keys in both this ByteSet and other.
+func (s *ByteSet) Intersection(other *ByteSet) *ByteSet {
+	return s.merge(other, false, true, false)
+}
+
+// Difference returns a new ByteSet with the keys in this ByteSet but not in other.
+func (s *ByteSet) Difference(other *ByteSet) *ByteSet {
+	return s.merge(other, true, false, false)
+}
+
+// Returns a new ByteSet with the keys in only s, both s and other, or only other, as requested.
+//
+//nolint:revive
+func (s *ByteSet) merge(other *ByteSet, onlyThis, both, onlyOther bool) *ByteSet {
+	result := NewByteSet()
+	next, stop := iter.Pull2(All(s.trie))
+	defer stop()
+	nextOther, stopOther := iter.Pull2(All(other.trie))
+	defer stopOther()
+	key, _, ok := next()
+	otherKey, _, otherOk := nextOther()
+	for ok || otherOk {
+		cmp := 0
+		switch {
+		case !ok:
+			cmp = +1
+		case !otherOk:
+			cmp = -1
+		default:
+			cmp = bytes.Compare(key, otherKey)
+		}
+		switch {
+		case cmp < 0:
+			if onlyThis {
+				result.Add(key)
+			}
+			key, _, ok = next()
+		case cmp > 0:
+			if onlyOther {
+				result.Add(otherKey)
+			}
+			otherKey, _, otherOk = nextOther()
+		default:
+			if both {
+				result.Add(key)
+			}
+			key, _, ok = next()
+			otherKey, _, otherOk = nextOther()
+		}
+	}
+	return result
+}
@@ -0,0 +1,71 @@
+package btrie_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/phiryll/btrie"
+	"github.com/stretchr/testify/assert"
+)
+
+func newByteSet(keys ...string) *btrie.ByteSet {
+	set := btrie.NewByteSet()
+	for _, key := range keys {
+		set.Add([]byte(key))
+	}
+	return set
+}
+
+func setKeys(set *btrie.ByteSet) [][]byte {
+	return slices.Collect(set.RangeKeys(forwardAll))
+}
+
+func TestByteSet(t *testing.T) {
+	t.Parallel()
+	set := btrie.NewByteSet()
+	assert.Equal(t, 0, set.Len())
+	assert.True(t, set.Add([]byte("b")))
+	assert.True(t, set.Add([]byte{}))
+	assert.True(t, set.Add([]byte("a")))
+	assert.False(t, set.Add([]byte("a")))
+	assert.Equal(t, 3, set.Len())
+	assert.True(t, set.Contains([]byte{}))
+	assert.True(t, set.Contains([]byte("a")))
+	assert.False(t, set.Contains([]byte("c")))
+	assert.Equal(t, keys("", "a", "b"), setKeys(set))
+	assert.Equal(t, keys("b", "a"), slices.Collect(set.RangeKeys(From([]byte("c")).DownTo([]byte{}))))
+
+	assert.True(t, set.Remove([]byte("a")))
+	assert.False(t, set.Remove([]byte("a")))
+	assert.Equal(t, 2, set.Len())
+	assert.False(t, set.Contains([]byte("a")))
+
+	// need an early yield for test coverage
+	for range set.RangeKeys(forwardAll) {
+		break
+	}
+}
+
+func TestByteSetAlgebra(t *testing.T) {
+	t.Parallel()
+	a := newByteSet("", "a", "ab", "b", "d")
+	b := newByteSet("a", "abc", "b", "c", "e")
+	empty := btrie.NewByteSet()
+
+	union := a.Union(b)
+	assert.Equal(t, keys("", "a", "ab", "abc", "b", "c", "d", "e"), setKeys(union))
+	assert.Equal(t, 8, union.Len())
+	assert.Equal(t, keys("a", "b"), setKeys(a.Intersection(b)))
+	assert.Equal(t, keys("", "ab", "d"), setKeys(a.Difference(b)))
+	assert.Equal(t, keys("abc", "c", "e"), setKeys(b.Difference(a)))
+
+	assert.Equal(t, setKeys(a), setKeys(a.Union(empty)))
+	assert.Equal(t, setKeys(a), setKeys(empty.Union(a)))
+	assert.Empty(t, setKeys(a.Intersection(empty)))
+	assert.Equal(t, setKeys(a), setKeys(a.Difference(empty)))
+	assert.Empty(t, setKeys(a.Difference(a)))
+
+	// The operands are unchanged.
+	assert.Equal(t, keys("", "a", "ab", "b", "d"), setKeys(a))
+	assert.Equal(t, keys("a", "abc", "b", "c", "e"), setKeys(b))
+}
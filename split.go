@@ -0,0 +1,150 @@
+package btrie
+
+import "bytes"
+
+// SplitPoints returns up to n-1 keys in increasing order which divide trie into n ranges
+// having approximately the same number of entries.
+// The ranges are From(nil).To(points[0]), From(points[0]).To(points[1]), ..., From(points[n-2]).To(nil).
+// If trie has fewer than n entries, fewer points are returned, so that no range is empty unless trie is.
+// With prefix counts, each point is found by descending from the root.
+// Otherwise, trie is ranged over twice, once to count the entries and once to find the points.
+// SplitPoints will panic if n is less than 1.
+func SplitPoints[V any](trie BTrie[V], n int) [][]byte {
+	if n < 1 {
+		panic("n must be positive")
+	}
+	if t, ok := trie.(rankSelector); ok {
+		total := t.len()
+		points := [][]byte{}
+		for _, rank := range splitRanks(total, n) {
+			points = append(points, t.keyAt(rank))
+		}
+		return points
+	}
+	total := 0
+	for range All(trie) {
+		total++
+	}
+	ranks := splitRanks(total, n)
+	points := make([][]byte, 0, len(ranks))
+	rank := 0
+	for key := range All(trie) {
+		if len(points) == len(ranks) {
+			break
+		}
+		if rank == ranks[len(points)] {
+			points = append(points, key)
+		}
+		rank++
+	}
+	return points
+}
+
+// Implemented by tries which can efficiently find the key having a given rank.
+type rankSelector interface {
+	len() int
+	keyAt(rank int) []byte
+	prefixRanks(prefix []byte) (int, int)
+	rankOf(key []byte) int
+}
+
+// Returns the distinct, increasing, and positive ranks of the keys beginning each range after the first.
+func splitRanks(total, n int) []int {
+	ranks := []int{}
+	for i := 1; i < n; i++ {
+		rank := i * total / n
+		if rank > 0 && (len(ranks) == 0 || rank > ranks[len(ranks)-1]) {
+			ranks = append(ranks, rank)
+		}
+	}
+	return ranks
+}
+
+func (n *arrayTrieNode[V]) len() int {
+	return n.count
+}
+
+// Returns the key having the given rank, which must be less than n.count.
+func (n *arrayTrieNode[V]) keyAt(rank int) []byte {
+	key := []byte{}
+	for {
+		if n.suffix != nil {
+			return append(key, n.suffix...)
+		}
+		if n.isTerminal {
+			if rank == 0 {
+				return key
+			}
+			rank--
+		}
+		for i, child := range n.children {
+			if child == nil {
+				continue
+			}
+			if rank < child.count {
+				key = append(key, byte(i))
+				n = child
+				break
+			}
+			rank -= child.count
+		}
+	}
+}
+
+// Returns the rank of the first key having prefix, and the number of keys having prefix.
+func (n *arrayTrieNode[V]) prefixRanks(prefix []byte) (int, int) {
+	rank := 0
+	i := 0
+	for ; i < len(prefix) && n.suffix == nil; i++ {
+		if n.children == nil {
+			return 0, 0
+		}
+		if n.isTerminal {
+			rank++
+		}
+		for _, child := range n.children[:prefix[i]] {
+			if child != nil {
+				rank += child.count
+			}
+		}
+		n = n.children[prefix[i]]
+		if n == nil {
+			return 0, 0
+		}
+	}
+	if n.suffix != nil && !bytes.HasPrefix(n.suffix, prefix[i:]) {
+		return 0, 0
+	}
+	return rank, n.count
+}
+
+// Returns the number of keys less than key.
+func (n *arrayTrieNode[V]) rankOf(key []byte) int {
+	rank := 0
+	for i := 0; ; i++ {
+		if n.suffix != nil {
+			if bytes.Compare(n.suffix, key[i:]) < 0 {
+				rank += n.count
+			}
+			return rank
+		}
+		if i == len(key) {
+			return rank
+		}
+		if n.isTerminal {
+			rank++
+		}
+		if n.children == nil {
+			return rank
+		}
+		for _, child := range n.children[:key[i]] {
+			if child != nil {
+				rank += child.count
+			}
+		}
+		n = n.children[key[i]]
+		if n == nil {
+			return rank
+		}
+	}
+}
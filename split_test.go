@@ -0,0 +1,41 @@
+package btrie_test
+
+import (
+	"testing"
+
+	"github.com/phiryll/btrie"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitPoints(t *testing.T) {
+	t.Parallel()
+	for _, test := range createTestTries(rangeTestConfigs) {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Panics(t, func() { btrie.SplitPoints[byte](test.trie, 0) })
+			total := len(test.config.entries)
+			overlay := btrie.NewOverlay[byte](test.trie)
+			for _, n := range []int{1, 2, 3, 7, 100, total, total + 1} {
+				if n < 1 {
+					continue
+				}
+				points := btrie.SplitPoints[byte](test.trie, n)
+				assert.Equal(t, points, btrie.SplitPoints[byte](overlay, n), "%d", n)
+				assert.Len(t, points, max(min(n, total)-1, 0), "%d", n)
+
+				// Every range must have floor or ceiling of total / n entries.
+				var begin []byte
+				for i := range len(points) + 1 {
+					var end []byte
+					if i < len(points) {
+						end = points[i]
+					}
+					count := len(collect(test.trie.Range(From(begin).To(end))))
+					assert.GreaterOrEqual(t, count, total/n, "%d %d", n, i)
+					assert.LessOrEqual(t, count, (total+n-1)/n, "%d %d", n, i)
+					begin = end
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package btrie
+
+import (
+	"bufio"
+	"database/sql"
+	"encoding/hex"
+	"io"
+	"strings"
+)
+
+// ExportSQL writes an SQL INSERT statement to w for every entry of trie, in increasing key order,
+// inserting into the "key" and "value" columns of table.
+// Keys are written as blob literals, X'0123', and values as the SQL expressions returned by valueSQL,
+// which must be valid and properly quoted; ExportSQL does not check them.
+// The table and column names are quoted as standard SQL identifiers, in double quotes, so they are used as given.
+// This is understood by SQLite and PostgreSQL, among others, but only by MySQL in its ANSI_QUOTES mode.
+// ExportSQL will panic if valueSQL is nil.
+func ExportSQL[V any](w io.Writer, table string, trie BTrie[V], valueSQL func(V) string) error {
+	if valueSQL == nil {
+		panic("valueSQL must be non-nil")
+	}
+	bw := bufio.NewWriter(w)
+	prefix := "INSERT INTO " + quoteSQLIdentifier(table) + ` ("key", "value") VALUES (X'`
+	for key, value := range All(trie) {
+		if _, err := bw.WriteString(prefix); err != nil {
+			return err
+		}
+		if _, err := bw.WriteString(hex.EncodeToString(key)); err != nil {
+			return err
+		}
+		if _, err := bw.WriteString("', " + valueSQL(value) + ");\n"); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+func quoteSQLIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// ImportSQL puts an entry into trie for every row of rows, which must have two columns, the key and the value.
+// The key is scanned into a []byte, and the value into a V, as by [sql.Rows.Scan];
+// so V must be a type the driver can convert to, or implement [sql.Scanner].
+// A NULL key is put as the empty key.
+// ImportSQL returns the number of entries put, and closes rows.
+// Entries scanned before an error is encountered will have been put into trie.
+func ImportSQL[V any](rows *sql.Rows, trie BTrie[V]) (int, error) {
+	defer rows.Close()
+	count := 0
+	for rows.Next() {
+		var key []byte
+		var value V
+		if err := rows.Scan(&key, &value); err != nil {
+			return count, err
+		}
+		if key == nil {
+			key = []byte{}
+		}
+		trie.Put(key, value)
+		count++
+	}
+	return count, rows.Err()
+}
@@ -0,0 +1,107 @@
+package btrie_test
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/phiryll/btrie"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportSQL(t *testing.T) {
+	t.Parallel()
+	trie := btrie.NewArrayTrie[byte]()
+	trie.Put([]byte{0xAB, 0x01}, 7)
+	trie.Put([]byte{}, 3)
+	var s strings.Builder
+	require.NoError(t, btrie.ExportSQL(&s, `my "table"`, trie, func(v byte) string {
+		return strconv.Itoa(int(v))
+	}))
+	assert.Equal(t, `INSERT INTO "my ""table""" ("key", "value") VALUES (X'', 3);
+INSERT INTO "my ""table""" ("key", "value") VALUES (X'ab01', 7);
+`, s.String())
+
+	// A double quote in the table name is doubled, and the name is otherwise unchanged.
+	s.Reset()
+	require.NoError(t, btrie.ExportSQL(&s, `"`, trie, func(byte) string { return "NULL" }))
+	assert.Equal(t, `INSERT INTO """" ("key", "value") VALUES (X'', NULL);
+INSERT INTO """" ("key", "value") VALUES (X'ab01', NULL);
+`, s.String())
+
+	require.ErrorIs(t, btrie.ExportSQL(&failingWriter{}, "t", trie, func(byte) string { return "0" }), errWrite)
+	assert.Panics(t, func() { _ = btrie.ExportSQL[byte](&s, "t", trie, nil) })
+}
+
+// sqlRows is the data returned by every query to a fakeSQLDriver connection, by data source name.
+var sqlRows = map[string][][]driver.Value{
+	"good": {{[]byte{1}, int64(5)}, {nil, int64(7)}, {[]byte("ab"), int64(255)}},
+	"bad":  {{[]byte{1}, int64(5)}, {[]byte{2}, int64(300)}, {[]byte{3}, int64(6)}},
+}
+
+func init() {
+	sql.Register("btrie-test", fakeSQLDriver{})
+}
+
+// A database/sql driver returning sqlRows[name] for every query.
+type (
+	fakeSQLDriver struct{}
+	fakeSQLConn   struct{ rows [][]driver.Value }
+	fakeSQLStmt   struct{ rows [][]driver.Value }
+	fakeSQLRows   struct{ rows [][]driver.Value }
+)
+
+var errNotSupported = errors.New("not supported")
+
+func (fakeSQLDriver) Open(name string) (driver.Conn, error) {
+	return fakeSQLConn{sqlRows[name]}, nil
+}
+
+func (c fakeSQLConn) Prepare(string) (driver.Stmt, error) { return fakeSQLStmt(c), nil }
+func (fakeSQLConn) Close() error                          { return nil }
+func (fakeSQLConn) Begin() (driver.Tx, error)             { return nil, errNotSupported }
+
+func (fakeSQLStmt) Close() error                                { return nil }
+func (fakeSQLStmt) NumInput() int                               { return 0 }
+func (fakeSQLStmt) Exec([]driver.Value) (driver.Result, error)  { return nil, errNotSupported }
+func (s fakeSQLStmt) Query([]driver.Value) (driver.Rows, error) { return &fakeSQLRows{s.rows}, nil }
+func (*fakeSQLRows) Columns() []string                          { return []string{"key", "value"} }
+func (*fakeSQLRows) Close() error                               { return nil }
+
+func (r *fakeSQLRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+func TestImportSQL(t *testing.T) {
+	t.Parallel()
+	query := func(name string) *sql.Rows {
+		db, err := sql.Open("btrie-test", name)
+		require.NoError(t, err)
+		t.Cleanup(func() { db.Close() })
+		rows, err := db.Query("SELECT key, value FROM t")
+		require.NoError(t, err)
+		return rows
+	}
+
+	trie := btrie.NewArrayTrie[byte]()
+	count, err := btrie.ImportSQL(query("good"), trie)
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+	assertSame(t, map[string]byte{"\x01": 5, "": 7, "ab": 255}, trie)
+
+	trie = btrie.NewArrayTrie[byte]()
+	count, err = btrie.ImportSQL(query("bad"), trie)
+	require.Error(t, err, "300 does not fit in a byte")
+	assert.Equal(t, 1, count)
+	assertSame(t, map[string]byte{"\x01": 5}, trie)
+}
@@ -0,0 +1,291 @@
+package btrie
+
+import (
+	"bytes"
+	"container/heap"
+	"iter"
+	"math"
+	"slices"
+	"unsafe"
+)
+
+// PrefixStat is the number of keys in a BTrie having a given prefix, including the prefix itself if it is a key.
+type PrefixStat struct {
+	Prefix []byte
+	Count  int
+}
+
+// HeavyPrefixes returns a sequence of every prefix of length at most maxDepth
+// which at least minCount keys in trie have, in increasing prefix order.
+// The empty prefix, if reported, has the number of keys in trie.
+// If trie keeps prefix counts, see [NewArrayTrie], only the reported prefixes and their children are visited.
+// Otherwise, trie is ranged over in its entirety before the first prefix is yielded.
+// HeavyPrefixes will panic if minCount is less than 1 or maxDepth is negative.
+func HeavyPrefixes[V any](trie BTrie[V], minCount, maxDepth int) iter.Seq[PrefixStat] {
+	if minCount < 1 {
+		panic("minCount must be positive")
+	}
+	if maxDepth < 0 {
+		panic("maxDepth must be non-negative")
+	}
+	if t, ok := trie.(prefixCounter); ok {
+		return t.heavyPrefixes(minCount, maxDepth)
+	}
+	return func(yield func(PrefixStat) bool) {
+		for _, stat := range countPrefixes(trie, minCount, maxDepth) {
+			if !yield(stat) {
+				return
+			}
+		}
+	}
+}
+
+// BuildDictionary returns up to maxEntries non-empty prefixes shared by at least two keys in trie,
+// for use as a compression dictionary for the keys.
+// The prefixes are those accounting for the most key bytes, which is a prefix's length times its count
+// as reported by [HeavyPrefixes], in decreasing order of that product.
+// Prefixes with equal products are in increasing order.
+// Because a prefix's count includes those of its extensions, a dictionary often contains prefixes of its other entries.
+// BuildDictionary will panic if maxEntries is negative.
+func BuildDictionary[V any](trie BTrie[V], maxEntries int) [][]byte {
+	if maxEntries < 0 {
+		panic("maxEntries must be non-negative")
+	}
+	if maxEntries == 0 {
+		return [][]byte{}
+	}
+	// A min-heap of the best prefixes so far, see SuggestRanked.
+	best := &suggestionHeap{}
+	for stat := range HeavyPrefixes(trie, 2, math.MaxInt) {
+		if len(stat.Prefix) == 0 {
+			continue
+		}
+		s := suggestion{stat.Prefix, float64(len(stat.Prefix) * stat.Count)}
+		if best.Len() < maxEntries {
+			heap.Push(best, s)
+		} else if best.less(best.items[0], s) {
+			best.items[0] = s
+			heap.Fix(best, 0)
+		}
+	}
+	result := make([][]byte, best.Len())
+	for i := len(result) - 1; i >= 0; i-- {
+		//nolint:forcetypeassert
+		result[i] = heap.Pop(best).(suggestion).key
+	}
+	return result
+}
+
+// Implemented by tries which maintain the number of keys having each prefix.
+type prefixCounter interface {
+	heavyPrefixes(minCount, maxDepth int) iter.Seq[PrefixStat]
+}
+
+// Returns the prefix stats for HeavyPrefixes by counting every key in trie, in increasing prefix order.
+func countPrefixes[V any](trie BTrie[V], minCount, maxDepth int) []PrefixStat {
+	result := []PrefixStat{}
+	// The prefixes of the previous key, up to maxDepth, with stack[i] having length i.
+	// Each key is only counted at the top of the stack, and added to the next lower entry when popped.
+	var prev []byte
+	var stack []int
+	pop := func() {
+		count := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if count >= minCount {
+			result = append(result, PrefixStat{bytes.Clone(prev[:len(stack)]), count})
+		}
+		if len(stack) > 0 {
+			stack[len(stack)-1] += count
+		}
+	}
+	for key := range All(trie) {
+		if stack == nil {
+			stack = []int{0}
+		}
+		common := 0
+		for common < len(prev) && common < len(key) && prev[common] == key[common] {
+			common++
+		}
+		for len(stack) > common+1 {
+			pop()
+		}
+		for len(stack) <= min(len(key), maxDepth) {
+			stack = append(stack, 0)
+		}
+		stack[len(stack)-1]++
+		prev = key
+	}
+	for len(stack) > 0 {
+		pop()
+	}
+	slices.SortFunc(result, func(a, b PrefixStat) int {
+		return bytes.Compare(a.Prefix, b.Prefix)
+	})
+	return result
+}
+
+// FanoutCounts returns the number of keys in trie beginning with each byte, indexed by that byte.
+// The empty key, if present, is not counted.
+// This is useful for routing keys to shards by their first byte, and balancing the shards.
+// With prefix counts, only the counts of the root's children are read.
+// Otherwise, trie is ranged over in its entirety.
+func FanoutCounts[V any](trie BTrie[V]) [256]int {
+	if t, ok := trie.(fanoutCounter); ok {
+		return t.fanoutCounts()
+	}
+	var counts [256]int
+	for key := range All(trie) {
+		if len(key) > 0 {
+			counts[key[0]]++
+		}
+	}
+	return counts
+}
+
+// Implemented by tries which maintain the number of keys beginning with each byte.
+type fanoutCounter interface {
+	fanoutCounts() [256]int
+}
+
+func (n *arrayTrieNode[V]) fanoutCounts() [256]int {
+	var counts [256]int
+	if n.children == nil {
+		return counts
+	}
+	for i, child := range n.children {
+		if child != nil {
+			counts[i] = child.count
+		}
+	}
+	return counts
+}
+
+// DepthProfile returns the number of entries in trie for each key length,
+// and the number of nodes at each depth of an uncompressed trie having the same keys.
+// Both slices are indexed by key length or depth, and have a length one more than the longest key.
+// The root is the only node at depth 0, even if trie is empty.
+// The profile is computed by ranging over trie in its entirety.
+func DepthProfile[V any](trie BTrie[V]) ([]int, []int) {
+	entries, nodes := []int{0}, []int{1}
+	var prev []byte
+	for key := range All(trie) {
+		for len(entries) <= len(key) {
+			entries = append(entries, 0)
+			nodes = append(nodes, 0)
+		}
+		entries[len(key)]++
+		for depth := CommonPrefixLen(prev, key) + 1; depth <= len(key); depth++ {
+			nodes[depth]++
+		}
+		prev = key
+	}
+	return entries, nodes
+}
+
+// SizeOf returns an estimate of the heap memory in bytes used by the entries in trie having the given prefix.
+// If sizer is non-nil, it returns the number of bytes a value refers to outside of itself,
+// such as the backing array of a slice, which is added to the estimate; otherwise values are assumed to refer to nothing.
+// For tries created by [NewArrayTrie], the estimate is the size of the nodes in the subtree for prefix.
+// For other tries, the structure is unknown, and the estimate is
+// the number of nodes an uncompressed trie would have below prefix, as one key byte each,
+// plus the size of V for every entry.
+// SizeOf will panic if prefix is nil.
+func SizeOf[V any](trie BTrie[V], prefix []byte, sizer func(V) int64) int64 {
+	if prefix == nil {
+		panic("prefix must be non-nil")
+	}
+	if t, ok := trie.(sizeEstimator[V]); ok {
+		return t.sizeOf(prefix, sizer)
+	}
+	var zero V
+	valueSize := int64(unsafe.Sizeof(zero))
+	end, _ := PrefixSuccessor(prefix)
+	size := int64(0)
+	prev := prefix
+	for key, value := range trie.Range(From(prefix).To(end)) {
+		size += int64(len(key)-CommonPrefixLen(prev, key)) + valueSize
+		if sizer != nil {
+			size += sizer(value)
+		}
+		prev = key
+	}
+	return size
+}
+
+// Implemented by tries which know the size of their own nodes.
+type sizeEstimator[V any] interface {
+	sizeOf(prefix []byte, sizer func(V) int64) int64
+}
+
+func (n *arrayTrieNode[V]) sizeOf(prefix []byte, sizer func(V) int64) int64 {
+	i := 0
+	for ; i < len(prefix) && n.suffix == nil; i++ {
+		if n.children == nil {
+			return 0
+		}
+		n = n.children[prefix[i]]
+		if n == nil {
+			return 0
+		}
+	}
+	if n.suffix != nil && !bytes.HasPrefix(n.suffix, prefix[i:]) {
+		return 0
+	}
+	return n.subtreeSize(sizer)
+}
+
+func (n *arrayTrieNode[V]) subtreeSize(sizer func(V) int64) int64 {
+	size := int64(unsafe.Sizeof(*n)) + int64(cap(n.suffix))
+	if n.isTerminal && sizer != nil {
+		size += sizer(n.value)
+	}
+	if n.children == nil {
+		return size
+	}
+	size += int64(unsafe.Sizeof(*n.children))
+	for _, child := range n.children {
+		if child != nil {
+			size += child.subtreeSize(sizer)
+		}
+	}
+	return size
+}
+
+func (n *arrayTrieNode[V]) heavyPrefixes(minCount, maxDepth int) iter.Seq[PrefixStat] {
+	return func(yield func(PrefixStat) bool) {
+		n.yieldHeavyPrefixes([]byte{}, minCount, maxDepth, yield)
+	}
+}
+
+// Returns true if done (some yield has returned false).
+func (n *arrayTrieNode[V]) yieldHeavyPrefixes(prefix []byte, minCount, maxDepth int, yield func(PrefixStat) bool) bool {
+	if n.count < minCount {
+		return false
+	}
+	if !yield(PrefixStat{bytes.Clone(prefix), n.count}) {
+		return true
+	}
+	if len(prefix) == maxDepth {
+		return false
+	}
+	if n.suffix != nil {
+		// Every prefix ending within the suffix has the same count.
+		for _, suffixByte := range n.suffix[:min(len(n.suffix), maxDepth-len(prefix))] {
+			prefix = append(prefix, suffixByte)
+			if !yield(PrefixStat{bytes.Clone(prefix), n.count}) {
+				return true
+			}
+		}
+		return false
+	}
+	if n.children == nil {
+		return false
+	}
+	for i, child := range n.children {
+		// prefix is only a reused buffer here, each yielded prefix is cloned
+		if child != nil && child.yieldHeavyPrefixes(append(prefix, byte(i)), minCount, maxDepth, yield) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,236 @@
+package btrie_test
+
+import (
+	"bytes"
+	"iter"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/phiryll/btrie"
+	"github.com/stretchr/testify/assert"
+)
+
+// Returns the expected result of btrie.HeavyPrefixes for a trie with the given entries.
+func expectedHeavyPrefixes(entries map[string]byte, minCount, maxDepth int) []btrie.PrefixStat {
+	counts := map[string]int{}
+	for k := range entries {
+		for i := range min(len(k), maxDepth) + 1 {
+			counts[k[:i]]++
+		}
+	}
+	result := []btrie.PrefixStat{}
+	for prefix, count := range counts {
+		if count >= minCount {
+			result = append(result, btrie.PrefixStat{Prefix: []byte(prefix), Count: count})
+		}
+	}
+	slices.SortFunc(result, func(a, b btrie.PrefixStat) int {
+		return bytes.Compare(a.Prefix, b.Prefix)
+	})
+	return result
+}
+
+func collectStats(itr iter.Seq[btrie.PrefixStat]) []btrie.PrefixStat {
+	return append([]btrie.PrefixStat{}, slices.Collect(itr)...)
+}
+
+func TestHeavyPrefixes(t *testing.T) {
+	t.Parallel()
+	for _, test := range createTestTries(rangeTestConfigs) {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Panics(t, func() { btrie.HeavyPrefixes[byte](test.trie, 0, 1) })
+			assert.Panics(t, func() { btrie.HeavyPrefixes[byte](test.trie, 1, -1) })
+			overlay := btrie.NewOverlay[byte](test.trie)
+			for _, minCount := range []int{1, 2, 3, 10} {
+				for _, maxDepth := range []int{0, 1, 2, 100} {
+					expected := expectedHeavyPrefixes(test.config.entries, minCount, maxDepth)
+					assert.Equal(t, expected, collectStats(btrie.HeavyPrefixes[byte](test.trie, minCount, maxDepth)),
+						"%d %d", minCount, maxDepth)
+					assert.Equal(t, expected, collectStats(btrie.HeavyPrefixes[byte](overlay, minCount, maxDepth)),
+						"%d %d", minCount, maxDepth)
+				}
+			}
+			// need an early yield for test coverage
+			for range btrie.HeavyPrefixes[byte](test.trie, 1, 100) {
+				break
+			}
+			for range btrie.HeavyPrefixes[byte](overlay, 1, 100) {
+				break
+			}
+		})
+	}
+}
+
+// Prefix counts must be maintained through every kind of mutation.
+func TestHeavyPrefixesAfterMutation(t *testing.T) {
+	t.Parallel()
+	for _, def := range implDefs {
+		t.Run(def.name, func(t *testing.T) {
+			t.Parallel()
+			trie := def.factory()
+			entries := map[string]byte{}
+			for i, key := range presentTestKeys {
+				trie.Put(key, byte(i))
+				entries[string(key)] = byte(i)
+			}
+			for i, key := range presentTestKeys {
+				switch i % 3 {
+				case 0:
+					trie.Delete(key)
+					delete(entries, string(key))
+				case 1:
+					trie.Put(key, 0) // replacement
+				}
+			}
+			for _, key := range absentTestKeys {
+				trie.Delete(key)
+			}
+			assert.Equal(t, expectedHeavyPrefixes(entries, 1, 100), collectStats(btrie.HeavyPrefixes[byte](trie, 1, 100)))
+			for key := range entries {
+				trie.Delete([]byte(key))
+			}
+			assert.Empty(t, collectStats(btrie.HeavyPrefixes[byte](trie, 1, 100)))
+		})
+	}
+}
+
+// Returns the expected result of btrie.FanoutCounts for a trie with the given entries.
+func expectedFanoutCounts(entries map[string]byte) [256]int {
+	var counts [256]int
+	for k := range entries {
+		if len(k) > 0 {
+			counts[k[0]]++
+		}
+	}
+	return counts
+}
+
+func TestFanoutCounts(t *testing.T) {
+	t.Parallel()
+	for _, def := range implDefs {
+		t.Run(def.name, func(t *testing.T) {
+			t.Parallel()
+			trie := def.factory()
+			assert.Equal(t, [256]int{}, btrie.FanoutCounts[byte](trie))
+			entries := map[string]byte{}
+			for i, key := range append(slices.Clone(presentTestKeys), absentTestKeys...) {
+				trie.Put(key, byte(i))
+				entries[string(key)] = byte(i)
+			}
+			assert.Equal(t, expectedFanoutCounts(entries), btrie.FanoutCounts[byte](trie))
+			overlay := btrie.NewOverlay[byte](trie)
+			assert.Equal(t, expectedFanoutCounts(entries), btrie.FanoutCounts[byte](overlay))
+			// Counts must be maintained through deletions and replacements.
+			for i, key := range presentTestKeys {
+				if i%2 == 0 {
+					trie.Delete(key)
+					delete(entries, string(key))
+				} else {
+					trie.Put(key, 0)
+				}
+			}
+			assert.Equal(t, expectedFanoutCounts(entries), btrie.FanoutCounts[byte](trie))
+		})
+	}
+}
+
+func TestDepthProfile(t *testing.T) {
+	t.Parallel()
+	for _, test := range createTestTries(rangeTestConfigs) {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			expectedEntries, expectedNodes := []int{0}, []int{1}
+			prefixes := map[string]bool{"": true}
+			for k := range test.config.entries {
+				for len(expectedEntries) <= len(k) {
+					expectedEntries = append(expectedEntries, 0)
+					expectedNodes = append(expectedNodes, 0)
+				}
+				expectedEntries[len(k)]++
+				for i := 1; i <= len(k); i++ {
+					if !prefixes[k[:i]] {
+						prefixes[k[:i]] = true
+						expectedNodes[i]++
+					}
+				}
+			}
+			entries, nodes := btrie.DepthProfile[byte](test.trie)
+			assert.Equal(t, expectedEntries, entries)
+			assert.Equal(t, expectedNodes, nodes)
+		})
+	}
+	trie := btrie.NewArrayTrie[byte]()
+	entries, nodes := btrie.DepthProfile(trie)
+	assert.Equal(t, []int{0}, entries)
+	assert.Equal(t, []int{1}, nodes)
+	trie.Put([]byte{1, 2, 3}, 0)
+	trie.Put([]byte{1, 2}, 0)
+	trie.Put([]byte{1, 5, 6}, 0)
+	entries, nodes = btrie.DepthProfile(trie)
+	assert.Equal(t, []int{0, 0, 1, 2}, entries)
+	assert.Equal(t, []int{1, 1, 2, 2}, nodes)
+}
+
+func TestSizeOf(t *testing.T) {
+	t.Parallel()
+	assert.Panics(t, func() { btrie.SizeOf(btrie.NewArrayTrie[byte](), nil, nil) })
+	const valueRefSize = 1000
+	refSizer := func(byte) int64 { return valueRefSize }
+	for _, test := range createTestTries(rangeTestConfigs) {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			overlay := btrie.NewOverlay[byte](test.trie)
+			for _, prefix := range [][]byte{{}, {0x23}, {0xC5, 0x42}, {0x99, 0x99, 0x99}} {
+				count := 0
+				nodes := map[string]bool{}
+				for k := range test.config.entries {
+					if strings.HasPrefix(k, string(prefix)) {
+						count++
+						for i := len(prefix) + 1; i <= len(k); i++ {
+							nodes[k[:i]] = true
+						}
+					}
+				}
+				// The fallback estimate is one byte per node below prefix, plus one byte per byte value.
+				assert.Equal(t, int64(len(nodes)+count), btrie.SizeOf[byte](overlay, prefix, nil), "%v", prefix)
+				for _, trie := range []btrie.BTrie[byte]{test.trie, overlay} {
+					size := btrie.SizeOf(trie, prefix, nil)
+					assert.Equal(t, size+int64(count*valueRefSize), btrie.SizeOf(trie, prefix, refSizer), "%v", prefix)
+					if count == 0 && len(prefix) > 0 {
+						assert.Zero(t, size, "%v", prefix)
+					}
+					if len(prefix) > 0 {
+						assert.LessOrEqual(t, size, btrie.SizeOf(trie, prefix[:len(prefix)-1], nil), "%v", prefix)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestBuildDictionary(t *testing.T) {
+	t.Parallel()
+	trie := btrie.NewArrayTrie[byte]()
+	assert.Panics(t, func() { btrie.BuildDictionary(trie, -1) })
+	assert.Equal(t, keys(), btrie.BuildDictionary(trie, 5))
+	for _, key := range []string{
+		"https://example.com/a",
+		"https://example.com/b",
+		"https://example.com/c",
+		"https://other.org/x",
+		"https://other.org/y",
+		"ftp://z",
+	} {
+		trie.Put([]byte(key), 0)
+	}
+	assert.Equal(t, keys(), btrie.BuildDictionary(trie, 0))
+	// "https://example.com/" accounts for 3 * 20 bytes, "https://" for 5 * 8 bytes,
+	// and "https://other.org/" for 2 * 18 bytes.
+	assert.Equal(t, keys("https://example.com/", "https://example.com", "https://example.co"),
+		btrie.BuildDictionary(trie, 3))
+	dict := btrie.BuildDictionary(trie, 100)
+	assert.Len(t, dict, 20+18-8)
+	assert.Equal(t, dict, btrie.BuildDictionary(btrie.NewOverlay(trie), 100))
+}
@@ -0,0 +1,111 @@
+package btrie
+
+import (
+	"bytes"
+	"container/heap"
+	"iter"
+)
+
+// Suggest returns up to n keys in trie having the given prefix, in increasing key order.
+// The prefix itself is included if it is a key in trie.
+// Suggest will panic if prefix is nil or n is negative.
+func Suggest[V any](trie BTrie[V], prefix []byte, n int) [][]byte {
+	if prefix == nil {
+		panic("prefix must be non-nil")
+	}
+	if n < 0 {
+		panic("n must be non-negative")
+	}
+	result := [][]byte{}
+	if n == 0 {
+		return result
+	}
+	for key := range rangePrefix(trie, prefix) {
+		result = append(result, key)
+		if len(result) == n {
+			break
+		}
+	}
+	return result
+}
+
+// SuggestRanked returns up to n keys in trie having the given prefix, in decreasing order of weight.
+// Keys with equal weights are in increasing key order.
+// Unlike Suggest, SuggestRanked must visit every key having the given prefix.
+// SuggestRanked will panic if prefix is nil or n is negative.
+func SuggestRanked[V any](trie BTrie[V], prefix []byte, n int, weight func(key []byte, value V) float64) [][]byte {
+	if prefix == nil {
+		panic("prefix must be non-nil")
+	}
+	if n < 0 {
+		panic("n must be non-negative")
+	}
+	if n == 0 {
+		return [][]byte{}
+	}
+	// A min-heap of the n best suggestions so far.
+	// Because keys are visited in increasing order, a later key never replaces an earlier key of equal weight.
+	best := &suggestionHeap{}
+	for key, value := range rangePrefix(trie, prefix) {
+		s := suggestion{key, weight(key, value)}
+		if best.Len() < n {
+			heap.Push(best, s)
+		} else if best.less(best.items[0], s) {
+			best.items[0] = s
+			heap.Fix(best, 0)
+		}
+	}
+	result := make([][]byte, best.Len())
+	for i := len(result) - 1; i >= 0; i-- {
+		//nolint:forcetypeassert
+		result[i] = heap.Pop(best).(suggestion).key
+	}
+	return result
+}
+
+// Returns the entries in trie having the given prefix, in increasing key order.
+func rangePrefix[V any](trie BTrie[V], prefix []byte) iter.Seq2[[]byte, V] {
+	bounds, _ := From(nil).To(nil).Clamp(bytes.Clone(prefix))
+	return trie.Range(bounds)
+}
+
+type suggestion struct {
+	key    []byte
+	weight float64
+}
+
+// A heap.Interface whose minimum is the worst suggestion.
+type suggestionHeap struct {
+	items []suggestion
+}
+
+// Returns true if a is a worse suggestion than b.
+func (*suggestionHeap) less(a, b suggestion) bool {
+	if a.weight != b.weight {
+		return a.weight < b.weight
+	}
+	return bytes.Compare(a.key, b.key) > 0
+}
+
+func (h *suggestionHeap) Len() int {
+	return len(h.items)
+}
+
+func (h *suggestionHeap) Less(i, j int) bool {
+	return h.less(h.items[i], h.items[j])
+}
+
+func (h *suggestionHeap) Swap(i, j int) {
+	h.items[i], h.items[j] = h.items[j], h.items[i]
+}
+
+func (h *suggestionHeap) Push(x any) {
+	//nolint:forcetypeassert
+	h.items = append(h.items, x.(suggestion))
+}
+
+func (h *suggestionHeap) Pop() any {
+	last := h.items[len(h.items)-1]
+	h.items = h.items[:len(h.items)-1]
+	return last
+}
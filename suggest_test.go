@@ -0,0 +1,75 @@
+package btrie_test
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+
+	"github.com/phiryll/btrie"
+	"github.com/stretchr/testify/assert"
+)
+
+func suggestTestTrie() btrie.BTrie[byte] {
+	trie := btrie.NewArrayTrie[byte]()
+	for i, key := range []string{"car", "card", "care", "careful", "cart", "cat", "dog", ""} {
+		trie.Put([]byte(key), byte(i))
+	}
+	return trie
+}
+
+func keys(strs ...string) [][]byte {
+	result := [][]byte{}
+	for _, s := range strs {
+		result = append(result, []byte(s))
+	}
+	return result
+}
+
+func TestSuggest(t *testing.T) {
+	t.Parallel()
+	trie := suggestTestTrie()
+	assert.Panics(t, func() { btrie.Suggest(trie, nil, 1) })
+	assert.Panics(t, func() { btrie.Suggest(trie, nil, 0) })
+	assert.Panics(t, func() { btrie.Suggest(trie, []byte("c"), -1) })
+	assert.Equal(t, keys(), btrie.Suggest(trie, []byte("c"), 0))
+	assert.Equal(t, keys("car", "card", "care"), btrie.Suggest(trie, []byte("car"), 3))
+	assert.Equal(t, keys("car", "card", "care", "careful", "cart", "cat"), btrie.Suggest(trie, []byte("c"), 100))
+	assert.Equal(t, keys("", "car"), btrie.Suggest(trie, []byte{}, 2))
+	assert.Equal(t, keys(), btrie.Suggest(trie, []byte("x"), 2))
+}
+
+func TestSuggestRanked(t *testing.T) {
+	t.Parallel()
+	trie := suggestTestTrie()
+	byValue := func(_ []byte, value byte) float64 { return float64(value) }
+	byLength := func(key []byte, _ byte) float64 { return float64(len(key)) }
+	assert.Panics(t, func() { btrie.SuggestRanked(trie, nil, 1, byValue) })
+	assert.Panics(t, func() { btrie.SuggestRanked(trie, nil, 0, byValue) })
+	assert.Panics(t, func() { btrie.SuggestRanked(trie, []byte("c"), -1, byValue) })
+	assert.Equal(t, keys(), btrie.SuggestRanked(trie, []byte("c"), 0, byValue))
+	assert.Equal(t, keys("cat", "cart", "careful"), btrie.SuggestRanked(trie, []byte("c"), 3, byValue))
+	assert.Equal(t, keys("careful", "card", "care", "cart"), btrie.SuggestRanked(trie, []byte("car"), 4, byLength))
+	assert.Equal(t, keys("careful", "card", "care", "cart", "car"), btrie.SuggestRanked(trie, []byte("car"), 100, byLength))
+	assert.Equal(t, keys(), btrie.SuggestRanked(trie, []byte("x"), 2, byLength))
+
+	// Compare against sorting every key with the prefix.
+	for _, test := range createTestTries(rangeTestConfigs) {
+		for _, prefix := range [][]byte{{}, {0x00}, {0x80}} {
+			expected := [][]byte{}
+			for k := range test.trie.Range(forwardAll) {
+				if bytes.HasPrefix(k, prefix) {
+					expected = append(expected, k)
+				}
+			}
+			weights := map[string]byte{}
+			for k, v := range test.trie.Range(forwardAll) {
+				weights[string(k)] = v
+			}
+			slices.SortStableFunc(expected, func(a, b []byte) int {
+				return int(weights[string(b)]) - int(weights[string(a)])
+			})
+			expected = expected[:min(len(expected), 10)]
+			assert.Equal(t, expected, btrie.SuggestRanked[byte](test.trie, prefix, 10, byValue), "%s %X", test.name, prefix)
+		}
+	}
+}
@@ -0,0 +1,385 @@
+package btrie
+
+import (
+	"bytes"
+	"iter"
+	"sync"
+	"sync/atomic"
+)
+
+// Synchronized wraps a BTrie to make it safe for concurrent use, using a [sync.RWMutex].
+// Get and Range share a read lock, while Put and Delete take the write lock.
+// A Range iteration holds the read lock until it finishes,
+// so the trie must not be mutated from within a Range loop, or it will deadlock.
+// Synchronized implements [BTrie].
+type Synchronized[V any] struct {
+	mu       sync.RWMutex
+	trie     BTrie[V]
+	watchers map[*watcher[V]]struct{} // guarded by mu's write lock
+}
+
+// NewSynchronized returns a new Synchronized wrapping trie.
+// After this call, trie should only be accessed through the returned Synchronized.
+func NewSynchronized[V any](trie BTrie[V]) *Synchronized[V] {
+	return &Synchronized[V]{trie: trie}
+}
+
+func (s *Synchronized[V]) Get(key []byte) (V, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.trie.Get(key)
+}
+
+func (s *Synchronized[V]) Put(key []byte, value V) (V, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.putLocked(key, value)
+}
+
+func (s *Synchronized[V]) Delete(key []byte) (V, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.deleteLocked(key)
+}
+
+// CompareAndSwap sets the value for key to newValue if key exists and its value is equal to old according to eq,
+// and returns whether it did. The comparison and the put are atomic with respect to all other methods of s.
+func (s *Synchronized[V]) CompareAndSwap(key []byte, old, newValue V, eq Equaler[V]) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if value, ok := s.trie.Get(key); !ok || !eq.Equal(value, old) {
+		return false
+	}
+	s.putLocked(key, newValue)
+	return true
+}
+
+// CompareAndDelete deletes key if it exists and its value is equal to old according to eq, and returns whether it did.
+// The comparison and the delete are atomic with respect to all other methods of s.
+func (s *Synchronized[V]) CompareAndDelete(key []byte, old V, eq Equaler[V]) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if value, ok := s.trie.Get(key); !ok || !eq.Equal(value, old) {
+		return false
+	}
+	s.deleteLocked(key)
+	return true
+}
+
+// The write lock must be held.
+func (s *Synchronized[V]) putLocked(key []byte, value V) (V, bool) {
+	prev, ok := s.trie.Put(key, value)
+	s.notify(key, value, false)
+	return prev, ok
+}
+
+// The write lock must be held.
+func (s *Synchronized[V]) deleteLocked(key []byte) (V, bool) {
+	prev, ok := s.trie.Delete(key)
+	if ok {
+		s.notify(key, prev, true)
+	}
+	return prev, ok
+}
+
+func (s *Synchronized[V]) Range(bounds *Bounds) iter.Seq2[[]byte, V] {
+	if bounds == nil {
+		panic("bounds must be non-nil")
+	}
+	return func(yield func([]byte, V) bool) {
+		s.mu.RLock()
+		defer s.mu.RUnlock()
+		for k, v := range s.trie.Range(bounds) {
+			if !yield(k, v) {
+				return
+			}
+		}
+	}
+}
+
+// Swap replaces the wrapped trie with trie, and returns the trie it replaced.
+// Readers see either the entire old trie or the entire new one, and a Range iteration in progress
+// continues over the old trie. Watchers are not notified of any changes resulting from a Swap.
+// After this call, trie should only be accessed through s, and the returned trie is no longer used by s.
+func (s *Synchronized[V]) Swap(trie BTrie[V]) BTrie[V] {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	prev := s.trie
+	s.trie = trie
+	return prev
+}
+
+// Begin starts a new transaction on this trie.
+func (s *Synchronized[V]) Begin() *Txn[V] {
+	return &Txn[V]{s, NewOverlay[V](s), false}
+}
+
+// Txn is a transaction on a [Synchronized] trie.
+// Puts and deletes are buffered in the Txn, and its reads see those buffered changes over the current
+// state of the trie, which may include changes committed by other transactions after this one began.
+// Commit applies all buffered changes while holding the trie's write lock,
+// so concurrent readers of the trie see either all of them or none of them.
+// There is no conflict detection; if transactions change the same key, the last to commit wins.
+// Txn implements [BTrie], but is not itself safe for concurrent use.
+// Every method will panic if called after Commit or Discard.
+type Txn[V any] struct {
+	owner    *Synchronized[V]
+	overlay  *Overlay[V]
+	finished bool
+}
+
+func (t *Txn[V]) check() {
+	if t.finished {
+		panic("transaction is finished")
+	}
+}
+
+func (t *Txn[V]) Get(key []byte) (V, bool) {
+	t.check()
+	return t.overlay.Get(key)
+}
+
+func (t *Txn[V]) Put(key []byte, value V) (V, bool) {
+	t.check()
+	return t.overlay.Put(key, value)
+}
+
+func (t *Txn[V]) Delete(key []byte) (V, bool) {
+	t.check()
+	return t.overlay.Delete(key)
+}
+
+func (t *Txn[V]) Range(bounds *Bounds) iter.Seq2[[]byte, V] {
+	t.check()
+	return t.overlay.Range(bounds)
+}
+
+// Commit atomically applies all changes made in this transaction to the trie, and finishes the transaction.
+func (t *Txn[V]) Commit() {
+	t.check()
+	t.finished = true
+	t.owner.mu.Lock()
+	defer t.owner.mu.Unlock()
+	for k, c := range All(t.overlay.changes) {
+		if c.deleted {
+			t.owner.deleteLocked(k)
+		} else {
+			t.owner.putLocked(k, c.value)
+		}
+	}
+}
+
+// Discard drops all changes made in this transaction, and finishes the transaction.
+func (t *Txn[V]) Discard() {
+	t.check()
+	t.finished = true
+	t.overlay.Discard()
+}
+
+// Change is a put or delete sent to a channel returned by [Synchronized.Watch].
+type Change[V any] struct {
+	// Key is the key that was changed.
+	Key []byte
+
+	// Value is the new value if Deleted is false, and the deleted value if Deleted is true.
+	Value V
+
+	// Deleted is false for a put, and true for a delete.
+	Deleted bool
+}
+
+// The size of the channel buffer for each watcher.
+const watchBufferSize = 16
+
+type watcher[V any] struct {
+	prefix []byte
+	ch     chan Change[V]
+	done   chan struct{}
+}
+
+// Watch returns a channel receiving every change to a key having the given prefix,
+// and a function to stop watching which closes the channel.
+// Deletes of keys that do not exist are not sent.
+// Changes are sent while the write lock is held, so a watcher that does not keep up will block writers
+// once the channel's small buffer is full; stopping the watcher unblocks them.
+// Watch will panic if prefix is nil.
+func (s *Synchronized[V]) Watch(prefix []byte) (<-chan Change[V], func()) {
+	if prefix == nil {
+		panic("prefix must be non-nil")
+	}
+	w := &watcher[V]{bytes.Clone(prefix), make(chan Change[V], watchBufferSize), make(chan struct{})}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.watchers == nil {
+		s.watchers = map[*watcher[V]]struct{}{}
+	}
+	s.watchers[w] = struct{}{}
+	var once sync.Once
+	return w.ch, func() {
+		once.Do(func() {
+			// Closing done first unblocks a writer sending to w, so the lock can be acquired.
+			close(w.done)
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			delete(s.watchers, w)
+			close(w.ch)
+		})
+	}
+}
+
+// The write lock must be held.
+func (s *Synchronized[V]) notify(key []byte, value V, deleted bool) {
+	var c *Change[V]
+	for w := range s.watchers {
+		if !bytes.HasPrefix(key, w.prefix) {
+			continue
+		}
+		if c == nil {
+			c = &Change[V]{bytes.Clone(key), value, deleted}
+		}
+		select {
+		case w.ch <- *c:
+		case <-w.done:
+		}
+	}
+}
+
+// Striped is a BTrie which is safe for concurrent use, partitioning keys by their first byte into stripes,
+// each of which is a separate trie guarded by its own [sync.RWMutex].
+// Unlike [Synchronized], whose single lock serializes all writers,
+// Get, Put, and Delete lock only the stripe of their key, so writes to unrelated prefixes do not contend.
+// Each stripe holds a contiguous range of first bytes, and the empty key is in the first stripe.
+//
+// A Range iteration takes the read locks of every stripe it could yield keys from, in increasing order,
+// and holds them until it finishes, so it sees a consistent state of those stripes.
+// Like Synchronized, the trie must not be mutated from within a Range loop, or it may deadlock.
+// [Striped.Clear] takes every write lock in the same order.
+// Striped implements [BTrie].
+type Striped[V any] struct {
+	factory func() BTrie[V]
+	stripes []stripe[V]
+}
+
+type stripe[V any] struct {
+	mu   sync.RWMutex
+	trie BTrie[V]
+}
+
+// NewStriped returns a new empty Striped with n stripes, each a trie created by factory.
+// NewStriped will panic if n is not between 1 and 256, inclusive.
+func NewStriped[V any](n int, factory func() BTrie[V]) *Striped[V] {
+	if n < 1 || n > 256 {
+		panic("number of stripes must be between 1 and 256")
+	}
+	s := &Striped[V]{factory, make([]stripe[V], n)}
+	for i := range s.stripes {
+		s.stripes[i].trie = factory()
+	}
+	return s
+}
+
+// Returns the index of the stripe holding keys beginning with b.
+func (s *Striped[V]) stripeIndex(b byte) int {
+	return int(b) * len(s.stripes) / 256
+}
+
+// Returns the stripe holding key.
+func (s *Striped[V]) stripeOf(key []byte) *stripe[V] {
+	if len(key) == 0 {
+		return &s.stripes[0]
+	}
+	return &s.stripes[s.stripeIndex(key[0])]
+}
+
+func (s *Striped[V]) Get(key []byte) (V, bool) {
+	st := s.stripeOf(key)
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+	return st.trie.Get(key)
+}
+
+func (s *Striped[V]) Put(key []byte, value V) (V, bool) {
+	st := s.stripeOf(key)
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return st.trie.Put(key, value)
+}
+
+func (s *Striped[V]) Delete(key []byte) (V, bool) {
+	st := s.stripeOf(key)
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return st.trie.Delete(key)
+}
+
+func (s *Striped[V]) Range(bounds *Bounds) iter.Seq2[[]byte, V] {
+	if bounds == nil {
+		panic("bounds must be non-nil")
+	}
+	bounds = bounds.Clone()
+	return func(yield func([]byte, V) bool) {
+		// If no first byte is within bounds, only the empty key could be, which is in the first stripe.
+		low, high := 0, 0
+		if start, stop, ok := bounds.childBounds([]byte{}); ok {
+			low, high = s.stripeIndex(min(start, stop)), s.stripeIndex(max(start, stop))
+		}
+		for i := low; i <= high; i++ {
+			s.stripes[i].mu.RLock()
+			defer s.stripes[i].mu.RUnlock()
+		}
+		for n := range high - low + 1 {
+			i := low + n
+			if bounds.IsReverse {
+				i = high - n
+			}
+			for k, v := range s.stripes[i].trie.Range(bounds) {
+				if !yield(k, v) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Clear removes every entry from s, replacing each stripe's trie with a new one created by its factory.
+// Clear takes the write locks of all stripes, so concurrent readers see either every entry or none.
+func (s *Striped[V]) Clear() {
+	for i := range s.stripes {
+		s.stripes[i].mu.Lock()
+		defer s.stripes[i].mu.Unlock()
+	}
+	for i := range s.stripes {
+		s.stripes[i].trie = s.factory()
+	}
+}
+
+// AtomicTrie holds a BTrie which can be replaced atomically, without locking.
+// This allows a trie to be built in the background and then published to readers,
+// which will see either the old trie or the new one, but never a partially built trie.
+// AtomicTrie does not make the held trie safe for concurrent use;
+// a trie must not be mutated after it is stored unless it is itself safe for concurrent use.
+// The zero value holds nil, and an AtomicTrie must not be copied after first use.
+type AtomicTrie[V any] struct {
+	p atomic.Pointer[BTrie[V]]
+}
+
+// Load returns the held trie, or nil if none has been stored.
+func (a *AtomicTrie[V]) Load() BTrie[V] {
+	if p := a.p.Load(); p != nil {
+		return *p
+	}
+	return nil
+}
+
+// Store replaces the held trie with trie.
+func (a *AtomicTrie[V]) Store(trie BTrie[V]) {
+	a.p.Store(&trie)
+}
+
+// Swap replaces the held trie with trie, and returns the trie it replaced, or nil if there was none.
+func (a *AtomicTrie[V]) Swap(trie BTrie[V]) BTrie[V] {
+	if p := a.p.Swap(&trie); p != nil {
+		return *p
+	}
+	return nil
+}
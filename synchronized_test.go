@@ -0,0 +1,367 @@
+package btrie_test
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/phiryll/btrie"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSynchronized(t *testing.T) {
+	t.Parallel()
+	for _, test := range createTestTries(rangeTestConfigs) {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			trie := btrie.NewSynchronized[byte](test.trie.Clone())
+			assertSame(t, test.config.entries, trie)
+			for _, keys := range test.config.absent {
+				for _, key := range keys {
+					assertAbsent(t, key, trie)
+				}
+			}
+			ref := createReferenceTrie(test.config)
+			for _, bounds := range test.config.reverse {
+				assert.Equal(t, collect(ref.Range(&bounds)), collect(trie.Range(&bounds)), "%s", bounds)
+			}
+		})
+	}
+	assert.Panics(t, func() {
+		btrie.NewSynchronized(btrie.NewArrayTrie[byte]()).Range(nil)
+	})
+}
+
+func TestSynchronizedConcurrent(t *testing.T) {
+	t.Parallel()
+	const numWriters = 4
+	const numKeys = 256
+	trie := btrie.NewSynchronized(btrie.NewArrayTrie[byte]())
+	var wg sync.WaitGroup
+	for w := range numWriters {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for i := range numKeys {
+				trie.Put([]byte{byte(w), byte(i)}, byte(i))
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for range 10 {
+				for k, v := range trie.Range(forwardAll) {
+					assert.Equal(t, k[1], v)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	count := 0
+	for range trie.Range(forwardAll) {
+		count++
+	}
+	assert.Equal(t, numWriters*numKeys, count)
+}
+
+func TestTxn(t *testing.T) {
+	t.Parallel()
+	trie := btrie.NewSynchronized(btrie.NewArrayTrie[byte]())
+	trie.Put([]byte{1}, 1)
+	trie.Put([]byte{2}, 2)
+
+	txn := trie.Begin()
+	txn.Put([]byte{3}, 3)
+	txn.Delete([]byte{1})
+	value, ok := txn.Get([]byte{3})
+	assert.True(t, ok)
+	assert.Equal(t, byte(3), value)
+	_, ok = txn.Get([]byte{1})
+	assert.False(t, ok)
+	assert.Equal(t, []entry{{[]byte{2}, 2}, {[]byte{3}, 3}}, collect(txn.Range(forwardAll)))
+
+	// Not visible until committed.
+	assertSame(t, map[string]byte{"\x01": 1, "\x02": 2}, trie)
+	txn.Commit()
+	assertSame(t, map[string]byte{"\x02": 2, "\x03": 3}, trie)
+
+	txn = trie.Begin()
+	txn.Put([]byte{4}, 4)
+	txn.Discard()
+	assertSame(t, map[string]byte{"\x02": 2, "\x03": 3}, trie)
+
+	assert.Panics(t, func() { txn.Get([]byte{2}) })
+	assert.Panics(t, func() { txn.Put([]byte{2}, 0) })
+	assert.Panics(t, func() { txn.Delete([]byte{2}) })
+	assert.Panics(t, func() { txn.Range(forwardAll) })
+	assert.Panics(t, txn.Commit)
+	assert.Panics(t, txn.Discard)
+}
+
+func TestTxnAtomic(t *testing.T) {
+	t.Parallel()
+	// Every committed transaction changes all keys to the same value,
+	// so a reader must never see different values.
+	const numKeys = 64
+	trie := btrie.NewSynchronized(btrie.NewArrayTrie[byte]())
+	for i := range numKeys {
+		trie.Put([]byte{byte(i)}, 0)
+	}
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for n := range 100 {
+			txn := trie.Begin()
+			for i := range numKeys {
+				txn.Put([]byte{byte(i)}, byte(n))
+			}
+			txn.Commit()
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for range 100 {
+			values := map[byte]bool{}
+			for _, v := range trie.Range(forwardAll) {
+				values[v] = true
+			}
+			assert.Len(t, values, 1)
+		}
+	}()
+	wg.Wait()
+}
+
+func TestCompareAndSwap(t *testing.T) {
+	t.Parallel()
+	eq := btrie.Comparable[byte]()
+	trie := btrie.NewSynchronized(btrie.NewArrayTrie[byte]())
+	changes, stop := trie.Watch([]byte{})
+	assert.False(t, trie.CompareAndSwap([]byte{1}, 0, 1, eq)) // absent
+	trie.Put([]byte{1}, 10)
+	assert.False(t, trie.CompareAndSwap([]byte{1}, 11, 12, eq))
+	assert.True(t, trie.CompareAndSwap([]byte{1}, 10, 12, eq))
+	value, _ := trie.Get([]byte{1})
+	assert.Equal(t, byte(12), value)
+
+	assert.False(t, trie.CompareAndDelete([]byte{2}, 0, eq)) // absent
+	assert.False(t, trie.CompareAndDelete([]byte{1}, 10, eq))
+	assert.True(t, trie.CompareAndDelete([]byte{1}, 12, eq))
+	assertAbsent(t, []byte{1}, trie)
+	stop()
+
+	var all []btrie.Change[byte]
+	for c := range changes {
+		all = append(all, c)
+	}
+	assert.Equal(t, []btrie.Change[byte]{
+		{[]byte{1}, 10, false},
+		{[]byte{1}, 12, false},
+		{[]byte{1}, 12, true},
+	}, all)
+}
+
+// Concurrent increments using CompareAndSwap must not lose any updates.
+func TestCompareAndSwapConcurrent(t *testing.T) {
+	t.Parallel()
+	const numWriters = 8
+	const numIncrements = 100
+	eq := btrie.Comparable[int]()
+	trie := btrie.NewSynchronized(btrie.NewArrayTrie[int]())
+	key := []byte("counter")
+	trie.Put(key, 0)
+	var wg sync.WaitGroup
+	for range numWriters {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range numIncrements {
+				for {
+					value, _ := trie.Get(key)
+					if trie.CompareAndSwap(key, value, value+1, eq) {
+						break
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	value, _ := trie.Get(key)
+	assert.Equal(t, numWriters*numIncrements, value)
+}
+
+func TestWatch(t *testing.T) {
+	t.Parallel()
+	trie := btrie.NewSynchronized(btrie.NewArrayTrie[byte]())
+	all, stopAll := trie.Watch([]byte{})
+	some, stopSome := trie.Watch([]byte{5})
+
+	key := []byte{5, 1}
+	trie.Put(key, 1)
+	key[1] = 0xFF // must not affect the sent change
+	trie.Put([]byte{6}, 2)
+	trie.Delete([]byte{5, 1})
+	trie.Delete([]byte{5, 1}) // absent, not sent
+	txn := trie.Begin()
+	txn.Put([]byte{5}, 3)
+	txn.Delete([]byte{6})
+	txn.Commit()
+
+	stopAll()
+	stopSome()
+	stopSome() // must be idempotent
+
+	var allChanges, someChanges []btrie.Change[byte]
+	for c := range all {
+		allChanges = append(allChanges, c)
+	}
+	for c := range some {
+		someChanges = append(someChanges, c)
+	}
+	assert.Equal(t, []btrie.Change[byte]{
+		{[]byte{5, 1}, 1, false},
+		{[]byte{6}, 2, false},
+		{[]byte{5, 1}, 1, true},
+		{[]byte{5}, 3, false},
+		{[]byte{6}, 2, true},
+	}, allChanges)
+	assert.Equal(t, []btrie.Change[byte]{
+		{[]byte{5, 1}, 1, false},
+		{[]byte{5, 1}, 1, true},
+		{[]byte{5}, 3, false},
+	}, someChanges)
+	assert.Panics(t, func() {
+		trie.Watch(nil)
+	})
+}
+
+func TestWatchStopUnblocksWriters(t *testing.T) {
+	t.Parallel()
+	trie := btrie.NewSynchronized(btrie.NewArrayTrie[byte]())
+	_, stop := trie.Watch([]byte{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		// Far more changes than the channel buffer, nobody is receiving them.
+		for i := range 1000 {
+			trie.Put([]byte{byte(i)}, 0)
+		}
+	}()
+	stop()
+	<-done
+}
+
+// Readers must see every entry of either the old or new trie, never a mix.
+func TestSynchronizedSwap(t *testing.T) {
+	t.Parallel()
+	const numKeys = 64
+	build := func(value byte) btrie.BTrie[byte] {
+		trie := btrie.NewArrayTrie[byte]()
+		for i := range numKeys {
+			trie.Put([]byte{byte(i)}, value)
+		}
+		return trie
+	}
+	old := build(1)
+	trie := btrie.NewSynchronized(old)
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				var values []byte
+				for _, v := range btrie.All[byte](trie) {
+					values = append(values, v)
+				}
+				assert.Len(t, values, numKeys)
+				for _, v := range values {
+					assert.Equal(t, values[0], v)
+				}
+			}
+		}()
+	}
+	for i := range 100 {
+		assert.NotNil(t, trie.Swap(build(byte(i))))
+	}
+	wg.Wait()
+	assert.Same(t, old, btrie.NewSynchronized(old).Swap(build(0)))
+}
+
+func TestAtomicTrie(t *testing.T) {
+	t.Parallel()
+	var holder btrie.AtomicTrie[byte]
+	assert.Nil(t, holder.Load())
+	first := btrie.NewArrayTrie[byte]()
+	first.Put([]byte{1}, 1)
+	assert.Nil(t, holder.Swap(first))
+	assert.Same(t, first, holder.Load())
+	second := btrie.NewArrayTrie[byte]()
+	holder.Store(second)
+	assert.Same(t, second, holder.Load())
+	assert.Same(t, second, holder.Swap(first))
+	value, ok := holder.Load().Get([]byte{1})
+	assert.True(t, ok)
+	assert.Equal(t, byte(1), value)
+}
+
+func TestStriped(t *testing.T) {
+	t.Parallel()
+	for _, n := range []int{1, 3, 256} {
+		for _, test := range createTestTries(rangeTestConfigs) {
+			t.Run(fmt.Sprintf("stripes=%d/%s", n, test.name), func(t *testing.T) {
+				t.Parallel()
+				trie := btrie.NewStriped(n, btrie.NewArrayTrie[byte])
+				for k, v := range test.config.entries {
+					trie.Put([]byte(k), v)
+				}
+				assertSame(t, test.config.entries, trie)
+				for _, keys := range test.config.absent {
+					for _, key := range keys {
+						assertAbsent(t, key, trie)
+					}
+				}
+				ref := createReferenceTrie(test.config)
+				for _, bounds := range slices.Concat(test.config.forward, test.config.reverse) {
+					assert.Equal(t, collect(ref.Range(&bounds)), collect(trie.Range(&bounds)), "%s", bounds)
+				}
+				trie.Clear()
+				assertSame(t, map[string]byte{}, trie)
+			})
+		}
+	}
+	assert.Panics(t, func() { btrie.NewStriped(0, btrie.NewArrayTrie[byte]) })
+	assert.Panics(t, func() { btrie.NewStriped(257, btrie.NewArrayTrie[byte]) })
+	assert.Panics(t, func() { btrie.NewStriped(4, btrie.NewArrayTrie[byte]).Range(nil) })
+}
+
+func TestStripedConcurrent(t *testing.T) {
+	t.Parallel()
+	const numWriters = 4
+	const numKeys = 256
+	trie := btrie.NewStriped(4, btrie.NewArrayTrie[byte])
+	var wg sync.WaitGroup
+	for w := range numWriters {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for i := range numKeys {
+				trie.Put([]byte{byte(w * 64), byte(i)}, byte(i))
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for range 10 {
+				for k, v := range trie.Range(reverseAll) {
+					assert.Equal(t, k[1], v)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	count := 0
+	for range trie.Range(forwardAll) {
+		count++
+	}
+	assert.Equal(t, numWriters*numKeys, count)
+}
@@ -2,26 +2,32 @@ package btrie
 
 import (
 	"iter"
+	"slices"
 )
 
+// Traversers over user-defined graphs, given a root node and an adjacency function.
+// These are the same traversals the tries in this package use, and work for any tree-shaped graph.
+// If the graph has cycles, traversals will not terminate unless bounded by [MaxDepth] or [StopAt].
+
 // Traversers returning nodes.
 
-// An adjacency function from a node to adjacent nodes.
+// AdjFunction is an adjacency function from a node to adjacent nodes.
 // Adjacency functions should be idempotent.
-type adjFunction[T any] func(T) iter.Seq[T]
+type AdjFunction[T any] func(T) iter.Seq[T]
 
-// A traverser returns a sequence of nodes given a root node and an adjacency function.
+// Traverser returns a sequence of nodes given a root node and an adjacency function.
 // Traversers should be idempotent.
-type traverser[T any] func(T, adjFunction[T]) iter.Seq[T]
+type Traverser[T any] func(T, AdjFunction[T]) iter.Seq[T]
 
-func preOrder[T any](root T, adj adjFunction[T]) iter.Seq[T] {
+// PreOrder returns a sequence of the nodes reachable from root, yielding each node before its adjacent nodes.
+func PreOrder[T any](root T, adj AdjFunction[T]) iter.Seq[T] {
 	return func(yield func(T) bool) {
 		preOrderRecurse(root, adj, yield)
 	}
 }
 
 // Returns true if done (some yield has returned false).
-func preOrderRecurse[T any](node T, adj adjFunction[T], yield func(T) bool) bool {
+func preOrderRecurse[T any](node T, adj AdjFunction[T], yield func(T) bool) bool {
 	if !yield(node) {
 		return true
 	}
@@ -33,14 +39,15 @@ func preOrderRecurse[T any](node T, adj adjFunction[T], yield func(T) bool) bool
 	return false
 }
 
-func postOrder[T any](root T, adj adjFunction[T]) iter.Seq[T] {
+// PostOrder returns a sequence of the nodes reachable from root, yielding each node after its adjacent nodes.
+func PostOrder[T any](root T, adj AdjFunction[T]) iter.Seq[T] {
 	return func(yield func(T) bool) {
 		postOrderRecurse(root, adj, yield)
 	}
 }
 
 // Returns true if done (some yield has returned false).
-func postOrderRecurse[T any](node T, adj adjFunction[T], yield func(T) bool) bool {
+func postOrderRecurse[T any](node T, adj AdjFunction[T], yield func(T) bool) bool {
 	for adjNode := range adj(node) {
 		if postOrderRecurse(adjNode, adj, yield) {
 			return true
@@ -51,24 +58,27 @@ func postOrderRecurse[T any](node T, adj adjFunction[T], yield func(T) bool) boo
 
 // Traversers returning paths.
 
-// An adjacency function from a path to nodes adjacent to the path's end.
+// PathAdjFunction is an adjacency function from a path to nodes adjacent to the path's end.
+// The path must not be modified or retained by the function.
 // Adjacency functions should be idempotent.
-type pathAdjFunction[T any] func([]T) iter.Seq[T]
+type PathAdjFunction[T any] func([]T) iter.Seq[T]
 
-// A pathTraverser returns a sequence of paths given a root node and a pathAdjFunction.
+// PathTraverser returns a sequence of paths given a root node and a PathAdjFunction.
 // Traversers should be idempotent.
-type pathTraverser[T any] func(T, pathAdjFunction[T]) iter.Seq[[]T]
+type PathTraverser[T any] func(T, PathAdjFunction[T]) iter.Seq[[]T]
 
-// The elements of the returned sequence reference a volatile internal slice,
-// clone it if you need it after a step in the iteration.
-func preOrderPaths[T any](root T, pathAdj pathAdjFunction[T]) iter.Seq[[]T] {
+// PreOrderPaths returns a sequence of the paths from root to each reachable node,
+// yielding each path before the paths extending it.
+// The yielded paths share a volatile internal slice, and must not be modified.
+// Use [ClonePaths] if they are needed after a step in the iteration.
+func PreOrderPaths[T any](root T, pathAdj PathAdjFunction[T]) iter.Seq[[]T] {
 	return func(yield func([]T) bool) {
 		preOrderPathsRecurse([]T{root}, pathAdj, yield)
 	}
 }
 
 // Returns true if done (some yield has returned false).
-func preOrderPathsRecurse[T any](path []T, pathAdj pathAdjFunction[T], yield func([]T) bool) bool {
+func preOrderPathsRecurse[T any](path []T, pathAdj PathAdjFunction[T], yield func([]T) bool) bool {
 	if !yield(path) {
 		return true
 	}
@@ -80,16 +90,18 @@ func preOrderPathsRecurse[T any](path []T, pathAdj pathAdjFunction[T], yield fun
 	return false
 }
 
-// The elements of the returned sequence reference a volatile internal slice,
-// clone it if you need it after a step in the iteration.
-func postOrderPaths[T any](root T, pathAdj pathAdjFunction[T]) iter.Seq[[]T] {
+// PostOrderPaths returns a sequence of the paths from root to each reachable node,
+// yielding each path after the paths extending it.
+// The yielded paths share a volatile internal slice, and must not be modified.
+// Use [ClonePaths] if they are needed after a step in the iteration.
+func PostOrderPaths[T any](root T, pathAdj PathAdjFunction[T]) iter.Seq[[]T] {
 	return func(yield func([]T) bool) {
 		postOrderPathsRecurse([]T{root}, pathAdj, yield)
 	}
 }
 
 // Returns true if done (some yield has returned false).
-func postOrderPathsRecurse[T any](path []T, pathAdj pathAdjFunction[T], yield func([]T) bool) bool {
+func postOrderPathsRecurse[T any](path []T, pathAdj PathAdjFunction[T], yield func([]T) bool) bool {
 	for adjNode := range pathAdj(path) {
 		if postOrderPathsRecurse(append(path, adjNode), pathAdj, yield) {
 			return true
@@ -97,3 +109,44 @@ func postOrderPathsRecurse[T any](path []T, pathAdj pathAdjFunction[T], yield fu
 	}
 	return !yield(path)
 }
+
+// Adjacency function decorators.
+
+// MaxDepth returns a PathAdjFunction with the same adjacent nodes as pathAdj,
+// except that paths with depth edges (depth+1 nodes) have no adjacent nodes.
+// MaxDepth will panic if depth is negative.
+func MaxDepth[T any](pathAdj PathAdjFunction[T], depth int) PathAdjFunction[T] {
+	if depth < 0 {
+		panic("depth must be non-negative")
+	}
+	return func(path []T) iter.Seq[T] {
+		if len(path) > depth {
+			return emptySeq
+		}
+		return pathAdj(path)
+	}
+}
+
+// StopAt returns a PathAdjFunction with the same adjacent nodes as pathAdj,
+// except that paths for which stop returns true have no adjacent nodes.
+// Those paths are still traversed, but their descendants are not.
+func StopAt[T any](pathAdj PathAdjFunction[T], stop func([]T) bool) PathAdjFunction[T] {
+	return func(path []T) iter.Seq[T] {
+		if stop(path) {
+			return emptySeq
+		}
+		return pathAdj(path)
+	}
+}
+
+// ClonePaths returns a sequence of clones of the paths in paths,
+// which the caller may retain and modify, such as those returned by [PreOrderPaths] and [PostOrderPaths].
+func ClonePaths[T any](paths iter.Seq[[]T]) iter.Seq[[]T] {
+	return func(yield func([]T) bool) {
+		for path := range paths {
+			if !yield(slices.Clone(path)) {
+				return
+			}
+		}
+	}
+}
@@ -9,7 +9,7 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
-// adjInt returns a simple adjFunction[int] for testing traversals.
+// adjInt returns a simple AdjFunction[int] for testing traversals.
 // If k <= limit, children(k) == [4*k+1, 4*k+2, 4*k+3].
 // If k > limit, children(k) == [].
 func adjInt(limit int) func(int) iter.Seq[int] {
@@ -30,7 +30,7 @@ func adjInt(limit int) func(int) iter.Seq[int] {
 	}
 }
 
-// pathAdjInt returns a pathAdjFunction[int] with the same children as adjInt.
+// pathAdjInt returns a PathAdjFunction[int] with the same children as adjInt.
 func pathAdjInt(limit int) func([]int) iter.Seq[int] {
 	if limit < 0 {
 		panic("limit must be non-negative")
@@ -114,12 +114,12 @@ var expectedPostOrderPaths = [][]int{
 	{0},
 }
 
-func preOrder(root int, adj btrie.TestingAdjFunction) []int {
-	return slices.Collect(btrie.TestingPreOrder(root, adj))
+func preOrder(root int, adj btrie.AdjFunction[int]) []int {
+	return slices.Collect(btrie.PreOrder[int](root, adj))
 }
 
-func postOrder(root int, adj btrie.TestingAdjFunction) []int {
-	return slices.Collect(btrie.TestingPostOrder(root, adj))
+func postOrder(root int, adj btrie.AdjFunction[int]) []int {
+	return slices.Collect(btrie.PostOrder[int](root, adj))
 }
 
 func endNodes(paths [][]int) []int {
@@ -139,7 +139,7 @@ func TestPreOrder(t *testing.T) {
 	assert.Equal(t, endNodes(expectedPreOrderPaths), preOrder(0, adjInt(10)))
 
 	// need an early yield for test coverage
-	for node := range btrie.TestingPreOrder(0, adjInt(10)) {
+	for node := range btrie.PreOrder[int](0, adjInt(10)) {
 		if node == 7 {
 			break
 		}
@@ -155,24 +155,24 @@ func TestPostOrder(t *testing.T) {
 	assert.Equal(t, endNodes(expectedPostOrderPaths), postOrder(0, adjInt(10)))
 
 	// need an early yield for test coverage
-	for node := range btrie.TestingPostOrder(0, adjInt(10)) {
+	for node := range btrie.PostOrder[int](0, adjInt(10)) {
 		if node == 7 {
 			break
 		}
 	}
 }
 
-func preOrderPaths(root int, pathAdj btrie.TestingPathAdjFunction) [][]int {
+func preOrderPaths(root int, pathAdj btrie.PathAdjFunction[int]) [][]int {
 	paths := [][]int{}
-	for path := range btrie.TestingPreOrderPaths(root, pathAdj) {
+	for path := range btrie.PreOrderPaths[int](root, pathAdj) {
 		paths = append(paths, slices.Clone(path))
 	}
 	return paths
 }
 
-func postOrderPaths(root int, pathAdj btrie.TestingPathAdjFunction) [][]int {
+func postOrderPaths(root int, pathAdj btrie.PathAdjFunction[int]) [][]int {
 	paths := [][]int{}
-	for path := range btrie.TestingPostOrderPaths(root, pathAdj) {
+	for path := range btrie.PostOrderPaths[int](root, pathAdj) {
 		paths = append(paths, slices.Clone(path))
 	}
 	return paths
@@ -187,7 +187,7 @@ func TestPreOrderPaths(t *testing.T) {
 	assert.Equal(t, expectedPreOrderPaths, preOrderPaths(0, pathAdjInt(10)))
 
 	// need an early yield for test coverage
-	for path := range btrie.TestingPreOrderPaths(0, pathAdjInt(10)) {
+	for path := range btrie.PreOrderPaths[int](0, pathAdjInt(10)) {
 		if path[len(path)-1] == 7 {
 			break
 		}
@@ -203,9 +203,45 @@ func TestPostOrderPaths(t *testing.T) {
 	assert.Equal(t, expectedPostOrderPaths, postOrderPaths(0, pathAdjInt(10)))
 
 	// need an early yield for test coverage
-	for path := range btrie.TestingPostOrderPaths(0, pathAdjInt(10)) {
+	for path := range btrie.PostOrderPaths[int](0, pathAdjInt(10)) {
 		if path[len(path)-1] == 7 {
 			break
 		}
 	}
 }
+
+func TestMaxDepth(t *testing.T) {
+	t.Parallel()
+	assert.Panics(t, func() { btrie.MaxDepth[int](pathAdjInt(10), -1) })
+	assert.Equal(t, [][]int{{0}}, preOrderPaths(0, btrie.MaxDepth[int](pathAdjInt(10), 0)))
+	assert.Equal(t, [][]int{{0}, {0, 1}, {0, 2}, {0, 3}}, preOrderPaths(0, btrie.MaxDepth[int](pathAdjInt(10), 1)))
+	assert.Equal(t, [][]int{{0, 1}, {0, 2}, {0, 3}, {0}}, postOrderPaths(0, btrie.MaxDepth[int](pathAdjInt(10), 1)))
+	assert.Equal(t, expectedPreOrderPaths, preOrderPaths(0, btrie.MaxDepth[int](pathAdjInt(10), 3)))
+	assert.Equal(t, expectedPreOrderPaths, preOrderPaths(0, btrie.MaxDepth[int](pathAdjInt(10), 100)))
+}
+
+func TestStopAt(t *testing.T) {
+	t.Parallel()
+	isTwo := func(path []int) bool { return path[len(path)-1] == 2 }
+	never := func([]int) bool { return false }
+	assert.Equal(t, [][]int{{2}}, preOrderPaths(2, btrie.StopAt[int](pathAdjInt(10), isTwo)))
+	assert.Equal(t, expectedPreOrderPaths, preOrderPaths(0, btrie.StopAt[int](pathAdjInt(10), never)))
+	expected := [][]int{}
+	for _, path := range expectedPreOrderPaths {
+		if len(path) < 3 || path[1] != 2 {
+			expected = append(expected, path)
+		}
+	}
+	assert.Equal(t, expected, preOrderPaths(0, btrie.StopAt[int](pathAdjInt(10), isTwo)))
+}
+
+func TestClonePaths(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, expectedPreOrderPaths, slices.Collect(btrie.ClonePaths(btrie.PreOrderPaths[int](0, pathAdjInt(10)))))
+	assert.Equal(t, expectedPostOrderPaths, slices.Collect(btrie.ClonePaths(btrie.PostOrderPaths[int](0, pathAdjInt(10)))))
+
+	// need an early yield for test coverage
+	for range btrie.ClonePaths(btrie.PreOrderPaths[int](0, pathAdjInt(10))) {
+		break
+	}
+}
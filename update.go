@@ -0,0 +1,96 @@
+package btrie
+
+import "bytes"
+
+// RangeUpdate replaces the value of every entry in trie within bounds with the value returned by fn,
+// or deletes the entry if fn returns true, calling fn in the same order as trie.Range(bounds) would yield the entries.
+// Tries which support it, such as those created by [NewArrayTrie], replace values in place during a single traversal,
+// and only delete entries after the traversal is done.
+// Other tries are ranged over to collect the changes, which are then applied with trie.Put and trie.Delete.
+// The key passed to fn must not be modified, and is only valid until fn returns. fn must not mutate trie.
+// RangeUpdate will panic if fn is nil.
+func RangeUpdate[V any](trie BTrie[V], bounds *Bounds, fn func(key []byte, value V) (V, bool)) {
+	if fn == nil {
+		panic("fn must be non-nil")
+	}
+	if t, ok := trie.(valueUpdater[V]); ok {
+		for _, key := range t.updateValues(bounds, fn) {
+			trie.Delete(key)
+		}
+		return
+	}
+	var puts []Entry[V]
+	var deletes [][]byte
+	for key, value := range trie.Range(bounds) {
+		value, remove := fn(key, value)
+		if remove {
+			deletes = append(deletes, key)
+		} else {
+			puts = append(puts, Entry[V]{key, value})
+		}
+	}
+	for _, entry := range puts {
+		trie.Put(entry.Key, entry.Value)
+	}
+	for _, key := range deletes {
+		trie.Delete(key)
+	}
+}
+
+// Implemented by tries which can replace values in place while traversing.
+type valueUpdater[V any] interface {
+	// Replaces values within bounds as described by RangeUpdate,
+	// and returns the keys of the entries to be deleted, which the caller must delete.
+	updateValues(bounds *Bounds, fn func(key []byte, value V) (V, bool)) [][]byte
+}
+
+func (n *arrayTrieNode[V]) updateValues(bounds *Bounds, fn func(key []byte, value V) (V, bool)) [][]byte {
+	var deletes [][]byte
+	n.updateNode(bounds, []byte{}, fn, &deletes)
+	return deletes
+}
+
+// Traverses n's subtree in the same order as Range, returning false if the traversal went past bounds.
+func (n *arrayTrieNode[V]) updateNode(bounds *Bounds, key []byte, fn func([]byte, V) (V, bool),
+	deletes *[][]byte,
+) bool {
+	if !bounds.IsReverse && !n.updateValue(bounds, key, fn, deletes) {
+		return false
+	}
+	if n.children != nil {
+		// Sometimes a child is not within the bounds, but one of its descendants is.
+		if start, stop, ok := bounds.childBounds(key); ok {
+			step := 1
+			if bounds.IsReverse {
+				step = -1
+			}
+			for i := int(start); ; i += step {
+				if child := n.children[i]; child != nil && !child.updateNode(bounds, append(key, byte(i)), fn, deletes) {
+					return false
+				}
+				if i == int(stop) {
+					break
+				}
+			}
+		}
+	}
+	return !bounds.IsReverse || n.updateValue(bounds, key, fn, deletes)
+}
+
+// Updates n's value if it is within bounds, returning false if it is past bounds.
+func (n *arrayTrieNode[V]) updateValue(bounds *Bounds, key []byte, fn func([]byte, V) (V, bool),
+	deletes *[][]byte,
+) bool {
+	// This does not modify key, only possibly the unused part of its backing array.
+	valueKey := append(key, n.suffix...)
+	cmp := bounds.Compare(valueKey)
+	if cmp == 0 && n.isTerminal {
+		value, remove := fn(valueKey, n.value)
+		if remove {
+			*deletes = append(*deletes, bytes.Clone(valueKey))
+		} else {
+			n.value = value
+		}
+	}
+	return cmp <= 0
+}
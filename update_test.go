@@ -0,0 +1,37 @@
+package btrie_test
+
+import (
+	"testing"
+
+	"github.com/phiryll/btrie"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRangeUpdate(t *testing.T) {
+	t.Parallel()
+	assert.Panics(t, func() { btrie.RangeUpdate(btrie.NewArrayTrie[byte](), forwardAll, nil) })
+	for _, test := range createTestTries(rangeTestConfigs) {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			for _, bounds := range append(test.config.forward, test.config.reverse...) {
+				expected := createReferenceTrie(test.config)
+				expectedVisited := collect(test.trie.Range(&bounds))
+				for _, e := range expectedVisited {
+					if e.value%3 == 0 {
+						expected.Delete(e.key)
+					} else {
+						expected.Put(e.key, e.value+1)
+					}
+				}
+				trie := test.trie.Clone()
+				visited := []entry{}
+				btrie.RangeUpdate(trie, &bounds, func(key []byte, value byte) (byte, bool) {
+					visited = append(visited, entry{[]byte(string(key)), value})
+					return value + 1, value%3 == 0
+				})
+				assert.Equal(t, expectedVisited, visited, "%s", bounds)
+				assert.Equal(t, collect(expected.Range(forwardAll)), collect(trie.Range(forwardAll)), "%s", bounds)
+			}
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package btrie
+
+import (
+	"bytes"
+	"compress/flate"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"sync"
+)
+
+// ValueEncoded is a BTrie which stores its values encoded by a Codec in another trie,
+// and decodes them in every method returning them, including Range.
+// With a codec such as [CompressedBytesCodec], large compressible values are kept compressed in memory,
+// and every access path transparently sees the original values.
+// Each encoded value is stored in its own exactly-sized slice.
+//
+// If the codec returns an error, a Put is not applied and a Get, Put, Delete, or Range returns whatever value
+// the codec returned, normally a zero value, and the first such error is reported by [ValueEncoded.Err].
+// ValueEncoded implements [BTrie], and is not safe for concurrent use.
+type ValueEncoded[V any] struct {
+	trie  BTrie[[]byte]
+	codec Codec[V]
+	buf   []byte
+	err   error
+}
+
+// WithValueCodec returns a ValueEncoded storing values in trie after encoding them with codec.
+// Existing entries in trie must have been encoded by codec.
+// Mutations of trie not made through the returned ValueEncoded must also store values encoded by codec.
+func WithValueCodec[V any](trie BTrie[[]byte], codec Codec[V]) *ValueEncoded[V] {
+	return &ValueEncoded[V]{trie: trie, codec: codec}
+}
+
+// Err returns the first error returned by the codec, or nil if there was none.
+func (t *ValueEncoded[V]) Err() error {
+	return t.err
+}
+
+func (t *ValueEncoded[V]) Get(key []byte) (V, bool) {
+	data, ok := t.trie.Get(key)
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	return t.decode(data), true
+}
+
+func (t *ValueEncoded[V]) Put(key []byte, value V) (V, bool) {
+	if key == nil {
+		panic("key must be non-nil")
+	}
+	var err error
+	t.buf, err = t.codec.AppendValue(t.buf[:0], value)
+	if err != nil {
+		t.setErr(err)
+		return t.Get(key)
+	}
+	data := make([]byte, len(t.buf))
+	copy(data, t.buf)
+	prev, ok := t.trie.Put(key, data)
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	return t.decode(prev), true
+}
+
+func (t *ValueEncoded[V]) Delete(key []byte) (V, bool) {
+	prev, ok := t.trie.Delete(key)
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	return t.decode(prev), true
+}
+
+func (t *ValueEncoded[V]) Range(bounds *Bounds) iter.Seq2[[]byte, V] {
+	return func(yield func([]byte, V) bool) {
+		for key, data := range t.trie.Range(bounds) {
+			if !yield(key, t.decode(data)) {
+				return
+			}
+		}
+	}
+}
+
+// Returns the decoded value of data, or whatever the codec returned if it could not be decoded.
+func (t *ValueEncoded[V]) decode(data []byte) V {
+	value, err := t.codec.DecodeValue(data)
+	if err != nil {
+		t.setErr(err)
+	}
+	return value
+}
+
+func (t *ValueEncoded[V]) setErr(err error) {
+	if t.err == nil {
+		t.err = err
+	}
+}
+
+// CompressedBytesCodec is a Codec for []byte values, which compresses values using [compress/flate]
+// if they are at least Threshold bytes long, and if compressing makes them smaller.
+// The encoded form is a tag byte, 0 for an uncompressed value and 1 for a compressed value, followed by the value.
+// A nil value is decoded as an empty slice.
+type CompressedBytesCodec struct {
+	// Threshold is the length of the shortest value which is compressed.
+	// Compressing short values costs time, and rarely makes them smaller.
+	Threshold int
+}
+
+// Tags of values encoded by CompressedBytesCodec.
+const (
+	valueUncompressed byte = iota
+	valueFlate
+)
+
+// Reusable flate writers for CompressedBytesCodec, which are expensive to allocate.
+var flateWriters = sync.Pool{
+	New: func() any {
+		// The error is only non-nil for an invalid level.
+		fw, _ := flate.NewWriter(nil, flate.DefaultCompression)
+		return fw
+	},
+}
+
+func (c CompressedBytesCodec) AppendValue(buf, value []byte) ([]byte, error) {
+	if len(value) >= c.Threshold && len(value) > 0 {
+		compressed := bytes.NewBuffer(append(buf, valueFlate))
+		//nolint:forcetypeassert
+		fw := flateWriters.Get().(*flate.Writer)
+		fw.Reset(compressed)
+		// Writing to a bytes.Buffer never fails.
+		_, _ = fw.Write(value)
+		_ = fw.Close()
+		// Don't let the pool retain the buffer.
+		fw.Reset(io.Discard)
+		flateWriters.Put(fw)
+		if compressed.Len() < len(buf)+1+len(value) {
+			return compressed.Bytes(), nil
+		}
+		buf = compressed.Bytes()[:len(buf)]
+	}
+	buf = append(buf, valueUncompressed)
+	return append(buf, value...), nil
+}
+
+func (CompressedBytesCodec) DecodeValue(data []byte) ([]byte, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("%w: missing compressed value tag", ErrInvalidFormat)
+	}
+	switch data[0] {
+	case valueUncompressed:
+		return append([]byte{}, data[1:]...), nil
+	case valueFlate:
+		fr := flate.NewReader(bytes.NewReader(data[1:]))
+		defer fr.Close()
+		value, err := io.ReadAll(fr)
+		var corrupt flate.CorruptInputError
+		if errors.As(err, &corrupt) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidFormat, err)
+		}
+		if value == nil {
+			value = []byte{}
+		}
+		return value, err
+	default:
+		return nil, fmt.Errorf("%w: unknown compressed value tag %d", ErrInvalidFormat, data[0])
+	}
+}
@@ -0,0 +1,68 @@
+package btrie_test
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"github.com/phiryll/btrie"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithValueCodec(t *testing.T) {
+	t.Parallel()
+	store := btrie.NewArrayTrie[[]byte]()
+	trie := btrie.WithValueCodec[[]byte](store, btrie.CompressedBytesCodec{Threshold: 64})
+	large := bytes.Repeat([]byte("compressible "), 100)
+	random := make([]byte, 1000)
+	rand.New(rand.NewSource(42)).Read(random)
+	_, ok := trie.Put([]byte{1}, large)
+	assert.False(t, ok)
+	trie.Put([]byte{2}, []byte("small"))
+	trie.Put([]byte{3}, random)
+	trie.Put([]byte{4}, nil)
+	assertSameBytes(t, map[string][]byte{"\x01": large, "\x02": []byte("small"), "\x03": random, "\x04": {}}, trie)
+
+	// Only large compressible values are stored compressed.
+	encoded, _ := store.Get([]byte{1})
+	assert.Equal(t, byte(1), encoded[0])
+	assert.Less(t, len(encoded), len(large)/10)
+	assert.Equal(t, len(encoded), cap(encoded))
+	encoded, _ = store.Get([]byte{2})
+	assert.Equal(t, []byte("\x00small"), encoded)
+	encoded, _ = store.Get([]byte{3})
+	assert.Equal(t, append([]byte{0}, random...), encoded)
+
+	prev, ok := trie.Put([]byte{1}, []byte("replaced"))
+	assert.True(t, ok)
+	assert.Equal(t, large, prev)
+	prev, ok = trie.Delete([]byte{3})
+	assert.True(t, ok)
+	assert.Equal(t, random, prev)
+	_, ok = trie.Delete([]byte{3})
+	assert.False(t, ok)
+	assertSameBytes(t, map[string][]byte{"\x01": []byte("replaced"), "\x02": []byte("small"), "\x04": {}}, trie)
+	require.NoError(t, trie.Err())
+}
+
+func TestWithValueCodecErrors(t *testing.T) {
+	t.Parallel()
+	trie := btrie.WithValueCodec[[]byte](btrie.NewArrayTrie[[]byte](), failingCodec{})
+	_, ok := trie.Put([]byte{1}, []byte("abc"))
+	assert.False(t, ok)
+	require.ErrorIs(t, trie.Err(), errEncode)
+	_, ok = trie.Get([]byte{1})
+	assert.False(t, ok, "failed put must not be applied")
+
+	store := btrie.NewArrayTrie[[]byte]()
+	store.Put([]byte{1}, []byte{})
+	store.Put([]byte{2}, []byte{9})
+	store.Put([]byte{3}, []byte{1, 0xFF, 0xFF})
+	for _, key := range [][]byte{{1}, {2}, {3}} {
+		trie = btrie.WithValueCodec[[]byte](store, btrie.CompressedBytesCodec{})
+		_, ok := trie.Get(key)
+		assert.True(t, ok)
+		require.ErrorIs(t, trie.Err(), btrie.ErrInvalidFormat, keyName(key))
+	}
+}
@@ -0,0 +1,404 @@
+package btrie
+
+import (
+	"bytes"
+	"container/heap"
+	"fmt"
+	"iter"
+	"math"
+	"slices"
+	"strings"
+)
+
+// The key bytes of a node's children are packed into keyBytes, separately from the pointers to the children,
+// so that searching even a node with 256 children reads only a few cache lines and dereferences no children.
+// keyBytes[i] is the key byte of children[i], and keyBytes is sorted.
+//
+//nolint:govet  // govet wants V first, but that doesn't give the best alignment
+type weightedTrieNode[V any] struct {
+	keyBytes   []byte
+	children   []*weightedTrieNode[V]
+	value      V       // valid only if isTerminal is true
+	weight     float64 // valid only if isTerminal is true
+	maxWeight  float64 // the maximum weight in this subtree, -Inf if there are no values
+	isTerminal bool
+}
+
+// WeightedTrie is a BTrie which maintains the maximum weight of the values in every subtree,
+// allowing TopSuggestions to find the highest weighted keys having a prefix without visiting every such key.
+// Pointers to children are stored densely in slices, with the children's key bytes packed into a separate slice.
+type WeightedTrie[V any] struct {
+	root   *weightedTrieNode[V]
+	weight func(V) float64
+}
+
+// NewWeightedTrie returns a new WeightedTrie, where the weight of each value is given by weight.
+// The weight of a value must not change while it is in the trie.
+// NewWeightedTrie will panic if weight is nil.
+func NewWeightedTrie[V any](weight func(V) float64) *WeightedTrie[V] {
+	if weight == nil {
+		panic("weight must be non-nil")
+	}
+	return &WeightedTrie[V]{newWeightedTrieNode[V](), weight}
+}
+
+func newWeightedTrieNode[V any]() *weightedTrieNode[V] {
+	var zero V
+	return &weightedTrieNode[V]{nil, nil, zero, 0, math.Inf(-1), false}
+}
+
+func (t *WeightedTrie[V]) Get(key []byte) (V, bool) {
+	if key == nil {
+		panic("key must be non-nil")
+	}
+	var zero V
+	n := t.root
+	for _, keyByte := range key {
+		index, found := searchKeyBytes(n.keyBytes, keyByte)
+		if !found {
+			return zero, false
+		}
+		n = n.children[index]
+	}
+	// n = found key
+	if n.isTerminal {
+		return n.value, true
+	}
+	return zero, false
+}
+
+// Put will panic if the weight of value is NaN.
+func (t *WeightedTrie[V]) Put(key []byte, value V) (V, bool) {
+	if key == nil {
+		panic("key must be non-nil")
+	}
+	weight := t.weight(value)
+	if math.IsNaN(weight) {
+		panic("weight must not be NaN")
+	}
+	var zero V
+	path := make([]*weightedTrieNode[V], 0, len(key)+1)
+	n := t.root
+	for _, keyByte := range key {
+		path = append(path, n)
+		index, found := searchKeyBytes(n.keyBytes, keyByte)
+		if !found {
+			n.keyBytes = slices.Insert(n.keyBytes, index, keyByte)
+			n.children = slices.Insert(n.children, index, newWeightedTrieNode[V]())
+		}
+		n = n.children[index]
+	}
+	// n = found or created key
+	prev, ok := n.value, n.isTerminal
+	n.value = value
+	n.weight = weight
+	n.isTerminal = true
+	n.updateMaxWeight()
+	for i := len(path) - 1; i >= 0; i-- {
+		path[i].updateMaxWeight()
+	}
+	if ok {
+		return prev, true
+	}
+	return zero, false
+}
+
+func (t *WeightedTrie[V]) Delete(key []byte) (V, bool) {
+	if key == nil {
+		panic("key must be non-nil")
+	}
+	var zero V
+	path := make([]*weightedTrieNode[V], 0, len(key)+1)
+	n := t.root
+	for _, keyByte := range key {
+		path = append(path, n)
+		index, found := searchKeyBytes(n.keyBytes, keyByte)
+		if !found {
+			return zero, false
+		}
+		n = n.children[index]
+	}
+	// n = found key
+	if !n.isTerminal {
+		return zero, false
+	}
+	prev := n.value
+	n.value = zero
+	n.isTerminal = false
+	n.updateMaxWeight()
+	// Remove any nodes which no longer have values in their subtrees, then update the remaining ancestors.
+	for i := len(path) - 1; i >= 0; i-- {
+		parent := path[i]
+		if !n.isTerminal && len(n.children) == 0 {
+			index, _ := searchKeyBytes(parent.keyBytes, key[i])
+			parent.keyBytes = slices.Delete(parent.keyBytes, index, index+1)
+			parent.children = slices.Delete(parent.children, index, index+1)
+		}
+		parent.updateMaxWeight()
+		n = parent
+	}
+	return prev, true
+}
+
+// Assumes the maximum weights of n's children are correct.
+func (n *weightedTrieNode[V]) updateMaxWeight() {
+	maxWeight := math.Inf(-1)
+	if n.isTerminal {
+		maxWeight = n.weight
+	}
+	for _, child := range n.children {
+		maxWeight = max(maxWeight, child.maxWeight)
+	}
+	n.maxWeight = maxWeight
+}
+
+// TopSuggestions returns up to n keys having the given prefix, in decreasing order of weight.
+// Keys with equal weights are in increasing key order.
+// This is a best-first search which only visits subtrees that could contain one of the returned keys.
+// TopSuggestions will panic if prefix is nil or n is negative.
+func (t *WeightedTrie[V]) TopSuggestions(prefix []byte, n int) [][]byte {
+	if prefix == nil {
+		panic("prefix must be non-nil")
+	}
+	if n < 0 {
+		panic("n must be non-negative")
+	}
+	result := [][]byte{}
+	node := t.root
+	for _, keyByte := range prefix {
+		index, found := searchKeyBytes(node.keyBytes, keyByte)
+		if !found {
+			return result
+		}
+		node = node.children[index]
+	}
+	if n == 0 || math.IsInf(node.maxWeight, -1) {
+		return result
+	}
+	// Candidates are either a subtree, with the maximum weight in that subtree,
+	// or the value of a single node, with that value's weight.
+	// A subtree's key is never greater than any key within it,
+	// so popping candidates in (decreasing weight, increasing key) order yields keys in that order as well.
+	candidates := &weightedCandidateHeap[V]{}
+	heap.Push(candidates, weightedCandidate[V]{node, bytes.Clone(prefix), node.maxWeight, true})
+	for candidates.Len() > 0 && len(result) < n {
+		//nolint:forcetypeassert
+		c := heap.Pop(candidates).(weightedCandidate[V])
+		if !c.isSubtree {
+			result = append(result, c.key)
+			continue
+		}
+		if c.node.isTerminal {
+			heap.Push(candidates, weightedCandidate[V]{c.node, c.key, c.node.weight, false})
+		}
+		for i, child := range c.node.children {
+			key := append(bytes.Clone(c.key), c.node.keyBytes[i])
+			heap.Push(candidates, weightedCandidate[V]{child, key, child.maxWeight, true})
+		}
+	}
+	return result
+}
+
+type weightedCandidate[V any] struct {
+	node      *weightedTrieNode[V]
+	key       []byte
+	weight    float64
+	isSubtree bool
+}
+
+// A heap.Interface whose minimum is the best candidate.
+type weightedCandidateHeap[V any] []weightedCandidate[V]
+
+func (h weightedCandidateHeap[V]) Len() int {
+	return len(h)
+}
+
+func (h weightedCandidateHeap[V]) Less(i, j int) bool {
+	if h[i].weight != h[j].weight {
+		return h[i].weight > h[j].weight
+	}
+	if cmp := bytes.Compare(h[i].key, h[j].key); cmp != 0 {
+		return cmp < 0
+	}
+	// A node's own value comes before the rest of its subtree.
+	return !h[i].isSubtree
+}
+
+func (h weightedCandidateHeap[V]) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+}
+
+func (h *weightedCandidateHeap[V]) Push(x any) {
+	//nolint:forcetypeassert
+	*h = append(*h, x.(weightedCandidate[V]))
+}
+
+func (h *weightedCandidateHeap[V]) Pop() any {
+	old := *h
+	last := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return last
+}
+
+// A node being traversed by Range, and the remaining children of that node to traverse.
+// This serves the same purpose as arrayTrieFrame.
+type weightedTrieFrame[V any] struct {
+	node *weightedTrieNode[V]
+	next int  // index of the next child to consider
+	stop byte // key byte of the last child to consider, inclusive
+}
+
+func (t *WeightedTrie[V]) Range(bounds *Bounds) iter.Seq2[[]byte, V] {
+	return t.rangeWithMaxDepth(bounds, 0)
+}
+
+func (t *WeightedTrie[V]) rangeWithMaxDepth(bounds *Bounds, maxDepth int) iter.Seq2[[]byte, V] {
+	bounds = bounds.Clone()
+	if bounds.IsReverse {
+		return t.root.rangeReverse(bounds, maxDepth)
+	}
+	return t.root.rangeForward(bounds, maxDepth)
+}
+
+// Traverses in pre-order.
+func (n *weightedTrieNode[V]) rangeForward(bounds *Bounds, maxDepth int) iter.Seq2[[]byte, V] {
+	return func(yield func([]byte, V) bool) {
+		key := make([]byte, 0, maxDepth)
+		stack := make([]weightedTrieFrame[V], 0, maxDepth)
+		node := n
+		for {
+			// invariant: key = path from root to node, len(key) = len(stack)
+			cmp := bounds.Compare(key)
+			if cmp > 0 {
+				return
+			}
+			if cmp == 0 && node.isTerminal && !yield(bytes.Clone(key), node.value) {
+				return
+			}
+			if len(node.children) > 0 {
+				// Sometimes a child is not within the bounds, but one of its descendants is.
+				start, stop, ok := bounds.childBounds(key)
+				if !ok {
+					// Unreachable because of how the trie is traversed forward.
+					panic("unreachable")
+				}
+				next, _ := searchKeyBytes(node.keyBytes, start)
+				stack = append(stack, weightedTrieFrame[V]{node, next, stop})
+			}
+			node = nil
+			for node == nil {
+				if len(stack) == 0 {
+					return
+				}
+				top := &stack[len(stack)-1]
+				if top.next < len(top.node.keyBytes) && top.node.keyBytes[top.next] <= top.stop {
+					node = top.node.children[top.next]
+					key = append(key[:len(stack)-1], top.node.keyBytes[top.next])
+					top.next++
+				} else {
+					stack = stack[:len(stack)-1]
+				}
+			}
+		}
+	}
+}
+
+// Traverses in post-order, visiting children in reverse.
+func (n *weightedTrieNode[V]) rangeReverse(bounds *Bounds, maxDepth int) iter.Seq2[[]byte, V] {
+	return func(yield func([]byte, V) bool) {
+		key := make([]byte, 0, maxDepth)
+		stack := append(make([]weightedTrieFrame[V], 0, maxDepth+1), n.reverseFrame(bounds, key))
+		for {
+			// invariant: key = path from root to top.node, len(key) = len(stack) - 1
+			top := &stack[len(stack)-1]
+			if top.next >= 0 && top.node.keyBytes[top.next] >= top.stop {
+				child := top.node.children[top.next]
+				key = append(key, top.node.keyBytes[top.next])
+				top.next--
+				stack = append(stack, child.reverseFrame(bounds, key))
+				continue
+			}
+			node := top.node
+			cmp := bounds.Compare(key)
+			if cmp > 0 {
+				return
+			}
+			if cmp == 0 && node.isTerminal && !yield(bytes.Clone(key), node.value) {
+				return
+			}
+			stack = stack[:len(stack)-1]
+			if len(stack) == 0 {
+				return
+			}
+			key = key[:len(key)-1]
+		}
+	}
+}
+
+func (n *weightedTrieNode[V]) reverseFrame(bounds *Bounds, key []byte) weightedTrieFrame[V] {
+	if len(n.children) == 0 {
+		return weightedTrieFrame[V]{n, -1, 0}
+	}
+	// Sometimes a child is not within the bounds, but one of its descendants is.
+	start, stop, ok := bounds.childBounds(key)
+	if !ok {
+		return weightedTrieFrame[V]{n, -1, 0}
+	}
+	next, found := searchKeyBytes(n.keyBytes, start)
+	if !found {
+		next--
+	}
+	return weightedTrieFrame[V]{n, next, stop}
+}
+
+func (t *WeightedTrie[V]) String() string {
+	var s strings.Builder
+	t.printTo(&s, -1)
+	return s.String()
+}
+
+func (t *WeightedTrie[V]) Format(f fmt.State, verb rune) {
+	formatTrie(f, verb, t)
+}
+
+func (t *WeightedTrie[V]) printTo(s *strings.Builder, levels int) {
+	t.root.printNode(s, 0, "", levels)
+}
+
+func (t *WeightedTrie[V]) stats() (int, int) {
+	return t.root.stats()
+}
+
+//nolint:revive
+func (n *weightedTrieNode[V]) printNode(s *strings.Builder, keyByte byte, indent string, levels int) {
+	if indent == "" {
+		s.WriteString("[]")
+	} else {
+		fmt.Fprintf(s, "%s%02X", indent, keyByte)
+	}
+	if n.isTerminal {
+		fmt.Fprintf(s, ": %v (%v)\n", n.value, n.weight)
+	} else {
+		s.WriteString("\n")
+	}
+	if len(n.children) > 0 && levels == 0 {
+		s.WriteString(indent + "  ...\n")
+		return
+	}
+	for i, child := range n.children {
+		child.printNode(s, n.keyBytes[i], indent+"  ", levels-1)
+	}
+}
+
+func (n *weightedTrieNode[V]) stats() (int, int) {
+	size, depth := 0, 0
+	if n.isTerminal {
+		size = 1
+	}
+	for _, child := range n.children {
+		childSize, childDepth := child.stats()
+		size += childSize
+		depth = max(depth, childDepth+1)
+	}
+	return size, depth
+}
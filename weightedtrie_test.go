@@ -0,0 +1,86 @@
+package btrie_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/phiryll/btrie"
+	"github.com/stretchr/testify/assert"
+)
+
+func byteWeight(v byte) float64 {
+	return float64(v)
+}
+
+func TestWeightedTrieTopSuggestions(t *testing.T) {
+	t.Parallel()
+	assert.Panics(t, func() { btrie.NewWeightedTrie[byte](nil) })
+	trie := btrie.NewWeightedTrie(byteWeight)
+	assert.Panics(t, func() { trie.TopSuggestions(nil, 1) })
+	assert.Panics(t, func() { trie.TopSuggestions([]byte{}, -1) })
+	assert.Equal(t, keys(), trie.TopSuggestions([]byte{}, 5))
+	for key, weight := range map[string]byte{
+		"":        1,
+		"car":     5,
+		"card":    2,
+		"care":    7,
+		"careful": 7,
+		"cart":    3,
+		"cat":     9,
+		"dog":     7,
+	} {
+		trie.Put([]byte(key), weight)
+	}
+	assert.Equal(t, keys(), trie.TopSuggestions([]byte("c"), 0))
+	assert.Equal(t, keys(), trie.TopSuggestions([]byte("x"), 5))
+	assert.Equal(t, keys("cat", "care", "careful"), trie.TopSuggestions([]byte("c"), 3))
+	assert.Equal(t, keys("cat", "care", "careful", "dog", "car"), trie.TopSuggestions([]byte{}, 5))
+	assert.Equal(t, keys("care", "careful", "car", "cart", "card"), trie.TopSuggestions([]byte("car"), 100))
+
+	// Maximum weights must be maintained through replacements and deletions.
+	trie.Put([]byte("cat"), 0)
+	assert.Equal(t, keys("care", "careful"), trie.TopSuggestions([]byte("c"), 2))
+	trie.Delete([]byte("care"))
+	trie.Delete([]byte("careful"))
+	assert.Equal(t, keys("car", "cart"), trie.TopSuggestions([]byte("c"), 2))
+	assert.Equal(t, keys("dog", "car"), trie.TopSuggestions([]byte{}, 2))
+}
+
+func TestWeightedTrieNaN(t *testing.T) {
+	t.Parallel()
+	trie := btrie.NewWeightedTrie(func(v float64) float64 { return v })
+	assert.Panics(t, func() { trie.Put([]byte{1}, math.NaN()) })
+	trie.Put([]byte{1}, math.Inf(-1))
+	trie.Put([]byte{2}, math.Inf(1))
+	assert.Equal(t, keys("\x02", "\x01"), trie.TopSuggestions([]byte{}, 5))
+}
+
+func TestWeightedTrieMatchesSuggestRanked(t *testing.T) {
+	t.Parallel()
+	byValue := func(_ []byte, value byte) float64 { return float64(value) }
+	for _, config := range rangeTestConfigs {
+		trie := btrie.NewWeightedTrie(byteWeight)
+		for k, v := range config.entries {
+			trie.Put([]byte(k), v)
+		}
+		// Delete some entries to exercise pruning.
+		for k := range config.entries {
+			if k != "" && k[len(k)-1]%3 == 0 {
+				trie.Delete([]byte(k))
+			}
+		}
+		prefixes := [][]byte{{}, {0x00}, {0x80}}
+		for k := range trie.Range(forwardAll) {
+			prefixes = append(prefixes, k[:len(k)/2])
+			if len(prefixes) > 20 {
+				break
+			}
+		}
+		for _, prefix := range prefixes {
+			for _, n := range []int{1, 10, 1000} {
+				expected := btrie.SuggestRanked[byte](trie, prefix, n, byValue)
+				assert.Equal(t, expected, trie.TopSuggestions(prefix, n), "%s %X %d", config.name, prefix, n)
+			}
+		}
+	}
+}